@@ -2,46 +2,546 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
-	graw "github.com/jamesprial/go-reddit-api-wrapper"
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
 )
 
+// RedditClient is the subset of the Reddit API client used by the Archiver.
+// *graw.Client satisfies it; tests can substitute a fake.
+type RedditClient interface {
+	GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error)
+	GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error)
+	GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error)
+	GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error)
+}
+
+// ListingClient is implemented by Reddit clients that can fetch the "top"
+// and "rising" listings, which ArchiveSubreddit needs for SortTop and
+// SortRising. *graw.Client doesn't fetch them yet.
+type ListingClient interface {
+	GetTop(ctx context.Context, req *types.PostsRequest, timeRange TimeRange) (*types.PostsResponse, error)
+	GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error)
+}
+
+// MoreCommentsClient is implemented by Reddit clients that can expand the
+// "more" stubs of a truncated comment tree, as *graw.Client does.
+// ArchiveOptions.MaxMoreRequests needs one.
+type MoreCommentsClient interface {
+	GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error)
+}
+
+// moreCommentsBatchSize is the most comment IDs Reddit expands per request
+const moreCommentsBatchSize = 100
+
+// moreCommentsClient returns c as a MoreCommentsClient, if it is one
+func moreCommentsClient(c RedditClient) (MoreCommentsClient, error) {
+	mc, ok := c.(MoreCommentsClient)
+	if !ok {
+		return nil, errors.New("reddit client cannot expand more comments")
+	}
+	return mc, nil
+}
+
+// InfoClient is implemented by Reddit clients that can look up posts by
+// fullname in batches, like Reddit's /api/info endpoint. UpdateScores uses
+// one to refresh up to 100 posts per request instead of fetching each post's
+// comment tree; *graw.Client doesn't look posts up this way yet.
+type InfoClient interface {
+	// GetInfo returns the posts among fullnames ("t3_" IDs) that Reddit
+	// still has, in any order
+	GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error)
+}
+
+// infoBatchSize is the most fullnames Reddit looks up per request
+const infoBatchSize = 100
+
+// infoClient returns c as an InfoClient, if it is one
+func infoClient(c RedditClient) (InfoClient, error) {
+	ic, ok := c.(InfoClient)
+	if !ok {
+		return nil, errors.New("reddit client cannot look up posts by fullname")
+	}
+	return ic, nil
+}
+
+// ErrSortUnsupported is returned by ArchiveSubreddit when the archiver's
+// client can't fetch the requested listing
+var ErrSortUnsupported = errors.New("reddit client does not support this sort")
+
+// listingClient returns c as a ListingClient, if it is one
+func listingClient(c RedditClient) (ListingClient, error) {
+	lc, ok := c.(ListingClient)
+	if !ok {
+		return nil, ErrSortUnsupported
+	}
+	return lc, nil
+}
+
+// unwrapClient returns the client beneath the archiver's metering, timeout,
+// throttling and retry wrappers, which implement every optional client
+// interface
+func unwrapClient(c RedditClient) RedditClient {
+	for {
+		switch w := c.(type) {
+		case *throttledClient:
+			c = w.client
+		case *retryingClient:
+			c = w.client
+		case *meteredClient:
+			c = w.client
+		case *timeoutClient:
+			c = w.client
+		default:
+			return c
+		}
+	}
+}
+
 // Archiver combines Reddit API client with storage backend
 type Archiver struct {
-	client  *graw.Client
-	storage Storage
+	client       RedditClient
+	storage      Storage
+	progress     ProgressFunc
+	logger       *slog.Logger
+	scoreHistory bool
+	metrics      *archiverMetrics
+
+	onPostArchived     func(*types.Post)
+	onCommentsArchived func(postID string, count int)
+	onError            func(op string, err error)
+
+	pause    pauseGate
+	schedule schedule
+}
+
+// NewArchiver creates a new archiver instance. Transient API errors are
+// retried with DefaultRetryPolicy.
+func NewArchiver(client RedditClient, storage Storage) *Archiver {
+	return NewArchiverWithOptions(client, storage, nil)
+}
+
+// ArchiverOptions configures an Archiver
+type ArchiverOptions struct {
+	// MinRequestInterval is the least time between the starts of successive
+	// Reddit API calls, across every archiver method, to stay under
+	// Reddit's rate limits. Waits end early with ctx's error if ctx is
+	// cancelled. 0 makes calls back to back.
+	// Default: 0
+	MinRequestInterval time.Duration
+
+	// Retry controls retrying API calls that fail with a transient error.
+	// Each retry also waits out MinRequestInterval. Set MaxAttempts to 1 to
+	// disable retries.
+	// Default: DefaultRetryPolicy()
+	Retry *RetryPolicy
+
+	// RequestTimeout bounds each Reddit API call, so a hung request can't
+	// stall an archive, including ContinuousArchive, forever. Each retry
+	// attempt gets its own deadline, and a call that runs out fails with an
+	// error wrapping context.DeadlineExceeded, which is retried like other
+	// transient errors. The caller's ctx still cancels the whole operation.
+	// 0 leaves calls bounded only by ctx.
+	// Default: 0
+	RequestTimeout time.Duration
+
+	// Progress, if set, is called after each batch of work in
+	// ArchiveSubreddit, ArchiveSearch, Backfill and UpdateScores
+	Progress ProgressFunc
+
+	// Logger receives the archiver's events, such as a post whose comments
+	// couldn't be fetched or a retried API call, with subreddit, post_id and
+	// error attributes where they apply. Use a logger with a discarding
+	// handler to silence them.
+	// Default: slog.Default()
+	Logger *slog.Logger
+
+	// RecordScoreHistory saves a ScoreSnapshot of every post the archiver
+	// saves or refreshes, building a time series of its score and comment
+	// count (see Storage.GetScoreHistory). It costs an extra write per
+	// batch of posts.
+	// Default: false
+	RecordScoreHistory bool
+
+	// OnPostArchived, if set, is called with each post right after it is
+	// saved, for example to index it elsewhere. Like the other hooks it runs
+	// synchronously, and concurrently when ArchiveOptions.Concurrency is
+	// above 1. A panic is recovered and reported as an error wrapping
+	// ErrHookPanic, as if the save had failed.
+	OnPostArchived func(post *types.Post)
+
+	// OnCommentsArchived, if set, is called with a post's ID and the number
+	// of its comments just saved
+	OnCommentsArchived func(postID string, count int)
+
+	// OnError, if set, is called with the errors the archiver logs and works
+	// past, such as a post whose comments couldn't be fetched, and the step
+	// that failed. Errors returned to the caller aren't passed to it.
+	OnError func(op string, err error)
 }
 
-// NewArchiver creates a new archiver instance
-func NewArchiver(client *graw.Client, storage Storage) *Archiver {
+// Progress reports how far an archive operation has got. Counts are totals
+// for the operation so far.
+type Progress struct {
+	Op            string // "archive_subreddit", "archive_new", "archive_search", "backfill" or "update_scores"
+	Subreddit     string
+	PostsFetched  int    // Posts fetched from Reddit
+	PostsSaved    int    // Posts written to storage
+	CommentsSaved int    // Comments written to storage
+	Page          int    // Listing pages fetched (Backfill only)
+	After         string // Cursor of the next listing page (Backfill only)
+	Errors        int    // Posts skipped after a fetch or save error
+}
+
+// ProgressFunc receives Progress reports. A single operation never calls it
+// concurrently, but a slow callback slows the archive down.
+type ProgressFunc func(Progress)
+
+// NewArchiverWithOptions creates an archiver with custom options
+func NewArchiverWithOptions(client RedditClient, storage Storage, opts *ArchiverOptions) *Archiver {
+	if opts == nil {
+		opts = &ArchiverOptions{}
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	metrics := &archiverMetrics{}
+	client = &meteredClient{client: client, metrics: metrics}
+
+	if opts.RequestTimeout > 0 {
+		client = &timeoutClient{client: client, timeout: opts.RequestTimeout}
+	}
+
+	if opts.MinRequestInterval > 0 {
+		client = &throttledClient{client: client, interval: opts.MinRequestInterval}
+	}
+
+	policy := DefaultRetryPolicy()
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+	if policy.MaxAttempts > 1 {
+		client = &retryingClient{client: client, policy: policy, logger: logger, metrics: metrics}
+	}
+
 	return &Archiver{
-		client:  client,
-		storage: storage,
+		client:       client,
+		storage:      storage,
+		progress:     opts.Progress,
+		logger:       logger,
+		scoreHistory: opts.RecordScoreHistory,
+		metrics:      metrics,
+
+		onPostArchived:     opts.OnPostArchived,
+		onCommentsArchived: opts.OnCommentsArchived,
+		onError:            opts.OnError,
+	}
+}
+
+// report passes p to the progress callback, if one is set
+func (a *Archiver) report(p *Progress) {
+	if a.progress != nil {
+		a.progress(*p)
+	}
+}
+
+// Flusher is implemented by storage that buffers writes. Flush must persist
+// everything buffered so far before returning.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush persists writes buffered below the archiver. The archiver saves
+// synchronously, so there is only work to do when the storage buffers writes
+// and implements Flusher. ContinuousArchive and BackfillSubreddit call it
+// before returning on cancellation.
+func (a *Archiver) Flush(ctx context.Context) error {
+	if f, ok := a.storage.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// flushOnCancel flushes buffered writes after ctx is cancelled and returns
+// the error the cancelled operation should report
+func (a *Archiver) flushOnCancel(ctx context.Context) error {
+	if err := a.Flush(context.WithoutCancel(ctx)); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// SortType selects the listing ArchiveSubreddit fetches posts from
+type SortType string
+
+// Listings accepted by ArchiveOptions.Sort
+const (
+	SortHot    SortType = "hot"
+	SortNew    SortType = "new"
+	SortTop    SortType = "top"    // Needs a ListingClient
+	SortRising SortType = "rising" // Needs a ListingClient
+)
+
+// Valid reports whether s is one of the SortType constants
+func (s SortType) Valid() bool {
+	switch s {
+	case SortHot, SortNew, SortTop, SortRising:
+		return true
+	}
+	return false
+}
+
+// TimeRange selects the period the "top" listing ranks posts over
+type TimeRange string
+
+// Periods accepted by ArchiveOptions.TimeRange
+const (
+	TimeRangeDay   TimeRange = "day"
+	TimeRangeWeek  TimeRange = "week"
+	TimeRangeMonth TimeRange = "month"
+	TimeRangeYear  TimeRange = "year"
+	TimeRangeAll   TimeRange = "all"
+)
+
+// Valid reports whether r is one of the TimeRange constants
+func (r TimeRange) Valid() bool {
+	switch r {
+	case TimeRangeDay, TimeRangeWeek, TimeRangeMonth, TimeRangeYear, TimeRangeAll:
+		return true
 	}
+	return false
 }
 
 // ArchiveOptions configures archiving behavior
 type ArchiveOptions struct {
-	Sort            string // "hot", "new", "top"
-	Limit           int    // Max posts to fetch per batch
-	IncludeComments bool   // Whether to archive comments
-	MaxCommentDepth int    // Maximum depth for comment trees
-	UpdateExisting  bool   // Re-fetch and update existing posts
+	Sort            SortType  // SortHot (default), SortNew, SortTop or SortRising
+	TimeRange       TimeRange // Period for SortTop (Reddit defaults to TimeRangeDay); only valid with SortTop
+	Limit           int       // Max posts to fetch per batch
+	IncludeComments bool      // Whether to archive comments
+	MaxCommentDepth int       // Drop comments this deep or deeper via SaveCommentsOptions.MaxDepth (1 = top-level only, 0 = no limit); lossy
+	UpdateExisting  bool      // Re-fetch comments for stored posts even if their num_comments hasn't changed
+	AccountID       string    // Tag saved posts and comments with the archiving account (QueryOptions.Account filters on it)
+	ResolveMedia    bool      // Store each post's media type and dimensions (see ParseMediaInfo)
+
+	// SkipCompleteThreads skips fetching comments for posts whose stored
+	// comment count already reaches the num_comments Reddit reports, saving
+	// API calls on repeated passes. Reddit's count includes comments that
+	// are never archived (removed, or beyond MaxCommentDepth), so such
+	// threads are still re-fetched.
+	SkipCompleteThreads bool
+
+	// Concurrency is how many posts' comments are fetched and saved at once.
+	// 0 or 1 archives them one after another.
+	Concurrency int
+
+	// MaxMoreRequests is how many follow-up requests may be spent per post
+	// expanding the "more" stubs Reddit leaves in large threads, each loading
+	// up to 100 hidden comments. Comments left unexpanded when the budget
+	// runs out are counted in StoredPost.MoreCommentsCount. Expanding needs a
+	// client implementing MoreCommentsClient. 0 stores only the comments in
+	// the first response.
+	MaxMoreRequests int
+
+	// CommentBatchSize is how many of a thread's comments are written per
+	// transaction (see SaveCommentsOptions.BatchSize), so saving a megathread
+	// doesn't block readers for seconds and a failure keeps the batches
+	// already written. 0 uses the storage backend's MaxBatchSize.
+	CommentBatchSize int
+
+	// FailIfErrorRateAbove makes ArchiveSubreddit and ArchiveSearch return
+	// an error wrapping ErrErrorRateExceeded when more than this fraction
+	// of the threads they fetched failed, for example because Reddit
+	// rate limited most comment requests. Everything that could be saved
+	// still is. 0 never fails on per-post errors; they are only listed in
+	// the result.
+	FailIfErrorRateAbove float64
+
+	// ArchiveCrosspostParents also saves the post each crosspost was made
+	// from, without its comments, when it isn't stored yet. Finding parents
+	// needs a client implementing CrosspostClient, and fetching them one
+	// implementing InfoClient. A parent that can't be fetched is logged and
+	// skipped.
+	ArchiveCrosspostParents bool
+}
+
+// ErrErrorRateExceeded is wrapped by the error returned when the share of
+// posts whose comments failed exceeds ArchiveOptions.FailIfErrorRateAbove
+var ErrErrorRateExceeded = errors.New("comment error rate exceeded")
+
+// ArchiveResult summarizes what an archive operation stored
+type ArchiveResult struct {
+	PostsSaved    int
+	NewPosts      int // Posts among PostsSaved that weren't stored before; set by ArchiveSubreddit and ArchiveSearch
+	CommentsSaved int
+	PostsSkipped  int      // Posts whose comments weren't fetched because the stored thread was unchanged or complete
+	FailedPosts   []string // IDs of posts whose comments could not be archived
+	Duration      time.Duration
+
+	// PostErrors holds the error each of FailedPosts failed with
+	PostErrors map[string]error
+}
+
+// fail records that postID's comments could not be archived
+func (r *ArchiveResult) fail(postID string, err error) {
+	if r.PostErrors == nil {
+		r.PostErrors = make(map[string]error)
+	}
+	r.FailedPosts = append(r.FailedPosts, postID)
+	r.PostErrors[postID] = err
+}
+
+// Err joins the errors of FailedPosts into one error, in the order the posts
+// failed, or returns nil if every thread was archived
+func (r *ArchiveResult) Err() error {
+	errs := make([]error, len(r.FailedPosts))
+	for i, postID := range r.FailedPosts {
+		errs[i] = fmt.Errorf("post %s: %w", postID, r.PostErrors[postID])
+	}
+	return errors.Join(errs...)
+}
+
+// checkErrorRate returns an error wrapping ErrErrorRateExceeded and the
+// per-post errors if more than maxRate of the threads fetched failed
+func (r *ArchiveResult) checkErrorRate(maxRate float64) error {
+	fetched := r.PostsSaved - r.PostsSkipped
+	if maxRate <= 0 || fetched <= 0 || float64(len(r.FailedPosts)) <= maxRate*float64(fetched) {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d threads failed: %w", ErrErrorRateExceeded, len(r.FailedPosts), fetched, r.Err())
+}
+
+// String formats the result as a one-line summary for logs
+func (r *ArchiveResult) String() string {
+	return fmt.Sprintf("%d posts saved (%d skipped), %d comments saved, %d posts failed in %s",
+		r.PostsSaved, r.PostsSkipped, r.CommentsSaved, len(r.FailedPosts), r.Duration.Round(time.Millisecond))
+}
+
+// ArchiveSubreddit fetches and stores posts from a subreddit. The run's
+// timings are recorded with Storage.RecordArchiveRun. The result covers
+// whatever was stored before an error, including when ctx is cancelled.
+// Posts whose comments fail are skipped and listed in the result, whose Err
+// joins their errors; set opts.FailIfErrorRateAbove to have too many
+// failures returned as an error too.
+func (a *Archiver) ArchiveSubreddit(ctx context.Context, subreddit string, opts ArchiveOptions) (*ArchiveResult, error) {
+	result := &ArchiveResult{}
+
+	// Reject a typo or an unfetchable listing before spending API calls or
+	// recording a run
+	if err := a.checkOptions(opts); err != nil {
+		return result, &StorageError{Op: "archive_subreddit", Err: err}
+	}
+
+	start := time.Now()
+	run := &ArchiveRun{Subreddit: subreddit, StartedAt: start}
+	err := a.archiveSubreddit(ctx, subreddit, opts, run, result)
+	if ctx.Err() != nil {
+		err = a.flushOnCancel(ctx)
+	} else if err == nil {
+		if rateErr := result.checkErrorRate(opts.FailIfErrorRateAbove); rateErr != nil {
+			err = &StorageError{Op: "archive_subreddit", Err: rateErr}
+		}
+	}
+	run.PostsProcessed = result.PostsSaved
+	run.CommentsSaved = result.CommentsSaved
+	a.recordRun(ctx, run, err)
+
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// SubredditsResult summarizes an ArchiveSubreddits call
+type SubredditsResult struct {
+	Succeeded []string         // Subreddits archived without error, in order
+	Failed    map[string]error // Error each failed subreddit stopped with
+}
+
+// Err joins the failures into one error, or returns nil if every subreddit
+// succeeded
+func (r *SubredditsResult) Err() error {
+	var errs []error
+	for _, subreddit := range slices.Sorted(maps.Keys(r.Failed)) {
+		errs = append(errs, fmt.Errorf("r/%s: %w", subreddit, r.Failed[subreddit]))
+	}
+	return errors.Join(errs...)
+}
+
+// ArchiveSubreddits archives each subreddit in turn exactly as
+// ArchiveSubreddit does, sharing the archiver's rate limiting and retries. A
+// subreddit that fails is logged and recorded in the result's Failed map, and
+// the rest are still archived. If ctx is cancelled the remaining subreddits
+// are skipped and ctx's error is returned along with the result so far.
+func (a *Archiver) ArchiveSubreddits(ctx context.Context, subreddits []string, opts ArchiveOptions) (*SubredditsResult, error) {
+	result := &SubredditsResult{Failed: make(map[string]error)}
+
+	// Options that fail one subreddit would fail them all
+	if err := a.checkOptions(opts); err != nil {
+		return result, &StorageError{Op: "archive_subreddits", Err: err}
+	}
+	for _, subreddit := range subreddits {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if _, err := a.ArchiveSubreddit(ctx, subreddit, opts); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			a.logger.Error("archiving subreddit failed", "subreddit", subreddit, "error", err)
+			a.reportError("archive_subreddit", err)
+			result.Failed[subreddit] = err
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, subreddit)
+	}
+	return result, nil
+}
+
+// checkOptions reports whether opts asks for anything the archiver can't
+// fetch
+func (a *Archiver) checkOptions(opts ArchiveOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := a.checkCrosspostOptions(opts); err != nil {
+		return err
+	}
+	if opts.Sort == SortTop || opts.Sort == SortRising {
+		if _, err := listingClient(unwrapClient(a.client)); err != nil {
+			return fmt.Errorf("sort %s: %w", opts.Sort, err)
+		}
+	}
+	if opts.MaxMoreRequests > 0 {
+		if _, err := moreCommentsClient(unwrapClient(a.client)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// ArchiveSubreddit fetches and stores posts from a subreddit
-func (a *Archiver) ArchiveSubreddit(ctx context.Context, subreddit string, opts ArchiveOptions) error {
+func (a *Archiver) archiveSubreddit(ctx context.Context, subreddit string, opts ArchiveOptions, run *ArchiveRun, result *ArchiveResult) error {
 	// Fetch subreddit info first
+	fetchStart := time.Now()
 	subInfo, err := a.client.GetSubreddit(ctx, subreddit)
+	run.FetchDuration += time.Since(fetchStart)
 	if err != nil {
 		return &StorageError{Op: "fetch_subreddit", Err: err}
 	}
 
-	if err := a.storage.SaveSubreddit(ctx, subInfo); err != nil {
+	saveStart := time.Now()
+	err = a.storage.SaveSubreddit(ctx, subInfo)
+	run.SaveDuration += time.Since(saveStart)
+	if err != nil {
 		return err
 	}
 
@@ -50,7 +550,7 @@ func (a *Archiver) ArchiveSubreddit(ctx context.Context, subreddit string, opts
 		opts.Limit = 25
 	}
 	if opts.Sort == "" {
-		opts.Sort = "hot"
+		opts.Sort = SortHot
 	}
 
 	// Fetch posts based on sort type
@@ -62,198 +562,1482 @@ func (a *Archiver) ArchiveSubreddit(ctx context.Context, subreddit string, opts
 		},
 	}
 
+	fetchStart = time.Now()
 	switch opts.Sort {
-	case "hot":
+	case SortHot:
 		postsResponse, err = a.client.GetHot(ctx, req)
-	case "new", "top":
-		// Note: "top" is not yet supported by the API wrapper, so we use "new"
+	case SortNew:
 		postsResponse, err = a.client.GetNew(ctx, req)
+	case SortTop, SortRising:
+		var lc ListingClient
+		if lc, err = listingClient(a.client); err != nil {
+			break
+		}
+		if opts.Sort == SortTop {
+			postsResponse, err = lc.GetTop(ctx, req, opts.TimeRange)
+		} else {
+			postsResponse, err = lc.GetRising(ctx, req)
+		}
 	default:
 		return &StorageError{Op: "archive_subreddit", Err: fmt.Errorf("invalid sort type: %s", opts.Sort)}
 	}
+	run.FetchDuration += time.Since(fetchStart)
 
 	if err != nil {
 		return &StorageError{Op: "fetch_posts", Err: err}
 	}
 
 	posts := postsResponse.Posts
+	progress := &Progress{Op: "archive_subreddit", Subreddit: subreddit, PostsFetched: len(posts)}
+
+	if err := a.countNewPosts(ctx, posts, result); err != nil {
+		return err
+	}
 
 	// Save posts
-	if err := a.storage.SavePosts(ctx, posts); err != nil {
+	saveStart = time.Now()
+	err = a.savePosts(ctx, posts, opts)
+	run.SaveDuration += time.Since(saveStart)
+	if err != nil {
 		return err
 	}
+	result.PostsSaved = len(posts)
+	progress.PostsSaved = len(posts)
+	a.report(progress)
 
 	// Archive comments if requested
 	if opts.IncludeComments {
-		for _, post := range posts {
-			if err := a.ArchivePost(ctx, subreddit, post.ID, true); err != nil {
-				// Log error but continue with other posts
-				log.Printf("Error archiving comments for post %s: %v", post.ID, err)
-			}
+		pending, err := a.threadsToFetch(ctx, posts, opts, result)
+		if err != nil {
+			return err
 		}
+		return a.archiveComments(ctx, subreddit, pending, opts, run, progress, result)
 	}
 
 	return nil
 }
 
-// ArchivePost fetches and stores a single post with comments
-func (a *Archiver) ArchivePost(ctx context.Context, subreddit, postID string, includeComments bool) error {
-	// Fetch post and comments
-	commentsReq := &types.CommentsRequest{
-		Subreddit: subreddit,
-		PostID:    postID,
+// countNewPosts counts the posts not stored yet in result.NewPosts. It must
+// run before the posts are saved.
+func (a *Archiver) countNewPosts(ctx context.Context, posts []*types.Post, result *ArchiveResult) error {
+	if len(posts) == 0 {
+		return nil
 	}
 
-	commentsResp, err := a.client.GetComments(ctx, commentsReq)
+	stored, err := a.storage.GetStoredNumComments(ctx, postIDs(posts))
 	if err != nil {
-		return &StorageError{Op: "fetch_post_and_comments", Err: err}
+		return err
+	}
+	for _, post := range posts {
+		if _, ok := stored[post.ID]; !ok {
+			result.NewPosts++
+		}
 	}
+	return nil
+}
 
-	// Save post
-	if err := a.storage.SavePost(ctx, commentsResp.Post); err != nil {
-		return err
+// threadsToFetch returns the IDs of the posts whose comments need fetching:
+// every post with opts.UpdateExisting, otherwise those whose comments were
+// never fetched successfully or whose num_comments changed since, and with
+// opts.SkipCompleteThreads those not already complete. The check uses
+// Storage.GetCommentsFetched rather than the stored num_comments, which
+// only says what Reddit reported, so a thread whose fetch failed or that was
+// saved without its comments is fetched again. Skipped posts are counted in
+// result.PostsSkipped.
+func (a *Archiver) threadsToFetch(ctx context.Context, posts []*types.Post, opts ArchiveOptions, result *ArchiveResult) ([]string, error) {
+	ids := postIDs(posts)
+
+	var fetched map[string]int
+	if !opts.UpdateExisting {
+		var err error
+		if fetched, err = a.storage.GetCommentsFetched(ctx, ids); err != nil {
+			return nil, err
+		}
 	}
 
-	// Save comments if requested and available
-	if includeComments && len(commentsResp.Comments) > 0 {
-		if err := a.storage.SaveComments(ctx, commentsResp.Comments); err != nil {
-			return err
+	var archived map[string]int
+	if opts.SkipCompleteThreads {
+		var err error
+		if archived, err = a.storage.GetArchivedCommentCounts(ctx, ids); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	var pending []string
+	for _, post := range posts {
+		if count, ok := fetched[post.ID]; ok && count == post.NumComments {
+			result.PostsSkipped++
+			continue
+		}
+		if count, ok := archived[post.ID]; ok && count >= post.NumComments {
+			result.PostsSkipped++
+			continue
+		}
+		pending = append(pending, post.ID)
+	}
+	return pending, nil
 }
 
-// ContinuousArchive continuously monitors and archives new content
-func (a *Archiver) ContinuousArchive(ctx context.Context, subreddit string, interval time.Duration) error {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// Initial archive
-	opts := ArchiveOptions{
-		Sort:            "new",
-		Limit:           25,
-		IncludeComments: true,
+// postIDs returns the IDs of posts
+func postIDs(posts []*types.Post) []string {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
 	}
+	return ids
+}
+
+// archiveComments archives the comments of each post in postIDs, using
+// opts.Concurrency workers when it is above 1, and reports progress after
+// each post. A post that fails is logged and listed in result.FailedPosts.
+// Each post's comments are saved as soon as they are fetched, so when ctx
+// is cancelled the workers stop after their current post and ctx's error is
+// returned with everything finished so far already stored.
+func (a *Archiver) archiveComments(ctx context.Context, subreddit string, postIDs []string, opts ArchiveOptions, run *ArchiveRun, progress *Progress, result *ArchiveResult) error {
+	if opts.Concurrency <= 1 {
+		for _, postID := range postIDs {
+			if err := a.waitIfPaused(ctx); err != nil {
+				return err
+			}
 
-	if err := a.ArchiveSubreddit(ctx, subreddit, opts); err != nil {
-		log.Printf("Error during initial archive: %v", err)
+			count, err := a.archivePost(ctx, subreddit, postID, true, opts, run)
+			if err != nil {
+				// Log error but continue with other posts
+				a.logger.Warn("archiving comments failed", "subreddit", subreddit, "post_id", postID, "error", err)
+				a.reportError("archive_comments", err)
+				result.fail(postID, err)
+				progress.Errors++
+			} else {
+				result.CommentsSaved += count
+				progress.CommentsSaved += count
+			}
+			a.report(progress)
+		}
+		return nil
 	}
 
-	// Continuous monitoring
-	for {
-		select {
-		case <-ticker.C:
-			if err := a.ArchiveSubreddit(ctx, subreddit, opts); err != nil {
-				log.Printf("Error during continuous archive: %v", err)
+	jobs := make(chan string)
+	var mu sync.Mutex // Guards run, progress and result
+	var wg sync.WaitGroup
+
+	for range min(opts.Concurrency, len(postIDs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for postID := range jobs {
+				if a.waitIfPaused(ctx) != nil {
+					return
+				}
+
+				// Timings are collected per post and summed, so with
+				// several workers they add up to more than wall time
+				var timing ArchiveRun
+				count, err := a.archivePost(ctx, subreddit, postID, true, opts, &timing)
+
+				mu.Lock()
+				run.FetchDuration += timing.FetchDuration
+				run.SaveDuration += timing.SaveDuration
+				if err == nil {
+					result.CommentsSaved += count
+					progress.CommentsSaved += count
+				} else {
+					result.fail(postID, err)
+					progress.Errors++
+				}
+				a.report(progress)
+				mu.Unlock()
+
+				if err != nil {
+					a.logger.Warn("archiving comments failed", "subreddit", subreddit, "post_id", postID, "error", err)
+					a.reportError("archive_comments", err)
+				}
 			}
+		}()
+	}
 
+send:
+	for _, postID := range postIDs {
+		select {
+		case jobs <- postID:
 		case <-ctx.Done():
-			return ctx.Err()
+			break send
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
+	return ctx.Err()
 }
 
-// UpdateScores refreshes scores for recently archived posts
-func (a *Archiver) UpdateScores(ctx context.Context, subreddit string, maxAge time.Duration) error {
-	// Calculate cutoff time
-	cutoff := time.Now().Add(-maxAge)
+// ArchivePost fetches and stores a single post with all its fetched
+// comments. Use ArchivePostWithOptions to apply MaxCommentDepth.
+func (a *Archiver) ArchivePost(ctx context.Context, subreddit, postID string, includeComments bool) (*ArchiveResult, error) {
+	return a.archivePostResult(ctx, subreddit, postID, includeComments, ArchiveOptions{})
+}
 
-	// Fetch recent posts from storage
-	opts := QueryOptions{
-		Limit:     100,
-		SortBy:    "created",
-		SortOrder: "desc",
-		StartDate: cutoff,
+// ArchivePostWithOptions fetches and stores a single post like ArchivePost,
+// applying opts.IncludeComments, MaxCommentDepth, MaxMoreRequests,
+// CommentBatchSize, AccountID and ResolveMedia.
+func (a *Archiver) ArchivePostWithOptions(ctx context.Context, subreddit, postID string, opts ArchiveOptions) (*ArchiveResult, error) {
+	if err := a.checkOptions(opts); err != nil {
+		return &ArchiveResult{}, &StorageError{Op: "archive_post", Err: err}
 	}
+	return a.archivePostResult(ctx, subreddit, postID, opts.IncludeComments, opts)
+}
 
-	posts, err := a.storage.GetPostsBySubreddit(ctx, subreddit, opts)
+// archivePostResult runs archivePost and summarizes it as an ArchiveResult
+func (a *Archiver) archivePostResult(ctx context.Context, subreddit, postID string, includeComments bool, opts ArchiveOptions) (*ArchiveResult, error) {
+	start := time.Now()
+	count, err := a.archivePost(ctx, subreddit, postID, includeComments, opts, &ArchiveRun{})
+	result := &ArchiveResult{Duration: time.Since(start)}
 	if err != nil {
-		return err
+		return result, err
 	}
+	result.PostsSaved = 1
+	result.CommentsSaved = count
+	return result, nil
+}
 
-	// Update each post
-	for _, post := range posts {
-		commentsReq := &types.CommentsRequest{
-			Subreddit: subreddit,
-			PostID:    post.ID,
-		}
-
-		commentsResp, err := a.client.GetComments(ctx, commentsReq)
-		if err != nil {
-			log.Printf("Error fetching updated post %s: %v", post.ID, err)
-			continue
-		}
+// GetOrArchivePost returns the stored copy of a post, archiving it from Reddit
+// first when it isn't stored yet. Storage errors other than ErrNotFound are
+// returned without contacting Reddit.
+func (a *Archiver) GetOrArchivePost(ctx context.Context, subreddit, postID string, includeComments bool) (*types.Post, error) {
+	post, err := a.storage.GetPost(ctx, postID)
+	if err == nil {
+		return post, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
 
-		if err := a.storage.SavePost(ctx, commentsResp.Post); err != nil {
-			log.Printf("Error saving updated post %s: %v", post.ID, err)
-			continue
-		}
+	if _, err := a.ArchivePost(ctx, subreddit, postID, includeComments); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return a.storage.GetPost(ctx, postID)
 }
 
-// BackfillSubreddit archives historical posts from a subreddit
-func (a *Archiver) BackfillSubreddit(ctx context.Context, subreddit string, maxPosts int, includeComments bool) error {
-	fetched := 0
-	after := ""
-
-	for fetched < maxPosts {
-		// Calculate batch size
-		batchSize := 100
-		if maxPosts-fetched < batchSize {
-			batchSize = maxPosts - fetched
-		}
+// PostDiff describes how a post on Reddit differs from its stored copy
+type PostDiff struct {
+	PostID        string
+	ScoreDelta    int  // Current score minus stored score
+	CommentsDelta int  // Current comment count minus stored count
+	Edited        bool // Edited on Reddit since the stored copy was saved
+	Removed       bool // Removed or deleted on Reddit since the stored copy was saved
 
-		// Fetch batch of posts
-		req := &types.PostsRequest{
-			Subreddit: subreddit,
-			Pagination: types.Pagination{
-				Limit: batchSize,
-				After: after,
-			},
-		}
+	Stored  *types.Post
+	Current *types.Post
+}
 
-		postsResponse, err := a.client.GetNew(ctx, req)
-		if err != nil {
-			return &StorageError{Op: "backfill_fetch", Err: err}
-		}
+// Changed reports whether any tracked field differs
+func (d *PostDiff) Changed() bool {
+	return d.ScoreDelta != 0 || d.CommentsDelta != 0 || d.Edited || d.Removed
+}
 
-		if len(postsResponse.Posts) == 0 {
-			break // No more posts
-		}
+// DiffPost fetches the current version of a stored post from Reddit and
+// reports what changed since it was saved. Nothing is written, so it can feed
+// monitoring without touching the archive; use ArchivePost to store the
+// changes. A post that isn't stored returns an error wrapping ErrNotFound.
+func (a *Archiver) DiffPost(ctx context.Context, subreddit, postID string) (*PostDiff, error) {
+	stored, err := a.storage.GetPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
 
-		// Save posts
-		if err := a.storage.SavePosts(ctx, postsResponse.Posts); err != nil {
-			return err
-		}
+	commentsResp, err := a.client.GetComments(ctx, &types.CommentsRequest{
+		Subreddit: subreddit,
+		PostID:    postID,
+	})
+	if err != nil {
+		return nil, &StorageError{Op: "fetch_post", Err: err}
+	}
+	current := commentsResp.Post
+	if current == nil {
+		return nil, &StorageError{Op: "fetch_post", Err: fmt.Errorf("post %s: %w", postID, ErrNotFound)}
+	}
 
-		// Archive comments if requested
-		if includeComments {
-			for _, post := range postsResponse.Posts {
-				if err := a.ArchivePost(ctx, subreddit, post.ID, true); err != nil {
-					log.Printf("Error archiving comments for post %s: %v", post.ID, err)
-				}
-			}
-		}
+	removed := IsRemovedPost(current) && !IsRemovedPost(stored)
 
-		fetched += len(postsResponse.Posts)
-		log.Printf("Backfilled %d/%d posts from r/%s", fetched, maxPosts, subreddit)
+	// Edit timestamps round-trip through storage at second precision
+	edited := current.Edited.IsEdited &&
+		int64(current.Edited.Timestamp) > int64(stored.Edited.Timestamp)
+	if !removed && current.SelfText != stored.SelfText {
+		edited = true
+	}
 
-		// Update after parameter for pagination
-		after = postsResponse.AfterFullname
-		if after == "" {
-			break // No more pages
-		}
+	return &PostDiff{
+		PostID:        postID,
+		ScoreDelta:    current.Score - stored.Score,
+		CommentsDelta: current.NumComments - stored.NumComments,
+		Edited:        edited,
+		Removed:       removed,
+		Stored:        stored,
+		Current:       current,
+	}, nil
+}
 
-		// Check context cancellation
-		select {
+// archivePost fetches and stores a single post, returning how many comments
+// were saved. Comments opts.MaxCommentDepth or more levels deep are dropped
+// (0 for no limit), and up to opts.MaxMoreRequests follow-up requests expand
+// "more" stubs. Time spent fetching and saving is added to run.
+func (a *Archiver) archivePost(ctx context.Context, subreddit, postID string, includeComments bool, opts ArchiveOptions, run *ArchiveRun) (int, error) {
+	// Fetch post and comments
+	commentsReq := &types.CommentsRequest{
+		Subreddit: subreddit,
+		PostID:    postID,
+	}
+
+	fetchStart := time.Now()
+	commentsResp, err := a.client.GetComments(ctx, commentsReq)
+	run.FetchDuration += time.Since(fetchStart)
+	if err != nil {
+		return 0, &StorageError{Op: "fetch_post_and_comments", Err: err}
+	}
+
+	comments := dropPlaceholderComments(commentsResp.Comments)
+	moreIDs := commentsResp.MoreIDs
+	if includeComments && opts.MaxMoreRequests > 0 && len(moreIDs) > 0 {
+		fetchStart = time.Now()
+		var expanded []*types.Comment
+		expanded, moreIDs, err = a.expandMoreComments(ctx, postID, moreIDs, opts.MaxMoreRequests)
+		run.FetchDuration += time.Since(fetchStart)
+		if err != nil {
+			// Keep what was expanded before the failure
+			a.logger.Warn("expanding more comments failed", "subreddit", subreddit, "post_id", postID, "error", err)
+			a.reportError("expand_more_comments", err)
+		}
+		comments = append(comments, dropPlaceholderComments(expanded)...)
+	}
+
+	saveStart := time.Now()
+	defer func() { run.SaveDuration += time.Since(saveStart) }()
+
+	// Save post along with how much of its thread is still unexpanded
+	stored := a.storedPosts([]*types.Post{commentsResp.Post}, opts)
+	moreComments := len(moreIDs)
+	stored[0].MoreCommentsCount = &moreComments
+	if err := a.storage.SaveStoredPosts(ctx, stored); err != nil {
+		return 0, err
+	}
+	if err := a.postsSaved(ctx, commentsResp.Post); err != nil {
+		return 0, err
+	}
+	a.archiveCrosspostParents(ctx, stored, opts)
+
+	if !includeComments {
+		return 0, nil
+	}
+
+	saved := 0
+	if len(comments) > 0 {
+		saved, err = a.storage.SaveCommentsWithOptions(ctx, comments, SaveCommentsOptions{
+			MaxDepth:  opts.MaxCommentDepth,
+			Account:   opts.AccountID,
+			BatchSize: opts.CommentBatchSize,
+		})
+		a.metrics.saved(0, saved)
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	// Only now is the thread archived; threadsToFetch skips it until its
+	// num_comments changes
+	if err := a.storage.MarkCommentsFetched(ctx, postID, commentsResp.Post.NumComments); err != nil {
+		return saved, err
+	}
+	return saved, a.commentsArchived(postID, saved)
+}
+
+// expandMoreComments loads the comments behind a post's "more" stubs in
+// batches, spending at most maxRequests requests. It returns the comments
+// loaded and the IDs left unexpanded, which on error include the batch that
+// failed.
+func (a *Archiver) expandMoreComments(ctx context.Context, postID string, moreIDs []string, maxRequests int) ([]*types.Comment, []string, error) {
+	client, err := moreCommentsClient(a.client)
+	if err != nil {
+		return nil, moreIDs, err
+	}
+
+	var expanded []*types.Comment
+	for requests := 0; requests < maxRequests && len(moreIDs) > 0; requests++ {
+		batch := moreIDs[:min(moreCommentsBatchSize, len(moreIDs))]
+		comments, err := client.GetMoreComments(ctx, &types.MoreCommentsRequest{
+			LinkID:     postID,
+			CommentIDs: batch,
+		})
+		if err != nil {
+			return expanded, moreIDs, err
+		}
+		expanded = append(expanded, comments...)
+		moreIDs = moreIDs[len(batch):]
+	}
+	return expanded, moreIDs, nil
+}
+
+// savePosts saves posts, tagging them with opts.AccountID when one is set,
+// recording their media metadata when opts.ResolveMedia is on and their
+// crosspost parents when the client reports them
+func (a *Archiver) savePosts(ctx context.Context, posts []*types.Post, opts ArchiveOptions) error {
+	// Plain posts need none of the columns beyond types.Post
+	_, noCrossposts := crosspostClient(unwrapClient(a.client))
+	if noCrossposts != nil && opts.AccountID == "" && !opts.ResolveMedia {
+		if err := a.storage.SavePosts(ctx, posts); err != nil {
+			return err
+		}
+		return a.postsSaved(ctx, posts...)
+	}
+
+	stored := a.storedPosts(posts, opts)
+	if err := a.storage.SaveStoredPosts(ctx, stored); err != nil {
+		return err
+	}
+	if err := a.postsSaved(ctx, posts...); err != nil {
+		return err
+	}
+	a.archiveCrosspostParents(ctx, stored, opts)
+	return nil
+}
+
+// postsSaved does the bookkeeping for posts just written to storage:
+// counting them in the metrics, saving a score snapshot of each when
+// ArchiverOptions.RecordScoreHistory is set, and calling the OnPostArchived
+// hook
+func (a *Archiver) postsSaved(ctx context.Context, posts ...*types.Post) error {
+	a.metrics.saved(len(posts), 0)
+	if a.scoreHistory && len(posts) > 0 {
+		if err := a.recordScores(ctx, posts); err != nil {
+			return err
+		}
+	}
+	return a.postsArchived(posts)
+}
+
+// recordScores saves a ScoreSnapshot of each of posts
+func (a *Archiver) recordScores(ctx context.Context, posts []*types.Post) error {
+	now := time.Now()
+	snapshots := make([]*ScoreSnapshot, len(posts))
+	for i, post := range posts {
+		snapshots[i] = &ScoreSnapshot{
+			PostID:      post.ID,
+			Score:       post.Score,
+			NumComments: post.NumComments,
+			RecordedAt:  now,
+		}
+	}
+	return a.storage.SaveScoreSnapshots(ctx, snapshots)
+}
+
+// storedPosts wraps posts for SaveStoredPosts with the account tag and media
+// metadata opts asks for, and the crosspost parents the client reports
+func (a *Archiver) storedPosts(posts []*types.Post, opts ArchiveOptions) []*StoredPost {
+	stored := make([]*StoredPost, len(posts))
+	for i, post := range posts {
+		stored[i] = &StoredPost{Post: post, Account: opts.AccountID, CrosspostParentID: a.crosspostParentID(post)}
+		if opts.ResolveMedia {
+			stored[i].Media = ParseMediaInfo(post)
+		}
+	}
+	return stored
+}
+
+// dropPlaceholderComments filters out "more" stubs and other placeholders
+// that aren't real comments, so they never become bogus comment rows. The
+// API wrapper normally reports these as MoreIDs, but a stub that slips
+// through shows up with no ID, the "_" ID of a "continue this thread" link,
+// or a fullname of another kind.
+func dropPlaceholderComments(comments []*types.Comment) []*types.Comment {
+	kept := make([]*types.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if comment == nil || comment.ID == "" || comment.ID == "_" {
+			continue
+		}
+		if comment.Name != "" && !strings.HasPrefix(comment.Name, "t1_") {
+			continue
+		}
+		kept = append(kept, comment)
+	}
+	return kept
+}
+
+// recordRun stores a finished run's timings. Failing to record is logged
+// rather than returned so monitoring never fails an archive.
+func (a *Archiver) recordRun(ctx context.Context, run *ArchiveRun, err error) {
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	// Record even when the run ended because ctx was cancelled
+	if recErr := a.storage.RecordArchiveRun(context.WithoutCancel(ctx), *run); recErr != nil {
+		a.logger.Warn("recording archive run failed", "subreddit", run.Subreddit, "error", recErr)
+		a.reportError("record_archive_run", recErr)
+	}
+}
+
+// ArchiveNew archives posts created since the newest post already stored for
+// a subreddit. It pages through the "new" listing until it reaches that post,
+// so a scheduled job only fetches the gap since its last run. When nothing is
+// stored yet there is no gap to bound, so a single page of opts.Limit posts is
+// archived. The run's timings are recorded with Storage.RecordArchiveRun.
+// If ctx is cancelled, buffered writes are flushed before returning.
+func (a *Archiver) ArchiveNew(ctx context.Context, subreddit string, opts ArchiveOptions) (*ArchiveResult, error) {
+	if err := a.checkOptions(opts); err != nil {
+		return &ArchiveResult{}, &StorageError{Op: "archive_new", Err: err}
+	}
+
+	run := &ArchiveRun{Subreddit: subreddit, StartedAt: time.Now()}
+	result, err := a.archiveNew(ctx, subreddit, opts, run)
+	if ctx.Err() != nil {
+		err = a.flushOnCancel(ctx)
+	}
+	if result != nil {
+		run.PostsProcessed = result.PostsSaved
+		run.CommentsSaved = result.CommentsSaved
+	}
+	a.recordRun(ctx, run, err)
+	return result, err
+}
+
+func (a *Archiver) archiveNew(ctx context.Context, subreddit string, opts ArchiveOptions, run *ArchiveRun) (*ArchiveResult, error) {
+	start := time.Now()
+	result := &ArchiveResult{}
+	progress := &Progress{Op: "archive_new", Subreddit: subreddit}
+
+	if opts.Limit == 0 {
+		opts.Limit = 25
+	}
+
+	latest, err := a.storage.GetLatestPost(ctx, subreddit)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	after := ""
+	for {
+		if err := a.waitIfPaused(ctx); err != nil {
+			result.Duration = time.Since(start)
+			return result, err
+		}
+
+		req := &types.PostsRequest{
+			Subreddit: subreddit,
+			Pagination: types.Pagination{
+				Limit: opts.Limit,
+				After: after,
+			},
+		}
+
+		fetchStart := time.Now()
+		postsResponse, err := a.client.GetNew(ctx, req)
+		run.FetchDuration += time.Since(fetchStart)
+		if err != nil {
+			result.Duration = time.Since(start)
+			return result, &StorageError{Op: "fetch_new", Err: err}
+		}
+
+		// Keep only posts newer than the stored latest; the listing is newest
+		// first, so the stored latest itself or an older post marks the end
+		// of the gap. Posts created in the same second as the stored latest
+		// are kept until it is reached, since they may have come after it.
+		var fresh []*types.Post
+		reachedLatest := false
+		for _, post := range postsResponse.Posts {
+			if latest != nil && (post.ID == latest.ID || post.CreatedUTC < latest.CreatedUTC) {
+				reachedLatest = true
+				break
+			}
+			fresh = append(fresh, post)
+		}
+
+		progress.PostsFetched += len(postsResponse.Posts)
+		if len(fresh) > 0 {
+			saveStart := time.Now()
+			err := a.savePosts(ctx, fresh, opts)
+			run.SaveDuration += time.Since(saveStart)
+			if err != nil {
+				result.Duration = time.Since(start)
+				return result, err
+			}
+			result.PostsSaved += len(fresh)
+			progress.PostsSaved += len(fresh)
+		}
+		a.report(progress)
+
+		if opts.IncludeComments {
+			if err := a.archiveComments(ctx, subreddit, postIDs(fresh), opts, run, progress, result); err != nil {
+				result.Duration = time.Since(start)
+				return result, err
+			}
+		}
+
+		if latest == nil || reachedLatest || len(postsResponse.Posts) == 0 || postsResponse.AfterFullname == "" {
+			break
+		}
+		after = postsResponse.AfterFullname
+
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			result.Duration = time.Since(start)
+			return result, ctx.Err()
+		default:
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// ContinuousOptions configures ContinuousArchiveWithOptions and
+// ContinuousArchiveSubreddits
+type ContinuousOptions struct {
+	// Interval is the time between the starts of successive passes over a
+	// subreddit. A pass that overruns it is followed immediately by the next.
+	Interval time.Duration
+
+	// Intervals overrides Interval for the subreddits it lists
+	Intervals map[string]time.Duration
+
+	// Jitter is the fraction, from 0 to 1, by which each interval is
+	// randomly lengthened or shortened, so archivers started together
+	// don't poll Reddit in lockstep. 0 waits exactly Interval.
+	Jitter float64
+
+	// OnCycle, if set, is called after every pass, including the first,
+	// with the subreddit archived and the pass's result and error
+	OnCycle func(subreddit string, result *ArchiveResult, err error)
+
+	// FailIfErrorRateAbove is passed to each pass as
+	// ArchiveOptions.FailIfErrorRateAbove, so a pass in which too many
+	// threads failed is logged and reported to OnCycle as failed
+	FailIfErrorRateAbove float64
+
+	// Adaptive rescales each subreddit's interval after every successful
+	// pass by how many new posts it found (see NextInterval), so quiet
+	// subreddits are polled less often and busy ones more. Interval and
+	// Intervals set where each subreddit starts. Off by default, keeping
+	// the interval fixed.
+	Adaptive bool
+
+	// MinInterval and MaxInterval bound the intervals Adaptive picks. They
+	// default to a minute and an hour.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+}
+
+// continuousLimit is how many of a subreddit's newest posts each continuous
+// pass fetches
+const continuousLimit = 25
+
+// NextInterval returns the interval to wait after a pass that waited current
+// and found newPosts posts not stored before. Passes that find about half of
+// the continuousLimit posts they fetch keep their interval; busier passes
+// shorten it and quieter ones lengthen it, by at most half or double per
+// pass. A pass whose whole listing is new halves it, since posts may have
+// been missed, and one with nothing new doubles it. The result is kept
+// between MinInterval and MaxInterval. It returns current unchanged unless
+// Adaptive is set.
+func (o ContinuousOptions) NextInterval(current time.Duration, newPosts int) time.Duration {
+	if !o.Adaptive {
+		return current
+	}
+
+	var next time.Duration
+	switch {
+	case newPosts <= 0:
+		next = current * 2
+	case newPosts >= continuousLimit:
+		next = current / 2
+	default:
+		factor := float64(continuousLimit/2) / float64(newPosts)
+		next = time.Duration(float64(current) * min(max(factor, 0.5), 2))
+	}
+
+	lo, hi := o.MinInterval, o.MaxInterval
+	if lo <= 0 {
+		lo = time.Minute
+	}
+	if hi <= 0 {
+		hi = time.Hour
+	}
+	return min(max(next, lo), max(hi, lo))
+}
+
+// ContinuousArchive continuously monitors and archives new content
+func (a *Archiver) ContinuousArchive(ctx context.Context, subreddit string, interval time.Duration) error {
+	return a.ContinuousArchiveWithOptions(ctx, subreddit, ContinuousOptions{Interval: interval})
+}
+
+// ContinuousArchiveWithOptions archives a subreddit's newest posts once, then
+// again every opts.Interval, varied by opts.Jitter and rescaled by
+// opts.Adaptive, until ctx is cancelled.
+// Cancellation ends a wait immediately; buffered writes are flushed before
+// ctx's error is returned. A failed pass is logged and the next one still
+// runs.
+func (a *Archiver) ContinuousArchiveWithOptions(ctx context.Context, subreddit string, opts ContinuousOptions) error {
+	return a.ContinuousArchiveSubreddits(ctx, []string{subreddit}, opts)
+}
+
+// ContinuousArchiveSubreddits continuously archives several subreddits from
+// one archiver, as ContinuousArchiveWithOptions does for one. Passes run one
+// at a time, so they share the archiver's rate limiting, and the subreddits'
+// first passes are spread evenly across their interval so they don't all
+// fire at once. A subreddit whose pass fails is logged and reported to
+// opts.OnCycle, and the others carry on.
+func (a *Archiver) ContinuousArchiveSubreddits(ctx context.Context, subreddits []string, opts ContinuousOptions) error {
+	if len(subreddits) == 0 {
+		return &StorageError{Op: "continuous_archive", Err: errors.New("no subreddits given")}
+	}
+
+	archiveOpts := ArchiveOptions{
+		Sort:            SortNew,
+		Limit:           continuousLimit,
+		IncludeComments: true,
+
+		FailIfErrorRateAbove: opts.FailIfErrorRateAbove,
+	}
+
+	// Each subreddit waits its jittered, possibly adapted interval
+	intervals := make(map[string]time.Duration, len(subreddits))
+	waits := make(map[string]time.Duration, len(subreddits))
+	for _, subreddit := range subreddits {
+		intervals[subreddit] = opts.interval(subreddit)
+		waits[subreddit] = intervals[subreddit]
+	}
+	passes := make([]scheduledPass, len(subreddits))
+
+	return a.runPasses(ctx, nil, func() []scheduledPass {
+		for i, subreddit := range subreddits {
+			passes[i] = scheduledPass{subreddit: subreddit, interval: waits[subreddit], opts: archiveOpts}
+		}
+		return passes
+	}, func(pass scheduledPass, result *ArchiveResult, err error) {
+		if opts.OnCycle != nil {
+			opts.OnCycle(pass.subreddit, result, err)
+		}
+		if err == nil && opts.Adaptive {
+			intervals[pass.subreddit] = opts.NextInterval(intervals[pass.subreddit], result.NewPosts)
+			a.logger.Debug("adapted continuous interval", "subreddit", pass.subreddit, "new_posts", result.NewPosts, "interval", intervals[pass.subreddit])
+		}
+		waits[pass.subreddit] = opts.jittered(intervals[pass.subreddit])
+	})
+}
+
+// scheduledPass is a subreddit archived with opts every interval
+type scheduledPass struct {
+	subreddit string
+	interval  time.Duration
+	opts      ArchiveOptions
+}
+
+// runPasses is the loop behind ContinuousArchiveSubreddits and Run. It
+// archives each subreddit listed by passes once its interval has passed
+// since its previous pass, one pass at a time, until ctx is cancelled, and
+// hands every result to done, if set. The first passes are staggered:
+// subreddit i of n starts i/n of its interval in, so n subreddits sharing an
+// interval are spread evenly across it.
+//
+// passes is called again before every wait, and reload (which may be nil)
+// interrupts a wait to call it at once, so the subreddits and their
+// intervals may change between passes. A subreddit added since the first
+// call starts straight away; a changed interval counts from the
+// subreddit's last pass. A failed pass is logged and the others carry on.
+// Cancellation ends a wait immediately; buffered writes are flushed before
+// ctx's error is returned.
+func (a *Archiver) runPasses(ctx context.Context, reload <-chan struct{}, passes func() []scheduledPass, done func(scheduledPass, *ArchiveResult, error)) error {
+	initial := passes()
+	next := make(map[string]time.Time, len(initial))
+	last := make(map[string]time.Time, len(initial))
+	now := time.Now()
+	for i, pass := range initial {
+		next[NormalizeSubreddit(pass.subreddit)] = now.Add(pass.interval * time.Duration(i) / time.Duration(len(initial)))
+	}
+
+	for {
+		current := passes()
+
+		due := -1
+		for i, pass := range current {
+			name := NormalizeSubreddit(pass.subreddit)
+			if began, ok := last[name]; ok {
+				next[name] = began.Add(pass.interval)
+			} else if _, ok := next[name]; !ok {
+				next[name] = time.Now()
+			}
+			if due < 0 || next[name].Before(next[NormalizeSubreddit(current[due].subreddit)]) {
+				due = i
+			}
+		}
+		pass := current[due]
+		name := NormalizeSubreddit(pass.subreddit)
+
+		timer := time.NewTimer(time.Until(next[name]))
+		select {
+		case <-timer.C:
+		case <-reload:
+			timer.Stop()
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		// Don't start a pass if ctx ended as the timer fired, or while
+		// paused
+		if a.waitIfPaused(ctx) != nil {
+			return a.flushOnCancel(ctx)
+		}
+
+		began := time.Now()
+		result, err := a.ArchiveSubreddit(ctx, pass.subreddit, pass.opts)
+		if err != nil {
+			a.logger.Error("scheduled archive failed", "subreddit", pass.subreddit, "error", err)
+			a.reportError("archive_subreddit", err)
+		} else {
+			a.logArchived(pass.subreddit, result)
+		}
+		last[name] = began
+		if done != nil {
+			done(pass, result, err)
+		}
+	}
+}
+
+// interval returns the interval between subreddit's passes
+func (o ContinuousOptions) interval(subreddit string) time.Duration {
+	if d, ok := o.Intervals[subreddit]; ok {
+		return d
+	}
+	return o.Interval
+}
+
+// jittered returns interval shifted by a random amount of up to Jitter of it
+// in either direction
+func (o ContinuousOptions) jittered(interval time.Duration) time.Duration {
+	jitter := min(max(o.Jitter, 0), 1)
+	if jitter == 0 {
+		return interval
+	}
+	return interval + time.Duration(jitter*(2*rand.Float64()-1)*float64(interval))
+}
+
+// logArchived logs the summary of a continuous archive pass
+func (a *Archiver) logArchived(subreddit string, result *ArchiveResult) {
+	a.logger.Info("archived subreddit",
+		"subreddit", subreddit,
+		"posts_saved", result.PostsSaved,
+		"posts_skipped", result.PostsSkipped,
+		"comments_saved", result.CommentsSaved,
+		"failed_posts", len(result.FailedPosts),
+		"duration", result.Duration)
+}
+
+// UpdateScores refreshes scores for recently archived posts. With a client
+// implementing InfoClient the posts are looked up 100 per request; otherwise
+// each post is re-fetched with its comment tree, whose comments are ignored.
+func (a *Archiver) UpdateScores(ctx context.Context, subreddit string, maxAge time.Duration) error {
+	posts, err := a.recentPosts(ctx, subreddit, maxAge)
+	if err != nil {
+		return err
+	}
+
+	progress := &Progress{Op: "update_scores", Subreddit: subreddit}
+	if _, err := infoClient(unwrapClient(a.client)); err == nil {
+		return a.updateScoresBatched(ctx, subreddit, posts, progress)
+	}
+
+	// Update each post
+	for _, post := range posts {
+		if a.waitIfPaused(ctx) != nil {
+			return a.flushOnCancel(ctx)
+		}
+
+		commentsReq := &types.CommentsRequest{
+			Subreddit: subreddit,
+			PostID:    post.ID,
+		}
+
+		commentsResp, err := a.client.GetComments(ctx, commentsReq)
+		if err != nil {
+			a.logger.Warn("fetching updated post failed", "subreddit", subreddit, "post_id", post.ID, "error", err)
+			a.reportError("update_scores", err)
+			progress.Errors++
+			a.report(progress)
+			continue
+		}
+		progress.PostsFetched++
+
+		err = a.storage.SavePost(ctx, commentsResp.Post)
+		if err == nil {
+			err = a.postsSaved(ctx, commentsResp.Post)
+		}
+		if err != nil {
+			a.logger.Warn("saving updated post failed", "subreddit", subreddit, "post_id", post.ID, "error", err)
+			a.reportError("update_scores", err)
+			progress.Errors++
+		} else {
+			progress.PostsSaved++
+		}
+		a.report(progress)
+	}
+
+	return nil
+}
+
+// updateScoresBatched refreshes posts through InfoClient lookups of up to
+// infoBatchSize fullnames. A post Reddit no longer returns counts as an
+// error in progress and keeps its stored values.
+func (a *Archiver) updateScoresBatched(ctx context.Context, subreddit string, posts []*types.Post, progress *Progress) error {
+	client, err := infoClient(a.client)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(posts); start += infoBatchSize {
+		if a.waitIfPaused(ctx) != nil {
+			return a.flushOnCancel(ctx)
+		}
+
+		batch := posts[start:min(start+infoBatchSize, len(posts))]
+		fullnames := make([]string, len(batch))
+		for i, post := range batch {
+			fullnames[i] = "t3_" + post.ID
+		}
+
+		fresh, err := client.GetInfo(ctx, fullnames)
+		if err != nil {
+			a.logger.Warn("fetching updated posts failed", "subreddit", subreddit, "posts", len(batch), "error", err)
+			a.reportError("update_scores", err)
+			progress.Errors += len(batch)
+			a.report(progress)
+			continue
+		}
+		progress.PostsFetched += len(fresh)
+		progress.Errors += max(len(batch)-len(fresh), 0)
+
+		if len(fresh) > 0 {
+			err = a.storage.SavePosts(ctx, fresh)
+			if err == nil {
+				err = a.postsSaved(ctx, fresh...)
+			}
+			if err != nil {
+				a.logger.Warn("saving updated posts failed", "subreddit", subreddit, "posts", len(fresh), "error", err)
+				a.reportError("update_scores", err)
+				progress.Errors += len(fresh)
+			} else {
+				progress.PostsSaved += len(fresh)
+			}
+		}
+		a.report(progress)
+	}
+
+	return nil
+}
+
+// recentPosts returns the newest 100 stored posts of a subreddit created
+// within maxAge, the posts UpdateScores and UpdateComments refresh
+func (a *Archiver) recentPosts(ctx context.Context, subreddit string, maxAge time.Duration) ([]*types.Post, error) {
+	opts := QueryOptions{
+		Limit:     100,
+		SortBy:    "created",
+		SortOrder: "desc",
+		StartDate: time.Now().Add(-maxAge),
+	}
+	return a.storage.GetPostsBySubreddit(ctx, subreddit, opts)
+}
+
+// UpdateCommentsResult summarizes an UpdateComments or UpdateActivePosts run
+type UpdateCommentsResult struct {
+	PostsRefreshed  int
+	PostsUnchanged  int // Posts UpdateActivePosts left alone because their score and comment count hadn't changed
+	CommentsSaved   int
+	CommentsChanged int      // Saved comments that are new or whose body or score changed
+	FailedPosts     []string // IDs of posts whose comments could not be refreshed
+}
+
+// UpdateComments re-fetches the comment trees of the posts UpdateScores
+// would refresh and upserts them, so edited, deleted and re-scored comments
+// are brought up to date. The posts themselves are re-saved too. A post
+// that fails is logged and listed in the result's FailedPosts.
+func (a *Archiver) UpdateComments(ctx context.Context, subreddit string, maxAge time.Duration) (*UpdateCommentsResult, error) {
+	posts, err := a.recentPosts(ctx, subreddit, maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpdateCommentsResult{}
+	for _, post := range posts {
+		if a.waitIfPaused(ctx) != nil {
+			return result, a.flushOnCancel(ctx)
+		}
+
+		saved, changed, err := a.updatePostComments(ctx, subreddit, post.ID)
+		if err != nil {
+			a.logger.Warn("updating comments failed", "subreddit", subreddit, "post_id", post.ID, "error", err)
+			a.reportError("update_comments", err)
+			result.FailedPosts = append(result.FailedPosts, post.ID)
+			continue
+		}
+		result.PostsRefreshed++
+		result.CommentsSaved += saved
+		result.CommentsChanged += changed
+	}
+
+	return result, nil
+}
+
+// UpdateActivePosts refreshes the posts UpdateScores would, but only spends
+// full comment fetches on posts with activity. It first looks the posts up
+// through InfoClient, 100 per request, and compares each one's score and
+// num_comments with the stored values from Storage.GetPostCounts; posts
+// whose counts changed get their comment trees re-fetched and saved as by
+// UpdateComments, and the rest are left untouched. It needs a client
+// implementing InfoClient. Posts Reddit no longer returns, and posts that
+// fail, are logged and listed in the result's FailedPosts.
+func (a *Archiver) UpdateActivePosts(ctx context.Context, subreddit string, maxAge time.Duration) (*UpdateCommentsResult, error) {
+	// Check the client beneath the wrappers before spending a query
+	if _, err := infoClient(unwrapClient(a.client)); err != nil {
+		return nil, &StorageError{Op: "update_active_posts", Err: err}
+	}
+	client, err := infoClient(a.client)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := a.storage.GetPostCounts(ctx, subreddit, QueryOptions{
+		Limit:     100,
+		SortBy:    "created",
+		SortOrder: "desc",
+		StartDate: time.Now().Add(-maxAge),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpdateCommentsResult{}
+	for start := 0; start < len(stored); start += infoBatchSize {
+		if a.waitIfPaused(ctx) != nil {
+			return result, a.flushOnCancel(ctx)
+		}
+
+		batch := stored[start:min(start+infoBatchSize, len(stored))]
+		fullnames := make([]string, len(batch))
+		for i, post := range batch {
+			fullnames[i] = "t3_" + post.ID
+		}
+
+		fresh, err := client.GetInfo(ctx, fullnames)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, a.flushOnCancel(ctx)
+			}
+			a.logger.Warn("fetching updated posts failed", "subreddit", subreddit, "posts", len(batch), "error", err)
+			a.reportError("update_active_posts", err)
+			for _, post := range batch {
+				result.FailedPosts = append(result.FailedPosts, post.ID)
+			}
+			continue
+		}
+
+		current := make(map[string]*types.Post, len(fresh))
+		for _, post := range fresh {
+			current[post.ID] = post
+		}
+
+		for _, post := range batch {
+			latest, ok := current[post.ID]
+			if !ok {
+				a.logger.Warn("post no longer returned by reddit", "subreddit", subreddit, "post_id", post.ID)
+				result.FailedPosts = append(result.FailedPosts, post.ID)
+				continue
+			}
+			if latest.Score == post.Score && latest.NumComments == post.NumComments {
+				result.PostsUnchanged++
+				continue
+			}
+
+			if a.waitIfPaused(ctx) != nil {
+				return result, a.flushOnCancel(ctx)
+			}
+
+			saved, changed, err := a.updatePostComments(ctx, subreddit, post.ID)
+			if err != nil {
+				a.logger.Warn("updating comments failed", "subreddit", subreddit, "post_id", post.ID, "error", err)
+				a.reportError("update_active_posts", err)
+				result.FailedPosts = append(result.FailedPosts, post.ID)
+				continue
+			}
+			result.PostsRefreshed++
+			result.CommentsSaved += saved
+			result.CommentsChanged += changed
+		}
+	}
+
+	return result, nil
+}
+
+// updatePostComments re-fetches and saves one post's comments, returning how
+// many were saved and how many of those differ from the stored copies
+func (a *Archiver) updatePostComments(ctx context.Context, subreddit, postID string) (saved, changed int, err error) {
+	stored, err := a.storage.GetCommentsByPost(ctx, postID)
+	if err != nil {
+		return 0, 0, err
+	}
+	previous := make(map[string]*types.Comment, len(stored))
+	for _, comment := range stored {
+		previous[comment.ID] = comment
+	}
+
+	resp, err := a.client.GetComments(ctx, &types.CommentsRequest{Subreddit: subreddit, PostID: postID})
+	if err != nil {
+		return 0, 0, &StorageError{Op: "fetch_post_and_comments", Err: err}
+	}
+
+	if err := a.storage.SavePost(ctx, resp.Post); err != nil {
+		return 0, 0, err
+	}
+	if err := a.postsSaved(ctx, resp.Post); err != nil {
+		return 0, 0, err
+	}
+
+	comments := dropPlaceholderComments(resp.Comments)
+	if err := a.storage.SaveComments(ctx, comments); err != nil {
+		return 0, 0, err
+	}
+	a.metrics.saved(0, len(comments))
+	if err := a.commentsArchived(postID, len(comments)); err != nil {
+		return 0, 0, err
+	}
+
+	for _, comment := range comments {
+		old, ok := previous[comment.ID]
+		if !ok || old.Body != comment.Body || old.Score != comment.Score {
+			changed++
+		}
+	}
+	return len(comments), changed, nil
+}
+
+// RefreshComments re-fetches and re-saves the comments of every post stored
+// for a subreddit, oldest first, so existing archives pick up fixes to how
+// comments are saved, such as depth computation, in place. Stored posts are
+// walked with keyset pagination in pages of opts.Limit (default 100), so
+// posts archived meanwhile don't shift the walk. opts.MaxCommentDepth,
+// CommentBatchSize, AccountID and ResolveMedia apply as for ArchiveSubreddit;
+// the other fields are ignored. A post whose comments can't be fetched or
+// saved is logged and skipped. The run's timings are recorded with
+// Storage.RecordArchiveRun, and buffered writes are flushed if ctx is
+// cancelled.
+func (a *Archiver) RefreshComments(ctx context.Context, subreddit string, opts ArchiveOptions) error {
+	if err := a.checkOptions(opts); err != nil {
+		return &StorageError{Op: "refresh_comments", Err: err}
+	}
+
+	run := &ArchiveRun{Subreddit: subreddit, StartedAt: time.Now()}
+	err := a.refreshComments(ctx, subreddit, opts, run)
+	if ctx.Err() != nil {
+		err = a.flushOnCancel(ctx)
+	}
+	a.recordRun(ctx, run, err)
+	return err
+}
+
+func (a *Archiver) refreshComments(ctx context.Context, subreddit string, opts ArchiveOptions, run *ArchiveRun) error {
+	pageSize := opts.Limit
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+
+	query := QueryOptions{Limit: pageSize, SortOrder: "asc"}
+	for {
+		posts, err := a.storage.GetPostsBySubreddit(ctx, subreddit, query)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			if err := a.waitIfPaused(ctx); err != nil {
+				return err
+			}
+
+			count, err := a.archivePost(ctx, subreddit, post.ID, true, opts, run)
+			if err != nil {
+				a.logger.Warn("refreshing comments failed", "subreddit", subreddit, "post_id", post.ID, "error", err)
+				a.reportError("refresh_comments", err)
+				continue
+			}
+			run.PostsProcessed++
+			run.CommentsSaved += count
+		}
+
+		if len(posts) < pageSize {
+			return nil
+		}
+		query.After = NextCursor(posts)
+	}
+}
+
+// BackfillOptions configures Backfill
+type BackfillOptions struct {
+	MaxPosts        int  // Stop after this many posts; 0 means no limit when Since is set
+	IncludeComments bool // Whether to archive comments
+
+	// Since stops the backfill at the first post created before it. The
+	// older posts in that page aren't saved, and the backfill counts as
+	// finished. The zero value means no cutoff.
+	Since time.Time
+
+	// StopAtExisting ends the backfill at the first page whose posts are
+	// all stored already, checked with one Storage.GetStoredNumComments call
+	// per page, so catching up after downtime doesn't walk the whole
+	// listing again. That page is not saved again.
+	StopAtExisting bool
+
+	// After resumes the "new" listing from a fullname, normally
+	// BackfillResult.After from an earlier bounded run. Empty starts from
+	// the newest post.
+	After string
+
+	// MaxDuration stops the backfill once it has run this long, after the
+	// page in progress is saved, so a nightly window can be bounded without
+	// killing a write midway. 0 means no limit.
+	MaxDuration time.Duration
+
+	// Resume continues from the subreddit's stored BackfillCheckpoint in
+	// place of After, counting the posts the earlier runs saved toward
+	// MaxPosts. Without a checkpoint the backfill starts from After as usual.
+	Resume bool
+
+	// Concurrency is how many posts' comments are fetched and saved at once
+	// when IncludeComments is set, and overlaps them with fetching the next
+	// page of the listing. That page is discarded if the backfill stops
+	// first, e.g. at MaxDuration. 0 or 1 archives comments one after
+	// another before the next page is fetched.
+	Concurrency int
+}
+
+// BackfillResult summarizes a Backfill run. Its PostsSaved includes posts
+// saved by resumed runs; the other counts cover this run only.
+type BackfillResult struct {
+	ArchiveResult
+
+	// After is the fullname to pass as BackfillOptions.After to continue
+	// where this run stopped. It is empty once the listing is exhausted.
+	After string
+
+	// TimedOut reports that the run stopped because MaxDuration elapsed
+	TimedOut bool
+}
+
+// BackfillSubreddit archives historical posts from a subreddit. If ctx is
+// cancelled, buffered writes are flushed before returning.
+func (a *Archiver) BackfillSubreddit(ctx context.Context, subreddit string, maxPosts int, includeComments bool) (*ArchiveResult, error) {
+	result, err := a.Backfill(ctx, subreddit, BackfillOptions{
+		MaxPosts:        maxPosts,
+		IncludeComments: includeComments,
+	})
+	return &result.ArchiveResult, err
+}
+
+// Backfill archives historical posts from a subreddit's "new" listing, page
+// by page, until opts.MaxPosts posts are saved, the listing is exhausted, it
+// reaches posts older than opts.Since or a page already archived (with
+// opts.StopAtExisting), or opts.MaxDuration elapses. The result's After
+// continues the listing in a later run. If ctx is cancelled, buffered writes
+// are flushed before returning; the result still covers the pages saved so
+// far.
+//
+// Progress is checkpointed with Storage.SaveBackfillCheckpoint after every
+// page, so a run that dies can be picked up with opts.Resume. A backfill that
+// finishes deletes its checkpoint, and one started without opts.Resume
+// replaces it.
+func (a *Archiver) Backfill(ctx context.Context, subreddit string, opts BackfillOptions) (*BackfillResult, error) {
+	start := time.Now()
+	result := &BackfillResult{After: opts.After}
+	defer func() { result.Duration = time.Since(start) }()
+
+	if opts.Resume {
+		checkpoint, err := a.storage.GetBackfillCheckpoint(ctx, subreddit)
+		switch {
+		case err == nil:
+			result.After = checkpoint.After
+			result.PostsSaved = checkpoint.PostsFetched
+			a.logger.Info("resuming backfill", "subreddit", subreddit, "after", result.After, "posts_saved", result.PostsSaved)
+		case !errors.Is(err, ErrNotFound):
+			return result, err
+		}
+	}
+	if err := a.saveCheckpoint(ctx, subreddit, result); err != nil {
+		return result, err
+	}
+
+	err := a.backfill(ctx, subreddit, opts, result)
+	if ctx.Err() != nil {
+		return result, a.flushOnCancel(ctx)
+	}
+	if err == nil && !result.TimedOut {
+		err = a.storage.DeleteBackfillCheckpoint(ctx, subreddit)
+	}
+	return result, err
+}
+
+// backfillPage is one page of a backfill's "new" listing, or the error
+// fetching it
+type backfillPage struct {
+	response *types.PostsResponse
+	err      error
+}
+
+// fetchBackfillPage fetches up to remaining posts, at most 100, from
+// subreddit's "new" listing after the fullname after, once the archiver
+// isn't paused
+func (a *Archiver) fetchBackfillPage(ctx context.Context, subreddit, after string, remaining int) *backfillPage {
+	req := &types.PostsRequest{
+		Subreddit: subreddit,
+		Pagination: types.Pagination{
+			Limit: min(remaining, 100),
+			After: after,
+		},
+	}
+
+	if err := a.waitIfPaused(ctx); err != nil {
+		return &backfillPage{err: err}
+	}
+
+	response, err := a.client.GetNew(ctx, req)
+	return &backfillPage{response: response, err: err}
+}
+
+// saveCheckpoint records result's position as subreddit's backfill
+// checkpoint. It is saved even if ctx has just been cancelled, so the pages
+// already written aren't fetched again on resume.
+func (a *Archiver) saveCheckpoint(ctx context.Context, subreddit string, result *BackfillResult) error {
+	return a.storage.SaveBackfillCheckpoint(context.WithoutCancel(ctx), BackfillCheckpoint{
+		Subreddit:    subreddit,
+		After:        result.After,
+		PostsFetched: result.PostsSaved,
+	})
+}
+
+func (a *Archiver) backfill(ctx context.Context, subreddit string, opts BackfillOptions, result *BackfillResult) error {
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
+	progress := &Progress{Op: "backfill", Subreddit: subreddit}
+
+	maxPosts := opts.MaxPosts
+	if maxPosts <= 0 && !opts.Since.IsZero() {
+		maxPosts = math.MaxInt
+	}
+
+	// A page fetched while the previous page's comments were archived
+	var next *backfillPage
+
+	for result.PostsSaved < maxPosts {
+		page := next
+		next = nil
+		if page == nil {
+			page = a.fetchBackfillPage(ctx, subreddit, result.After, maxPosts-result.PostsSaved)
+		}
+		postsResponse, err := page.response, page.err
+		if err != nil {
+			return &StorageError{Op: "backfill_fetch", Err: err}
+		}
+
+		if len(postsResponse.Posts) == 0 {
+			result.After = ""
+			break // No more posts
+		}
+		progress.Page++
+		progress.PostsFetched += len(postsResponse.Posts)
+
+		// The listing is newest first, so the first post older than the
+		// cutoff ends the backfill
+		posts := postsResponse.Posts
+		reachedSince := false
+		if !opts.Since.IsZero() {
+			cutoff := float64(opts.Since.Unix())
+			for i, post := range posts {
+				if post.CreatedUTC < cutoff {
+					posts = posts[:i]
+					reachedSince = true
+					break
+				}
+			}
+		}
+
+		if opts.StopAtExisting && len(posts) > 0 {
+			stored, err := a.storage.GetStoredNumComments(ctx, postIDs(posts))
+			if err != nil {
+				return err
+			}
+			if len(stored) == len(posts) {
+				a.logger.Info("backfill reached archived posts", "subreddit", subreddit, "posts_saved", result.PostsSaved)
+				result.After = ""
+				break
+			}
+		}
+
+		// Save posts
+		if len(posts) > 0 {
+			if err := a.storage.SavePosts(ctx, posts); err != nil {
+				return err
+			}
+			if err := a.postsSaved(ctx, posts...); err != nil {
+				return err
+			}
+		}
+
+		// Archive comments if requested, fetching the next page meanwhile
+		// when they are archived concurrently
+		if opts.IncludeComments {
+			var wg sync.WaitGroup
+			after, remaining := postsResponse.AfterFullname, maxPosts-result.PostsSaved-len(posts)
+			if opts.Concurrency > 1 && after != "" && !reachedSince && remaining > 0 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					next = a.fetchBackfillPage(ctx, subreddit, after, remaining)
+				}()
+			}
+			err := a.archiveComments(ctx, subreddit, postIDs(posts), ArchiveOptions{Concurrency: opts.Concurrency}, &ArchiveRun{}, progress, &result.ArchiveResult)
+			wg.Wait()
+			if err != nil {
+				return err
+			}
+		}
+
+		result.PostsSaved += len(posts)
+		a.logger.Info("backfilled page", "subreddit", subreddit, "posts_saved", result.PostsSaved, "max_posts", opts.MaxPosts)
+
+		// Update after parameter for pagination
+		result.After = postsResponse.AfterFullname
+		if reachedSince {
+			result.After = ""
+		}
+		progress.PostsSaved = result.PostsSaved
+		progress.After = result.After
+		a.report(progress)
+		if result.After == "" {
+			break // No more pages, or reached Since
+		}
+
+		if err := a.saveCheckpoint(ctx, subreddit, result); err != nil {
+			return err
+		}
+
+		// Check context cancellation
+		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			a.logger.Info("backfill reached its time limit", "subreddit", subreddit, "max_duration", opts.MaxDuration, "after", result.After)
+			result.TimedOut = true
+			break
+		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
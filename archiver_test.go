@@ -1,7 +1,15 @@
 package storage_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,9 +29,22 @@ type mockRedditClient struct {
 	newError       error
 	commentsError  error
 	subredditError error
+	commentsDelay  time.Duration // Simulated GetComments latency
+
+	mu          sync.Mutex
+	calls       int // Number of API calls made
+	inFlight    int // GetComments calls in progress
+	maxInFlight int // Most GetComments calls in progress at once
+}
+
+func (m *mockRedditClient) record() {
+	m.mu.Lock()
+	m.calls++
+	m.mu.Unlock()
 }
 
 func (m *mockRedditClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	m.record()
 	if m.subredditError != nil {
 		return nil, m.subredditError
 	}
@@ -31,6 +52,7 @@ func (m *mockRedditClient) GetSubreddit(ctx context.Context, name string) (*type
 }
 
 func (m *mockRedditClient) GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	m.record()
 	if m.hotError != nil {
 		return nil, m.hotError
 	}
@@ -38,6 +60,7 @@ func (m *mockRedditClient) GetHot(ctx context.Context, req *types.PostsRequest)
 }
 
 func (m *mockRedditClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	m.record()
 	if m.newError != nil {
 		return nil, m.newError
 	}
@@ -55,10 +78,30 @@ func (m *mockRedditClient) GetNew(ctx context.Context, req *types.PostsRequest)
 }
 
 func (m *mockRedditClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	m.record()
 	if m.commentsError != nil {
 		return nil, m.commentsError
 	}
 
+	if m.commentsDelay > 0 {
+		m.mu.Lock()
+		m.inFlight++
+		m.maxInFlight = max(m.maxInFlight, m.inFlight)
+		m.mu.Unlock()
+
+		defer func() {
+			m.mu.Lock()
+			m.inFlight--
+			m.mu.Unlock()
+		}()
+
+		select {
+		case <-time.After(m.commentsDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	postID := req.PostID
 	if resp, ok := m.commentsMap[postID]; ok {
 		return resp, nil
@@ -72,8 +115,9 @@ func (m *mockRedditClient) GetComments(ctx context.Context, req *types.CommentsR
 }
 
 func setupTestArchiver(t *testing.T) (*storage.Archiver, storage.Storage, *mockRedditClient) {
-	// Create in-memory SQLite storage
-	store, err := sqlite.New(":memory:")
+	// Use a temporary file rather than :memory: since each pooled connection
+	// to an in-memory database sees its own empty database
+	store, err := sqlite.New(t.TempDir() + "/archiver.db")
 	if err != nil {
 		t.Fatalf("Failed to create storage: %v", err)
 	}
@@ -99,8 +143,7 @@ func setupTestArchiver(t *testing.T) (*storage.Archiver, storage.Storage, *mockR
 	}
 
 	// Create archiver with mock client
-	// Note: In actual tests, we would need the archiver to accept an interface
-	archiver := storage.NewArchiver(nil, store)
+	archiver := storage.NewArchiver(mockClient, store)
 
 	return archiver, store, mockClient
 }
@@ -116,11 +159,7 @@ func TestArchiveSubreddit(t *testing.T) {
 		IncludeComments: false,
 	}
 
-	// This test requires adapting the archiver to use an interface
-	// For now, we'll test the storage layer directly
-	t.Skip("Requires archiver refactoring to use interface")
-
-	err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	_, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
 	if err != nil {
 		t.Fatalf("ArchiveSubreddit failed: %v", err)
 	}
@@ -144,6 +183,191 @@ func TestArchiveSubreddit(t *testing.T) {
 	}
 }
 
+func TestArchiveSubredditResult(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, post := range mockClient.posts {
+		comment := testutil.NewTestComment("c_"+post.ID, post.ID, "user1", "Comment")
+		comment.ParentID = "t3_" + post.ID
+		mockClient.commentsMap[post.ID] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{comment}}
+	}
+
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true, UpdateExisting: true}
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if result.PostsSaved != 2 || result.CommentsSaved != 2 || result.PostsSkipped != 0 || len(result.FailedPosts) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if result.Duration <= 0 {
+		t.Error("Expected the duration to be recorded")
+	}
+
+	// Posts whose comments can't be fetched are listed
+	mockClient.commentsError = errors.New("API error")
+	result, err = archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if result.PostsSaved != 2 || result.CommentsSaved != 0 || !slices.Equal(result.FailedPosts, []string{"post1", "post2"}) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if err := result.Err(); !errors.Is(err, mockClient.commentsError) || !strings.Contains(err.Error(), "post post2") {
+		t.Errorf("Expected the per-post errors joined, got %v", err)
+	}
+}
+
+// failingCommentsClient fails GetComments for one post
+type failingCommentsClient struct {
+	*mockRedditClient
+	failPost string
+	err      error
+}
+
+func (c *failingCommentsClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	if req.PostID == c.failPost {
+		return nil, c.err
+	}
+	return c.mockRedditClient.GetComments(ctx, req)
+}
+
+func TestArchiveSubredditErrorRate(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	failing := &failingCommentsClient{mockRedditClient: mockClient, failPost: "post2", err: errors.New("API request failed with status 429")}
+	archiver := storage.NewArchiverWithOptions(failing, store, &storage.ArchiverOptions{
+		Retry: &storage.RetryPolicy{MaxAttempts: 1},
+	})
+
+	// Half the threads failing is within a 50% threshold
+	opts := storage.ArchiveOptions{Limit: 25, IncludeComments: true, UpdateExisting: true, FailIfErrorRateAbove: 0.5}
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("Expected no error at the threshold, got %v", err)
+	}
+	if !slices.Equal(result.FailedPosts, []string{"post2"}) || result.Err() == nil {
+		t.Errorf("Expected post2 listed as failed, got %+v", result)
+	}
+
+	opts.FailIfErrorRateAbove = 0.25
+	result, err = archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if !errors.Is(err, storage.ErrErrorRateExceeded) || !errors.Is(err, failing.err) {
+		t.Errorf("Expected ErrErrorRateExceeded wrapping the post's error, got %v", err)
+	}
+	if result.PostsSaved != 2 {
+		t.Errorf("Expected the posts to be saved anyway, got %+v", result)
+	}
+}
+
+func TestArchiveSubredditInvalidSort(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	_, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Sort: "hott"})
+	if err == nil {
+		t.Fatal("Expected an invalid sort to be rejected")
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for an invalid sort, got %d", mockClient.calls)
+	}
+
+	runs, err := store.GetArchiveRuns(ctx, "golang", 10)
+	if err != nil {
+		t.Fatalf("GetArchiveRuns failed: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("Expected no run to be recorded, got %d", len(runs))
+	}
+
+	for _, sort := range []storage.SortType{"", storage.SortHot, storage.SortNew} {
+		if _, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Sort: sort}); err != nil {
+			t.Errorf("ArchiveSubreddit with sort %q failed: %v", sort, err)
+		}
+	}
+}
+
+// mockListingClient adds the "top" and "rising" listings to mockRedditClient
+type mockListingClient struct {
+	*mockRedditClient
+	topPosts    []*types.Post
+	risingPosts []*types.Post
+	timeRange   storage.TimeRange // Time range of the last GetTop call
+}
+
+func (m *mockListingClient) GetTop(ctx context.Context, req *types.PostsRequest, timeRange storage.TimeRange) (*types.PostsResponse, error) {
+	m.record()
+	m.timeRange = timeRange
+	return &types.PostsResponse{Posts: m.topPosts}, nil
+}
+
+func (m *mockListingClient) GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	m.record()
+	return &types.PostsResponse{Posts: m.risingPosts}, nil
+}
+
+func TestArchiveSubredditTopAndRising(t *testing.T) {
+	plain, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// A client without the listings is refused up front rather than served
+	// a different listing
+	for _, sort := range []storage.SortType{storage.SortTop, storage.SortRising} {
+		_, err := plain.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Sort: sort})
+		if !errors.Is(err, storage.ErrSortUnsupported) {
+			t.Errorf("Expected ErrSortUnsupported for sort %q, got %v", sort, err)
+		}
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for an unsupported sort, got %d", mockClient.calls)
+	}
+
+	client := &mockListingClient{
+		mockRedditClient: mockClient,
+		topPosts:         []*types.Post{testutil.NewTestPost("top1", "golang", "Top post")},
+		risingPosts:      []*types.Post{testutil.NewTestPost("rising1", "golang", "Rising post")},
+	}
+	archiver := storage.NewArchiver(client, store)
+
+	opts := storage.ArchiveOptions{Sort: storage.SortTop, TimeRange: storage.TimeRangeWeek}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit with sort top failed: %v", err)
+	}
+	if client.timeRange != storage.TimeRangeWeek {
+		t.Errorf("Expected the week's top posts, got time range %q", client.timeRange)
+	}
+	if _, err := store.GetPost(ctx, "top1"); err != nil {
+		t.Errorf("Expected the top post to be stored, got %v", err)
+	}
+
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Sort: storage.SortRising}); err != nil {
+		t.Fatalf("ArchiveSubreddit with sort rising failed: %v", err)
+	}
+	if _, err := store.GetPost(ctx, "rising1"); err != nil {
+		t.Errorf("Expected the rising post to be stored, got %v", err)
+	}
+
+	// Time ranges are validated and only apply to top
+	invalid := []storage.ArchiveOptions{
+		{Sort: storage.SortTop, TimeRange: "fortnight"},
+		{Sort: storage.SortHot, TimeRange: storage.TimeRangeDay},
+	}
+	for _, opts := range invalid {
+		if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err == nil {
+			t.Errorf("Expected %+v to be rejected", opts)
+		}
+	}
+}
+
 func TestArchivePost(t *testing.T) {
 	archiver, store, mockClient := setupTestArchiver(t)
 	defer store.Close()
@@ -166,9 +390,7 @@ func TestArchivePost(t *testing.T) {
 		},
 	}
 
-	t.Skip("Requires archiver refactoring to use interface")
-
-	err := archiver.ArchivePost(ctx, "golang", postID, true)
+	_, err := archiver.ArchivePost(ctx, "golang", postID, true)
 	if err != nil {
 		t.Fatalf("ArchivePost failed: %v", err)
 	}
@@ -223,8 +445,6 @@ func TestUpdateScores(t *testing.T) {
 		Comments: []*types.Comment{},
 	}
 
-	t.Skip("Requires archiver refactoring to use interface")
-
 	// Update scores for posts within last 24 hours
 	err := archiver.UpdateScores(ctx, "golang", 24*time.Hour)
 	if err != nil {
@@ -241,141 +461,1911 @@ func TestUpdateScores(t *testing.T) {
 	}
 }
 
-func TestBackfillSubreddit(t *testing.T) {
-	archiver, store, mockClient := setupTestArchiver(t)
+// mockInfoClient adds batched fullname lookups to mockRedditClient, serving
+// the posts in info and recording each request's fullnames
+type mockInfoClient struct {
+	*mockRedditClient
+	info     map[string]*types.Post
+	requests [][]string
+}
+
+func (m *mockInfoClient) GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error) {
+	m.requests = append(m.requests, fullnames)
+
+	var posts []*types.Post
+	for _, fullname := range fullnames {
+		if post, ok := m.info[fullname]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func TestUpdateScoresBatched(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
 	defer store.Close()
 
 	ctx := context.Background()
 
-	// Setup mock to return posts
-	mockClient.posts = []*types.Post{
-		testutil.NewTestPost("bp1", "golang", "Backfill Post 1"),
-		testutil.NewTestPost("bp2", "golang", "Backfill Post 2"),
+	client := &mockInfoClient{mockRedditClient: mockClient, info: map[string]*types.Post{}}
+	for i, id := range []string{"b1", "b2", "gone"} {
+		post := testutil.NewTestPost(id, "golang", "Batched post")
+		post.CreatedUTC = float64(time.Now().Add(-time.Duration(i+1) * time.Hour).Unix())
+		if err := store.SavePost(ctx, post); err != nil {
+			t.Fatalf("Failed to save post: %v", err)
+		}
+
+		if id != "gone" {
+			updated := testutil.NewTestPost(id, "golang", "Batched post")
+			updated.CreatedUTC = post.CreatedUTC
+			updated.Score = 100 + i
+			client.info["t3_"+id] = updated
+		}
 	}
 
-	t.Skip("Requires archiver refactoring to use interface")
+	var last storage.Progress
+	archiver := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{
+		Progress: func(p storage.Progress) { last = p },
+	})
+	if err := archiver.UpdateScores(ctx, "golang", 24*time.Hour); err != nil {
+		t.Fatalf("UpdateScores failed: %v", err)
+	}
 
-	err := archiver.BackfillSubreddit(ctx, "golang", 100, false)
-	if err != nil {
-		t.Fatalf("BackfillSubreddit failed: %v", err)
+	if len(client.requests) != 1 || len(client.requests[0]) != 3 {
+		t.Errorf("Expected one lookup of 3 fullnames, got %v", client.requests)
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no GetComments calls, got %d API calls", mockClient.calls)
 	}
 
-	// Verify posts were saved
-	posts, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Limit: 100})
-	if err != nil {
-		t.Fatalf("Failed to get posts: %v", err)
+	for i, id := range []string{"b1", "b2"} {
+		post, err := store.GetPost(ctx, id)
+		if err != nil {
+			t.Fatalf("GetPost failed: %v", err)
+		}
+		if post.Score != 100+i {
+			t.Errorf("Expected %s to have score %d, got %d", id, 100+i, post.Score)
+		}
 	}
-	if len(posts) < 2 {
-		t.Errorf("Expected at least 2 posts, got %d", len(posts))
+
+	// A post Reddit no longer returns is reported and left as stored
+	if last.PostsSaved != 2 || last.Errors != 1 {
+		t.Errorf("Expected 2 posts saved and 1 error, got %+v", last)
 	}
 }
 
-// TestArchiverWithRealStorage tests the archiver with real storage operations
-func TestArchiverWithRealStorage(t *testing.T) {
-	// Create in-memory SQLite storage
-	store, err := sqlite.New(":memory:")
-	if err != nil {
-		t.Fatalf("Failed to create storage: %v", err)
-	}
+func TestUpdateScoresRecordsHistory(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
 	defer store.Close()
 
 	ctx := context.Background()
-	if err := store.RunMigrations(ctx); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{RecordScoreHistory: true})
+
+	post := testutil.NewTestPost("rising", "golang", "Rising post")
+	post.CreatedUTC = float64(time.Now().Add(-time.Hour).Unix())
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
 	}
 
-	// Test that we can create an archiver (without actually using it)
-	// In real usage, this would be a real Reddit client
-	var client *graw.Client // nil for this test
-	archiver := storage.NewArchiver(client, store)
+	for _, score := range []int{10, 40} {
+		refreshed := testutil.NewTestPost("rising", "golang", "Rising post")
+		refreshed.Score = score
+		refreshed.NumComments = score / 10
+		mockClient.commentsMap["rising"] = &types.CommentsResponse{Post: refreshed}
 
-	if archiver == nil {
-		t.Fatal("Expected non-nil archiver")
+		if err := archiver.UpdateScores(ctx, "golang", 24*time.Hour); err != nil {
+			t.Fatalf("UpdateScores failed: %v", err)
+		}
 	}
-	// Note: Cannot test private fields from external test package
-	// The fact that NewArchiver returns successfully is sufficient
-}
 
-// TestCommentDepthCalculation tests proper depth calculation for nested comments
-func TestCommentDepthCalculation(t *testing.T) {
-	// Create in-memory SQLite storage
-	store, err := sqlite.New(":memory:")
+	history, err := store.GetScoreHistory(ctx, "rising")
 	if err != nil {
-		t.Fatalf("Failed to create storage: %v", err)
+		t.Fatalf("GetScoreHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(history))
+	}
+	if history[0].Score != 10 || history[0].NumComments != 1 || history[1].Score != 40 || history[1].NumComments != 4 {
+		t.Errorf("Unexpected snapshots: %+v, %+v", history[0], history[1])
+	}
+	if history[1].RecordedAt.Before(history[0].RecordedAt) {
+		t.Errorf("Expected snapshots oldest first, got %v then %v", history[0].RecordedAt, history[1].RecordedAt)
+	}
+
+	// Recording is off by default
+	plain := storage.NewArchiver(mockClient, store)
+	if err := plain.UpdateScores(ctx, "golang", 24*time.Hour); err != nil {
+		t.Fatalf("UpdateScores failed: %v", err)
+	}
+	if history, _ := store.GetScoreHistory(ctx, "rising"); len(history) != 2 {
+		t.Errorf("Expected no new snapshots without RecordScoreHistory, got %d in total", len(history))
 	}
+}
+
+func TestUpdateScoresRemovedPost(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
 	defer store.Close()
 
 	ctx := context.Background()
-	if err := store.RunMigrations(ctx); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
 
-	// Save a post first
-	post := testutil.NewTestPost("depthtest", "golang", "Depth Test Post")
+	post := testutil.NewTestPost("gone", "golang", "Original title")
+	post.Author = "gopher"
+	post.SelfText = "Original body"
+	post.CreatedUTC = float64(time.Now().Add(-time.Hour).Unix())
 	if err := store.SavePost(ctx, post); err != nil {
 		t.Fatalf("Failed to save post: %v", err)
 	}
 
-	// Create nested comments
-	// Level 0: Top-level comment
-	c1 := testutil.NewTestComment("c1", "depthtest", "user1", "Top level")
-	c1.ParentID = "t3_depthtest"
+	// Reddit now shows the post deleted, with placeholders for its content
+	deleted := testutil.NewTestPost("gone", "golang", "[deleted by user]")
+	deleted.Author = storage.DeletedAuthor
+	deleted.SelfText = "[deleted]"
+	deleted.Score = 3
+	mockClient.commentsMap["gone"] = &types.CommentsResponse{Post: deleted}
 
-	// Level 1: Reply to c1
-	c2 := testutil.NewTestComment("c2", "depthtest", "user2", "Reply to c1")
-	c2.ParentID = "t1_c1"
+	if err := archiver.UpdateScores(ctx, "golang", 24*time.Hour); err != nil {
+		t.Fatalf("UpdateScores failed: %v", err)
+	}
 
-	// Level 2: Reply to c2
-	c3 := testutil.NewTestComment("c3", "depthtest", "user3", "Reply to c2")
-	c3.ParentID = "t1_c2"
+	stored, err := store.GetStoredPost(ctx, "gone")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if stored.RemovedAt == nil {
+		t.Error("Expected the post to be recorded as removed")
+	}
+	if stored.Title != "Original title" || stored.SelfText != "Original body" || stored.Author != "gopher" {
+		t.Errorf("Expected the archived content to be kept, got %q/%q by %q", stored.Title, stored.SelfText, stored.Author)
+	}
+	if stored.Score != 3 {
+		t.Errorf("Expected the score to be refreshed to 3, got %d", stored.Score)
+	}
 
-	// Level 1: Another reply to c1
-	c4 := testutil.NewTestComment("c4", "depthtest", "user4", "Another reply to c1")
-	c4.ParentID = "t1_c1"
+	live, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{ExcludeRemoved: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(live) != 0 {
+		t.Errorf("Expected ExcludeRemoved to leave out the removed post, got %d posts", len(live))
+	}
+}
 
-	// Save all comments together
-	comments := []*types.Comment{c1, c2, c3, c4}
-	if err := store.SaveComments(ctx, comments); err != nil {
-		t.Fatalf("Failed to save comments: %v", err)
+func TestUpdateComments(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := testutil.NewTestPost("fresh", "golang", "Fresh post")
+	post.CreatedUTC = float64(time.Now().Add(-time.Hour).Unix())
+	stale := testutil.NewTestPost("stale", "golang", "Old post")
+	stale.CreatedUTC = float64(time.Now().Add(-72 * time.Hour).Unix())
+
+	c1 := testutil.NewTestComment("uc1", "fresh", "user1", "Original")
+	c1.ParentID = "t3_fresh"
+	c2 := testutil.NewTestComment("uc2", "fresh", "user2", "Unchanged")
+	c2.ParentID = "t3_fresh"
+	mockClient.commentsMap["fresh"] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{c1, c2}}
+	mockClient.commentsMap["stale"] = &types.CommentsResponse{Post: stale}
+
+	for _, id := range []string{"fresh", "stale"} {
+		if _, err := archiver.ArchivePost(ctx, "golang", id, true); err != nil {
+			t.Fatalf("ArchivePost failed: %v", err)
+		}
 	}
 
-	// Retrieve comments and verify depths
-	savedComments, err := store.GetCommentsByPost(ctx, "depthtest")
+	// One comment is edited, one is new
+	edited := *c1
+	edited.Body = "Edited"
+	edited.Score = c1.Score + 10
+	added := testutil.NewTestComment("uc3", "fresh", "user3", "New")
+	added.ParentID = "t3_fresh"
+	mockClient.commentsMap["fresh"] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{&edited, c2, added}}
+
+	mockClient.calls = 0
+	result, err := archiver.UpdateComments(ctx, "golang", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("UpdateComments failed: %v", err)
+	}
+	if result.PostsRefreshed != 1 || result.CommentsSaved != 3 || result.CommentsChanged != 2 || len(result.FailedPosts) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if mockClient.calls != 1 {
+		t.Errorf("Expected only the recent post to be fetched, got %d calls", mockClient.calls)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "fresh")
 	if err != nil {
 		t.Fatalf("Failed to get comments: %v", err)
 	}
+	bodies := make(map[string]string)
+	for _, comment := range comments {
+		bodies[comment.ID] = comment.Body
+	}
+	if bodies["uc1"] != "Edited" || bodies["uc3"] != "New" {
+		t.Errorf("Expected the edit and the new comment to be stored, got %v", bodies)
+	}
+}
 
-	if len(savedComments) != 4 {
-		t.Fatalf("Expected 4 comments, got %d", len(savedComments))
+func TestUpdateActivePosts(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	client := &mockInfoClient{mockRedditClient: mockClient, info: map[string]*types.Post{}}
+	for i, id := range []string{"active", "quiet", "gone"} {
+		post := testutil.NewTestPost(id, "golang", "Tracked post")
+		post.CreatedUTC = float64(time.Now().Add(-time.Duration(i+1) * time.Hour).Unix())
+		post.Score = 10
+		post.NumComments = 1
+		if err := store.SavePost(ctx, post); err != nil {
+			t.Fatalf("Failed to save post: %v", err)
+		}
+
+		if id != "gone" {
+			current := *post
+			client.info["t3_"+id] = &current
+		}
 	}
 
-	// Map comments by ID for easy lookup
-	commentMap := make(map[string]*types.Comment)
-	for _, c := range savedComments {
-		commentMap[c.ID] = c
+	// Only the active post gained a comment
+	active := client.info["t3_active"]
+	active.NumComments = 2
+	c1 := testutil.NewTestComment("ac1", "active", "user1", "First")
+	c1.ParentID = "t3_active"
+	c2 := testutil.NewTestComment("ac2", "active", "user2", "Second")
+	c2.ParentID = "t3_active"
+	mockClient.commentsMap["active"] = &types.CommentsResponse{Post: active, Comments: []*types.Comment{c1, c2}}
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.UpdateActivePosts(ctx, "golang", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("UpdateActivePosts failed: %v", err)
 	}
 
-	// Note: The actual depth is stored in the database but not exposed in types.Comment
-	// We would need to query it directly or add depth to the Comment type
-	// For now, we verify that the comments were saved correctly
-	if commentMap["c1"] == nil {
-		t.Error("Comment c1 not found")
+	if len(client.requests) != 1 || len(client.requests[0]) != 3 {
+		t.Errorf("Expected one lookup of 3 fullnames, got %v", client.requests)
 	}
-	if commentMap["c2"] == nil {
-		t.Error("Comment c2 not found")
+	if mockClient.calls != 1 {
+		t.Errorf("Expected only the active post's comments to be fetched, got %d calls", mockClient.calls)
 	}
-	if commentMap["c3"] == nil {
-		t.Error("Comment c3 not found")
+	if result.PostsRefreshed != 1 || result.PostsUnchanged != 1 || result.CommentsSaved != 2 {
+		t.Errorf("Unexpected result: %+v", result)
 	}
-	if commentMap["c4"] == nil {
-		t.Error("Comment c4 not found")
+	if len(result.FailedPosts) != 1 || result.FailedPosts[0] != "gone" {
+		t.Errorf("Expected the vanished post to be listed as failed, got %v", result.FailedPosts)
 	}
 
-	// Verify parent relationships
-	if commentMap["c2"].ParentID != "t1_c1" {
-		t.Errorf("Expected c2 parent to be t1_c1, got %s", commentMap["c2"].ParentID)
+	post, err := store.GetPost(ctx, "active")
+	if err != nil || post.NumComments != 2 {
+		t.Errorf("Expected the active post's new comment count to be stored, got %+v (err=%v)", post, err)
 	}
-	if commentMap["c3"].ParentID != "t1_c2" {
-		t.Errorf("Expected c3 parent to be t1_c2, got %s", commentMap["c3"].ParentID)
+
+	// Without InfoClient there's no cheap lookup
+	plain, plainStore, _ := setupTestArchiver(t)
+	defer plainStore.Close()
+	if _, err := plain.UpdateActivePosts(ctx, "golang", 24*time.Hour); err == nil {
+		t.Error("Expected an error for a client without InfoClient")
+	}
+}
+
+func TestBackfillSubreddit(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Setup mock to return posts
+	mockClient.posts = []*types.Post{
+		testutil.NewTestPost("bp1", "golang", "Backfill Post 1"),
+		testutil.NewTestPost("bp2", "golang", "Backfill Post 2"),
+	}
+
+	result, err := archiver.BackfillSubreddit(ctx, "golang", 100, false)
+	if err != nil {
+		t.Fatalf("BackfillSubreddit failed: %v", err)
+	}
+	if result.PostsSaved != 2 {
+		t.Errorf("Expected 2 posts saved, got %d", result.PostsSaved)
+	}
+
+	// Verify posts were saved
+	posts, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
 	}
-}
\ No newline at end of file
+	if len(posts) < 2 {
+		t.Errorf("Expected at least 2 posts, got %d", len(posts))
+	}
+}
+
+func TestBackfillMaxDuration(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// The window elapses while the first page is saved, which still
+	// completes before the backfill stops
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, MaxDuration: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if !result.TimedOut || result.PostsSaved != len(mockClient.posts) || result.After != "t3_after" {
+		t.Errorf("Expected a timed-out run after one page, got %+v", result)
+	}
+
+	posts, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != len(mockClient.posts) {
+		t.Errorf("Expected the fetched page to be saved, got %d posts", len(posts))
+	}
+
+	// Resuming from the cursor reaches the end of the listing
+	result, err = archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, After: result.After, MaxDuration: time.Hour})
+	if err != nil {
+		t.Fatalf("Resumed Backfill failed: %v", err)
+	}
+	if result.TimedOut || result.PostsSaved != 0 || result.After != "" {
+		t.Errorf("Expected the resumed run to exhaust the listing, got %+v", result)
+	}
+}
+
+func TestBackfillSince(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Newest first, crossing the cutoff within the page
+	mockClient.posts = nil
+	for i, created := range []time.Time{since.Add(48 * time.Hour), since.Add(time.Hour), since.Add(-time.Hour), since.Add(-48 * time.Hour)} {
+		post := testutil.NewTestPost(fmt.Sprintf("s%d", i), "golang", "Post")
+		post.CreatedUTC = float64(created.Unix())
+		mockClient.posts = append(mockClient.posts, post)
+	}
+
+	// MaxPosts 0 is unlimited when Since is set
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{Since: since})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if result.PostsSaved != 2 || result.After != "" {
+		t.Errorf("Expected 2 posts and a finished backfill, got %+v", result)
+	}
+
+	for _, id := range []string{"s2", "s3"} {
+		if _, err := store.GetPost(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected %s, older than the cutoff, not to be saved; got %v", id, err)
+		}
+	}
+
+	// Reaching the cutoff finishes the backfill, so no checkpoint is left
+	if _, err := store.GetBackfillCheckpoint(ctx, "golang"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the checkpoint to be deleted, got %v", err)
+	}
+}
+
+func TestBackfillStopAtExisting(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	if _, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000}); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+
+	// The first page is all stored, so nothing is fetched past it or saved
+	mockClient.calls = 0
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, IncludeComments: true, StopAtExisting: true})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if result.PostsSaved != 0 || result.After != "" {
+		t.Errorf("Expected a finished backfill with nothing saved, got %+v", result)
+	}
+	if mockClient.calls != 1 {
+		t.Errorf("Expected only the first page to be fetched, got %d calls", mockClient.calls)
+	}
+
+	// A page with a new post is saved in full
+	mockClient.posts = append(mockClient.posts, testutil.NewTestPost("post3", "golang", "New"))
+	result, err = archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, StopAtExisting: true})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if result.PostsSaved != 3 {
+		t.Errorf("Expected the page with a new post to be saved, got %d posts", result.PostsSaved)
+	}
+}
+
+func TestBackfillResume(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// A run cut short keeps its checkpoint
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, MaxDuration: time.Nanosecond})
+	if err != nil || !result.TimedOut {
+		t.Fatalf("Expected a timed-out backfill, got %+v (err=%v)", result, err)
+	}
+
+	checkpoint, err := store.GetBackfillCheckpoint(ctx, "golang")
+	if err != nil {
+		t.Fatalf("GetBackfillCheckpoint failed: %v", err)
+	}
+	if checkpoint.After != "t3_after" || checkpoint.PostsFetched != len(mockClient.posts) {
+		t.Errorf("Expected a checkpoint after the first page, got %+v", checkpoint)
+	}
+
+	// Resuming continues from the checkpoint, ignoring After, and a finished
+	// backfill clears it
+	result, err = archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, After: "t3_ignored", Resume: true})
+	if err != nil {
+		t.Fatalf("Resumed Backfill failed: %v", err)
+	}
+	if result.PostsSaved != len(mockClient.posts) || result.After != "" {
+		t.Errorf("Expected the resumed run to exhaust the listing, got %+v", result)
+	}
+	if _, err := store.GetBackfillCheckpoint(ctx, "golang"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the checkpoint to be cleared, got %v", err)
+	}
+
+	// A fresh backfill replaces a stale checkpoint
+	if err := store.SaveBackfillCheckpoint(ctx, storage.BackfillCheckpoint{Subreddit: "golang", After: "t3_stale", PostsFetched: 7500}); err != nil {
+		t.Fatalf("SaveBackfillCheckpoint failed: %v", err)
+	}
+	result, err = archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 1000, MaxDuration: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	checkpoint, err = store.GetBackfillCheckpoint(ctx, "golang")
+	if err != nil || checkpoint.After != "t3_after" || checkpoint.PostsFetched != len(mockClient.posts) {
+		t.Errorf("Expected the fresh run to replace the checkpoint, got %+v (err=%v)", checkpoint, err)
+	}
+}
+
+// pagedNewClient serves pages of the "new" listing, the page after "t3_pageN"
+// being pages[N], and notes whether a page was requested while comment
+// fetches were in flight
+type pagedNewClient struct {
+	*mockRedditClient
+	pages      [][]*types.Post
+	overlapped bool
+}
+
+func (c *pagedNewClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	c.record()
+
+	c.mu.Lock()
+	if c.inFlight > 0 {
+		c.overlapped = true
+	}
+	c.mu.Unlock()
+
+	page := 0
+	if req.Pagination.After != "" {
+		fmt.Sscanf(req.Pagination.After, "t3_page%d", &page)
+	}
+	if page >= len(c.pages) {
+		return &types.PostsResponse{}, nil
+	}
+	return &types.PostsResponse{Posts: c.pages[page], AfterFullname: fmt.Sprintf("t3_page%d", page+1)}, nil
+}
+
+func TestBackfillConcurrency(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	client := &pagedNewClient{mockRedditClient: mockClient, pages: make([][]*types.Post, 3)}
+	for i := range 12 {
+		id := fmt.Sprintf("bf%d", i)
+		post := testutil.NewTestPost(id, "golang", "Backfill "+id)
+		comment := testutil.NewTestComment("c_"+id, id, "user1", "Comment")
+		comment.ParentID = "t3_" + id
+		client.pages[i/4] = append(client.pages[i/4], post)
+		mockClient.commentsMap[id] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{comment}}
+	}
+	mockClient.commentsDelay = 20 * time.Millisecond
+
+	var progress []storage.Progress
+	archiver := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{
+		Progress: func(p storage.Progress) { progress = append(progress, p) },
+	})
+
+	ctx := context.Background()
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 100, IncludeComments: true, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if result.PostsSaved != 12 || result.CommentsSaved != 12 || result.After != "" {
+		t.Errorf("Expected 12 posts and comments with the listing exhausted, got %+v", result)
+	}
+
+	if mockClient.maxInFlight < 2 || mockClient.maxInFlight > 3 {
+		t.Errorf("Expected between 2 and 3 concurrent fetches, got %d", mockClient.maxInFlight)
+	}
+	if !client.overlapped {
+		t.Error("Expected the next page to be fetched while comments were archived")
+	}
+
+	for _, page := range client.pages {
+		for _, post := range page {
+			if comments, err := store.GetCommentsByPost(ctx, post.ID); err != nil || len(comments) != 1 {
+				t.Errorf("Expected 1 comment for %s, got %d (err=%v)", post.ID, len(comments), err)
+			}
+		}
+	}
+
+	last := progress[len(progress)-1]
+	if last.PostsSaved != 12 || last.CommentsSaved != 12 || last.Errors != 0 {
+		t.Errorf("Expected final progress to count 12 posts and comments, got %+v", last)
+	}
+}
+
+func TestArchiveNew(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// The last run stored a post from two hours ago
+	stored := testutil.NewTestPost("old", "golang", "Already Archived")
+	stored.CreatedUTC = float64(now.Add(-2 * time.Hour).Unix())
+	if err := store.SavePost(ctx, stored); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	newest := testutil.NewTestPost("new1", "golang", "Newest")
+	newest.CreatedUTC = float64(now.Unix())
+	newer := testutil.NewTestPost("new2", "golang", "Newer")
+	newer.CreatedUTC = float64(now.Add(-1 * time.Hour).Unix())
+	// Posted in the same second as the stored latest, but after it
+	sameSecond := testutil.NewTestPost("new3", "golang", "Same Second")
+	sameSecond.CreatedUTC = stored.CreatedUTC
+	older := testutil.NewTestPost("older", "golang", "Older")
+	older.CreatedUTC = float64(now.Add(-3 * time.Hour).Unix())
+
+	mockClient.posts = []*types.Post{newest, newer, sameSecond, stored, older}
+
+	result, err := archiver.ArchiveNew(ctx, "golang", storage.ArchiveOptions{Limit: 25})
+	if err != nil {
+		t.Fatalf("ArchiveNew failed: %v", err)
+	}
+
+	if result.PostsSaved != 3 {
+		t.Errorf("Expected 3 posts saved, got %d", result.PostsSaved)
+	}
+
+	for _, id := range []string{"new1", "new2", "new3"} {
+		if _, err := store.GetPost(ctx, id); err != nil {
+			t.Errorf("Expected post %s to be archived: %v", id, err)
+		}
+	}
+
+	if _, err := store.GetPost(ctx, "older"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected post older than the stored latest to be skipped, got %v", err)
+	}
+}
+
+// TestArchiverWithRealStorage tests the archiver with real storage operations
+func TestArchiverWithRealStorage(t *testing.T) {
+	// Create in-memory SQLite storage
+	store, err := sqlite.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Test that we can create an archiver (without actually using it)
+	// In real usage, this would be a real Reddit client
+	var client *graw.Client // nil for this test
+	archiver := storage.NewArchiver(client, store)
+
+	if archiver == nil {
+		t.Fatal("Expected non-nil archiver")
+	}
+	// Note: Cannot test private fields from external test package
+	// The fact that NewArchiver returns successfully is sufficient
+}
+
+// TestCommentDepthCalculation tests proper depth calculation for nested comments
+func TestCommentDepthCalculation(t *testing.T) {
+	// Create in-memory SQLite storage
+	store, err := sqlite.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Save a post first
+	post := testutil.NewTestPost("depthtest", "golang", "Depth Test Post")
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	// Create nested comments
+	// Level 0: Top-level comment
+	c1 := testutil.NewTestComment("c1", "depthtest", "user1", "Top level")
+	c1.ParentID = "t3_depthtest"
+
+	// Level 1: Reply to c1
+	c2 := testutil.NewTestComment("c2", "depthtest", "user2", "Reply to c1")
+	c2.ParentID = "t1_c1"
+
+	// Level 2: Reply to c2
+	c3 := testutil.NewTestComment("c3", "depthtest", "user3", "Reply to c2")
+	c3.ParentID = "t1_c2"
+
+	// Level 1: Another reply to c1
+	c4 := testutil.NewTestComment("c4", "depthtest", "user4", "Another reply to c1")
+	c4.ParentID = "t1_c1"
+
+	// Save all comments together
+	comments := []*types.Comment{c1, c2, c3, c4}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	// Retrieve comments and verify depths
+	savedComments, err := store.GetCommentsByPost(ctx, "depthtest")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+
+	if len(savedComments) != 4 {
+		t.Fatalf("Expected 4 comments, got %d", len(savedComments))
+	}
+
+	// Map comments by ID for easy lookup
+	commentMap := make(map[string]*types.Comment)
+	for _, c := range savedComments {
+		commentMap[c.ID] = c
+	}
+
+	// Note: The actual depth is stored in the database but not exposed in types.Comment
+	// We would need to query it directly or add depth to the Comment type
+	// For now, we verify that the comments were saved correctly
+	if commentMap["c1"] == nil {
+		t.Error("Comment c1 not found")
+	}
+	if commentMap["c2"] == nil {
+		t.Error("Comment c2 not found")
+	}
+	if commentMap["c3"] == nil {
+		t.Error("Comment c3 not found")
+	}
+	if commentMap["c4"] == nil {
+		t.Error("Comment c4 not found")
+	}
+
+	// Verify parent relationships
+	if commentMap["c2"].ParentID != "t1_c1" {
+		t.Errorf("Expected c2 parent to be t1_c1, got %s", commentMap["c2"].ParentID)
+	}
+	if commentMap["c3"].ParentID != "t1_c2" {
+		t.Errorf("Expected c3 parent to be t1_c2, got %s", commentMap["c3"].ParentID)
+	}
+}
+// TestArchiveSubredditMaxCommentDepth tests that deep comments are dropped at save time
+func TestArchiveSubredditMaxCommentDepth(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	postID := "deeppost"
+	mockClient.posts = []*types.Post{testutil.NewTestPost(postID, "golang", "Deep Thread")}
+
+	c1 := testutil.NewTestComment("d1", postID, "user1", "Top level")
+	c1.ParentID = "t3_" + postID
+
+	c2 := testutil.NewTestComment("d2", postID, "user2", "Reply to d1")
+	c2.ParentID = "t1_d1"
+
+	c3 := testutil.NewTestComment("d3", postID, "user3", "Reply to d2")
+	c3.ParentID = "t1_d2"
+
+	mockClient.commentsMap[postID] = &types.CommentsResponse{
+		Post:     mockClient.posts[0],
+		Comments: []*types.Comment{c1, c2, c3},
+	}
+
+	opts := storage.ArchiveOptions{
+		Sort:            "new",
+		IncludeComments: true,
+		MaxCommentDepth: 2,
+	}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, postID)
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+
+	var ids []string
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+
+	if len(ids) != 2 || ids[0] != "d1" || ids[1] != "d2" {
+		t.Errorf("Expected only d1 and d2 to be stored, got %v", ids)
+	}
+}
+
+func TestArchiveSubredditRecordsRun(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	opts := storage.ArchiveOptions{Sort: "hot", IncludeComments: true}
+
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	mockClient.hotError = errors.New("rate limited")
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err == nil {
+		t.Fatal("Expected ArchiveSubreddit to fail")
+	}
+
+	runs, err := store.GetArchiveRuns(ctx, "GoLang", 10)
+	if err != nil {
+		t.Fatalf("GetArchiveRuns failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d", len(runs))
+	}
+
+	// Newest first
+	failed, ok := runs[0], runs[1]
+	if failed.Error == "" || failed.PostsProcessed != 0 {
+		t.Errorf("Expected failed run with no posts, got %+v", failed)
+	}
+	if ok.Error != "" || ok.PostsProcessed != len(mockClient.posts) {
+		t.Errorf("Expected successful run with %d posts, got %+v", len(mockClient.posts), ok)
+	}
+	if ok.Subreddit != "golang" || ok.StartedAt.IsZero() {
+		t.Errorf("Expected subreddit and start time to be recorded, got %+v", ok)
+	}
+	if ok.StartedAt.After(failed.StartedAt) {
+		t.Errorf("Expected runs ordered newest first")
+	}
+}
+
+func TestArchiveSubredditAccountID(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	comment := testutil.NewTestComment("acc1", "post1", "user1", "Tagged comment")
+	comment.ParentID = "t3_post1"
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{
+		Post:     mockClient.posts[0],
+		Comments: []*types.Comment{comment},
+	}
+
+	opts := storage.ArchiveOptions{Sort: "hot", IncludeComments: true, AccountID: "bot-a"}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// A later run without an account must not clear the tag
+	opts.AccountID = ""
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	posts, err := store.GetStoredPostsBySubreddit(ctx, "golang", storage.QueryOptions{Account: "bot-a"})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != len(mockClient.posts) {
+		t.Fatalf("Expected %d posts for bot-a, got %d", len(mockClient.posts), len(posts))
+	}
+	for _, post := range posts {
+		if post.Account != "bot-a" {
+			t.Errorf("Expected post %s tagged bot-a, got %q", post.ID, post.Account)
+		}
+	}
+
+	other, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Account: "bot-b"})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("Expected no posts for bot-b, got %d", len(other))
+	}
+
+	comments, err := store.GetCommentsByAuthorWithContext(ctx, "user1", storage.QueryOptions{Account: "bot-a"})
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Comment.ID != "acc1" {
+		t.Errorf("Expected comment acc1 tagged bot-a, got %d comments", len(comments))
+	}
+}
+
+// flushingStorage records Flush calls made through the Flusher interface
+type flushingStorage struct {
+	storage.Storage
+	flushes int
+}
+
+func (f *flushingStorage) Flush(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.flushes++
+	return nil
+}
+
+func TestContinuousArchiveWithOptions(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var results []*storage.ArchiveResult
+	err := archiver.ContinuousArchiveWithOptions(ctx, "golang", storage.ContinuousOptions{
+		Interval: 5 * time.Millisecond,
+		Jitter:   0.5,
+		OnCycle: func(_ string, result *storage.ArchiveResult, err error) {
+			if err != nil {
+				t.Errorf("Cycle failed: %v", err)
+			}
+			results = append(results, result)
+			if len(results) == 3 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 cycles, got %d", len(results))
+	}
+	if results[0].PostsSaved != 2 {
+		t.Errorf("Expected the first cycle to save 2 posts, got %d", results[0].PostsSaved)
+	}
+}
+
+func TestContinuousAdaptiveInterval(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The listing's two posts are new on the first pass only
+	var newPosts []int
+	err := archiver.ContinuousArchiveWithOptions(ctx, "golang", storage.ContinuousOptions{
+		Interval:    time.Millisecond,
+		Adaptive:    true,
+		MinInterval: time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+		OnCycle: func(_ string, result *storage.ArchiveResult, err error) {
+			if err != nil {
+				t.Errorf("Cycle failed: %v", err)
+			}
+			newPosts = append(newPosts, result.NewPosts)
+			if len(newPosts) == 3 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if !slices.Equal(newPosts, []int{2, 0, 0}) {
+		t.Errorf("Expected 2 new posts and then none, got %v", newPosts)
+	}
+}
+
+func TestContinuousOptionsNextInterval(t *testing.T) {
+	opts := storage.ContinuousOptions{Adaptive: true, MinInterval: time.Minute, MaxInterval: time.Hour}
+
+	tests := []struct {
+		name     string
+		current  time.Duration
+		newPosts int
+		want     time.Duration
+	}{
+		{"nothing new backs off", 10 * time.Minute, 0, 20 * time.Minute},
+		{"full listing halves", 10 * time.Minute, 25, 5 * time.Minute},
+		{"half a listing holds", 10 * time.Minute, 12, 10 * time.Minute},
+		{"busy shortens", 10 * time.Minute, 24, 5 * time.Minute},
+		{"quiet lengthens at most double", 10 * time.Minute, 1, 20 * time.Minute},
+		{"some activity lengthens", 10 * time.Minute, 6, 20 * time.Minute},
+		{"clamped to max", 45 * time.Minute, 0, time.Hour},
+		{"clamped to min", 90 * time.Second, 25, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opts.NextInterval(tt.current, tt.newPosts); got != tt.want {
+				t.Errorf("NextInterval(%s, %d) = %s, want %s", tt.current, tt.newPosts, got, tt.want)
+			}
+		})
+	}
+
+	fixed := storage.ContinuousOptions{MinInterval: time.Minute}
+	if got := fixed.NextInterval(5*time.Second, 0); got != 5*time.Second {
+		t.Errorf("Expected a fixed interval without Adaptive, got %s", got)
+	}
+
+	defaults := storage.ContinuousOptions{Adaptive: true}
+	if got := defaults.NextInterval(50*time.Minute, 0); got != time.Hour {
+		t.Errorf("Expected the default one hour maximum, got %s", got)
+	}
+	if got := defaults.NextInterval(90*time.Second, 25); got != time.Minute {
+		t.Errorf("Expected the default one minute minimum, got %s", got)
+	}
+}
+
+func TestContinuousArchiveCancelDuringWait(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel once the first pass is done, so the long jittered wait is
+	// what gets interrupted
+	began := time.Now()
+	err := archiver.ContinuousArchiveWithOptions(ctx, "golang", storage.ContinuousOptions{
+		Interval: time.Hour,
+		Jitter:   1,
+		OnCycle: func(string, *storage.ArchiveResult, error) {
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				cancel()
+			}()
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to end the wait promptly, took %s", elapsed)
+	}
+}
+
+func TestArchiverFlushesOnCancel(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	flusher := &flushingStorage{Storage: store}
+	archiver := storage.NewArchiver(mockClient, flusher)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := archiver.ContinuousArchive(ctx, "golang", time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if flusher.flushes != 1 {
+		t.Errorf("Expected ContinuousArchive to flush once on cancel, got %d", flusher.flushes)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	if _, err := archiver.BackfillSubreddit(cancelled, "golang", 1000, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context canceled, got %v", err)
+	}
+	if flusher.flushes != 2 {
+		t.Errorf("Expected BackfillSubreddit to flush on cancel, got %d flushes", flusher.flushes)
+	}
+
+	// Storage without buffering has nothing to flush
+	if err := storage.NewArchiver(mockClient, store).Flush(context.Background()); err != nil {
+		t.Errorf("Expected no-op flush to succeed, got %v", err)
+	}
+}
+
+func TestGetOrArchivePost(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	comment := testutil.NewTestComment("gc1", "fresh", "user1", "First!")
+	comment.ParentID = "t3_fresh"
+	mockClient.commentsMap["fresh"] = &types.CommentsResponse{
+		Post:     testutil.NewTestPost("fresh", "golang", "Fresh Post"),
+		Comments: []*types.Comment{comment},
+	}
+
+	post, err := archiver.GetOrArchivePost(ctx, "golang", "fresh", true)
+	if err != nil {
+		t.Fatalf("GetOrArchivePost failed: %v", err)
+	}
+	if post.ID != "fresh" || post.Title != "Fresh Post" {
+		t.Errorf("Expected archived post, got %s %q", post.ID, post.Title)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "fresh")
+	if err != nil || len(comments) != 1 {
+		t.Errorf("Expected comments to be archived, got %d (err=%v)", len(comments), err)
+	}
+
+	// Once stored, the post is served without contacting Reddit
+	mockClient.commentsError = errors.New("should not fetch")
+	post, err = archiver.GetOrArchivePost(ctx, "golang", "fresh", true)
+	if err != nil {
+		t.Fatalf("Expected stored post, got error: %v", err)
+	}
+	if post.ID != "fresh" {
+		t.Errorf("Expected post fresh, got %s", post.ID)
+	}
+
+	if _, err := archiver.GetOrArchivePost(ctx, "golang", "missing", false); err == nil {
+		t.Error("Expected fetch error for a post that isn't stored")
+	}
+}
+
+func TestArchiveSubredditResolveMedia(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	video := mockClient.posts[0]
+	video.Media = json.RawMessage(`{"reddit_video": {"width": 1280, "height": 720, "duration": 12}}`)
+
+	opts := storage.ArchiveOptions{Sort: "hot", ResolveMedia: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	posts, err := store.GetStoredPostsBySubreddit(ctx, "golang", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+
+	for _, post := range posts {
+		if post.ID != video.ID {
+			if post.Media != nil {
+				t.Errorf("Expected no media for post %s, got %+v", post.ID, post.Media)
+			}
+			continue
+		}
+		want := storage.MediaInfo{Type: "video", Width: 1280, Height: 720}
+		if post.Media == nil || *post.Media != want {
+			t.Errorf("Expected media %+v, got %+v", want, post.Media)
+		}
+	}
+}
+
+func TestArchivePostDropsMoreStubs(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	comment := testutil.NewTestComment("real1", "post1", "user1", "A real comment")
+	comment.ParentID = "t3_post1"
+
+	// A "continue this thread" stub and a "more" object that leaked through
+	// as comments
+	continueStub := &types.Comment{ThingData: types.ThingData{ID: "_", Name: "t1__"}, LinkID: "t3_post1", ParentID: "t1_real1"}
+	moreStub := &types.Comment{ThingData: types.ThingData{ID: "more1", Name: "more_more1"}, LinkID: "t3_post1", ParentID: "t3_post1"}
+
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{
+		Post:     mockClient.posts[0],
+		Comments: []*types.Comment{comment, continueStub, moreStub},
+		MoreIDs:  []string{"m1", "m2", "m3"},
+	}
+
+	if _, err := archiver.ArchivePost(ctx, "golang", "post1", true); err != nil {
+		t.Fatalf("ArchivePost failed: %v", err)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "post1")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].ID != "real1" {
+		t.Errorf("Expected only real1 to be saved, got %d comments", len(comments))
+	}
+
+	posts, err := store.GetStoredPostsBySubreddit(ctx, "golang", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	for _, post := range posts {
+		if post.ID == "post1" && (post.MoreCommentsCount == nil || *post.MoreCommentsCount != 3) {
+			t.Errorf("Expected more_comments_count 3, got %v", post.MoreCommentsCount)
+		}
+	}
+}
+
+// The API wrapper's client can expand more stubs
+var _ storage.MoreCommentsClient = (*graw.Client)(nil)
+
+// mockMoreClient expands more stubs into top-level comments
+type mockMoreClient struct {
+	*mockRedditClient
+	requests [][]string // Comment IDs of each GetMoreComments call
+}
+
+func (m *mockMoreClient) GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error) {
+	m.record()
+	m.requests = append(m.requests, req.CommentIDs)
+	var comments []*types.Comment
+	for _, id := range req.CommentIDs {
+		comment := testutil.NewTestComment(id, req.LinkID, "user1", "Expanded")
+		comment.ParentID = "t3_" + req.LinkID
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func TestArchivePostMaxMoreRequests(t *testing.T) {
+	plain, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	first := testutil.NewTestComment("first", "post1", "user1", "Loaded")
+	first.ParentID = "t3_post1"
+	var moreIDs []string
+	for i := range 250 {
+		moreIDs = append(moreIDs, fmt.Sprintf("more%d", i))
+	}
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{
+		Post:     mockClient.posts[0],
+		Comments: []*types.Comment{first},
+		MoreIDs:  moreIDs,
+	}
+
+	moreCount := func() int {
+		posts, err := store.GetStoredPostsBySubreddit(ctx, "golang", storage.QueryOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get posts: %v", err)
+		}
+		for _, post := range posts {
+			if post.ID == "post1" && post.MoreCommentsCount != nil {
+				return *post.MoreCommentsCount
+			}
+		}
+		return -1
+	}
+
+	client := &mockMoreClient{mockRedditClient: mockClient}
+	archiver := storage.NewArchiver(client, store)
+
+	// Off by default
+	result, err := archiver.ArchivePostWithOptions(ctx, "golang", "post1", storage.ArchiveOptions{IncludeComments: true})
+	if err != nil {
+		t.Fatalf("ArchivePostWithOptions failed: %v", err)
+	}
+	if result.CommentsSaved != 1 || len(client.requests) != 0 || moreCount() != 250 {
+		t.Errorf("Expected only the loaded comment without follow-ups, saved %d with %d requests", result.CommentsSaved, len(client.requests))
+	}
+
+	// The budget caps the follow-up requests
+	result, err = archiver.ArchivePostWithOptions(ctx, "golang", "post1", storage.ArchiveOptions{IncludeComments: true, MaxMoreRequests: 2})
+	if err != nil {
+		t.Fatalf("ArchivePostWithOptions failed: %v", err)
+	}
+	if len(client.requests) != 2 || len(client.requests[0]) != 100 {
+		t.Errorf("Expected 2 requests of 100 IDs, got %d", len(client.requests))
+	}
+	if result.CommentsSaved != 201 {
+		t.Errorf("Expected 201 comments saved, got %d", result.CommentsSaved)
+	}
+	if got := moreCount(); got != 50 {
+		t.Errorf("Expected 50 comments left unexpanded, got %d", got)
+	}
+
+	// A large enough budget completes the tree
+	client.requests = nil
+	opts := storage.ArchiveOptions{IncludeComments: true, MaxMoreRequests: 10}
+	if _, err := archiver.ArchivePostWithOptions(ctx, "golang", "post1", opts); err != nil {
+		t.Fatalf("ArchivePostWithOptions failed: %v", err)
+	}
+	comments, err := store.GetCommentsByPost(ctx, "post1")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	if len(client.requests) != 3 || len(comments) != 251 || moreCount() != 0 {
+		t.Errorf("Expected the full tree in 3 requests, got %d comments in %d requests", len(comments), len(client.requests))
+	}
+
+	// MaxCommentDepth still applies to expanded comments
+	if result, err := archiver.ArchivePostWithOptions(ctx, "golang", "post1", storage.ArchiveOptions{
+		IncludeComments: true, MaxMoreRequests: 10, MaxCommentDepth: 1,
+	}); err != nil || result.CommentsSaved != 251 {
+		t.Errorf("Expected 251 top-level comments saved, got %d (err=%v)", result.CommentsSaved, err)
+	}
+
+	// A client that can't expand stubs is refused
+	if _, err := plain.ArchivePostWithOptions(ctx, "golang", "post1", opts); err == nil {
+		t.Error("Expected an error for a client without GetMoreComments")
+	}
+}
+
+func TestDiffPost(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	newPost := func(score, numComments int, selfText string) *types.Post {
+		post := testutil.NewTestPost("watched", "golang", "Watched Post")
+		post.Author = "gopher"
+		post.IsSelf = true
+		post.SelfText = selfText
+		post.Score = score
+		post.NumComments = numComments
+		return post
+	}
+	if err := store.SavePost(ctx, newPost(10, 2, "Original body")); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	edited := newPost(25, 5, "Edited body")
+	edited.Edited = types.Edited{IsEdited: true, Timestamp: float64(time.Now().Unix())}
+
+	tests := []struct {
+		name    string
+		current *types.Post
+		want    storage.PostDiff
+	}{
+		{"unchanged", newPost(10, 2, "Original body"), storage.PostDiff{}},
+		{"edited", edited, storage.PostDiff{ScoreDelta: 15, CommentsDelta: 3, Edited: true}},
+		{"removed", newPost(8, 2, "[removed]"), storage.PostDiff{ScoreDelta: -2, Removed: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient.commentsMap["watched"] = &types.CommentsResponse{Post: tt.current}
+
+			diff, err := archiver.DiffPost(ctx, "golang", "watched")
+			if err != nil {
+				t.Fatalf("DiffPost failed: %v", err)
+			}
+			if diff.PostID != "watched" || diff.ScoreDelta != tt.want.ScoreDelta || diff.CommentsDelta != tt.want.CommentsDelta ||
+				diff.Edited != tt.want.Edited || diff.Removed != tt.want.Removed {
+				t.Errorf("Expected %+v, got %+v", tt.want, *diff)
+			}
+			if diff.Changed() != tt.want.Changed() {
+				t.Errorf("Expected Changed() = %v", tt.want.Changed())
+			}
+		})
+	}
+
+	// Diffing is read-only
+	post, err := store.GetPost(ctx, "watched")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if post.Score != 10 || post.SelfText != "Original body" {
+		t.Errorf("Expected stored post to be untouched, got score %d body %q", post.Score, post.SelfText)
+	}
+
+	if _, err := archiver.DiffPost(ctx, "golang", "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a post that isn't stored, got %v", err)
+	}
+}
+
+func TestRefreshComments(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Posts stored without their comments, across several pages
+	for i, post := range mockClient.posts {
+		post.CreatedUTC = float64(time.Now().Add(-time.Duration(i+1) * time.Hour).Unix())
+	}
+	third := testutil.NewTestPost("post3", "golang", "Third Post")
+	mockClient.posts = append(mockClient.posts, third)
+	if err := store.SavePosts(ctx, mockClient.posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	for _, post := range mockClient.posts {
+		top := testutil.NewTestComment("top_"+post.ID, post.ID, "user1", "Top")
+		top.ParentID = "t3_" + post.ID
+		reply := testutil.NewTestComment("reply_"+post.ID, post.ID, "user2", "Reply")
+		reply.ParentID = "t1_top_" + post.ID
+		mockClient.commentsMap[post.ID] = &types.CommentsResponse{
+			Post:     post,
+			Comments: []*types.Comment{top, reply},
+		}
+	}
+
+	if err := archiver.RefreshComments(ctx, "golang", storage.ArchiveOptions{Limit: 2}); err != nil {
+		t.Fatalf("RefreshComments failed: %v", err)
+	}
+
+	for _, post := range mockClient.posts {
+		histogram, err := store.GetCommentDepthHistogram(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("GetCommentDepthHistogram failed: %v", err)
+		}
+		if histogram[0] != 1 || histogram[1] != 1 {
+			t.Errorf("Expected one comment at depths 0 and 1 for %s, got %v", post.ID, histogram)
+		}
+	}
+
+	runs, err := store.GetArchiveRuns(ctx, "golang", 1)
+	if err != nil {
+		t.Fatalf("GetArchiveRuns failed: %v", err)
+	}
+	if len(runs) != 1 || runs[0].PostsProcessed != 3 || runs[0].CommentsSaved != 6 {
+		t.Errorf("Expected a run refreshing 3 posts and 6 comments, got %+v", runs)
+	}
+
+	// Options the client can't serve are rejected before any post is fetched
+	mockClient.calls = 0
+	if err := archiver.RefreshComments(ctx, "golang", storage.ArchiveOptions{MaxMoreRequests: 1}); err == nil {
+		t.Error("Expected MaxMoreRequests without a MoreCommentsClient to be rejected")
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for rejected options, got %d", mockClient.calls)
+	}
+}
+
+func TestArchiveSubredditSkipCompleteThreads(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// post1 reports one comment and post2 two, but each thread holds one
+	for _, post := range mockClient.posts {
+		post.NumComments = 1
+		comment := testutil.NewTestComment("c_"+post.ID, post.ID, "user1", "Only comment")
+		comment.ParentID = "t3_" + post.ID
+		mockClient.commentsMap[post.ID] = &types.CommentsResponse{
+			Post:     post,
+			Comments: []*types.Comment{comment},
+		}
+	}
+	mockClient.posts[1].NumComments = 2
+
+	// UpdateExisting keeps unchanged threads from being skipped on that
+	// account alone
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true, SkipCompleteThreads: true, UpdateExisting: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// Second pass: only post2 still looks incomplete
+	mockClient.calls = 0
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// GetSubreddit, GetHot and one GetComments
+	if mockClient.calls != 3 {
+		t.Errorf("Expected 3 API calls on the second pass, got %d", mockClient.calls)
+	}
+}
+
+func TestArchiveSubredditSkipsUnchangedPosts(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, post := range mockClient.posts {
+		mockClient.commentsMap[post.ID] = &types.CommentsResponse{Post: post}
+	}
+
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// Second pass: post2 gained a comment, post1 is unchanged
+	mockClient.posts[1].NumComments++
+	mockClient.calls = 0
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// GetSubreddit, GetHot and GetComments for post2
+	if mockClient.calls != 3 {
+		t.Errorf("Expected 3 API calls on the second pass, got %d", mockClient.calls)
+	}
+	if result.PostsSkipped != 1 {
+		t.Errorf("Expected 1 post skipped, got %d", result.PostsSkipped)
+	}
+
+	// The listing is still saved, so the new count is stored
+	post, err := store.GetPost(ctx, mockClient.posts[1].ID)
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if post.NumComments != mockClient.posts[1].NumComments {
+		t.Errorf("Expected num_comments %d, got %d", mockClient.posts[1].NumComments, post.NumComments)
+	}
+
+	// UpdateExisting re-fetches every thread
+	opts.UpdateExisting = true
+	mockClient.calls = 0
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if mockClient.calls != 4 {
+		t.Errorf("Expected 4 API calls with UpdateExisting, got %d", mockClient.calls)
+	}
+}
+
+func TestArchiveSubredditRetriesFailedThreads(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}
+
+	// The first pass stores the posts but every comment fetch fails
+	mockClient.commentsError = errors.New("API error")
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if len(result.FailedPosts) != 2 {
+		t.Fatalf("Expected 2 failed posts, got %v", result.FailedPosts)
+	}
+
+	// Nothing changed on Reddit, but the threads were never archived
+	mockClient.commentsError = nil
+	for _, post := range mockClient.posts {
+		mockClient.commentsMap[post.ID] = &types.CommentsResponse{
+			Post:     post,
+			Comments: []*types.Comment{testutil.NewTestComment("c_"+post.ID, post.ID, "user", "Retried")},
+		}
+	}
+	mockClient.calls = 0
+	result, err = archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// GetSubreddit, GetHot and GetComments for both posts
+	if mockClient.calls != 4 {
+		t.Errorf("Expected 4 API calls on the second pass, got %d", mockClient.calls)
+	}
+	if result.PostsSkipped != 0 || result.CommentsSaved != 2 {
+		t.Errorf("Expected both threads to be retried, got %+v", result)
+	}
+	for _, post := range mockClient.posts {
+		comments, err := store.GetCommentsByPost(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("GetCommentsByPost failed: %v", err)
+		}
+		if len(comments) != 1 {
+			t.Errorf("Expected 1 comment stored for %s, got %d", post.ID, len(comments))
+		}
+	}
+
+	// Once archived, the unchanged threads are skipped
+	mockClient.calls = 0
+	result, err = archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if mockClient.calls != 2 || result.PostsSkipped != 2 {
+		t.Errorf("Expected both threads to be skipped, got %d calls and %d skipped", mockClient.calls, result.PostsSkipped)
+	}
+}
+
+func TestArchiverMinRequestInterval(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	interval := 20 * time.Millisecond
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{MinRequestInterval: interval})
+
+	// GetSubreddit, GetHot and GetComments for each of the two posts
+	began := time.Now()
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if mockClient.calls != 4 {
+		t.Fatalf("Expected 4 API calls, got %d", mockClient.calls)
+	}
+	if elapsed := time.Since(began); elapsed < 3*interval {
+		t.Errorf("Expected calls spaced %s apart, finished in %s", interval, elapsed)
+	}
+
+	// Cancellation interrupts the wait
+	slow := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{MinRequestInterval: time.Hour})
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	began = time.Now()
+	_, err := slow.ArchiveSubreddit(short, "golang", opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to end with the context's error, got %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to interrupt the wait, took %s", elapsed)
+	}
+}
+
+func TestArchiveSubredditConcurrency(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	mockClient.posts = nil
+	for i := range 12 {
+		id := fmt.Sprintf("cp%d", i)
+		post := testutil.NewTestPost(id, "golang", "Concurrent "+id)
+		comment := testutil.NewTestComment("c_"+id, id, "user1", "Comment")
+		comment.ParentID = "t3_" + id
+		mockClient.posts = append(mockClient.posts, post)
+		mockClient.commentsMap[id] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{comment}}
+	}
+	mockClient.commentsDelay = 20 * time.Millisecond
+
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, Limit: 100, IncludeComments: true, Concurrency: 4}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	if mockClient.maxInFlight < 2 || mockClient.maxInFlight > 4 {
+		t.Errorf("Expected between 2 and 4 concurrent fetches, got %d", mockClient.maxInFlight)
+	}
+
+	for _, post := range mockClient.posts {
+		comments, err := store.GetCommentsByPost(ctx, post.ID)
+		if err != nil || len(comments) != 1 {
+			t.Errorf("Expected 1 comment for %s, got %d (err=%v)", post.ID, len(comments), err)
+		}
+	}
+
+	runs, err := store.GetArchiveRuns(ctx, "golang", 1)
+	if err != nil || len(runs) != 1 || runs[0].CommentsSaved != len(mockClient.posts) {
+		t.Errorf("Expected a run with %d comments, got %+v (err=%v)", len(mockClient.posts), runs, err)
+	}
+}
+
+func TestArchiveSubredditConcurrencyCancel(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	mockClient.posts = nil
+	for i := range 40 {
+		id := fmt.Sprintf("xp%d", i)
+		post := testutil.NewTestPost(id, "golang", "Cancelled "+id)
+		comment := testutil.NewTestComment("c_"+id, id, "user1", "Comment")
+		comment.ParentID = "t3_" + id
+		mockClient.posts = append(mockClient.posts, post)
+		mockClient.commentsMap[id] = &types.CommentsResponse{Post: post, Comments: []*types.Comment{comment}}
+	}
+	mockClient.commentsDelay = 30 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel once the fifth thread is requested. With two workers, at least
+	// three threads have been fetched and saved by then.
+	go func() {
+		for {
+			mockClient.mu.Lock()
+			calls := mockClient.calls
+			mockClient.mu.Unlock()
+			if calls >= 2+5 || ctx.Err() != nil {
+				cancel()
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	began := time.Now()
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, Limit: 100, IncludeComments: true, Concurrency: 2}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the archive to stop with the context's error, got %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > 2*time.Second {
+		t.Errorf("Expected workers to stop promptly, took %s", elapsed)
+	}
+
+	// Threads finished before the cancellation stay saved
+	saved := 0
+	for _, post := range mockClient.posts {
+		comments, err := store.GetCommentsByPost(context.Background(), post.ID)
+		if err != nil {
+			t.Fatalf("GetCommentsByPost failed: %v", err)
+		}
+		saved += len(comments)
+	}
+	if saved == 0 || saved == len(mockClient.posts) {
+		t.Errorf("Expected partial progress to be persisted, got %d of %d threads", saved, len(mockClient.posts))
+	}
+}
+
+// flakyClient fails the first failures GetComments calls with err
+type flakyClient struct {
+	*mockRedditClient
+	failures int
+	err      error
+	attempts int
+}
+
+func (c *flakyClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	c.attempts++
+	if c.attempts <= c.failures {
+		return nil, c.err
+	}
+	return c.mockRedditClient.GetComments(ctx, req)
+}
+
+func TestArchiverRetry(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	policy := &storage.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	unavailable := errors.New("API request failed with status 503: request failed")
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"transient error recovers", 2, unavailable, false, 3},
+		{"attempts run out", 5, unavailable, true, 3},
+		{"not found fails fast", 5, errors.New("API request failed with status 404: request failed"), true, 1},
+		{"unknown error fails fast", 5, errors.New("boom"), true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &flakyClient{mockRedditClient: mockClient, failures: tt.failures, err: tt.err}
+			archiver := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{Retry: policy})
+
+			_, err := archiver.ArchivePost(ctx, "golang", "post1", true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ArchivePost error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client.attempts != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, client.attempts)
+			}
+		})
+	}
+
+	// MaxAttempts of 1 disables retries
+	client := &flakyClient{mockRedditClient: mockClient, failures: 1, err: unavailable}
+	archiver := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{Retry: &storage.RetryPolicy{MaxAttempts: 1}})
+	if _, err := archiver.ArchivePost(ctx, "golang", "post1", true); err == nil || client.attempts != 1 {
+		t.Errorf("Expected a single failed attempt with retries disabled, got %d attempts (err=%v)", client.attempts, err)
+	}
+
+	// Cancellation interrupts the backoff
+	client = &flakyClient{mockRedditClient: mockClient, failures: 5, err: unavailable}
+	slow := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{
+		Retry: &storage.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour},
+	})
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	began := time.Now()
+	if _, err := slow.ArchivePost(short, "golang", "post1", true); err == nil {
+		t.Error("Expected an error when the context ends during backoff")
+	}
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to interrupt the backoff, took %s", elapsed)
+	}
+}
+
+func TestArchiverLogger(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	mockClient.commentsError = errors.New("API error")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{Logger: logger})
+
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// One warning per failed post, carrying its attributes
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %d: %s", len(records), buf.String())
+	}
+	for i, record := range records {
+		if record["level"] != "WARN" || record["subreddit"] != "golang" || record["post_id"] != mockClient.posts[i].ID || record["error"] != "storage error during fetch_post_and_comments: API error" {
+			t.Errorf("Unexpected log record: %v", record)
+		}
+	}
+}
+
+func TestArchiverProgress(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	var reports []storage.Progress
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		Progress: func(p storage.Progress) { reports = append(reports, p) },
+	})
+
+	comment := testutil.NewTestComment("pc1", "post1", "user1", "Comment")
+	comment.ParentID = "t3_post1"
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{Post: mockClient.posts[0], Comments: []*types.Comment{comment}}
+
+	// One report for the listing, then one per post's comments
+	opts := storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 progress reports, got %d: %+v", len(reports), reports)
+	}
+	if first := reports[0]; first.Op != "archive_subreddit" || first.Subreddit != "golang" || first.PostsSaved != 2 || first.CommentsSaved != 0 {
+		t.Errorf("Unexpected first report: %+v", first)
+	}
+	if last := reports[2]; last.PostsFetched != 2 || last.PostsSaved != 2 || last.CommentsSaved != 1 || last.Errors != 0 {
+		t.Errorf("Unexpected last report: %+v", last)
+	}
+
+	// One report per page
+	reports = nil
+	result, err := archiver.Backfill(ctx, "golang", storage.BackfillOptions{MaxPosts: 100})
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 progress report, got %d: %+v", len(reports), reports)
+	}
+	want := storage.Progress{Op: "backfill", Subreddit: "golang", PostsFetched: 2, PostsSaved: result.PostsSaved, Page: 1, After: "t3_after"}
+	if reports[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, reports[0])
+	}
+
+	// One report per post, counting failures
+	reports = nil
+	mockClient.commentsError = errors.New("API error")
+	if err := archiver.UpdateScores(ctx, "golang", 100*365*24*time.Hour); err != nil {
+		t.Fatalf("UpdateScores failed: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 progress reports, got %d: %+v", len(reports), reports)
+	}
+	if last := reports[1]; last.Op != "update_scores" || last.PostsFetched != 0 || last.Errors != 2 {
+		t.Errorf("Unexpected last report: %+v", last)
+	}
+}
+
+// failingSubredditClient fails GetSubreddit for one subreddit
+type failingSubredditClient struct {
+	*mockRedditClient
+	name string
+}
+
+func (c *failingSubredditClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	if name == c.name {
+		return nil, errors.New("API request failed with status 403: forbidden")
+	}
+	return c.mockRedditClient.GetSubreddit(ctx, name)
+}
+
+func TestArchiveSubreddits(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	archiver := storage.NewArchiver(&failingSubredditClient{mockRedditClient: mockClient, name: "private"}, store)
+
+	result, err := archiver.ArchiveSubreddits(ctx, []string{"golang", "private", "rust"}, storage.ArchiveOptions{Sort: storage.SortHot})
+	if err != nil {
+		t.Fatalf("ArchiveSubreddits failed: %v", err)
+	}
+	if len(result.Succeeded) != 2 || result.Succeeded[0] != "golang" || result.Succeeded[1] != "rust" {
+		t.Errorf("Expected golang and rust to succeed, got %v", result.Succeeded)
+	}
+	if len(result.Failed) != 1 || result.Failed["private"] == nil {
+		t.Errorf("Expected only private to fail, got %v", result.Failed)
+	}
+	if joined := result.Err(); joined == nil || !strings.Contains(joined.Error(), "r/private") {
+		t.Errorf("Expected Err to name the failed subreddit, got %v", joined)
+	}
+
+	// Every subreddit records its own run
+	for _, name := range []string{"golang", "private", "rust"} {
+		runs, err := store.GetArchiveRuns(ctx, name, 1)
+		if err != nil || len(runs) != 1 {
+			t.Errorf("Expected a recorded run for %s, got %d (err=%v)", name, len(runs), err)
+		}
+	}
+
+	// Cancellation skips the remaining subreddits
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	result, err = archiver.ArchiveSubreddits(cancelled, []string{"golang", "rust"}, storage.ArchiveOptions{})
+	if !errors.Is(err, context.Canceled) || len(result.Succeeded) != 0 || len(result.Failed) != 0 {
+		t.Errorf("Expected nothing archived after cancellation, got %+v (err=%v)", result, err)
+	}
+	if (&storage.SubredditsResult{}).Err() != nil {
+		t.Error("Expected a nil error when nothing failed")
+	}
+}
+
+func TestContinuousArchiveSubreddits(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	archiver := storage.NewArchiver(&failingSubredditClient{mockRedditClient: mockClient, name: "private"}, store)
+
+	var order []string
+	failures := make(map[string]int)
+	err := archiver.ContinuousArchiveSubreddits(ctx, []string{"golang", "private", "rust"}, storage.ContinuousOptions{
+		Interval: 30 * time.Millisecond,
+		OnCycle: func(subreddit string, _ *storage.ArchiveResult, err error) {
+			order = append(order, subreddit)
+			if err != nil {
+				failures[subreddit]++
+			}
+			if len(order) == 6 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// First passes are staggered in order, and a failing subreddit doesn't
+	// stop the others
+	if !slices.Equal(order[:3], []string{"golang", "private", "rust"}) {
+		t.Errorf("Expected staggered first passes, got %v", order)
+	}
+	if failures["private"] != 2 || len(failures) != 1 {
+		t.Errorf("Expected only private to fail, twice; got %v", failures)
+	}
+
+	if err := archiver.ContinuousArchiveSubreddits(ctx, nil, storage.ContinuousOptions{}); err == nil {
+		t.Error("Expected an error for no subreddits")
+	}
+}
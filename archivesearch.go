@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// SearchClient is implemented by Reddit clients that can search a
+// subreddit's posts. ArchiveSearch needs one; *graw.Client doesn't search
+// yet, so wrap it or substitute a client that does.
+type SearchClient interface {
+	Search(ctx context.Context, req *SearchRequest) (*types.PostsResponse, error)
+}
+
+// SearchRequest describes a request for a page of a subreddit's posts
+// matching a query
+type SearchRequest struct {
+	Subreddit string
+	Query     string
+	TimeRange TimeRange // Restricts results to posts from this period; empty lets Reddit decide
+	types.Pagination
+}
+
+// ErrSearchUnsupported is returned by ArchiveSearch when the archiver's
+// client doesn't implement SearchClient
+var ErrSearchUnsupported = errors.New("reddit client does not support search")
+
+// searchClient returns c as a SearchClient, if it is one
+func searchClient(c RedditClient) (SearchClient, error) {
+	sc, ok := c.(SearchClient)
+	if !ok {
+		return nil, ErrSearchUnsupported
+	}
+	return sc, nil
+}
+
+// ArchiveSearch archives the posts in a subreddit that match a Reddit search
+// query, paging through the results 100 at a time until opts.Limit posts
+// (default 100) are saved or the results end. Posts are stored exactly as
+// ArchiveSubreddit stores them, and opts.IncludeComments, MaxCommentDepth,
+// MaxMoreRequests, CommentBatchSize, UpdateExisting, SkipCompleteThreads,
+// Concurrency, AccountID, ResolveMedia, FailIfErrorRateAbove and
+// ArchiveCrosspostParents apply as they do there.
+// opts.TimeRange limits the search to a period; opts.Sort is ignored. The
+// run's timings are recorded with Storage.RecordArchiveRun, and buffered
+// writes are flushed if ctx is cancelled.
+func (a *Archiver) ArchiveSearch(ctx context.Context, subreddit, query string, opts ArchiveOptions) (*ArchiveResult, error) {
+	result := &ArchiveResult{}
+
+	if err := a.checkSearchOptions(query, opts); err != nil {
+		return result, &StorageError{Op: "archive_search", Err: err}
+	}
+
+	start := time.Now()
+	run := &ArchiveRun{Subreddit: subreddit, StartedAt: start}
+	err := a.archiveSearch(ctx, subreddit, query, opts, run, result)
+	if ctx.Err() != nil {
+		err = a.flushOnCancel(ctx)
+	} else if err == nil {
+		if rateErr := result.checkErrorRate(opts.FailIfErrorRateAbove); rateErr != nil {
+			err = &StorageError{Op: "archive_search", Err: rateErr}
+		}
+	}
+	run.PostsProcessed = result.PostsSaved
+	run.CommentsSaved = result.CommentsSaved
+	a.recordRun(ctx, run, err)
+
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// checkSearchOptions reports whether ArchiveSearch can run query with opts
+func (a *Archiver) checkSearchOptions(query string, opts ArchiveOptions) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("empty search query")
+	}
+	// Sort is ignored, so a time range applies without SortTop
+	if opts.TimeRange != "" && !opts.TimeRange.Valid() {
+		return invalidOption("ArchiveOptions.TimeRange", fmt.Sprintf("%q", opts.TimeRange), "accepted values are day, week, month, year, all")
+	}
+	general := opts
+	general.Sort, general.TimeRange = "", ""
+	if err := general.Validate(); err != nil {
+		return err
+	}
+	if err := a.checkCrosspostOptions(opts); err != nil {
+		return err
+	}
+	if _, err := searchClient(unwrapClient(a.client)); err != nil {
+		return err
+	}
+	if opts.MaxMoreRequests > 0 {
+		if _, err := moreCommentsClient(unwrapClient(a.client)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archiver) archiveSearch(ctx context.Context, subreddit, query string, opts ArchiveOptions, run *ArchiveRun, result *ArchiveResult) error {
+	client, err := searchClient(a.client)
+	if err != nil {
+		return err
+	}
+
+	// Save the subreddit as ArchiveSubreddit does
+	fetchStart := time.Now()
+	subInfo, err := a.client.GetSubreddit(ctx, subreddit)
+	run.FetchDuration += time.Since(fetchStart)
+	if err != nil {
+		return &StorageError{Op: "fetch_subreddit", Err: err}
+	}
+	saveStart := time.Now()
+	err = a.storage.SaveSubreddit(ctx, subInfo)
+	run.SaveDuration += time.Since(saveStart)
+	if err != nil {
+		return err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	progress := &Progress{Op: "archive_search", Subreddit: subreddit}
+
+	var after string
+	for result.PostsSaved < limit {
+		req := &SearchRequest{
+			Subreddit:  subreddit,
+			Query:      query,
+			TimeRange:  opts.TimeRange,
+			Pagination: types.Pagination{Limit: min(100, limit-result.PostsSaved), After: after},
+		}
+
+		fetchStart := time.Now()
+		resp, err := client.Search(ctx, req)
+		run.FetchDuration += time.Since(fetchStart)
+		if err != nil {
+			return &StorageError{Op: "fetch_search", Err: err}
+		}
+		if len(resp.Posts) == 0 {
+			return nil
+		}
+		posts := resp.Posts
+		progress.Page++
+		progress.PostsFetched += len(posts)
+
+		if err := a.countNewPosts(ctx, posts, result); err != nil {
+			return err
+		}
+
+		saveStart := time.Now()
+		err = a.savePosts(ctx, posts, opts)
+		run.SaveDuration += time.Since(saveStart)
+		if err != nil {
+			return err
+		}
+		result.PostsSaved += len(posts)
+		progress.PostsSaved = result.PostsSaved
+		progress.After = resp.AfterFullname
+		a.report(progress)
+
+		if opts.IncludeComments {
+			pending, err := a.threadsToFetch(ctx, posts, opts, result)
+			if err != nil {
+				return err
+			}
+			if err := a.archiveComments(ctx, subreddit, pending, opts, run, progress, result); err != nil {
+				return err
+			}
+		}
+
+		if after = resp.AfterFullname; after == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
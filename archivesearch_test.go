@@ -0,0 +1,93 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+// mockSearchClient adds search to mockRedditClient, matching posts whose
+// title contains the query and serving them in pages
+type mockSearchClient struct {
+	*mockRedditClient
+	searchPosts []*types.Post
+	requests    []*storage.SearchRequest
+}
+
+func (m *mockSearchClient) Search(ctx context.Context, req *storage.SearchRequest) (*types.PostsResponse, error) {
+	m.requests = append(m.requests, req)
+
+	var matches []*types.Post
+	for _, post := range m.searchPosts {
+		if strings.Contains(post.Title, req.Query) {
+			matches = append(matches, post)
+		}
+	}
+
+	posts, next := page(matches, &storage.UserRequest{Pagination: req.Pagination}, func(p *types.Post) string { return "t3_" + p.ID })
+	return &types.PostsResponse{Posts: posts, AfterFullname: next}, nil
+}
+
+func TestArchiveSearch(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	client := &mockSearchClient{mockRedditClient: mockClient}
+	for i := range 150 {
+		client.searchPosts = append(client.searchPosts, testutil.NewTestPost(fmt.Sprintf("cve%d", i), "netsec", "CVE writeup"))
+	}
+	client.searchPosts = append(client.searchPosts, testutil.NewTestPost("other", "netsec", "Unrelated"))
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.ArchiveSearch(ctx, "netsec", "CVE", storage.ArchiveOptions{Limit: 120, IncludeComments: true})
+	if err != nil {
+		t.Fatalf("ArchiveSearch failed: %v", err)
+	}
+	if result.PostsSaved != 120 || len(result.FailedPosts) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// The limit is spread over pages of at most 100
+	if len(client.requests) != 2 || client.requests[0].Limit != 100 || client.requests[1].Limit != 20 || client.requests[1].After != "t3_cve99" {
+		t.Errorf("Expected pages of 100 and 20, got %d requests", len(client.requests))
+	}
+
+	// Results are stored like any archived post
+	posts, err := store.GetPostsBySubreddit(ctx, "netsec", storage.QueryOptions{Limit: 1000})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(posts) != 120 {
+		t.Errorf("Expected 120 stored posts, got %d", len(posts))
+	}
+	if _, err := store.GetPost(ctx, "other"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the unmatched post not to be archived, got %v", err)
+	}
+}
+
+func TestArchiveSearchInvalid(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if _, err := archiver.ArchiveSearch(ctx, "netsec", "CVE", storage.ArchiveOptions{}); !errors.Is(err, storage.ErrSearchUnsupported) {
+		t.Errorf("Expected ErrSearchUnsupported, got %v", err)
+	}
+
+	searcher := storage.NewArchiver(&mockSearchClient{mockRedditClient: mockClient}, store)
+	if _, err := searcher.ArchiveSearch(ctx, "netsec", "  ", storage.ArchiveOptions{}); err == nil {
+		t.Error("Expected an error for an empty query")
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls, got %d", mockClient.calls)
+	}
+}
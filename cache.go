@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// DefaultCacheSize is the number of posts and of subreddits CachingStorage
+// keeps when no size is given
+const DefaultCacheSize = 1000
+
+// CachingStorage is a decorator that keeps recently read posts and
+// subreddits in LRU caches, so repeated GetPost and GetSubreddit calls skip
+// the database. Saving or deleting through the decorator invalidates the
+// affected entries; writes made to the wrapped storage directly, or by
+// another process, aren't seen until the entry is evicted. Errors, including
+// ErrNotFound, are never cached. All other methods go straight through.
+type CachingStorage struct {
+	Storage
+
+	posts      *lruCache
+	subreddits *lruCache
+}
+
+// NewCachingStorage wraps next with caches holding up to size posts and size
+// subreddits. A size of 0 or less uses DefaultCacheSize.
+func NewCachingStorage(next Storage, size int) *CachingStorage {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &CachingStorage{
+		Storage:    next,
+		posts:      newLRUCache(size),
+		subreddits: newLRUCache(size),
+	}
+}
+
+// Caching returns a middleware that wraps storage in a CachingStorage
+func Caching(size int) StorageMiddleware {
+	return func(next Storage) Storage {
+		return NewCachingStorage(next, size)
+	}
+}
+
+// GetPost returns the cached post when present, otherwise reads and caches
+// it. Callers get their own copy, so modifying it doesn't touch the cache.
+func (c *CachingStorage) GetPost(ctx context.Context, id string) (*types.Post, error) {
+	if cached, ok := c.posts.get(id); ok {
+		post := *cached.(*types.Post)
+		return &post, nil
+	}
+
+	post, err := c.Storage.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := *post
+	c.posts.add(id, &cached)
+	return post, nil
+}
+
+// GetSubreddit returns the cached subreddit when present, otherwise reads and
+// caches it. Names are matched case-insensitively, as the backends do.
+func (c *CachingStorage) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	key := NormalizeSubreddit(name)
+	if cached, ok := c.subreddits.get(key); ok {
+		sub := *cached.(*types.SubredditData)
+		return &sub, nil
+	}
+
+	sub, err := c.Storage.GetSubreddit(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := *sub
+	c.subreddits.add(key, &cached)
+	return sub, nil
+}
+
+// SavePost saves the post and invalidates its cached copy
+func (c *CachingStorage) SavePost(ctx context.Context, post *types.Post) error {
+	defer c.posts.remove(post.ID)
+	return c.Storage.SavePost(ctx, post)
+}
+
+// SavePosts saves the posts and invalidates their cached copies
+func (c *CachingStorage) SavePosts(ctx context.Context, posts []*types.Post) error {
+	defer func() {
+		for _, post := range posts {
+			c.posts.remove(post.ID)
+		}
+	}()
+	return c.Storage.SavePosts(ctx, posts)
+}
+
+// SaveStoredPosts saves the posts and invalidates their cached copies
+func (c *CachingStorage) SaveStoredPosts(ctx context.Context, posts []*StoredPost) error {
+	defer func() {
+		for _, post := range posts {
+			c.posts.remove(post.ID)
+		}
+	}()
+	return c.Storage.SaveStoredPosts(ctx, posts)
+}
+
+// DeletePosts deletes the posts and invalidates their cached copies
+func (c *CachingStorage) DeletePosts(ctx context.Context, ids []string) (int, error) {
+	defer func() {
+		for _, id := range ids {
+			c.posts.remove(id)
+		}
+	}()
+	return c.Storage.DeletePosts(ctx, ids)
+}
+
+// DeletePost deletes the post and invalidates its cached copy
+func (c *CachingStorage) DeletePost(ctx context.Context, id string) error {
+	defer c.posts.remove(id)
+	return c.Storage.DeletePost(ctx, id)
+}
+
+// PrunePosts prunes posts as the wrapped storage does and invalidates the
+// cached copies of every post the prune matches
+func (c *CachingStorage) PrunePosts(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
+	defer func() {
+		key := NormalizeSubreddit(opts.Subreddit)
+		before := float64(opts.Before.UnixNano()) / float64(time.Second)
+		c.posts.removeIf(func(value interface{}) bool {
+			post := value.(*types.Post)
+			return (key == "" || NormalizeSubreddit(post.Subreddit) == key) &&
+				post.CreatedUTC < before &&
+				(opts.KeepMinScore <= 0 || post.Score < opts.KeepMinScore)
+		})
+	}()
+	return c.Storage.PrunePosts(ctx, opts)
+}
+
+// SaveSubreddit saves the subreddit and invalidates its cached copy along
+// with its cached posts, which carry the subreddit's display name
+func (c *CachingStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditData) error {
+	key := NormalizeSubreddit(sub.DisplayName)
+	defer func() {
+		c.subreddits.remove(key)
+		c.posts.removeIf(func(value interface{}) bool {
+			return NormalizeSubreddit(value.(*types.Post).Subreddit) == key
+		})
+	}()
+	return c.Storage.SaveSubreddit(ctx, sub)
+}
+
+// Flush flushes the wrapped storage if it buffers writes (see Flusher)
+func (c *CachingStorage) Flush(ctx context.Context) error {
+	if flusher, ok := c.Storage.(Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
+// lruCache is a fixed-size, concurrency-safe least-recently-used cache
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is most recently used
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+func (c *lruCache) add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// removeIf drops every entry whose value matches
+func (c *lruCache) removeIf(match func(value interface{}) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if match(elem.Value.(*lruEntry).value) {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+	"github.com/jamesprial/go-reddit-storage/sqlite"
+)
+
+// countingStorage counts reads that reach the wrapped storage
+type countingStorage struct {
+	storage.Storage
+	postReads      int
+	subredditReads int
+}
+
+func (c *countingStorage) GetPost(ctx context.Context, id string) (*types.Post, error) {
+	c.postReads++
+	return c.Storage.GetPost(ctx, id)
+}
+
+func (c *countingStorage) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	c.subredditReads++
+	return c.Storage.GetSubreddit(ctx, name)
+}
+
+func setupCachingStorage(t *testing.T, size int) (*storage.CachingStorage, *countingStorage) {
+	base, err := sqlite.New(t.TempDir() + "/cache.db")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { base.Close() })
+
+	if err := base.RunMigrations(context.Background()); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	counting := &countingStorage{Storage: base}
+	return storage.NewCachingStorage(counting, size), counting
+}
+
+func TestCachingStorageGetPost(t *testing.T) {
+	store, counting := setupCachingStorage(t, 2)
+	ctx := context.Background()
+
+	for _, id := range []string{"c1", "c2", "c3"} {
+		if err := store.SavePost(ctx, testutil.NewTestPost(id, "golang", "Post "+id)); err != nil {
+			t.Fatalf("SavePost failed: %v", err)
+		}
+	}
+
+	first, err := store.GetPost(ctx, "c1")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	first.Title = "modified by caller"
+
+	again, err := store.GetPost(ctx, "c1")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if counting.postReads != 1 {
+		t.Errorf("Expected second read to hit the cache, got %d reads", counting.postReads)
+	}
+	if again.Title != "Post c1" {
+		t.Errorf("Expected cached copy unaffected by caller, got %q", again.Title)
+	}
+
+	// Saving invalidates the entry
+	updated := testutil.NewTestPost("c1", "golang", "Post c1")
+	updated.Score = 999
+	if err := store.SavePost(ctx, updated); err != nil {
+		t.Fatalf("SavePost failed: %v", err)
+	}
+	post, err := store.GetPost(ctx, "c1")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if post.Score != 999 || counting.postReads != 2 {
+		t.Errorf("Expected a fresh read after save, got score %d with %d reads", post.Score, counting.postReads)
+	}
+
+	// Filling the cache evicts the least recently used post
+	store.GetPost(ctx, "c2")
+	store.GetPost(ctx, "c3")
+	store.GetPost(ctx, "c1")
+	if counting.postReads != 5 {
+		t.Errorf("Expected c1 to have been evicted, got %d reads", counting.postReads)
+	}
+
+	// Deleting invalidates the entry
+	if _, err := store.DeletePosts(ctx, []string{"c3"}); err != nil {
+		t.Fatalf("DeletePosts failed: %v", err)
+	}
+	if _, err := store.GetPost(ctx, "c3"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// So does deleting a single post
+	if _, err := store.GetPost(ctx, "c1"); err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if err := store.DeletePost(ctx, "c1"); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+	if _, err := store.GetPost(ctx, "c1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after DeletePost, got %v", err)
+	}
+}
+
+func TestCachingStoragePrunePosts(t *testing.T) {
+	store, _ := setupCachingStorage(t, 0)
+	ctx := context.Background()
+
+	old := testutil.NewTestPost("p1", "golang", "Old")
+	old.CreatedUTC = float64(time.Now().Add(-48 * time.Hour).Unix())
+	recent := testutil.NewTestPost("p2", "golang", "Recent")
+	for _, post := range []*types.Post{old, recent} {
+		if err := store.SavePost(ctx, post); err != nil {
+			t.Fatalf("SavePost failed: %v", err)
+		}
+		if _, err := store.GetPost(ctx, post.ID); err != nil {
+			t.Fatalf("GetPost failed: %v", err)
+		}
+	}
+
+	result, err := store.PrunePosts(ctx, storage.PruneOptions{Subreddit: "golang", Before: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("PrunePosts failed: %v", err)
+	}
+	if result.PostsDeleted != 1 {
+		t.Fatalf("Expected 1 post pruned, got %d", result.PostsDeleted)
+	}
+
+	if _, err := store.GetPost(ctx, "p1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for the pruned post, got %v", err)
+	}
+	if _, err := store.GetPost(ctx, "p2"); err != nil {
+		t.Errorf("Expected the recent post to be kept, got %v", err)
+	}
+}
+
+func TestCachingStorageGetSubreddit(t *testing.T) {
+	store, counting := setupCachingStorage(t, 0)
+	ctx := context.Background()
+
+	sub := &types.SubredditData{DisplayName: "golang", Title: "Go"}
+	if err := store.SaveSubreddit(ctx, sub); err != nil {
+		t.Fatalf("SaveSubreddit failed: %v", err)
+	}
+
+	store.GetSubreddit(ctx, "golang")
+	cached, err := store.GetSubreddit(ctx, "GoLang")
+	if err != nil {
+		t.Fatalf("GetSubreddit failed: %v", err)
+	}
+	if counting.subredditReads != 1 || cached.Title != "Go" {
+		t.Errorf("Expected a case-insensitive cache hit, got %q with %d reads", cached.Title, counting.subredditReads)
+	}
+
+	sub.Title = "The Go Programming Language"
+	if err := store.SaveSubreddit(ctx, sub); err != nil {
+		t.Fatalf("SaveSubreddit failed: %v", err)
+	}
+	fresh, err := store.GetSubreddit(ctx, "golang")
+	if err != nil {
+		t.Fatalf("GetSubreddit failed: %v", err)
+	}
+	if fresh.Title != sub.Title {
+		t.Errorf("Expected %q after save, got %q", sub.Title, fresh.Title)
+	}
+}
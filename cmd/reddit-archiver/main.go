@@ -10,22 +10,40 @@ import (
 
 	graw "github.com/jamesprial/go-reddit-api-wrapper"
 	"github.com/jamesprial/go-reddit-storage"
-	"github.com/jamesprial/go-reddit-storage/postgres"
-	"github.com/jamesprial/go-reddit-storage/sqlite"
+	_ "github.com/jamesprial/go-reddit-storage/postgres"
+	_ "github.com/jamesprial/go-reddit-storage/sqlite"
 )
 
 func main() {
 	var (
-		subreddit   = flag.String("subreddit", "", "Subreddit to archive (required)")
+		subreddit   = flag.String("subreddit", "", "Subreddit to archive (required; a comma-separated list with -continuous)")
 		dbType      = flag.String("db-type", "sqlite", "Database type: sqlite or postgres")
 		dbURL       = flag.String("db", "", "Database connection string")
-		sort        = flag.String("sort", "hot", "Sort: hot, new, top")
+		sort        = flag.String("sort", "hot", "Sort: hot, new, top, rising")
+		timeRange   = flag.String("time-range", "", "Period for -sort top: day, week, month, year, all")
 		limit       = flag.Int("limit", 25, "Number of posts")
 		comments    = flag.Bool("comments", true, "Include comments")
+		maxMore     = flag.Int("max-more-requests", 0, "Follow-up requests per post to expand truncated comment threads (0 = none)")
+		update      = flag.Bool("update-existing", false, "Re-fetch comments for stored posts even if their comment count hasn't changed")
 		continuous  = flag.Bool("continuous", false, "Continuously monitor and archive")
 		interval    = flag.Duration("interval", 5*time.Minute, "Interval for continuous archiving")
+		jitter      = flag.Float64("jitter", 0, "Fraction (0-1) by which each continuous interval is randomly varied")
+		adaptive    = flag.Bool("adaptive-interval", false, "Scale the continuous interval by how many new posts each pass finds")
+		minInterval = flag.Duration("min-interval", time.Minute, "Shortest interval chosen by -adaptive-interval")
+		maxInterval = flag.Duration("max-interval", time.Hour, "Longest interval chosen by -adaptive-interval")
 		backfill    = flag.Bool("backfill", false, "Backfill historical posts")
 		maxBackfill = flag.Int("max-backfill", 1000, "Maximum posts to backfill")
+		backfillFor = flag.Duration("backfill-duration", 0, "Stop backfilling after this long (0 = no limit)")
+		resumeAfter = flag.String("backfill-after", "", "Resume backfilling after this post fullname")
+		resume      = flag.Bool("backfill-resume", false, "Resume from the last unfinished backfill's checkpoint")
+		since       = flag.String("backfill-since", "", "Stop backfilling at posts created before this date (YYYY-MM-DD)")
+		stopAtKnown = flag.Bool("backfill-stop-at-existing", false, "Stop backfilling at the first page whose posts are all archived")
+		backfillCon = flag.Int("backfill-concurrency", 1, "Threads whose comments are fetched at once during a backfill")
+		reqInterval = flag.Duration("request-interval", 0, "Minimum time between Reddit API calls")
+		maxAttempts = flag.Int("max-attempts", 4, "Attempts per Reddit API call on transient errors (1 = no retries)")
+		reqTimeout  = flag.Duration("request-timeout", 0, "Timeout for each Reddit API call attempt (0 = none)")
+		scoreHist   = flag.Bool("score-history", false, "Record a score snapshot of every post saved or refreshed")
+		maxErrRate  = flag.Float64("max-error-rate", 0, "Fail a pass when more than this fraction (0-1) of comment fetches fail (0 = never)")
 	)
 	flag.Parse()
 
@@ -37,32 +55,18 @@ func main() {
 	// Setup database connection string
 	connString := *dbURL
 	if connString == "" {
-		switch *dbType {
-		case "sqlite":
-			connString = "./reddit.db"
-		case "postgres":
+		if strings.EqualFold(*dbType, "sqlite") {
+			connString = storage.DefaultSQLitePath
+		} else {
 			connString = os.Getenv("DATABASE_URL")
 			if connString == "" {
-				log.Fatal("Error: -db flag or DATABASE_URL environment variable required for postgres")
+				log.Fatalf("Error: -db flag or DATABASE_URL environment variable required for %s", *dbType)
 			}
-		default:
-			log.Fatalf("Error: unsupported database type: %s", *dbType)
 		}
 	}
 
 	// Initialize storage
-	var store storage.Storage
-	var err error
-
-	switch strings.ToLower(*dbType) {
-	case "sqlite":
-		store, err = sqlite.New(connString)
-	case "postgres", "postgresql":
-		store, err = postgres.New(connString)
-	default:
-		log.Fatalf("Error: unsupported database type: %s", *dbType)
-	}
-
+	store, err := storage.Open(storage.Config{DBType: *dbType, DSN: connString})
 	if err != nil {
 		log.Fatalf("Error initializing storage: %v", err)
 	}
@@ -98,35 +102,79 @@ func main() {
 	}
 
 	// Create archiver
-	archiver := storage.NewArchiver(client, store)
+	retry := storage.DefaultRetryPolicy()
+	retry.MaxAttempts = *maxAttempts
+	archiver := storage.NewArchiverWithOptions(client, store, &storage.ArchiverOptions{
+		MinRequestInterval: *reqInterval,
+		Retry:              &retry,
+		RequestTimeout:     *reqTimeout,
+		RecordScoreHistory: *scoreHist,
+	})
 
 	// Execute based on mode
 	if *backfill {
+		var sinceTime time.Time
+		if *since != "" {
+			if sinceTime, err = time.Parse(time.DateOnly, *since); err != nil {
+				log.Fatalf("Error: invalid -backfill-since date: %v", err)
+			}
+		}
+
 		log.Printf("Starting backfill of r/%s (max %d posts)...", *subreddit, *maxBackfill)
-		if err := archiver.BackfillSubreddit(ctx, *subreddit, *maxBackfill, *comments); err != nil {
+		result, err := archiver.Backfill(ctx, *subreddit, storage.BackfillOptions{
+			MaxPosts:        *maxBackfill,
+			IncludeComments: *comments,
+			After:           *resumeAfter,
+			MaxDuration:     *backfillFor,
+			Resume:          *resume,
+			Since:           sinceTime,
+			StopAtExisting:  *stopAtKnown,
+			Concurrency:     *backfillCon,
+		})
+		if err != nil {
 			log.Fatalf("Error during backfill: %v", err)
 		}
-		log.Printf("Backfill completed successfully")
+		log.Printf("Backfill of r/%s: %s", *subreddit, &result.ArchiveResult)
+		if result.After != "" {
+			log.Printf("Resume with -backfill-after %s", result.After)
+		}
 	} else if *continuous {
-		log.Printf("Starting continuous archiving of r/%s (interval: %s)...", *subreddit, *interval)
-		if err := archiver.ContinuousArchive(ctx, *subreddit, *interval); err != nil {
+		subreddits := strings.Split(*subreddit, ",")
+		log.Printf("Starting continuous archiving of r/%s (interval: %s)...", strings.Join(subreddits, ", r/"), *interval)
+		if err := archiver.ContinuousArchiveSubreddits(ctx, subreddits, storage.ContinuousOptions{
+			Interval:             *interval,
+			Jitter:               *jitter,
+			FailIfErrorRateAbove: *maxErrRate,
+			Adaptive:             *adaptive,
+			MinInterval:          *minInterval,
+			MaxInterval:          *maxInterval,
+		}); err != nil {
 			log.Fatalf("Error during continuous archive: %v", err)
 		}
 	} else {
 		// One-time archive
 		opts := storage.ArchiveOptions{
-			Sort:            *sort,
+			Sort:            storage.SortType(*sort),
+			TimeRange:       storage.TimeRange(*timeRange),
 			Limit:           *limit,
 			IncludeComments: *comments,
+			MaxMoreRequests: *maxMore,
+			UpdateExisting:  *update,
+
+			FailIfErrorRateAbove: *maxErrRate,
 		}
 
 		log.Printf("Archiving r/%s (sort: %s, limit: %d, comments: %v)...",
 			*subreddit, *sort, *limit, *comments)
 
-		if err := archiver.ArchiveSubreddit(ctx, *subreddit, opts); err != nil {
+		result, err := archiver.ArchiveSubreddit(ctx, *subreddit, opts)
+		if err != nil {
 			log.Fatalf("Error during archive: %v", err)
 		}
 
-		log.Printf("Successfully archived r/%s", *subreddit)
+		log.Printf("Archived r/%s: %s", *subreddit, result)
+		if err := result.Err(); err != nil {
+			log.Printf("Comments failed for %d posts:\n%v", len(result.FailedPosts), err)
+		}
 	}
 }
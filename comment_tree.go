@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// CommentNode is a comment nested under its parent, as returned by
+// GetCommentTreeNested
+type CommentNode struct {
+	Comment  *types.Comment
+	Depth    int // 0 for top-level comments
+	Children []*CommentNode
+}
+
+// BuildCommentTree nests a flat list of comments by parent ID. Children keep
+// the relative order they had in the input, so a list ordered by creation
+// time yields children ordered by creation time. Comments whose parent isn't
+// in the list are treated as top-level.
+func BuildCommentTree(comments []*types.Comment) []*CommentNode {
+	nodes := make(map[string]*CommentNode, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &CommentNode{Comment: comment}
+	}
+
+	var roots []*CommentNode
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+
+		parent, ok := nodes[strings.TrimPrefix(comment.ParentID, "t1_")]
+		if !strings.HasPrefix(comment.ParentID, "t1_") || !ok || parent == node {
+			roots = append(roots, node)
+			continue
+		}
+
+		parent.Children = append(parent.Children, node)
+	}
+
+	setCommentDepths(roots, 0)
+	return roots
+}
+
+// setCommentDepths assigns depths top-down from the given level
+func setCommentDepths(nodes []*CommentNode, depth int) {
+	for _, node := range nodes {
+		node.Depth = depth
+		setCommentDepths(node.Children, depth+1)
+	}
+}
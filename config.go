@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config selects and configures a storage backend for Open
+type Config struct {
+	// DBType names the backend: "sqlite" or "postgres" ("postgresql" is
+	// accepted too). The backend's package must be imported, usually for
+	// side effects only, so that it registers itself.
+	DBType string
+
+	// DSN is the database file path for SQLite or the connection string
+	// for PostgreSQL
+	DSN string
+
+	// Pool configures the connection pool. PostgreSQL only; nil uses
+	// postgres.DefaultPoolConfig.
+	Pool *PoolConfig
+
+	// Options holds backend-specific options: *sqlite.Options or
+	// *postgres.Options. Nil uses the backend's DefaultOptions; options
+	// of the wrong backend are an error.
+	Options interface{}
+}
+
+// PoolConfig configures a database connection pool
+type PoolConfig struct {
+	// MaxOpenConns sets the maximum number of open connections to the database
+	// Default: 0 (unlimited)
+	MaxOpenConns int
+
+	// MaxIdleConns sets the maximum number of connections in the idle connection pool
+	// Default: 2
+	MaxIdleConns int
+
+	// ConnMaxLifetime sets the maximum amount of time a connection may be reused
+	// Default: 0 (connections are reused forever)
+	ConnMaxLifetime time.Duration
+
+	// ConnMaxIdleTime sets the maximum amount of time a connection may be idle
+	// Default: 0 (connections are not closed due to idle time)
+	ConnMaxIdleTime time.Duration
+}
+
+// Opener opens a backend from a Config. Backends pass one to Register.
+type Opener func(cfg Config) (Storage, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = make(map[string]Opener)
+)
+
+// Register makes a backend available to Open under dbType. Backends call it
+// from init, so it panics on a nil opener or a name registered twice.
+func Register(dbType string, open Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+
+	dbType = strings.ToLower(dbType)
+	if open == nil {
+		panic("storage: Register opener is nil")
+	}
+	if _, dup := openers[dbType]; dup {
+		panic("storage: Register called twice for " + dbType)
+	}
+	openers[dbType] = open
+}
+
+// Open opens the backend named by cfg.DBType. It doesn't run migrations.
+func Open(cfg Config) (Storage, error) {
+	openersMu.RLock()
+	open, ok := openers[strings.ToLower(strings.TrimSpace(cfg.DBType))]
+	openersMu.RUnlock()
+
+	if !ok {
+		return nil, &StorageError{
+			Op:  "open",
+			Err: fmt.Errorf("unknown database type %q (registered: %s; is its package imported?)", cfg.DBType, strings.Join(registeredTypes(), ", ")),
+		}
+	}
+
+	return open(cfg)
+}
+
+// DefaultSQLitePath is the database file OpenFromEnv uses for SQLite when
+// DATABASE_URL is unset
+const DefaultSQLitePath = "./reddit.db"
+
+// OpenFromEnv opens the backend named by the DB_TYPE environment variable
+// (default "sqlite") using the DSN in DATABASE_URL. SQLite falls back to
+// DefaultSQLitePath; other backends require DATABASE_URL.
+func OpenFromEnv() (Storage, error) {
+	cfg := Config{
+		DBType: os.Getenv("DB_TYPE"),
+		DSN:    os.Getenv("DATABASE_URL"),
+	}
+	if cfg.DBType == "" {
+		cfg.DBType = "sqlite"
+	}
+
+	if cfg.DSN == "" {
+		if !strings.EqualFold(cfg.DBType, "sqlite") {
+			return nil, &StorageError{Op: "open", Err: fmt.Errorf("DATABASE_URL is required for %s", cfg.DBType)}
+		}
+		cfg.DSN = DefaultSQLitePath
+	}
+
+	return Open(cfg)
+}
+
+// registeredTypes lists the registered backend names in sorted order
+func registeredTypes() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	names := make([]string, 0, len(openers))
+	for name := range openers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/postgres"
+	"github.com/jamesprial/go-reddit-storage/sqlite"
+)
+
+func TestOpen(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "open.db")
+
+	store, err := storage.Open(storage.Config{DBType: "SQLite", DSN: dbPath})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*sqlite.SQLiteStorage); !ok {
+		t.Fatalf("Expected *sqlite.SQLiteStorage, got %T", store)
+	}
+	if err := store.RunMigrations(context.Background()); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+
+	// Backend options are passed through
+	opts := sqlite.DefaultOptions()
+	opts.ReadOnly = true
+	ro, err := storage.Open(storage.Config{DBType: "sqlite", DSN: dbPath, Options: opts})
+	if err != nil {
+		t.Fatalf("Open read-only failed: %v", err)
+	}
+	defer ro.Close()
+
+	if err := ro.RunMigrations(context.Background()); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	// Options for another backend are rejected
+	if _, err := storage.Open(storage.Config{DBType: "sqlite", DSN: dbPath, Options: postgres.DefaultOptions()}); err == nil {
+		t.Error("Expected error for postgres options on sqlite")
+	}
+
+	if _, err := storage.Open(storage.Config{DBType: "mysql", DSN: dbPath}); err == nil {
+		t.Error("Expected error for unknown database type")
+	}
+}
+
+func TestOpenFromEnv(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "env.db")
+	t.Setenv("DB_TYPE", "")
+	t.Setenv("DATABASE_URL", dbPath)
+
+	store, err := storage.OpenFromEnv()
+	if err != nil {
+		t.Fatalf("OpenFromEnv failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*sqlite.SQLiteStorage); !ok {
+		t.Errorf("Expected sqlite by default, got %T", store)
+	}
+
+	t.Setenv("DB_TYPE", "postgres")
+	t.Setenv("DATABASE_URL", "")
+	if _, err := storage.OpenFromEnv(); err == nil {
+		t.Error("Expected error for postgres without DATABASE_URL")
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// CrosspostClient is implemented by Reddit clients that report which post a
+// crosspost was made from. types.Post doesn't carry Reddit's crosspost_parent
+// field, so the archiver only records StoredPost.CrosspostParentID for posts
+// fetched through one; *graw.Client doesn't report it yet.
+type CrosspostClient interface {
+	// CrosspostParent returns the fullname ("t3_" ID) of the post that post
+	// was crossposted from, or "" if it isn't a crosspost
+	CrosspostParent(post *types.Post) string
+}
+
+// crosspostClient returns c as a CrosspostClient, if it is one
+func crosspostClient(c RedditClient) (CrosspostClient, error) {
+	cc, ok := c.(CrosspostClient)
+	if !ok {
+		return nil, errors.New("reddit client does not report crosspost parents")
+	}
+	return cc, nil
+}
+
+// crosspostParentID returns the bare ID of the post post was crossposted
+// from, or "" if it isn't a crosspost or the archiver's client can't tell
+func (a *Archiver) crosspostParentID(post *types.Post) string {
+	cc, err := crosspostClient(unwrapClient(a.client))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(cc.CrosspostParent(post), "t3_")
+}
+
+// checkCrosspostOptions reports whether the archiver's client can find and
+// fetch the crosspost parents opts asks for
+func (a *Archiver) checkCrosspostOptions(opts ArchiveOptions) error {
+	if !opts.ArchiveCrosspostParents {
+		return nil
+	}
+	if _, err := crosspostClient(unwrapClient(a.client)); err != nil {
+		return err
+	}
+	_, err := infoClient(unwrapClient(a.client))
+	return err
+}
+
+// archiveCrosspostParents saves the posts that the crossposts among stored
+// were made from, when opts.ArchiveCrosspostParents is set and they aren't
+// stored yet. Parents are looked up with InfoClient, up to 100 per request,
+// and saved without their comments. A failure is logged rather than
+// returned, so it never costs the crossposts themselves.
+func (a *Archiver) archiveCrosspostParents(ctx context.Context, stored []*StoredPost, opts ArchiveOptions) {
+	if !opts.ArchiveCrosspostParents {
+		return
+	}
+	if err := a.saveCrosspostParents(ctx, stored, opts); err != nil {
+		a.logger.Warn("archiving crosspost parents failed", "error", err)
+		a.reportError("archive_crosspost_parents", err)
+	}
+}
+
+func (a *Archiver) saveCrosspostParents(ctx context.Context, stored []*StoredPost, opts ArchiveOptions) error {
+	var parentIDs []string
+	seen := make(map[string]bool)
+	for _, post := range stored {
+		if id := post.CrosspostParentID; id != "" && !seen[id] {
+			seen[id] = true
+			parentIDs = append(parentIDs, id)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	existing, err := a.storage.GetStoredNumComments(ctx, parentIDs)
+	if err != nil {
+		return err
+	}
+	var fullnames []string
+	for _, id := range parentIDs {
+		if _, ok := existing[id]; !ok {
+			fullnames = append(fullnames, "t3_"+id)
+		}
+	}
+
+	client, err := infoClient(a.client)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(fullnames); start += infoBatchSize {
+		batch := fullnames[start:min(start+infoBatchSize, len(fullnames))]
+		parents, err := client.GetInfo(ctx, batch)
+		if err != nil {
+			return &StorageError{Op: "fetch_crosspost_parents", Err: err}
+		}
+		if len(parents) == 0 {
+			continue
+		}
+
+		if err := a.storage.SaveStoredPosts(ctx, a.storedPosts(parents, opts)); err != nil {
+			return err
+		}
+		if err := a.postsSaved(ctx, parents...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+// mockCrosspostClient reports the crosspost parents in parents, keyed by
+// post ID, on top of mockInfoClient's lookups
+type mockCrosspostClient struct {
+	*mockInfoClient
+	parents map[string]string
+}
+
+func (m *mockCrosspostClient) CrosspostParent(post *types.Post) string {
+	return m.parents[post.ID]
+}
+
+func TestArchiveCrosspostParents(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// post2's parent is archived already, post1's isn't
+	if err := store.SavePost(ctx, testutil.NewTestPost("known", "golang", "Known parent")); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	client := &mockCrosspostClient{
+		mockInfoClient: &mockInfoClient{
+			mockRedditClient: mockClient,
+			info:             map[string]*types.Post{"t3_orig": testutil.NewTestPost("orig", "programming", "Original post")},
+		},
+		parents: map[string]string{"post1": "t3_orig", "post2": "t3_known"},
+	}
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Limit: 10, ArchiveCrosspostParents: true})
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if result.PostsSaved != 2 {
+		t.Errorf("Expected the 2 listed posts to be counted, got %d", result.PostsSaved)
+	}
+
+	if len(client.requests) != 1 || len(client.requests[0]) != 1 || client.requests[0][0] != "t3_orig" {
+		t.Errorf("Expected only the missing parent to be looked up, got %v", client.requests)
+	}
+	if _, err := store.GetPost(ctx, "orig"); err != nil {
+		t.Errorf("Expected the parent to be archived: %v", err)
+	}
+
+	for parent, want := range map[string]string{"orig": "post1", "known": "post2"} {
+		crossposts, err := store.GetCrossposts(ctx, parent)
+		if err != nil {
+			t.Fatalf("GetCrossposts failed: %v", err)
+		}
+		if len(crossposts) != 1 || crossposts[0].ID != want {
+			t.Errorf("Expected %s to be crossposted as %s, got %v", parent, want, crossposts)
+		}
+	}
+}
+
+func TestArchiveCrosspostParentsUnsupported(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	_, err := archiver.ArchiveSubreddit(context.Background(), "golang", storage.ArchiveOptions{ArchiveCrosspostParents: true})
+	if err == nil {
+		t.Fatal("Expected a client that can't report crossposts to be rejected")
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls, got %d", mockClient.calls)
+	}
+}
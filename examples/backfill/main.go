@@ -50,11 +50,12 @@ func main() {
 	log.Printf("Starting backfill of r/%s (up to %d posts)...", subreddit, maxPosts)
 	log.Println("This may take a while depending on Reddit's API rate limits...")
 
-	if err := archiver.BackfillSubreddit(ctx, subreddit, maxPosts, includeComments); err != nil {
+	result, err := archiver.BackfillSubreddit(ctx, subreddit, maxPosts, includeComments)
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Println("Backfill completed successfully!")
+	log.Printf("Backfill completed: %s", result)
 
 	// Show statistics
 	queryOpts := storage.QueryOptions{
@@ -44,16 +44,18 @@ func main() {
 
 	// Archive subreddit
 	opts := storage.ArchiveOptions{
-		Sort:            "hot",
+		Sort:            storage.SortHot,
 		Limit:           100,
 		IncludeComments: true,
 		MaxCommentDepth: 10,
 	}
 
 	log.Println("Starting archive of r/golang...")
-	if err := archiver.ArchiveSubreddit(ctx, "golang", opts); err != nil {
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", opts)
+	if err != nil {
 		log.Fatal(err)
 	}
+	log.Printf("Archived r/golang: %s", result)
 
 	// Query stored data
 	queryOpts := storage.QueryOptions{
@@ -2,6 +2,8 @@ module github.com/jamesprial/go-reddit-storage
 
 go 1.25.0
 
+require github.com/klauspost/compress v1.18.0
+
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
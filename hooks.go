@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// ErrHookPanic is wrapped by the error reported when an ArchiverOptions hook
+// panics. The panic is recovered, so the operation carries on as it would
+// after a failed save.
+var ErrHookPanic = errors.New("archiver hook panicked")
+
+// callHook runs fn, turning a panic into an error wrapping ErrHookPanic
+func callHook(name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &StorageError{Op: name, Err: fmt.Errorf("%w: %v", ErrHookPanic, r)}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// postsArchived passes each of posts, just saved, to the OnPostArchived hook.
+// A panic stops the hook being called for the remaining posts.
+func (a *Archiver) postsArchived(posts []*types.Post) error {
+	if a.onPostArchived == nil {
+		return nil
+	}
+	return callHook("on_post_archived", func() {
+		for _, post := range posts {
+			a.onPostArchived(post)
+		}
+	})
+}
+
+// commentsArchived passes the comments just saved for a post to the
+// OnCommentsArchived hook
+func (a *Archiver) commentsArchived(postID string, count int) error {
+	if a.onCommentsArchived == nil || count == 0 {
+		return nil
+	}
+	return callHook("on_comments_archived", func() {
+		a.onCommentsArchived(strings.TrimPrefix(postID, "t3_"), count)
+	})
+}
+
+// reportError passes an error the archiver is working past to the OnError
+// hook. A panic in the hook is logged, since there is nowhere left to report
+// it.
+func (a *Archiver) reportError(op string, err error) {
+	if a.onError == nil {
+		return
+	}
+	if hookErr := callHook("on_error", func() { a.onError(op, err) }); hookErr != nil {
+		a.logger.Error("error hook failed", "op", op, "error", hookErr)
+	}
+}
@@ -0,0 +1,116 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+func TestArchiverHooks(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{
+		Post: mockClient.posts[0],
+		Comments: []*types.Comment{
+			testutil.NewTestComment("h1", "post1", "alice", "First"),
+			testutil.NewTestComment("h2", "post1", "bob", "Second"),
+		},
+	}
+
+	var archived []string
+	comments := make(map[string]int)
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		OnPostArchived:     func(post *types.Post) { archived = append(archived, post.ID) },
+		OnCommentsArchived: func(postID string, count int) { comments[postID] += count },
+	})
+
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Limit: 25, IncludeComments: true}); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	// Each post is reported once from the listing and again with its thread
+	if len(archived) != 4 || archived[0] != "post1" || archived[1] != "post2" {
+		t.Errorf("Expected the listing then each thread, got %v", archived)
+	}
+	if len(comments) != 1 || comments["post1"] != 2 {
+		t.Errorf("Expected 2 comments for post1, got %v", comments)
+	}
+}
+
+func TestArchiverOnError(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	mockClient.commentsError = errors.New("thread unavailable")
+
+	var ops []string
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		Retry: &storage.RetryPolicy{MaxAttempts: 1},
+		OnError: func(op string, err error) {
+			if !errors.Is(err, mockClient.commentsError) {
+				t.Errorf("Unexpected error for %s: %v", op, err)
+			}
+			ops = append(ops, op)
+		},
+	})
+
+	result, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Limit: 25, IncludeComments: true})
+	if err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+	if len(result.FailedPosts) != 2 || len(ops) != 2 || ops[0] != "archive_comments" {
+		t.Errorf("Expected both skipped threads reported, got %v", ops)
+	}
+}
+
+func TestArchiverHookPanic(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	var reported []error
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		Logger: slog.New(slog.DiscardHandler),
+		OnPostArchived: func(post *types.Post) {
+			if post.ID == "post2" {
+				panic("index unavailable")
+			}
+		},
+		OnError: func(op string, err error) {
+			reported = append(reported, err)
+			panic("error hook broken too")
+		},
+	})
+
+	// A panic while saving the listing fails the operation
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Limit: 25}); !errors.Is(err, storage.ErrHookPanic) {
+		t.Errorf("Expected ErrHookPanic from ArchiveSubreddit, got %v", err)
+	}
+
+	if _, err := archiver.ArchivePost(ctx, "golang", "post1", true); err != nil {
+		t.Errorf("Expected post1 to archive, got %v", err)
+	}
+	if _, err := archiver.ArchivePost(ctx, "golang", "post2", true); !errors.Is(err, storage.ErrHookPanic) {
+		t.Errorf("Expected ErrHookPanic for post2, got %v", err)
+	}
+
+	// UpdateScores works past the failed post, and the panicking OnError
+	// hook doesn't stop it
+	if err := archiver.UpdateScores(ctx, "golang", 24*time.Hour); err != nil {
+		t.Fatalf("UpdateScores failed: %v", err)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], storage.ErrHookPanic) {
+		t.Errorf("Expected the hook panic to be reported once, got %v", reported)
+	}
+}
@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// Default import settings
+const (
+	defaultImportBatchSize = 500
+	maxImportLineSize      = 16 * 1024 * 1024
+)
+
+// DefaultRequiredPostFields are the JSON fields a post record must contain
+var DefaultRequiredPostFields = []string{"id", "subreddit"}
+
+// DefaultRequiredCommentFields are the JSON fields a comment record must contain
+var DefaultRequiredCommentFields = []string{"id", "link_id"}
+
+// ImportOptions configures ImportSubreddit and ImportNDJSON
+type ImportOptions struct {
+	// SkipInvalid continues past records that fail validation or that
+	// storage rejects, collecting their errors in ImportResult.Errors instead
+	// of aborting the import. A batch that fails to save is retried one
+	// record at a time so only the rejected records are skipped.
+	SkipInvalid bool
+
+	// RequiredPostFields lists the JSON fields every post record must contain
+	// with a non-empty value
+	// Default: DefaultRequiredPostFields
+	RequiredPostFields []string
+
+	// RequiredCommentFields lists the JSON fields every comment record must
+	// contain with a non-empty value
+	// Default: DefaultRequiredCommentFields
+	RequiredCommentFields []string
+
+	// BatchSize sets how many records are buffered per SavePosts/SaveComments call
+	// Default: 500
+	BatchSize int
+
+	// Progress, if set, is called with the totals so far after each batch
+	// is saved
+	Progress func(ImportResult)
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	LinesRead        int
+	PostsImported    int
+	CommentsImported int
+	Errors           []*ImportError // Invalid records skipped when SkipInvalid is set
+}
+
+// ImportError reports a record that failed validation
+type ImportError struct {
+	Line int   // 1-based line number in the input
+	Err  error // Reason the record was rejected
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// ImportSubreddit imports newline-delimited JSON records into storage. Each
+// line holds either a bare post/comment object or a Reddit thing wrapper
+// ({"kind": "t3", "data": {...}}); bare objects with a link_id are treated
+// as comments and everything else as posts. Records missing a required field
+// are rejected with a line-numbered ImportError. If subreddit is non-empty,
+// posts from any other subreddit are rejected as well.
+//
+// Posts are always flushed before comments so comments can reference posts
+// that appear earlier in the same input. Moderator fields (num_reports,
+// removed_by_category) and crosspost_parent are stored when a post record
+// includes them.
+func ImportSubreddit(ctx context.Context, store Storage, subreddit string, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	im := newImporter(store, "import_subreddit", opts)
+	return im.run(ctx, r, func(data []byte) (*StoredPost, *types.Comment, error) {
+		return decodeImportRecord(data, subreddit, im.opts)
+	})
+}
+
+// importer streams newline-delimited records into storage in batches
+type importer struct {
+	store  Storage
+	op     string
+	opts   ImportOptions
+	result *ImportResult
+
+	posts        []*StoredPost
+	postLines    []int
+	comments     []*types.Comment
+	commentLines []int
+}
+
+// newImporter fills in opts' defaults
+func newImporter(store Storage, op string, opts ImportOptions) *importer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatchSize
+	}
+	if opts.RequiredPostFields == nil {
+		opts.RequiredPostFields = DefaultRequiredPostFields
+	}
+	if opts.RequiredCommentFields == nil {
+		opts.RequiredCommentFields = DefaultRequiredCommentFields
+	}
+	return &importer{store: store, op: op, opts: opts, result: &ImportResult{}}
+}
+
+// run decodes each non-blank line of r with decode and saves the records
+func (im *importer) run(ctx context.Context, r io.Reader, decode func([]byte) (*StoredPost, *types.Comment, error)) (*ImportResult, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		im.result.LinesRead = line
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		post, comment, err := decode(data)
+		if err != nil {
+			if err := im.reject(line, err); err != nil {
+				return im.result, err
+			}
+			continue
+		}
+
+		if post != nil {
+			im.posts = append(im.posts, post)
+			im.postLines = append(im.postLines, line)
+			if len(im.posts) >= im.opts.BatchSize {
+				if err := im.flushPosts(ctx); err != nil {
+					return im.result, err
+				}
+			}
+		}
+
+		if comment != nil {
+			im.comments = append(im.comments, comment)
+			im.commentLines = append(im.commentLines, line)
+			if len(im.comments) >= im.opts.BatchSize {
+				if err := im.flushComments(ctx); err != nil {
+					return im.result, err
+				}
+			}
+		}
+
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return im.result, ctx.Err()
+		default:
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return im.result, &StorageError{Op: im.op, Err: &ImportError{Line: line + 1, Err: err}}
+	}
+
+	if err := im.flushPosts(ctx); err != nil {
+		return im.result, err
+	}
+	if err := im.flushComments(ctx); err != nil {
+		return im.result, err
+	}
+
+	return im.result, nil
+}
+
+// reject records an invalid record on line, or returns it as the import's
+// error unless SkipInvalid is set
+func (im *importer) reject(line int, err error) error {
+	importErr := &ImportError{Line: line, Err: err}
+	if !im.opts.SkipInvalid {
+		return &StorageError{Op: im.op, Err: importErr}
+	}
+	im.result.Errors = append(im.result.Errors, importErr)
+	return nil
+}
+
+func (im *importer) flushPosts(ctx context.Context) error {
+	if len(im.posts) == 0 {
+		return nil
+	}
+
+	err := im.store.SaveStoredPosts(ctx, im.posts)
+	if err == nil {
+		im.result.PostsImported += len(im.posts)
+	} else if err = im.saveEach(ctx, err, im.postLines, func(i int) error {
+		if err := im.store.SaveStoredPosts(ctx, im.posts[i:i+1]); err != nil {
+			return err
+		}
+		im.result.PostsImported++
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	im.posts = im.posts[:0]
+	im.postLines = im.postLines[:0]
+	im.report()
+	return nil
+}
+
+func (im *importer) flushComments(ctx context.Context) error {
+	if len(im.comments) == 0 {
+		return nil
+	}
+	// Comments may reference posts still sitting in the buffer
+	if err := im.flushPosts(ctx); err != nil {
+		return err
+	}
+
+	err := im.store.SaveComments(ctx, im.comments)
+	if err == nil {
+		im.result.CommentsImported += len(im.comments)
+	} else if err = im.saveEach(ctx, err, im.commentLines, func(i int) error {
+		if err := im.store.SaveComment(ctx, im.comments[i]); err != nil {
+			return err
+		}
+		im.result.CommentsImported++
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	im.comments = im.comments[:0]
+	im.commentLines = im.commentLines[:0]
+	im.report()
+	return nil
+}
+
+// saveEach handles a batch that failed to save with batchErr. Unless
+// SkipInvalid is set the batch's error is returned; otherwise each record is
+// saved on its own by save so a single record storage rejects, such as a
+// comment whose post isn't stored, only loses that record.
+func (im *importer) saveEach(ctx context.Context, batchErr error, lines []int, save func(i int) error) error {
+	if !im.opts.SkipInvalid || ctx.Err() != nil {
+		return batchErr
+	}
+
+	for i, line := range lines {
+		if err := save(i); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			im.result.Errors = append(im.result.Errors, &ImportError{Line: line, Err: err})
+		}
+	}
+	return nil
+}
+
+// report passes the totals so far to the progress callback, if one is set
+func (im *importer) report() {
+	if im.opts.Progress != nil {
+		im.opts.Progress(*im.result)
+	}
+}
+
+// decodeImportRecord validates a single JSON record and decodes it into
+// either a post or a comment
+func decodeImportRecord(data []byte, subreddit string, opts ImportOptions) (*StoredPost, *types.Comment, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Unwrap Reddit thing wrappers
+	isComment := hasField(fields, "link_id")
+	if kindRaw, ok := fields["kind"]; ok && hasField(fields, "data") {
+		var kind string
+		if err := json.Unmarshal(kindRaw, &kind); err != nil {
+			return nil, nil, fmt.Errorf("invalid kind: %w", err)
+		}
+
+		switch kind {
+		case "t1":
+			isComment = true
+		case "t3":
+			isComment = false
+		default:
+			return nil, nil, fmt.Errorf("unsupported kind: %s", kind)
+		}
+
+		data = fields["data"]
+		fields = nil
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON data: %w", err)
+		}
+	}
+
+	if isComment {
+		if missing := missingFields(fields, opts.RequiredCommentFields); len(missing) > 0 {
+			return nil, nil, fmt.Errorf("comment missing required fields: %s", strings.Join(missing, ", "))
+		}
+
+		var comment types.Comment
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return nil, nil, fmt.Errorf("invalid comment: %w", err)
+		}
+		return nil, &comment, nil
+	}
+
+	if missing := missingFields(fields, opts.RequiredPostFields); len(missing) > 0 {
+		return nil, nil, fmt.Errorf("post missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	post, err := StoredPostFromJSON(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid post: %w", err)
+	}
+
+	if subreddit != "" && !strings.EqualFold(post.Subreddit, subreddit) {
+		return nil, nil, fmt.Errorf("post %s belongs to r/%s, not r/%s", post.ID, post.Subreddit, subreddit)
+	}
+
+	return post, nil, nil
+}
+
+// missingFields returns the required fields that are absent, null, or empty strings
+func missingFields(fields map[string]json.RawMessage, required []string) []string {
+	var missing []string
+	for _, name := range required {
+		if !hasField(fields, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// hasField reports whether a field is present with a non-null, non-empty value
+func hasField(fields map[string]json.RawMessage, name string) bool {
+	raw, ok := fields[name]
+	if !ok {
+		return false
+	}
+
+	value := string(bytes.TrimSpace(raw))
+	return value != "null" && value != `""`
+}
@@ -0,0 +1,155 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+const importFixture = `{"id": "imp1", "subreddit": "golang", "title": "First", "created_utc": 1700000000}
+{"id": "imp2", "title": "Missing subreddit", "created_utc": 1700000001}
+{"kind": "t3", "data": {"id": "imp3", "subreddit": "golang", "title": "Wrapped", "created_utc": 1700000002}}
+{"id": "impc1", "link_id": "t3_imp1", "parent_id": "t3_imp1", "body": "hello", "created_utc": 1700000003}
+{"id": "impc2", "body": "no link", "created_utc": 1700000004}
+not json
+`
+
+func TestImportSubreddit_RejectsInvalidRecord(t *testing.T) {
+	_, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	_, err := storage.ImportSubreddit(ctx, store, "golang", strings.NewReader(importFixture), storage.ImportOptions{})
+	if err == nil {
+		t.Fatal("Expected import to fail on the record missing a subreddit")
+	}
+
+	var importErr *storage.ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("Expected an ImportError, got %v", err)
+	}
+
+	if importErr.Line != 2 {
+		t.Errorf("Expected error on line 2, got line %d", importErr.Line)
+	}
+}
+
+func TestImportSubreddit_SkipInvalid(t *testing.T) {
+	_, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	opts := storage.ImportOptions{SkipInvalid: true}
+	result, err := storage.ImportSubreddit(ctx, store, "golang", strings.NewReader(importFixture), opts)
+	if err != nil {
+		t.Fatalf("ImportSubreddit failed: %v", err)
+	}
+
+	if result.PostsImported != 2 {
+		t.Errorf("Expected 2 posts imported, got %d", result.PostsImported)
+	}
+
+	if result.CommentsImported != 1 {
+		t.Errorf("Expected 1 comment imported, got %d", result.CommentsImported)
+	}
+
+	var lines []int
+	for _, importErr := range result.Errors {
+		lines = append(lines, importErr.Line)
+	}
+
+	if len(lines) != 3 || lines[0] != 2 || lines[1] != 5 || lines[2] != 6 {
+		t.Errorf("Expected errors on lines [2 5 6], got %v", lines)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "imp1")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	if len(comments) != 1 {
+		t.Errorf("Expected 1 imported comment on imp1, got %d", len(comments))
+	}
+}
+
+// pushshiftFixture mimics a dump: string timestamps, no fullnames, and a
+// comment on a post that isn't in the import
+const pushshiftFixture = `{"id": "ps1", "subreddit": "golang", "title": "Dumped", "created_utc": "1700000000", "score": 12, "num_comments": 1}
+{"id": "psc1", "link_id": "t3_ps1", "parent_id": "t3_ps1", "subreddit": "golang", "body": "reply", "created_utc": "1700000100"}
+{"id": "psc2", "link_id": "t3_missing", "parent_id": "t3_missing", "subreddit": "golang", "body": "orphan", "created_utc": 1700000200}
+`
+
+func TestImportNDJSON_Zstd(t *testing.T) {
+	_, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	var compressed bytes.Buffer
+	encoder, err := zstd.NewWriter(&compressed)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+	if _, err := encoder.Write([]byte(pushshiftFixture)); err != nil {
+		t.Fatalf("Failed to compress fixture: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("Failed to compress fixture: %v", err)
+	}
+
+	var reports []storage.ImportResult
+	opts := storage.ImportOptions{
+		SkipInvalid: true,
+		BatchSize:   1,
+		Progress:    func(r storage.ImportResult) { reports = append(reports, r) },
+	}
+	result, err := storage.ImportNDJSON(ctx, store, &compressed, opts)
+	if err != nil {
+		t.Fatalf("ImportNDJSON failed: %v", err)
+	}
+
+	if result.LinesRead != 3 || result.PostsImported != 1 || result.CommentsImported != 1 {
+		t.Errorf("Expected 3 lines, 1 post and 1 comment, got %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Expected the orphan comment on line 3 to be skipped, got %v", result.Errors)
+	}
+	if len(reports) == 0 || reports[len(reports)-1].CommentsImported != 1 {
+		t.Errorf("Expected progress reports up to 1 comment, got %+v", reports)
+	}
+
+	post, err := store.GetPost(ctx, "ps1")
+	if err != nil {
+		t.Fatalf("Failed to get imported post: %v", err)
+	}
+	if post.CreatedUTC != 1700000000 {
+		t.Errorf("Expected created_utc 1700000000, got %v", post.CreatedUTC)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "ps1")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	if len(comments) != 1 || comments[0].CreatedUTC != 1700000100 {
+		t.Errorf("Expected the imported comment created at 1700000100, got %+v", comments)
+	}
+}
+
+func TestImportNDJSON_OrphanCommentAborts(t *testing.T) {
+	_, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	result, err := storage.ImportNDJSON(context.Background(), store, strings.NewReader(pushshiftFixture), storage.ImportOptions{})
+	if err == nil {
+		t.Fatal("Expected import to fail on the comment whose post isn't stored")
+	}
+	if result.PostsImported != 1 {
+		t.Errorf("Expected the post to be imported before the failure, got %d", result.PostsImported)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// WritePostMarkdown renders a post and its comments as a Markdown document.
+// Comments must be in thread order (as returned by GetCommentsByPost); each
+// one becomes a list item indented by its stored depth, with the author and
+// score on the first line and the body quoted beneath.
+func WritePostMarkdown(w io.Writer, post *types.Post, comments []*StoredComment) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# %s\n\n", markdownLine(post.Title))
+	fmt.Fprintf(bw, "*r/%s · posted by u/%s · %s*\n\n", post.Subreddit, post.Author, pluralPoints(post.Score))
+
+	if !post.IsSelf && post.URL != "" {
+		fmt.Fprintf(bw, "<%s>\n\n", post.URL)
+	}
+
+	if body := strings.TrimSpace(post.SelfText); body != "" {
+		fmt.Fprintf(bw, "%s\n\n", body)
+	}
+
+	fmt.Fprintf(bw, "---\n\n## Comments (%d)\n", len(comments))
+
+	for _, comment := range comments {
+		depth := max(comment.Depth, 0)
+		indent := strings.Repeat("  ", depth)
+
+		fmt.Fprintf(bw, "\n%s- **u/%s** (%s)\n\n", indent, comment.Author, pluralPoints(comment.Score))
+
+		for _, line := range strings.Split(strings.TrimSpace(comment.Body), "\n") {
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" {
+				fmt.Fprintf(bw, "%s  >\n", indent)
+				continue
+			}
+			fmt.Fprintf(bw, "%s  > %s\n", indent, line)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// markdownLine collapses a value onto a single line for use in a heading
+func markdownLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// pluralPoints formats a score as "1 point" or "N points"
+func pluralPoints(score int) string {
+	if score == 1 || score == -1 {
+		return fmt.Sprintf("%d point", score)
+	}
+	return fmt.Sprintf("%d points", score)
+}
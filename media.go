@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// MediaURLPatterns are the SQL LIKE patterns QueryOptions.HasMedia matches
+// against a post's url, case-insensitively, to find image, video and gallery
+// posts. They cover Reddit's own media hosts, Imgur and links ending in a
+// common image or video extension; URLs with a query string after the
+// extension aren't recognized. Append to the slice before querying to treat
+// other hosts as media.
+var MediaURLPatterns = []string{
+	"%://i.redd.it/%",
+	"%://v.redd.it/%",
+	"%://preview.redd.it/%",
+	"%://reddit.com/gallery/%",
+	"%://www.reddit.com/gallery/%",
+	"%://i.imgur.com/%",
+	"%://imgur.com/a/%",
+	"%://imgur.com/gallery/%",
+	"%.jpg",
+	"%.jpeg",
+	"%.png",
+	"%.gif",
+	"%.gifv",
+	"%.webp",
+	"%.mp4",
+}
+
+// MediaInfo describes the media a post embeds, as reported by Reddit.
+// Width and Height are 0 when Reddit doesn't report them.
+type MediaInfo struct {
+	Type   string // "video" for Reddit-hosted video, else the oEmbed type ("video", "rich", "photo")
+	Width  int
+	Height int
+}
+
+// ParseMediaInfo extracts media metadata from a post's media and media_embed
+// fields without fetching anything. It returns nil for posts with no media
+// or media it doesn't recognize.
+func ParseMediaInfo(post *types.Post) *MediaInfo {
+	var media struct {
+		RedditVideo *struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"reddit_video"`
+		OEmbed *struct {
+			Type   string `json:"type"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"oembed"`
+	}
+
+	// Reddit sends null for posts without media; malformed JSON is treated
+	// the same way
+	if len(post.Media) > 0 && json.Unmarshal(post.Media, &media) == nil {
+		if v := media.RedditVideo; v != nil {
+			return &MediaInfo{Type: "video", Width: v.Width, Height: v.Height}
+		}
+		if o := media.OEmbed; o != nil && o.Type != "" {
+			return &MediaInfo{Type: o.Type, Width: o.Width, Height: o.Height}
+		}
+	}
+
+	var embed struct {
+		Content string `json:"content"`
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+	}
+	if len(post.MediaEmbed) > 0 && json.Unmarshal(post.MediaEmbed, &embed) == nil && embed.Content != "" {
+		return &MediaInfo{Type: "embed", Width: embed.Width, Height: embed.Height}
+	}
+
+	return nil
+}
+
+// Post media types recorded in PostMedia.Type
+const (
+	PostMediaImage   = "image"   // The post links directly to an image
+	PostMediaGallery = "gallery" // One image of a gallery post
+	PostMediaVideo   = "video"   // Reddit-hosted video
+	PostMediaEmbed   = "embed"   // Media embedded from another site; URL is the post's link
+	PostMediaPreview = "preview" // Reddit's preview image for a link post without other media
+)
+
+// PostMedia is one image, video or embed attached to a post. Position
+// orders a post's media from 0, following the gallery's order for gallery
+// posts. Width and Height are 0 when Reddit doesn't report them.
+type PostMedia struct {
+	PostID   string
+	Type     string
+	URL      string
+	Width    int
+	Height   int
+	Position int
+}
+
+// imageExtensions are the link extensions ParsePostMedia treats as images
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+}
+
+// ParsePostMedia lists the media a post links to or embeds, without
+// fetching anything: its Reddit-hosted video, its oEmbed or media_embed
+// embed, or the image it links to directly. types.Post doesn't carry
+// Reddit's media_metadata or preview fields, so gallery and preview images
+// are only found by StoredPostFromJSON. It returns nil for posts with no
+// media it recognizes.
+func ParsePostMedia(post *types.Post) []*PostMedia {
+	var media struct {
+		RedditVideo *struct {
+			FallbackURL string `json:"fallback_url"`
+			Width       int    `json:"width"`
+			Height      int    `json:"height"`
+		} `json:"reddit_video"`
+		OEmbed *struct {
+			Type   string `json:"type"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"oembed"`
+	}
+
+	var items []*PostMedia
+	if len(post.Media) > 0 && json.Unmarshal(post.Media, &media) == nil {
+		if v := media.RedditVideo; v != nil && v.FallbackURL != "" {
+			items = append(items, &PostMedia{Type: PostMediaVideo, URL: html.UnescapeString(v.FallbackURL), Width: v.Width, Height: v.Height})
+		} else if o := media.OEmbed; o != nil && o.Type != "" && post.URL != "" {
+			items = append(items, &PostMedia{Type: PostMediaEmbed, URL: post.URL, Width: o.Width, Height: o.Height})
+		}
+	}
+
+	if items == nil {
+		if info := ParseMediaInfo(post); info != nil && info.Type == "embed" && post.URL != "" {
+			items = append(items, &PostMedia{Type: PostMediaEmbed, URL: post.URL, Width: info.Width, Height: info.Height})
+		} else if isImageURL(post.URL) {
+			items = append(items, &PostMedia{Type: PostMediaImage, URL: post.URL})
+		}
+	}
+
+	return numberPostMedia(post.ID, items)
+}
+
+// parseJSONPostMedia is ParsePostMedia for a raw Reddit post object, adding
+// the gallery and preview images types.Post drops. A gallery's images
+// replace the media ParsePostMedia finds; a preview image is only listed
+// when there is nothing else.
+func parseJSONPostMedia(data []byte, post *types.Post) ([]*PostMedia, error) {
+	type mediaSource struct {
+		URL    string `json:"u"`
+		GIF    string `json:"gif"`
+		Width  int    `json:"x"`
+		Height int    `json:"y"`
+	}
+	var fields struct {
+		GalleryData *struct {
+			Items []struct {
+				MediaID string `json:"media_id"`
+			} `json:"items"`
+		} `json:"gallery_data"`
+		MediaMetadata map[string]struct {
+			Status string       `json:"status"`
+			Source *mediaSource `json:"s"`
+		} `json:"media_metadata"`
+		Preview *struct {
+			Images []struct {
+				Source struct {
+					URL    string `json:"url"`
+					Width  int    `json:"width"`
+					Height int    `json:"height"`
+				} `json:"source"`
+			} `json:"images"`
+		} `json:"preview"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	var gallery []*PostMedia
+	if fields.GalleryData != nil {
+		for _, item := range fields.GalleryData.Items {
+			meta, ok := fields.MediaMetadata[item.MediaID]
+			if !ok || meta.Source == nil || (meta.Status != "" && meta.Status != "valid") {
+				continue
+			}
+			src := meta.Source.URL
+			if src == "" {
+				src = meta.Source.GIF
+			}
+			if src == "" {
+				continue
+			}
+			gallery = append(gallery, &PostMedia{Type: PostMediaGallery, URL: html.UnescapeString(src), Width: meta.Source.Width, Height: meta.Source.Height})
+		}
+	}
+	if len(gallery) > 0 {
+		return numberPostMedia(post.ID, gallery), nil
+	}
+
+	if items := ParsePostMedia(post); items != nil {
+		return items, nil
+	}
+
+	if fields.Preview != nil && len(fields.Preview.Images) > 0 {
+		src := fields.Preview.Images[0].Source
+		if src.URL != "" {
+			preview := &PostMedia{Type: PostMediaPreview, URL: html.UnescapeString(src.URL), Width: src.Width, Height: src.Height}
+			return numberPostMedia(post.ID, []*PostMedia{preview}), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ResolvePostMedia returns the media to store for post: post.MediaItems, or
+// what ParsePostMedia finds in post.Post when it is nil, numbered in order.
+// Backends call it when saving; the caller's items are copied, never
+// modified.
+func ResolvePostMedia(post *StoredPost) []*PostMedia {
+	if post.MediaItems == nil {
+		return ParsePostMedia(post.Post)
+	}
+
+	items := make([]*PostMedia, 0, len(post.MediaItems))
+	for _, item := range post.MediaItems {
+		copied := *item
+		items = append(items, &copied)
+	}
+	return numberPostMedia(post.ID, items)
+}
+
+// numberPostMedia sets each item's PostID and Position in order
+func numberPostMedia(postID string, items []*PostMedia) []*PostMedia {
+	for i, item := range items {
+		item.PostID = postID
+		item.Position = i
+	}
+	return items
+}
+
+// isImageURL reports whether link points directly at an image, by host or
+// by extension
+func isImageURL(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, "i.redd.it") {
+		return !strings.HasSuffix(strings.ToLower(u.Path), ".mp4")
+	}
+	return imageExtensions[strings.ToLower(path.Ext(u.Path))]
+}
@@ -0,0 +1,113 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+func TestParseMediaInfo(t *testing.T) {
+	tests := []struct {
+		media, embed string
+		want         *storage.MediaInfo
+	}{
+		{"", "", nil},
+		{"null", "{}", nil},
+		{`{"reddit_video": {"width": 1920, "height": 1080}}`, "", &storage.MediaInfo{Type: "video", Width: 1920, Height: 1080}},
+		{`{"type": "youtube.com", "oembed": {"type": "video", "width": 356, "height": 200}}`, "", &storage.MediaInfo{Type: "video", Width: 356, Height: 200}},
+		{"null", `{"content": "<iframe>", "width": 600, "height": 400}`, &storage.MediaInfo{Type: "embed", Width: 600, Height: 400}},
+		{"{not json", "", nil},
+	}
+
+	for _, tt := range tests {
+		post := &types.Post{Media: json.RawMessage(tt.media), MediaEmbed: json.RawMessage(tt.embed)}
+		if got := storage.ParseMediaInfo(post); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMediaInfo(%q, %q) = %+v, want %+v", tt.media, tt.embed, got, tt.want)
+		}
+	}
+}
+
+func TestParsePostMedia(t *testing.T) {
+	tests := []struct {
+		name string
+		post *types.Post
+		want []*storage.PostMedia
+	}{
+		{"self post", &types.Post{ThingData: types.ThingData{ID: "a"}, IsSelf: true}, nil},
+		{"article link", &types.Post{ThingData: types.ThingData{ID: "a"}, URL: "https://example.com/story.html"}, nil},
+		{
+			"direct image",
+			&types.Post{ThingData: types.ThingData{ID: "a"}, URL: "https://i.redd.it/abc.png"},
+			[]*storage.PostMedia{{PostID: "a", Type: storage.PostMediaImage, URL: "https://i.redd.it/abc.png"}},
+		},
+		{
+			"reddit video",
+			&types.Post{
+				ThingData: types.ThingData{ID: "a"},
+				URL:       "https://v.redd.it/xyz",
+				Media:     json.RawMessage(`{"reddit_video": {"fallback_url": "https://v.redd.it/xyz/DASH_720.mp4?source=fallback&amp;x=1", "width": 1280, "height": 720}}`),
+			},
+			[]*storage.PostMedia{{PostID: "a", Type: storage.PostMediaVideo, URL: "https://v.redd.it/xyz/DASH_720.mp4?source=fallback&x=1", Width: 1280, Height: 720}},
+		},
+		{
+			"oembed",
+			&types.Post{
+				ThingData: types.ThingData{ID: "a"},
+				URL:       "https://youtu.be/abc",
+				Media:     json.RawMessage(`{"type": "youtube.com", "oembed": {"type": "video", "width": 356, "height": 200}}`),
+			},
+			[]*storage.PostMedia{{PostID: "a", Type: storage.PostMediaEmbed, URL: "https://youtu.be/abc", Width: 356, Height: 200}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storage.ParsePostMedia(tt.post); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePostMedia() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoredPostFromJSONMedia(t *testing.T) {
+	gallery := `{
+		"id": "gal1",
+		"url": "https://www.reddit.com/gallery/gal1",
+		"gallery_data": {"items": [{"media_id": "second"}, {"media_id": "first"}, {"media_id": "broken"}]},
+		"media_metadata": {
+			"first": {"status": "valid", "s": {"u": "https://preview.redd.it/first.jpg?width=800&amp;s=abc", "x": 800, "y": 600}},
+			"second": {"status": "valid", "s": {"gif": "https://i.redd.it/second.gif", "x": 400, "y": 300}},
+			"broken": {"status": "failed"}
+		}
+	}`
+	stored, err := storage.StoredPostFromJSON([]byte(gallery))
+	if err != nil {
+		t.Fatalf("StoredPostFromJSON failed: %v", err)
+	}
+	want := []*storage.PostMedia{
+		{PostID: "gal1", Type: storage.PostMediaGallery, URL: "https://i.redd.it/second.gif", Width: 400, Height: 300, Position: 0},
+		{PostID: "gal1", Type: storage.PostMediaGallery, URL: "https://preview.redd.it/first.jpg?width=800&s=abc", Width: 800, Height: 600, Position: 1},
+	}
+	if !reflect.DeepEqual(stored.MediaItems, want) {
+		t.Errorf("Expected gallery media %+v, got %+v", want, stored.MediaItems)
+	}
+
+	link := `{
+		"id": "link1",
+		"url": "https://example.com/story.html",
+		"preview": {"images": [{"source": {"url": "https://external-preview.redd.it/story.jpg?a=1&amp;b=2", "width": 1200, "height": 630}}]}
+	}`
+	stored, err = storage.StoredPostFromJSON([]byte(link))
+	if err != nil {
+		t.Fatalf("StoredPostFromJSON failed: %v", err)
+	}
+	want = []*storage.PostMedia{
+		{PostID: "link1", Type: storage.PostMediaPreview, URL: "https://external-preview.redd.it/story.jpg?a=1&b=2", Width: 1200, Height: 630},
+	}
+	if !reflect.DeepEqual(stored.MediaItems, want) {
+		t.Errorf("Expected preview media %+v, got %+v", want, stored.MediaItems)
+	}
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// ArchiverMetrics is a snapshot of an Archiver's running totals since it was
+// created, for feeding dashboards such as posts archived per hour or the API
+// error rate
+type ArchiverMetrics struct {
+	PostsSaved    int64 // Posts written to storage, counting re-saves
+	CommentsSaved int64 // Comments written to storage, counting re-saves
+	APICalls      int64 // Reddit API requests, counting each retry
+	APIErrors     int64 // Reddit API requests that failed, including ones later retried
+	Retries       int64 // Requests retried after a transient error
+
+	// LastSuccess is when posts or comments were last saved; zero if none
+	// have been
+	LastSuccess time.Time
+}
+
+// archiverMetrics holds the counters behind Archiver.Metrics. Every field is
+// updated atomically, so concurrent operations on one Archiver share it.
+type archiverMetrics struct {
+	postsSaved    atomic.Int64
+	commentsSaved atomic.Int64
+	apiCalls      atomic.Int64
+	apiErrors     atomic.Int64
+	retries       atomic.Int64
+	lastSuccess   atomic.Int64 // Unix nanoseconds; 0 before the first save
+}
+
+// saved counts posts and comments just written to storage
+func (m *archiverMetrics) saved(posts, comments int) {
+	if posts == 0 && comments == 0 {
+		return
+	}
+	m.postsSaved.Add(int64(posts))
+	m.commentsSaved.Add(int64(comments))
+	m.lastSuccess.Store(time.Now().UnixNano())
+}
+
+// Metrics returns a snapshot of the archiver's counters. It is safe to call
+// while operations are running.
+func (a *Archiver) Metrics() ArchiverMetrics {
+	m := ArchiverMetrics{
+		PostsSaved:    a.metrics.postsSaved.Load(),
+		CommentsSaved: a.metrics.commentsSaved.Load(),
+		APICalls:      a.metrics.apiCalls.Load(),
+		APIErrors:     a.metrics.apiErrors.Load(),
+		Retries:       a.metrics.retries.Load(),
+	}
+	if last := a.metrics.lastSuccess.Load(); last != 0 {
+		m.LastSuccess = time.Unix(0, last)
+	}
+	return m
+}
+
+// PublishExpvar publishes the archiver's Metrics as an expvar variable under
+// name, so they appear at /debug/vars as JSON. Like expvar.Publish it panics
+// if name is already in use.
+func (a *Archiver) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return a.Metrics() }))
+}
+
+// meteredClient counts the wrapped client's requests and failures in metrics.
+// It sits beneath the retry wrapper, so every attempt is counted.
+type meteredClient struct {
+	client  RedditClient
+	metrics *archiverMetrics
+}
+
+// observe counts one request that returned err
+func (c *meteredClient) observe(err error) {
+	c.metrics.apiCalls.Add(1)
+	if err != nil {
+		c.metrics.apiErrors.Add(1)
+	}
+}
+
+func (c *meteredClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	sub, err := c.client.GetSubreddit(ctx, name)
+	c.observe(err)
+	return sub, err
+}
+
+func (c *meteredClient) GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	resp, err := c.client.GetHot(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	resp, err := c.client.GetNew(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	resp, err := c.client.GetComments(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetUserPosts(ctx context.Context, req *UserRequest) (*types.PostsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := uc.GetUserPosts(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetUserComments(ctx context.Context, req *UserRequest) (*UserCommentsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := uc.GetUserComments(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetSaved(ctx context.Context, req *types.Pagination) (*SavedResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sc.GetSaved(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sc.GetUpvoted(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetTop(ctx context.Context, req *types.PostsRequest, timeRange TimeRange) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := lc.GetTop(ctx, req, timeRange)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := lc.GetRising(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error) {
+	mc, err := moreCommentsClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := mc.GetMoreComments(ctx, req)
+	c.observe(err)
+	return comments, err
+}
+
+func (c *meteredClient) Search(ctx context.Context, req *SearchRequest) (*types.PostsResponse, error) {
+	sc, err := searchClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sc.Search(ctx, req)
+	c.observe(err)
+	return resp, err
+}
+
+func (c *meteredClient) GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error) {
+	ic, err := infoClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := ic.GetInfo(ctx, fullnames)
+	c.observe(err)
+	return posts, err
+}
+
+func (c *meteredClient) GetSubredditRules(ctx context.Context, subreddit string) ([]SubredditRule, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := mc.GetSubredditRules(ctx, subreddit)
+	c.observe(err)
+	return rules, err
+}
+
+func (c *meteredClient) GetWikiPages(ctx context.Context, subreddit string) ([]string, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := mc.GetWikiPages(ctx, subreddit)
+	c.observe(err)
+	return pages, err
+}
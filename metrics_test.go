@@ -0,0 +1,78 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+func TestArchiverMetrics(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if m := archiver.Metrics(); m != (storage.ArchiverMetrics{}) {
+		t.Errorf("Expected zero metrics for a new archiver, got %+v", m)
+	}
+
+	mockClient.commentsMap["post1"] = &types.CommentsResponse{
+		Post: mockClient.posts[0],
+		Comments: []*types.Comment{
+			testutil.NewTestComment("m1", "post1", "alice", "First"),
+			testutil.NewTestComment("m2", "post1", "bob", "Second"),
+		},
+	}
+
+	before := time.Now()
+	if _, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Limit: 25, IncludeComments: true}); err != nil {
+		t.Fatalf("ArchiveSubreddit failed: %v", err)
+	}
+
+	m := archiver.Metrics()
+	if m.PostsSaved != 4 || m.CommentsSaved != 2 {
+		t.Errorf("Expected 4 post saves (listing and threads) and 2 comments, got %+v", m)
+	}
+	if m.APICalls != int64(mockClient.calls) || m.APIErrors != 0 || m.Retries != 0 {
+		t.Errorf("Expected %d API calls without errors, got %+v", mockClient.calls, m)
+	}
+	if m.LastSuccess.Before(before) {
+		t.Errorf("Expected LastSuccess after %v, got %v", before, m.LastSuccess)
+	}
+}
+
+func TestArchiverMetricsRetries(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	mockClient.commentsError = errors.New("unexpected status 503")
+
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		Retry: &storage.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	if _, err := archiver.ArchivePost(ctx, "golang", "post1", true); err == nil {
+		t.Fatal("Expected ArchivePost to fail")
+	}
+
+	m := archiver.Metrics()
+	if m.APICalls != 3 || m.APIErrors != 3 || m.Retries != 2 {
+		t.Errorf("Expected 3 failed calls and 2 retries, got %+v", m)
+	}
+	if m.PostsSaved != 0 || !m.LastSuccess.IsZero() {
+		t.Errorf("Expected nothing saved, got %+v", m)
+	}
+
+	archiver.PublishExpvar("test_archiver_metrics")
+	published := expvar.Get("test_archiver_metrics")
+	if published == nil || !strings.Contains(published.String(), `"Retries":2`) {
+		t.Errorf("Expected the metrics to be published, got %v", published)
+	}
+}
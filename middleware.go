@@ -0,0 +1,477 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// StorageMiddleware wraps a Storage with cross-cutting behavior such as
+// logging, metrics or caching. A middleware returns a Storage that forwards
+// every call to the one it wraps, doing its own work around the call.
+type StorageMiddleware func(Storage) Storage
+
+// Chain wraps s in middlewares. The first middleware is outermost, so it
+// sees each call first and its result last.
+func Chain(s Storage, middlewares ...StorageMiddleware) Storage {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		s = middlewares[i](s)
+	}
+	return s
+}
+
+// LoggingStorage is a decorator that logs every Storage call with its
+// latency and any error
+type LoggingStorage struct {
+	next   Storage
+	logger *log.Logger
+}
+
+// NewLoggingStorage wraps next so each call is logged to logger, or to the
+// standard logger if logger is nil
+func NewLoggingStorage(next Storage, logger *log.Logger) *LoggingStorage {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LoggingStorage{next: next, logger: logger}
+}
+
+// Logging returns a middleware that wraps storage in a LoggingStorage
+func Logging(logger *log.Logger) StorageMiddleware {
+	return func(next Storage) Storage {
+		return NewLoggingStorage(next, logger)
+	}
+}
+
+// logCall logs a finished call
+func (l *LoggingStorage) logCall(method string, began time.Time, err error) {
+	if err != nil {
+		l.logger.Printf("storage: %s failed after %s: %v", method, time.Since(began), err)
+		return
+	}
+	l.logger.Printf("storage: %s took %s", method, time.Since(began))
+}
+
+// Flush flushes the wrapped storage if it buffers writes (see Flusher)
+func (l *LoggingStorage) Flush(ctx context.Context) error {
+	flusher, ok := l.next.(Flusher)
+	if !ok {
+		return nil
+	}
+
+	began := time.Now()
+	err := flusher.Flush(ctx)
+	l.logCall("Flush", began, err)
+	return err
+}
+
+// The Storage methods forward to the wrapped storage and log the call
+
+func (l *LoggingStorage) SavePost(ctx context.Context, post *types.Post) error {
+	began := time.Now()
+	err := l.next.SavePost(ctx, post)
+	l.logCall("SavePost", began, err)
+	return err
+}
+
+func (l *LoggingStorage) SavePosts(ctx context.Context, posts []*types.Post) error {
+	began := time.Now()
+	err := l.next.SavePosts(ctx, posts)
+	l.logCall("SavePosts", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetPost(ctx context.Context, id string) (*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.GetPost(ctx, id)
+	l.logCall("GetPost", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetPostsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.GetPostsBySubreddit(ctx, subreddit, opts)
+	l.logCall("GetPostsBySubreddit", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetLatestPost(ctx context.Context, subreddit string) (*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.GetLatestPost(ctx, subreddit)
+	l.logCall("GetLatestPost", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) FindPosts(ctx context.Context, filter PostFilter, opts QueryOptions) ([]*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.FindPosts(ctx, filter, opts)
+	l.logCall("FindPosts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveStoredPosts(ctx context.Context, posts []*StoredPost) error {
+	began := time.Now()
+	err := l.next.SaveStoredPosts(ctx, posts)
+	l.logCall("SaveStoredPosts", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetStoredPost(ctx context.Context, id string) (*StoredPost, error) {
+	began := time.Now()
+	result, err := l.next.GetStoredPost(ctx, id)
+	l.logCall("GetStoredPost", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetStoredPostsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*StoredPost, error) {
+	began := time.Now()
+	result, err := l.next.GetStoredPostsBySubreddit(ctx, subreddit, opts)
+	l.logCall("GetStoredPostsBySubreddit", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetPostsUpdatedSince(ctx context.Context, since time.Time, opts QueryOptions) ([]*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.GetPostsUpdatedSince(ctx, since, opts)
+	l.logCall("GetPostsUpdatedSince", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) DeletePosts(ctx context.Context, ids []string) (int, error) {
+	began := time.Now()
+	result, err := l.next.DeletePosts(ctx, ids)
+	l.logCall("DeletePosts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) DeletePost(ctx context.Context, id string) error {
+	began := time.Now()
+	err := l.next.DeletePost(ctx, id)
+	l.logCall("DeletePost", began, err)
+	return err
+}
+
+func (l *LoggingStorage) PrunePosts(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
+	began := time.Now()
+	result, err := l.next.PrunePosts(ctx, opts)
+	l.logCall("PrunePosts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCrossposts(ctx context.Context, postID string) ([]*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.GetCrossposts(ctx, postID)
+	l.logCall("GetCrossposts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetPostCounts(ctx context.Context, subreddit string, opts QueryOptions) ([]*PostCounts, error) {
+	began := time.Now()
+	result, err := l.next.GetPostCounts(ctx, subreddit, opts)
+	l.logCall("GetPostCounts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetPostMedia(ctx context.Context, postID string) ([]*PostMedia, error) {
+	began := time.Now()
+	result, err := l.next.GetPostMedia(ctx, postID)
+	l.logCall("GetPostMedia", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetStoredNumComments(ctx context.Context, ids []string) (map[string]int, error) {
+	began := time.Now()
+	result, err := l.next.GetStoredNumComments(ctx, ids)
+	l.logCall("GetStoredNumComments", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentsFetched(ctx context.Context, ids []string) (map[string]int, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentsFetched(ctx, ids)
+	l.logCall("GetCommentsFetched", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) MarkCommentsFetched(ctx context.Context, postID string, numComments int) error {
+	began := time.Now()
+	err := l.next.MarkCommentsFetched(ctx, postID, numComments)
+	l.logCall("MarkCommentsFetched", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetPostRevisions(ctx context.Context, id string) ([]*Revision, error) {
+	began := time.Now()
+	result, err := l.next.GetPostRevisions(ctx, id)
+	l.logCall("GetPostRevisions", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveComment(ctx context.Context, comment *types.Comment) error {
+	began := time.Now()
+	err := l.next.SaveComment(ctx, comment)
+	l.logCall("SaveComment", began, err)
+	return err
+}
+
+func (l *LoggingStorage) SaveComments(ctx context.Context, comments []*types.Comment) error {
+	began := time.Now()
+	err := l.next.SaveComments(ctx, comments)
+	l.logCall("SaveComments", began, err)
+	return err
+}
+
+func (l *LoggingStorage) SaveCommentsWithOptions(ctx context.Context, comments []*types.Comment, opts SaveCommentsOptions) (int, error) {
+	began := time.Now()
+	result, err := l.next.SaveCommentsWithOptions(ctx, comments, opts)
+	l.logCall("SaveCommentsWithOptions", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentsByPost(ctx context.Context, postID string) ([]*types.Comment, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentsByPost(ctx, postID)
+	l.logCall("GetCommentsByPost", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentTreeNested(ctx context.Context, postID string) ([]*CommentNode, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentTreeNested(ctx, postID)
+	l.logCall("GetCommentTreeNested", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetTopComments(ctx context.Context, postID string, n int) ([]*StoredComment, error) {
+	began := time.Now()
+	result, err := l.next.GetTopComments(ctx, postID, n)
+	l.logCall("GetTopComments", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetTopLevelComments(ctx context.Context, postID string, opts QueryOptions) ([]*ThreadComment, error) {
+	began := time.Now()
+	result, err := l.next.GetTopLevelComments(ctx, postID, opts)
+	l.logCall("GetTopLevelComments", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetReplies(ctx context.Context, commentID string, opts QueryOptions) ([]*ThreadComment, error) {
+	began := time.Now()
+	result, err := l.next.GetReplies(ctx, commentID, opts)
+	l.logCall("GetReplies", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) ExportPostMarkdown(ctx context.Context, postID string, w io.Writer) error {
+	began := time.Now()
+	err := l.next.ExportPostMarkdown(ctx, postID, w)
+	l.logCall("ExportPostMarkdown", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetCommentsByAuthorWithContext(ctx context.Context, author string, opts QueryOptions) ([]*CommentWithPost, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentsByAuthorWithContext(ctx, author, opts)
+	l.logCall("GetCommentsByAuthorWithContext", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentRevisions(ctx context.Context, id string) ([]*Revision, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentRevisions(ctx, id)
+	l.logCall("GetCommentRevisions", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) DeleteComments(ctx context.Context, postID string) (int, error) {
+	began := time.Now()
+	result, err := l.next.DeleteComments(ctx, postID)
+	l.logCall("DeleteComments", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*types.Comment, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentsBySubreddit(ctx, subreddit, opts)
+	l.logCall("GetCommentsBySubreddit", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditData) error {
+	began := time.Now()
+	err := l.next.SaveSubreddit(ctx, sub)
+	l.logCall("SaveSubreddit", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	began := time.Now()
+	result, err := l.next.GetSubreddit(ctx, name)
+	l.logCall("GetSubreddit", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) ListSubreddits(ctx context.Context, opts QueryOptions) ([]*types.SubredditData, error) {
+	began := time.Now()
+	result, err := l.next.ListSubreddits(ctx, opts)
+	l.logCall("ListSubreddits", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) ListSubredditSummaries(ctx context.Context) ([]*SubredditSummary, error) {
+	began := time.Now()
+	result, err := l.next.ListSubredditSummaries(ctx)
+	l.logCall("ListSubredditSummaries", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveSubredditMetadata(ctx context.Context, metadata *SubredditMetadata) error {
+	began := time.Now()
+	err := l.next.SaveSubredditMetadata(ctx, metadata)
+	l.logCall("SaveSubredditMetadata", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetSubredditMetadata(ctx context.Context, subreddit string) (*SubredditMetadata, error) {
+	began := time.Now()
+	result, err := l.next.GetSubredditMetadata(ctx, subreddit)
+	l.logCall("GetSubredditMetadata", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SearchPosts(ctx context.Context, query string, opts QueryOptions) ([]*types.Post, error) {
+	began := time.Now()
+	result, err := l.next.SearchPosts(ctx, query, opts)
+	l.logCall("SearchPosts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SearchComments(ctx context.Context, query string, opts QueryOptions) ([]*types.Comment, error) {
+	began := time.Now()
+	result, err := l.next.SearchComments(ctx, query, opts)
+	l.logCall("SearchComments", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetPostStats(ctx context.Context, postID string) (*PostStats, error) {
+	began := time.Now()
+	result, err := l.next.GetPostStats(ctx, postID)
+	l.logCall("GetPostStats", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetCommentDepthHistogram(ctx context.Context, postID string) (map[int]int, error) {
+	began := time.Now()
+	result, err := l.next.GetCommentDepthHistogram(ctx, postID)
+	l.logCall("GetCommentDepthHistogram", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetArchivedCommentCounts(ctx context.Context, postIDs []string) (map[string]int, error) {
+	began := time.Now()
+	result, err := l.next.GetArchivedCommentCounts(ctx, postIDs)
+	l.logCall("GetArchivedCommentCounts", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) GetSubredditStatsRange(ctx context.Context, subreddit string, start, end time.Time) (*SubredditStats, error) {
+	began := time.Now()
+	result, err := l.next.GetSubredditStatsRange(ctx, subreddit, start, end)
+	l.logCall("GetSubredditStatsRange", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) RecordArchiveRun(ctx context.Context, run ArchiveRun) error {
+	began := time.Now()
+	err := l.next.RecordArchiveRun(ctx, run)
+	l.logCall("RecordArchiveRun", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetArchiveRuns(ctx context.Context, subreddit string, limit int) ([]*ArchiveRun, error) {
+	began := time.Now()
+	result, err := l.next.GetArchiveRuns(ctx, subreddit, limit)
+	l.logCall("GetArchiveRuns", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveScoreSnapshots(ctx context.Context, snapshots []*ScoreSnapshot) error {
+	began := time.Now()
+	err := l.next.SaveScoreSnapshots(ctx, snapshots)
+	l.logCall("SaveScoreSnapshots", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetScoreHistory(ctx context.Context, postID string) ([]*ScoreSnapshot, error) {
+	began := time.Now()
+	result, err := l.next.GetScoreHistory(ctx, postID)
+	l.logCall("GetScoreHistory", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveSavedItems(ctx context.Context, items []*SavedItem) error {
+	began := time.Now()
+	err := l.next.SaveSavedItems(ctx, items)
+	l.logCall("SaveSavedItems", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetSavedItems(ctx context.Context, source string, opts QueryOptions) ([]*SavedItem, error) {
+	began := time.Now()
+	result, err := l.next.GetSavedItems(ctx, source, opts)
+	l.logCall("GetSavedItems", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) SaveBackfillCheckpoint(ctx context.Context, checkpoint BackfillCheckpoint) error {
+	began := time.Now()
+	err := l.next.SaveBackfillCheckpoint(ctx, checkpoint)
+	l.logCall("SaveBackfillCheckpoint", began, err)
+	return err
+}
+
+func (l *LoggingStorage) GetBackfillCheckpoint(ctx context.Context, subreddit string) (*BackfillCheckpoint, error) {
+	began := time.Now()
+	result, err := l.next.GetBackfillCheckpoint(ctx, subreddit)
+	l.logCall("GetBackfillCheckpoint", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) DeleteBackfillCheckpoint(ctx context.Context, subreddit string) error {
+	began := time.Now()
+	err := l.next.DeleteBackfillCheckpoint(ctx, subreddit)
+	l.logCall("DeleteBackfillCheckpoint", began, err)
+	return err
+}
+
+func (l *LoggingStorage) ReadOutbox(ctx context.Context, afterID int64, limit int) ([]*OutboxEvent, error) {
+	began := time.Now()
+	result, err := l.next.ReadOutbox(ctx, afterID, limit)
+	l.logCall("ReadOutbox", began, err)
+	return result, err
+}
+
+func (l *LoggingStorage) AckOutbox(ctx context.Context, upToID int64) error {
+	began := time.Now()
+	err := l.next.AckOutbox(ctx, upToID)
+	l.logCall("AckOutbox", began, err)
+	return err
+}
+
+func (l *LoggingStorage) RunMigrations(ctx context.Context) error {
+	began := time.Now()
+	err := l.next.RunMigrations(ctx)
+	l.logCall("RunMigrations", began, err)
+	return err
+}
+
+func (l *LoggingStorage) Close() error {
+	began := time.Now()
+	err := l.next.Close()
+	l.logCall("Close", began, err)
+	return err
+}
@@ -0,0 +1,82 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+	"github.com/jamesprial/go-reddit-storage/sqlite"
+)
+
+func TestLoggingStorage(t *testing.T) {
+	base, err := sqlite.New(t.TempDir() + "/logging.db")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer base.Close()
+
+	var buf bytes.Buffer
+	store := storage.Chain(base, storage.Logging(log.New(&buf, "", 0)))
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+
+	post := testutil.NewTestPost("logged", "golang", "Logged post")
+	if err := store.SavePosts(ctx, []*types.Post{post}); err != nil {
+		t.Fatalf("SavePosts failed: %v", err)
+	}
+
+	if _, err := store.GetPost(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound through the decorator, got %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"storage: RunMigrations took", "storage: SavePosts took", "storage: GetPost failed after"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// recordingStorage notes the order in which wrapping middlewares see calls
+type recordingStorage struct {
+	storage.Storage
+	name  string
+	calls *[]string
+}
+
+func (r *recordingStorage) Close() error {
+	*r.calls = append(*r.calls, r.name)
+	return r.Storage.Close()
+}
+
+func TestChainOrder(t *testing.T) {
+	base, err := sqlite.New(t.TempDir() + "/chain.db")
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	var calls []string
+	record := func(name string) storage.StorageMiddleware {
+		return func(next storage.Storage) storage.Storage {
+			return &recordingStorage{Storage: next, name: name, calls: &calls}
+		}
+	}
+
+	store := storage.Chain(base, record("outer"), record("inner"))
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if strings.Join(calls, ",") != "outer,inner" {
+		t.Errorf("Expected outer,inner, got %v", calls)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// pauseGate holds back an archiver's API calls while it is paused. The zero
+// value is running.
+type pauseGate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // Closed by Resume; nil while running
+}
+
+// Pause stops the archiver from issuing API calls at the next safe point:
+// before a continuous pass, a Backfill or ArchiveNew page, a post's comment
+// fetch or a batch of UpdateScores and UpdateActivePosts lookups. The paused
+// run waits, keeping its in-memory state such as backfill cursors, until
+// Resume is called or its context is cancelled; calls already in flight
+// finish normally. Pausing a paused archiver does nothing. Pause and Resume
+// may be called from any goroutine.
+func (a *Archiver) Pause() {
+	a.pause.mu.Lock()
+	defer a.pause.mu.Unlock()
+
+	if a.pause.resumed == nil {
+		a.pause.resumed = make(chan struct{})
+		a.logger.Info("archiver paused")
+	}
+}
+
+// Resume lets a paused archiver carry on where it stopped. Resuming an
+// archiver that isn't paused does nothing.
+func (a *Archiver) Resume() {
+	a.pause.mu.Lock()
+	defer a.pause.mu.Unlock()
+
+	if a.pause.resumed != nil {
+		close(a.pause.resumed)
+		a.pause.resumed = nil
+		a.logger.Info("archiver resumed")
+	}
+}
+
+// Paused reports whether the archiver is paused
+func (a *Archiver) Paused() bool {
+	a.pause.mu.Lock()
+	defer a.pause.mu.Unlock()
+	return a.pause.resumed != nil
+}
+
+// waitIfPaused blocks while the archiver is paused, returning ctx's error if
+// it is cancelled first
+func (a *Archiver) waitIfPaused(ctx context.Context) error {
+	a.pause.mu.Lock()
+	resumed := a.pause.resumed
+	a.pause.mu.Unlock()
+
+	if resumed == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-resumed:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
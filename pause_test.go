@@ -0,0 +1,144 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+func TestArchiverPauseResume(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	archiver.Pause()
+	if !archiver.Paused() {
+		t.Fatal("Expected the archiver to report it is paused")
+	}
+
+	done := make(chan error, 1)
+	var result *storage.ArchiveResult
+	go func() {
+		var err error
+		result, err = archiver.BackfillSubreddit(ctx, "golang", 100, true)
+		done <- err
+	}()
+
+	// Nothing is fetched while paused
+	time.Sleep(30 * time.Millisecond)
+	mockClient.mu.Lock()
+	calls := mockClient.calls
+	mockClient.mu.Unlock()
+	if calls != 0 {
+		t.Fatalf("Expected no API calls while paused, got %d", calls)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the backfill to wait while paused, it returned %v", err)
+	default:
+	}
+
+	archiver.Resume()
+	if archiver.Paused() {
+		t.Error("Expected the archiver to report it is running")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("BackfillSubreddit failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the backfill to finish after Resume")
+	}
+	if result.PostsSaved != len(mockClient.posts) {
+		t.Errorf("Expected %d posts saved, got %d", len(mockClient.posts), result.PostsSaved)
+	}
+}
+
+func TestArchiverPausedContinuousCancel(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	archiver.Pause()
+	err := archiver.ContinuousArchive(ctx, "golang", time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the paused archive to end with its context, got %v", err)
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls while paused, got %d", mockClient.calls)
+	}
+}
+
+func TestArchiverPauseConcurrent(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if i%2 == 0 {
+					archiver.Pause()
+				} else {
+					archiver.Resume()
+				}
+				archiver.Paused()
+			}
+		}()
+	}
+	wg.Wait()
+
+	archiver.Resume()
+	if archiver.Paused() {
+		t.Error("Expected Resume to leave the archiver running")
+	}
+}
+
+func TestArchiverPausedOneShotCancel(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	if err := store.SavePosts(context.Background(), mockClient.posts); err != nil {
+		t.Fatalf("SavePosts failed: %v", err)
+	}
+
+	archiver.Pause()
+	runs := map[string]func(ctx context.Context) error{
+		"ArchiveNew": func(ctx context.Context) error {
+			_, err := archiver.ArchiveNew(ctx, "golang", storage.ArchiveOptions{IncludeComments: true})
+			return err
+		},
+		"UpdateScores": func(ctx context.Context) error {
+			return archiver.UpdateScores(ctx, "golang", 24*time.Hour)
+		},
+		"UpdateComments": func(ctx context.Context) error {
+			_, err := archiver.UpdateComments(ctx, "golang", 24*time.Hour)
+			return err
+		},
+		"RefreshComments": func(ctx context.Context) error {
+			return archiver.RefreshComments(ctx, "golang", storage.ArchiveOptions{})
+		},
+	}
+	for name, run := range runs {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := run(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected the paused run to end with its context, got %v", name, err)
+		}
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls while paused, got %d", mockClient.calls)
+	}
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// RecordArchiveRun stores the timing of one archiver run
+func (s *PostgresStorage) RecordArchiveRun(ctx context.Context, run storage.ArchiveRun) error {
+	if err := s.checkWritable("record_archive_run"); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO archive_runs (
+			subreddit, started_at, fetch_duration_ms, save_duration_ms,
+			posts_processed, comments_saved, error
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	var runErr interface{}
+	if run.Error != "" {
+		runErr = run.Error
+	}
+
+	startedAt := run.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, query,
+		storage.NormalizeSubreddit(run.Subreddit), startedAt.UTC(),
+		run.FetchDuration.Milliseconds(), run.SaveDuration.Milliseconds(),
+		run.PostsProcessed, run.CommentsSaved, runErr,
+	)
+
+	if err != nil {
+		return &storage.StorageError{Op: "record_archive_run", Err: err}
+	}
+
+	return nil
+}
+
+// GetArchiveRuns returns the most recent archiver runs for a subreddit,
+// newest first
+func (s *PostgresStorage) GetArchiveRuns(ctx context.Context, subreddit string, limit int) ([]*storage.ArchiveRun, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+
+	query := `
+		SELECT id, subreddit, started_at, fetch_duration_ms, save_duration_ms,
+			posts_processed, comments_saved, error
+		FROM archive_runs
+		WHERE subreddit = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, storage.NormalizeSubreddit(subreddit), limit)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_archive_runs", Err: err}
+	}
+	defer rows.Close()
+
+	var runs []*storage.ArchiveRun
+	for rows.Next() {
+		var run storage.ArchiveRun
+		var fetchMs, saveMs int64
+		var runErr sql.NullString
+
+		if err := rows.Scan(
+			&run.ID, &run.Subreddit, &run.StartedAt, &fetchMs, &saveMs,
+			&run.PostsProcessed, &run.CommentsSaved, &runErr,
+		); err != nil {
+			return nil, &storage.StorageError{Op: "scan_archive_run", Err: err}
+		}
+
+		run.StartedAt = run.StartedAt.UTC()
+		run.FetchDuration = time.Duration(fetchMs) * time.Millisecond
+		run.SaveDuration = time.Duration(saveMs) * time.Millisecond
+		run.Error = runErr.String
+		runs = append(runs, &run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "get_archive_runs", Err: err}
+	}
+
+	return runs, nil
+}
+
+// SaveBackfillCheckpoint records where a subreddit's backfill stopped,
+// replacing any earlier checkpoint for it
+func (s *PostgresStorage) SaveBackfillCheckpoint(ctx context.Context, checkpoint storage.BackfillCheckpoint) error {
+	if err := s.checkWritable("save_backfill_checkpoint"); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO backfill_checkpoints (subreddit, after, posts_fetched, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subreddit) DO UPDATE SET
+			after = excluded.after,
+			posts_fetched = excluded.posts_fetched,
+			updated_at = excluded.updated_at
+	`
+
+	updatedAt := checkpoint.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, query,
+		storage.NormalizeSubreddit(checkpoint.Subreddit), checkpoint.After,
+		checkpoint.PostsFetched, updatedAt.UTC(),
+	)
+
+	if err != nil {
+		return &storage.StorageError{Op: "save_backfill_checkpoint", Err: err}
+	}
+
+	return nil
+}
+
+// GetBackfillCheckpoint returns where a subreddit's last unfinished backfill
+// stopped
+func (s *PostgresStorage) GetBackfillCheckpoint(ctx context.Context, subreddit string) (*storage.BackfillCheckpoint, error) {
+	query := `
+		SELECT subreddit, after, posts_fetched, updated_at
+		FROM backfill_checkpoints
+		WHERE subreddit = $1
+	`
+
+	var checkpoint storage.BackfillCheckpoint
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(subreddit)).Scan(
+		&checkpoint.Subreddit, &checkpoint.After, &checkpoint.PostsFetched, &checkpoint.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, &storage.StorageError{Op: "get_backfill_checkpoint", Err: fmt.Errorf("backfill checkpoint %w: %s", storage.ErrNotFound, subreddit)}
+	}
+
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_backfill_checkpoint", Err: err}
+	}
+
+	checkpoint.UpdatedAt = checkpoint.UpdatedAt.UTC()
+	return &checkpoint, nil
+}
+
+// DeleteBackfillCheckpoint removes a subreddit's backfill checkpoint, if any
+func (s *PostgresStorage) DeleteBackfillCheckpoint(ctx context.Context, subreddit string) error {
+	if err := s.checkWritable("delete_backfill_checkpoint"); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM backfill_checkpoints WHERE subreddit = $1`
+
+	_, err := s.db.ExecContext(ctx, query, storage.NormalizeSubreddit(subreddit))
+
+	if err != nil {
+		return &storage.StorageError{Op: "delete_backfill_checkpoint", Err: err}
+	}
+
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
@@ -12,7 +15,13 @@ import (
 
 // SaveComment saves or updates a single comment
 func (s *PostgresStorage) SaveComment(ctx context.Context, comment *types.Comment) error {
-	rawJSON, err := json.Marshal(comment)
+	if err := s.checkWritable("save_comment"); err != nil {
+		return err
+	}
+
+	comment = s.sanitizeComment(comment)
+
+	rawJSON, err := s.commentRawJSON(comment)
 	if err != nil {
 		return &storage.StorageError{Op: "marshal_comment", Err: err}
 	}
@@ -20,15 +29,18 @@ func (s *PostgresStorage) SaveComment(ctx context.Context, comment *types.Commen
 	query := `
 		INSERT INTO comments (
 			id, post_id, parent_id, author, body, score,
-			depth, created_utc, edited_utc, raw_json, last_updated
+			depth, created_utc, edited_utc, is_edited, raw_json, subreddit, last_updated
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+			(SELECT subreddit FROM posts WHERE id = $2), NOW()
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			score = EXCLUDED.score,
 			body = EXCLUDED.body,
 			edited_utc = EXCLUDED.edited_utc,
+			is_edited = EXCLUDED.is_edited,
 			last_updated = NOW(),
+			subreddit = EXCLUDED.subreddit,
 			raw_json = EXCLUDED.raw_json
 	`
 
@@ -72,11 +84,18 @@ func (s *PostgresStorage) SaveComment(ctx context.Context, comment *types.Commen
 		hasEdited = false
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
-		comment.ID, postID, parentID, comment.Author,
+	save := statement{query: query, args: []interface{}{
+		comment.ID, postID, parentID, storage.NormalizeAuthor(comment.Author),
 		comment.Body, comment.Score, depth, createdAt,
-		timePtrOrNil(editedAt, hasEdited), rawJSON,
-	)
+		timePtrOrNil(editedAt, hasEdited), comment.Edited.IsEdited, rawJSON,
+	}}
+
+	stmts := []statement{save}
+	if revision := s.commentRevision(comment); revision != nil {
+		stmts = []statement{*revision, save}
+	}
+
+	err = s.execAllWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntityComment, comment.ID, stmts...)
 
 	if err != nil {
 		return &storage.StorageError{Op: "save_comment", Err: err}
@@ -85,17 +104,34 @@ func (s *PostgresStorage) SaveComment(ctx context.Context, comment *types.Commen
 	return nil
 }
 
-// SaveComments saves or updates multiple comments in a transaction
+// SaveComments saves or updates multiple comments. Batches larger than
+// Options.MaxBatchSize are written in several transactions; each chunk is
+// atomic but the batch as a whole is not.
 func (s *PostgresStorage) SaveComments(ctx context.Context, comments []*types.Comment) error {
-	if len(comments) == 0 {
-		return nil
+	if err := s.checkWritable("save_comments"); err != nil {
+		return err
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return &storage.StorageError{Op: "begin_transaction", Err: err}
+	_, err := s.saveComments(ctx, comments, storage.SaveCommentsOptions{})
+	return err
+}
+
+// SaveCommentsWithOptions saves or updates multiple comments like
+// SaveComments, dropping any that exceed opts.MaxDepth and writing
+// opts.BatchSize comments per transaction when set, and returns how many
+// were stored. On error the count covers the chunks already committed.
+func (s *PostgresStorage) SaveCommentsWithOptions(ctx context.Context, comments []*types.Comment, opts storage.SaveCommentsOptions) (int, error) {
+	if err := s.checkWritable("save_comments"); err != nil {
+		return 0, err
+	}
+
+	return s.saveComments(ctx, comments, opts)
+}
+
+func (s *PostgresStorage) saveComments(ctx context.Context, comments []*types.Comment, opts storage.SaveCommentsOptions) (int, error) {
+	if len(comments) == 0 {
+		return 0, nil
 	}
-	defer tx.Rollback()
 
 	// Build a map of comment ID to parent ID for depth calculation
 	commentMap := make(map[string]string) // commentID -> parentID (stripped)
@@ -112,8 +148,41 @@ func (s *PostgresStorage) SaveComments(ctx context.Context, comments []*types.Co
 		commentMap[comment.ID] = parentID
 	}
 
-	// Function to calculate depth by recursively following parent chain
+	// The depth cache outlives each chunk so replies in a later chunk can
+	// resolve parents saved by an earlier one without another query
 	depthCache := make(map[string]int)
+
+	saved := 0
+	batchSize := s.maxBatchSize(opts)
+	for start := 0; start < len(comments); start += batchSize {
+		end := min(start+batchSize, len(comments))
+
+		var chunkSaved int
+		err := s.withTxRetry(ctx, func() error {
+			var err error
+			chunkSaved, err = s.saveCommentChunk(ctx, comments[start:end], commentMap, depthCache, opts)
+			return err
+		})
+		if err != nil {
+			return saved, err
+		}
+		saved += chunkSaved
+	}
+
+	return saved, nil
+}
+
+// saveCommentChunk writes one chunk of a SaveComments batch in a transaction,
+// skipping comments at or below opts.MaxDepth (0 for no limit), and returns how
+// many were written
+func (s *PostgresStorage) saveCommentChunk(ctx context.Context, comments []*types.Comment, commentMap map[string]string, depthCache map[string]int, opts storage.SaveCommentsOptions) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	// Function to calculate depth by recursively following parent chain
 	var calculateDepth func(commentID string) int
 	calculateDepth = func(commentID string) int {
 		// Check cache first
@@ -148,29 +217,42 @@ func (s *PostgresStorage) SaveComments(ctx context.Context, comments []*types.Co
 	query := `
 		INSERT INTO comments (
 			id, post_id, parent_id, author, body, score,
-			depth, created_utc, edited_utc, raw_json, last_updated
+			depth, created_utc, edited_utc, is_edited, raw_json, account, subreddit, last_updated
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+			(SELECT subreddit FROM posts WHERE id = $2), NOW()
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			score = EXCLUDED.score,
 			body = EXCLUDED.body,
 			edited_utc = EXCLUDED.edited_utc,
+			is_edited = EXCLUDED.is_edited,
 			depth = EXCLUDED.depth,
+			account = COALESCE(EXCLUDED.account, comments.account),
 			last_updated = NOW(),
+			subreddit = EXCLUDED.subreddit,
 			raw_json = EXCLUDED.raw_json
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return &storage.StorageError{Op: "prepare_statement", Err: err}
+		return 0, &storage.StorageError{Op: "prepare_statement", Err: err}
 	}
 	defer stmt.Close()
 
+	saved := 0
 	for _, comment := range comments {
-		rawJSON, err := json.Marshal(comment)
+		// Calculate proper depth
+		depth := calculateDepth(comment.ID)
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			continue
+		}
+
+		comment := s.sanitizeComment(comment)
+
+		rawJSON, err := s.commentRawJSON(comment)
 		if err != nil {
-			return &storage.StorageError{Op: "marshal_comment", Err: err}
+			return 0, &storage.StorageError{Op: "marshal_comment", Err: err}
 		}
 
 		// Handle NULL parent_id for top-level comments
@@ -193,41 +275,141 @@ func (s *PostgresStorage) SaveComments(ctx context.Context, comments []*types.Co
 			postID = postID[3:]
 		}
 
-		// Calculate proper depth
-		depth := calculateDepth(comment.ID)
-
 		createdAt, _ := unixFloatToTime(comment.CreatedUTC)
 		editedAt, hasEdited := unixFloatToTime(comment.Edited.Timestamp)
 		if !comment.Edited.IsEdited {
 			hasEdited = false
 		}
 
+		if revision := s.commentRevision(comment); revision != nil {
+			if _, err := tx.ExecContext(ctx, revision.query, revision.args...); err != nil {
+				return 0, &storage.StorageError{Op: "insert_comment_revision", Err: err}
+			}
+		}
+
 		_, err = stmt.ExecContext(ctx,
-			comment.ID, postID, parentID, comment.Author,
+			comment.ID, postID, parentID, storage.NormalizeAuthor(comment.Author),
 			comment.Body, comment.Score, depth, createdAt,
-			timePtrOrNil(editedAt, hasEdited), rawJSON,
+			timePtrOrNil(editedAt, hasEdited), comment.Edited.IsEdited, rawJSON, nullIfEmpty(opts.Account),
 		)
 
 		if err != nil {
-			return &storage.StorageError{Op: "insert_comment", Err: err}
+			return 0, &storage.StorageError{Op: "insert_comment", Err: err}
+		}
+
+		if err := s.appendOutbox(ctx, tx, storage.OutboxOpSave, storage.OutboxEntityComment, comment.ID); err != nil {
+			return 0, err
 		}
+		saved++
 	}
 
 	if err := tx.Commit(); err != nil {
-		return &storage.StorageError{Op: "commit_transaction", Err: err}
+		return 0, &storage.StorageError{Op: "commit_transaction", Err: err}
 	}
 
-	return nil
+	return saved, nil
+}
+
+// DeleteComments deletes all of a post's comments in a single transaction,
+// keeping the post, and returns how many were deleted. A post that isn't
+// stored returns an error wrapping storage.ErrNotFound.
+func (s *PostgresStorage) DeleteComments(ctx context.Context, postID string) (int, error) {
+	if err := s.checkWritable("delete_comments"); err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	err := s.withTxRetry(ctx, func() error {
+		var err error
+		deleted, err = s.deleteComments(ctx, postID)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (s *PostgresStorage) deleteComments(ctx context.Context, postID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM posts WHERE id = $1", postID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, postID)}
+	}
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	// Collect the IDs up front: replies removed by the parent_id cascade
+	// aren't counted as affected rows, and outbox events need the IDs
+	var ids []string
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM comments WHERE post_id = $1", postID)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM saved_items WHERE post_id = $1 AND is_comment", postID); err != nil {
+		return 0, &storage.StorageError{Op: "delete_saved_items", Err: err}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE post_id = $1", postID); err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	if err := s.appendOutbox(ctx, tx, storage.OutboxOpDelete, storage.OutboxEntityComment, ids...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, &storage.StorageError{Op: "commit_transaction", Err: err}
+	}
+
+	return len(ids), nil
 }
 
 // GetCommentsByPost retrieves all comments for a post, preserving thread structure
 func (s *PostgresStorage) GetCommentsByPost(ctx context.Context, postID string) ([]*types.Comment, error) {
+	stored, err := s.getStoredCommentsByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*types.Comment, len(stored))
+	for i, comment := range stored {
+		comments[i] = comment.Comment
+	}
+
+	return comments, nil
+}
+
+// getStoredCommentsByPost retrieves all comments for a post in thread order
+// along with their stored depth. Each path element is the comment's creation
+// time in fixed-width form followed by its ID, so siblings created in the
+// same second still sort deterministically.
+func (s *PostgresStorage) getStoredCommentsByPost(ctx context.Context, postID string) ([]*storage.StoredComment, error) {
 	query := `
 		WITH RECURSIVE comment_tree AS (
 			-- Top-level comments
 			SELECT id, post_id, parent_id, author, body, score, depth,
-			       created_utc, edited_utc, raw_json, 0 as level,
-			       ARRAY[created_utc] as path
+			       created_utc, edited_utc, is_edited, raw_json, 0 as level,
+			       ARRAY[to_char(created_utc, 'YYYYMMDDHH24MISS.US') || ' ' || id] as path
 			FROM comments
 			WHERE post_id = $1 AND parent_id IS NULL
 
@@ -235,65 +417,364 @@ func (s *PostgresStorage) GetCommentsByPost(ctx context.Context, postID string)
 
 			-- Nested comments
 			SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score,
-			       c.depth, c.created_utc, c.edited_utc, c.raw_json,
+			       c.depth, c.created_utc, c.edited_utc, c.is_edited, c.raw_json,
 			       ct.level + 1,
-			       ct.path || c.created_utc
+			       ct.path || (to_char(c.created_utc, 'YYYYMMDDHH24MISS.US') || ' ' || c.id)
 			FROM comments c
 			JOIN comment_tree ct ON c.parent_id = ct.id
 		)
 		SELECT id, post_id, parent_id, author, body, score, depth,
-		       created_utc, edited_utc, raw_json
+		       created_utc, edited_utc, is_edited, raw_json
 		FROM comment_tree
-		ORDER BY path
+		ORDER BY path COLLATE "C"
 	`
 
+	ctx, cancel := s.threadQueryContext(ctx)
+	defer cancel()
+
 	rows, err := s.db.QueryContext(ctx, query, postID)
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_comments_by_post", Err: err}
+		return nil, queryError(ctx, "get_comments_by_post", err)
 	}
 	defer rows.Close()
 
-	var comments []*types.Comment
+	var comments []*storage.StoredComment
 
 	for rows.Next() {
-		var comment types.Comment
-		var rawJSON []byte
-		var parentID sql.NullString
-
-		var postIDRaw string
-		var depth int
-		var createdAt time.Time
-		var editedUTC sql.NullTime
-
-		err := rows.Scan(
-			&comment.ID, &postIDRaw, &parentID, &comment.Author,
-			&comment.Body, &comment.Score, &depth, &createdAt,
-			&editedUTC, &rawJSON,
-		)
+		comment, depth, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, &storage.StoredComment{Comment: comment, Depth: depth})
+	}
 
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, "scan_comments", err)
+	}
+
+	return comments, nil
+}
+
+// GetCommentTreeNested retrieves all comments for a post nested under their
+// parents
+func (s *PostgresStorage) GetCommentTreeNested(ctx context.Context, postID string) ([]*storage.CommentNode, error) {
+	comments, err := s.GetCommentsByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.BuildCommentTree(comments), nil
+}
+
+// GetTopComments retrieves the n highest-scoring top-level comments of a
+// post, ignoring replies. Ties go to the earlier comment.
+func (s *PostgresStorage) GetTopComments(ctx context.Context, postID string, n int) ([]*storage.StoredComment, error) {
+	if n <= 0 {
+		n = 25
+	}
+
+	query := `
+		SELECT id, post_id, parent_id, author, body, score, depth,
+		       created_utc, edited_utc, is_edited, raw_json
+		FROM comments
+		WHERE post_id = $1 AND parent_id IS NULL
+		ORDER BY score DESC, created_utc ASC, id ASC
+		LIMIT $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, n)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_top_comments", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*storage.StoredComment
+
+	for rows.Next() {
+		comment, depth, err := scanComment(rows)
 		if err != nil {
-			return nil, &storage.StorageError{Op: "scan_comment", Err: err}
+			return nil, err
 		}
 
-		comment.CreatedUTC = timeToUnixFloat(createdAt)
+		comments = append(comments, &storage.StoredComment{Comment: comment, Depth: depth})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
 
-		// Reconstruct fullnames with prefixes
-		comment.LinkID = "t3_" + postIDRaw
+// GetTopLevelComments retrieves a page of a post's top-level comments with
+// their reply counts
+func (s *PostgresStorage) GetTopLevelComments(ctx context.Context, postID string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	return s.getThreadComments(ctx, "get_top_level_comments", "c.post_id = $1 AND c.parent_id IS NULL", postID, opts)
+}
 
-		if parentID.Valid {
-			comment.ParentID = "t1_" + parentID.String
-		} else {
-			comment.ParentID = comment.LinkID // Top-level comments have post as parent
+// GetReplies retrieves a page of a comment's direct replies with their own
+// reply counts. commentID is the comment's ID without the t1_ prefix.
+func (s *PostgresStorage) GetReplies(ctx context.Context, commentID string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	return s.getThreadComments(ctx, "get_replies", "c.parent_id = $1", commentID, opts)
+}
+
+// getThreadComments pages through the comments matching where, which takes
+// a single $1 argument, counting each one's direct replies
+func (s *PostgresStorage) getThreadComments(ctx context.Context, op, where, arg string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: op, Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json,
+		       (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id)
+		FROM comments c
+		WHERE ` + where
+
+	args := []interface{}{arg}
+	argPos := 2
+
+	if opts.MinScore != nil {
+		query += fmt.Sprintf(" AND c.score >= $%d", argPos)
+		args = append(args, *opts.MinScore)
+		argPos++
+	}
+
+	sortBy := "c.score"
+	if opts.SortBy == "created" || opts.SortBy == "created_utc" {
+		sortBy = "c.created_utc"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	// The ID tie-breaker keeps pages stable across equal sort keys
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id %s", sortBy, sortOrder, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: op, Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*storage.ThreadComment
+
+	for rows.Next() {
+		var replyCount int
+		comment, _, err := scanComment(rows, &replyCount)
+		if err != nil {
+			return nil, err
 		}
 
-		// Reconstruct Edited field
-		if editedUTC.Valid {
-			comment.Edited = types.Edited{IsEdited: true, Timestamp: timeToUnixFloat(editedUTC.Time)}
-		} else {
-			comment.Edited = types.Edited{IsEdited: false}
+		comments = append(comments, &storage.ThreadComment{Comment: comment, ReplyCount: replyCount})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+// ExportPostMarkdown writes a post and its comment thread to w as Markdown
+func (s *PostgresStorage) ExportPostMarkdown(ctx context.Context, postID string, w io.Writer) error {
+	post, err := s.GetPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	comments, err := s.getStoredCommentsByPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.WritePostMarkdown(w, post, comments); err != nil {
+		return &storage.StorageError{Op: "export_post_markdown", Err: err}
+	}
+
+	return nil
+}
+
+// GetCommentsByAuthorWithContext retrieves an author's comments along with the
+// title and subreddit of the post each one belongs to
+func (s *PostgresStorage) GetCommentsByAuthorWithContext(ctx context.Context, author string, opts storage.QueryOptions) ([]*storage.CommentWithPost, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_author_with_context", Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json, p.title,
+		       COALESCE(NULLIF(sr.display_name, ''), p.subreddit)
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		LEFT JOIN subreddits sr ON sr.name = p.subreddit
+		WHERE c.author = $1
+	`
+
+	var args []interface{}
+	args = append(args, author)
+	argPos := 2
+
+	// Add date filters if provided
+	if !opts.StartDate.IsZero() {
+		query += fmt.Sprintf(" AND c.created_utc >= $%d", argPos)
+		args = append(args, opts.StartDate)
+		argPos++
+	}
+
+	if !opts.EndDate.IsZero() {
+		query += fmt.Sprintf(" AND c.created_utc <= $%d", argPos)
+		args = append(args, opts.EndDate)
+		argPos++
+	}
+
+	if opts.MinScore != nil {
+		query += fmt.Sprintf(" AND c.score >= $%d", argPos)
+		args = append(args, *opts.MinScore)
+		argPos++
+	}
+
+	if opts.Account != "" {
+		query += fmt.Sprintf(" AND c.account = $%d", argPos)
+		args = append(args, opts.Account)
+		argPos++
+	}
+
+	// Comments can only be sorted by creation time or score
+	sortBy := "c.created_utc"
+	if opts.SortBy == "score" {
+		sortBy = "c.score"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_author", Err: err}
+	}
+	defer rows.Close()
+
+	var results []*storage.CommentWithPost
+
+	for rows.Next() {
+		var result storage.CommentWithPost
+
+		comment, _, err := scanComment(rows, &result.PostTitle, &result.PostSubreddit)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Comment = comment
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return results, nil
+}
+
+// GetCommentsBySubreddit retrieves comments on any post in a subreddit using
+// the subreddit copied onto each comment when it is saved. Comments can be
+// sorted by creation time (default) or score.
+func (s *PostgresStorage) GetCommentsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*types.Comment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_subreddit", Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json
+		FROM comments c
+		WHERE c.subreddit = $1
+	`
+
+	var args []interface{}
+	args = append(args, storage.NormalizeSubreddit(subreddit))
+	argPos := 2
+
+	// Add date filters if provided
+	if !opts.StartDate.IsZero() {
+		query += fmt.Sprintf(" AND c.created_utc >= $%d", argPos)
+		args = append(args, opts.StartDate)
+		argPos++
+	}
+
+	if !opts.EndDate.IsZero() {
+		query += fmt.Sprintf(" AND c.created_utc <= $%d", argPos)
+		args = append(args, opts.EndDate)
+		argPos++
+	}
+
+	if opts.MinScore != nil {
+		query += fmt.Sprintf(" AND c.score >= $%d", argPos)
+		args = append(args, *opts.MinScore)
+		argPos++
+	}
+
+	if opts.Account != "" {
+		query += fmt.Sprintf(" AND c.account = $%d", argPos)
+		args = append(args, opts.Account)
+		argPos++
+	}
+
+	sortBy := "c.created_utc"
+	if opts.SortBy == "score" {
+		sortBy = "c.score"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	// The ID tie-breaker keeps pages stable across equal sort keys
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id %s", sortBy, sortOrder, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*types.Comment
+
+	for rows.Next() {
+		comment, _, err := scanComment(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		comments = append(comments, &comment)
+		comments = append(comments, comment)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -302,3 +783,179 @@ func (s *PostgresStorage) GetCommentsByPost(ctx context.Context, postID string)
 
 	return comments, nil
 }
+
+// SearchComments finds comments whose body matches query using full-text
+// search, best-scoring first. opts.SearchMode selects how query is parsed,
+// opts.Subreddit scopes the search and opts.MinScore drops low-scoring
+// comments.
+func (s *PostgresStorage) SearchComments(ctx context.Context, query string, opts storage.QueryOptions) ([]*types.Comment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "search_comments", Err: err}
+	}
+
+	tsQuery := "plainto_tsquery"
+	if opts.SearchMode == storage.SearchModeWeb {
+		tsQuery = "websearch_to_tsquery"
+	}
+
+	sqlQuery := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json
+		FROM comments c
+		WHERE to_tsvector('english', COALESCE(c.body, '')) @@ ` + tsQuery + `('english', $1)
+	`
+	args := []interface{}{query}
+	argPos := 2
+
+	if opts.Subreddit != "" {
+		sqlQuery += fmt.Sprintf(" AND c.subreddit = $%d", argPos)
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+		argPos++
+	}
+
+	if opts.MinScore != nil {
+		sqlQuery += fmt.Sprintf(" AND c.score >= $%d", argPos)
+		args = append(args, *opts.MinScore)
+		argPos++
+	}
+
+	sqlQuery += fmt.Sprintf(" ORDER BY c.score DESC, c.id LIMIT $%d OFFSET $%d", argPos, argPos+1)
+
+	limit, offset := pageBounds(opts)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "search_comments", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*types.Comment
+
+	for rows.Next() {
+		comment, _, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+// GetArchivedCommentCounts counts the stored comments of each post in
+// postIDs, so a repeated comment pass can compare them with num_comments and
+// skip threads that already look complete. Every requested ID is present in
+// the result; posts without stored comments count 0.
+func (s *PostgresStorage) GetArchivedCommentCounts(ctx context.Context, postIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(postIDs))
+	for _, id := range postIDs {
+		counts[id] = 0
+	}
+
+	for start := 0; start < len(postIDs); start += idChunkSize {
+		chunk := postIDs[start:min(start+idChunkSize, len(postIDs))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+
+		query := "SELECT post_id, COUNT(*) FROM comments WHERE post_id IN (" + strings.Join(placeholders, ", ") + ") GROUP BY post_id"
+		if err := scanCommentCounts(ctx, s.db, query, args, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// scanCommentCounts runs a post_id, COUNT(*) query and records each count
+func scanCommentCounts(ctx context.Context, db *sql.DB, query string, args []interface{}, counts map[string]int) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &storage.StorageError{Op: "get_archived_comment_counts", Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID string
+		var count int
+		if err := rows.Scan(&postID, &count); err != nil {
+			return &storage.StorageError{Op: "scan_comment_counts", Err: err}
+		}
+		counts[postID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return &storage.StorageError{Op: "scan_comment_counts", Err: err}
+	}
+
+	return nil
+}
+
+// commentRawJSON returns the raw_json value to store for a comment, or nil
+// when Options.StoreCommentRawJSON is off
+func (s *PostgresStorage) commentRawJSON(comment *types.Comment) (interface{}, error) {
+	if !s.opts.StoreCommentRawJSON {
+		return nil, nil
+	}
+
+	rawJSON, err := json.Marshal(comment)
+	if err != nil {
+		return nil, err
+	}
+	return rawJSON, nil
+}
+
+// scanComment scans a comment row selected as id, post_id, parent_id, author,
+// body, score, depth, created_utc, edited_utc, is_edited, raw_json followed by any extra
+// destinations, returning the comment and its stored depth
+func scanComment(rows *sql.Rows, extra ...interface{}) (*types.Comment, int, error) {
+	var comment types.Comment
+	var rawJSON []byte // nil when saved with StoreCommentRawJSON off
+	var parentID sql.NullString
+	var postIDRaw string
+	var depth int
+	var createdAt time.Time
+	var editedUTC sql.NullTime
+	var isEdited bool
+
+	dest := []interface{}{
+		&comment.ID, &postIDRaw, &parentID, &comment.Author,
+		&comment.Body, &comment.Score, &depth, &createdAt,
+		&editedUTC, &isEdited, &rawJSON,
+	}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, 0, &storage.StorageError{Op: "scan_comment", Err: err}
+	}
+
+	comment.CreatedUTC = timeToUnixFloat(createdAt)
+
+	// Reconstruct fullnames with prefixes
+	comment.LinkID = "t3_" + postIDRaw
+
+	if parentID.Valid {
+		comment.ParentID = "t1_" + parentID.String
+	} else {
+		comment.ParentID = comment.LinkID // Top-level comments have post as parent
+	}
+
+	// Reconstruct Edited field; Reddit sometimes reports an edit without a
+	// timestamp, which is only recorded in is_edited
+	if editedUTC.Valid {
+		comment.Edited = types.Edited{IsEdited: true, Timestamp: timeToUnixFloat(editedUTC.Time)}
+	} else {
+		comment.Edited = types.Edited{IsEdited: isEdited}
+	}
+
+	return &comment, depth, nil
+}
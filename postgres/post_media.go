@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// postMediaStatements returns the statements replacing a post's stored media
+// with items, or none when items is empty so a save that finds no media
+// keeps what is stored
+func postMediaStatements(postID string, items []*storage.PostMedia) []statement {
+	if len(items) == 0 {
+		return nil
+	}
+
+	stmts := []statement{{query: "DELETE FROM post_media WHERE post_id = $1", args: []interface{}{postID}}}
+	for _, item := range items {
+		stmts = append(stmts, statement{
+			query: `
+				INSERT INTO post_media (post_id, position, media_type, url, width, height)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`,
+			args: []interface{}{postID, item.Position, item.Type, item.URL, item.Width, item.Height},
+		})
+	}
+	return stmts
+}
+
+// GetPostMedia returns a post's stored images, videos and embeds in order.
+// A post without stored media returns none.
+func (s *PostgresStorage) GetPostMedia(ctx context.Context, postID string) ([]*storage.PostMedia, error) {
+	query := `
+		SELECT post_id, position, media_type, url, width, height
+		FROM post_media
+		WHERE post_id = $1
+		ORDER BY position
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_post_media", Err: err}
+	}
+	defer rows.Close()
+
+	var media []*storage.PostMedia
+	for rows.Next() {
+		var item storage.PostMedia
+		if err := rows.Scan(&item.PostID, &item.Position, &item.Type, &item.URL, &item.Width, &item.Height); err != nil {
+			return nil, &storage.StorageError{Op: "scan_post_media", Err: err}
+		}
+		media = append(media, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_post_media", Err: err}
+	}
+
+	return media, nil
+}
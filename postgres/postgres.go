@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -16,28 +17,109 @@ import (
 
 // PostgresStorage implements the Storage interface for PostgreSQL
 type PostgresStorage struct {
-	db *sql.DB
+	db   *sql.DB
+	opts Options
 }
 
-// PoolConfig configures the PostgreSQL connection pool
-type PoolConfig struct {
-	// MaxOpenConns sets the maximum number of open connections to the database
-	// Default: 0 (unlimited)
-	MaxOpenConns int
-
-	// MaxIdleConns sets the maximum number of connections in the idle connection pool
-	// Default: 2
-	MaxIdleConns int
-
-	// ConnMaxLifetime sets the maximum amount of time a connection may be reused
-	// Default: 0 (connections are reused forever)
-	ConnMaxLifetime time.Duration
-
-	// ConnMaxIdleTime sets the maximum amount of time a connection may be idle
-	// Default: 0 (connections are not closed due to idle time)
-	ConnMaxIdleTime time.Duration
+// defaultMaxBatchSize is used when Options.MaxBatchSize is unset
+const defaultMaxBatchSize = 1000
+
+// defaultQueryLimit is the page size used when QueryOptions.Limit is unset
+const defaultQueryLimit = 25
+
+// defaultThreadQueryTimeout is used when Options.ThreadQueryTimeout is unset
+const defaultThreadQueryTimeout = 30 * time.Second
+
+// Options configures PostgreSQL storage behavior
+type Options struct {
+	// MaxBatchSize caps how many comments SaveComments writes per transaction.
+	// Larger batches are split into several transactions so a very wide thread
+	// doesn't produce one enormous transaction that blocks other writers.
+	// Default: 1000
+	MaxBatchSize int
+
+	// ReadOnly sets default_transaction_read_only on every session so the
+	// server refuses writes, and rejects Save/Delete methods and RunMigrations with
+	// storage.ErrReadOnly before they reach the database.
+	// Default: false
+	ReadOnly bool
+
+	// TxRetries is how many times a transactional write failing with a
+	// serialization failure (40001) or deadlock (40P01) is re-run before the
+	// error is returned. Negative disables retrying.
+	// Default: 3
+	TxRetries int
+
+	// TxRetryBackoff is the base delay before the first transaction retry.
+	// It doubles on each attempt and is jittered.
+	// Default: 50ms
+	TxRetryBackoff time.Duration
+
+	// StoreCommentRawJSON keeps each comment's full API response in
+	// raw_json. Turning it off stores NULL instead, which roughly halves
+	// comment storage; nothing on the read path needs it. Re-saving a
+	// comment with it off clears previously stored JSON. Being a bool,
+	// it is only on by default when starting from DefaultOptions.
+	// Default: true
+	StoreCommentRawJSON bool
+
+	// SanitizeText strips NUL bytes and replaces invalid UTF-8 in post
+	// titles, selftext and comment bodies before saving, since Postgres
+	// rejects them and the whole save would fail. Like StoreCommentRawJSON
+	// it is only on by default when starting from DefaultOptions.
+	// Default: true
+	SanitizeText bool
+
+	// Outbox appends an event to the outbox table for every saved post,
+	// comment and subreddit and every deleted post, in the same
+	// transaction as the change. Consumers page through the events with
+	// ReadOutbox and remove them with AckOutbox.
+	// Default: false
+	Outbox bool
+
+	// ThreadQueryTimeout bounds each recursive comment-tree query
+	// (GetCommentsByPost, GetCommentTreeNested, ExportPostMarkdown and
+	// GetPostStats), so one giant thread can't hold a connection for long.
+	// The query fails with an error wrapping context.DeadlineExceeded when
+	// it runs out. Negative leaves only the caller's context in charge.
+	// Default: 30s
+	ThreadQueryTimeout time.Duration
+
+	// PreserveFirstSeen keeps the author and title a post was first saved
+	// with when it is saved again. Turning it off refreshes them on every
+	// save, e.g. to pick up an author later shown as [deleted]. created_utc
+	// is never overwritten either way. Like StoreCommentRawJSON it is only
+	// on by default when starting from DefaultOptions.
+	// Default: true
+	PreserveFirstSeen bool
+
+	// KeepRevisions records the stored selftext or body, score and edit
+	// time of a post or comment in post_revisions or comment_revisions
+	// whenever a save brings different content, so GetPostRevisions and
+	// GetCommentRevisions can reconstruct its edit history. With it on,
+	// re-saving a post also refreshes its selftext, which is otherwise kept
+	// from the first save; a removed post's placeholder is never stored
+	// over it.
+	// Default: false
+	KeepRevisions bool
 }
 
+// DefaultOptions returns the default PostgreSQL storage options
+func DefaultOptions() *Options {
+	return &Options{
+		MaxBatchSize:        defaultMaxBatchSize,
+		TxRetries:           defaultTxRetries,
+		TxRetryBackoff:      defaultTxRetryBackoff,
+		ThreadQueryTimeout:  defaultThreadQueryTimeout,
+		StoreCommentRawJSON: true,
+		PreserveFirstSeen:   true,
+		SanitizeText:        true,
+	}
+}
+
+// PoolConfig configures the PostgreSQL connection pool
+type PoolConfig = storage.PoolConfig
+
 // DefaultPoolConfig returns sensible defaults for production use
 func DefaultPoolConfig() *PoolConfig {
 	return &PoolConfig{
@@ -48,6 +130,29 @@ func DefaultPoolConfig() *PoolConfig {
 	}
 }
 
+func init() {
+	storage.Register("postgres", openConfig)
+	storage.Register("postgresql", openConfig)
+}
+
+// openConfig opens PostgreSQL storage for storage.Open
+func openConfig(cfg storage.Config) (storage.Storage, error) {
+	pool := cfg.Pool
+	if pool == nil {
+		pool = DefaultPoolConfig()
+	}
+
+	var opts *Options
+	if cfg.Options != nil {
+		var ok bool
+		if opts, ok = cfg.Options.(*Options); !ok {
+			return nil, &storage.StorageError{Op: "open", Err: fmt.Errorf("options of type %T are not *postgres.Options", cfg.Options)}
+		}
+	}
+
+	return NewWithOptions(cfg.DSN, pool, opts)
+}
+
 // New creates a new PostgreSQL storage instance with default pool configuration
 func New(connString string) (*PostgresStorage, error) {
 	return NewWithPool(connString, DefaultPoolConfig())
@@ -55,6 +160,20 @@ func New(connString string) (*PostgresStorage, error) {
 
 // NewWithPool creates a new PostgreSQL storage instance with custom pool configuration
 func NewWithPool(connString string, config *PoolConfig) (*PostgresStorage, error) {
+	return NewWithOptions(connString, config, DefaultOptions())
+}
+
+// NewWithOptions creates a new PostgreSQL storage instance with custom pool
+// configuration and storage options
+func NewWithOptions(connString string, config *PoolConfig, opts *Options) (*PostgresStorage, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if opts.ReadOnly {
+		connString = readOnlyConnString(connString)
+	}
+
 	db, err := sql.Open("postgres", connString)
 	if err != nil {
 		return nil, &storage.StorageError{Op: "open", Err: err}
@@ -72,11 +191,82 @@ func NewWithPool(connString string, config *PoolConfig) (*PostgresStorage, error
 		return nil, &storage.StorageError{Op: "ping", Err: err}
 	}
 
-	return &PostgresStorage{db: db}, nil
+	return &PostgresStorage{db: db, opts: *opts}, nil
+}
+
+// readOnlyConnString adds default_transaction_read_only to a connection string
+// in either URL or key=value form
+func readOnlyConnString(connString string) string {
+	if strings.HasPrefix(connString, "postgres://") || strings.HasPrefix(connString, "postgresql://") {
+		if strings.Contains(connString, "?") {
+			return connString + "&default_transaction_read_only=on"
+		}
+		return connString + "?default_transaction_read_only=on"
+	}
+	return strings.TrimSpace(connString + " default_transaction_read_only=on")
+}
+
+// checkWritable rejects write operations when the storage is read-only
+func (s *PostgresStorage) checkWritable(op string) error {
+	if s.opts.ReadOnly {
+		return &storage.StorageError{Op: op, Err: storage.ErrReadOnly}
+	}
+	return nil
+}
+
+// maxBatchSize returns the comment batch size for a save: opts.BatchSize if
+// set, otherwise the configured size or the default
+func (s *PostgresStorage) maxBatchSize(opts storage.SaveCommentsOptions) int {
+	if opts.BatchSize > 0 {
+		return opts.BatchSize
+	}
+	if s.opts.MaxBatchSize <= 0 {
+		return defaultMaxBatchSize
+	}
+	return s.opts.MaxBatchSize
+}
+
+// threadQueryContext derives the context for a recursive comment-tree query,
+// bounded by Options.ThreadQueryTimeout
+func (s *PostgresStorage) threadQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.opts.ThreadQueryTimeout
+	if timeout == 0 {
+		timeout = defaultThreadQueryTimeout
+	}
+	if timeout < 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// queryError wraps a failed query's error for op. When ctx was cancelled or
+// timed out the driver reports that in its own words, so the context's
+// error is returned instead for callers to match with errors.Is.
+func queryError(ctx context.Context, op string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	return &storage.StorageError{Op: op, Err: err}
+}
+
+// pageBounds returns the LIMIT and OFFSET for a paginated query. A zero or
+// negative opts.Limit falls back to the default page size and a negative
+// opts.Offset starts from the first row, so bad caller input can't reach
+// the SQL.
+func pageBounds(opts storage.QueryOptions) (limit, offset int) {
+	limit = opts.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	return limit, max(opts.Offset, 0)
 }
 
 // RunMigrations runs all pending database migrations
 func (s *PostgresStorage) RunMigrations(ctx context.Context) error {
+	if err := s.checkWritable("run_migrations"); err != nil {
+		return err
+	}
+
 	runner, err := schema.NewMigrationRunner(s.db, "postgres")
 	if err != nil {
 		return &storage.StorageError{Op: "create_migration_runner", Err: err}
@@ -97,8 +287,14 @@ func (s *PostgresStorage) Close() error {
 	return nil
 }
 
-// SaveSubreddit saves or updates a subreddit
+// SaveSubreddit saves or updates a subreddit. Empty titles and descriptions
+// and zero subscriber counts keep the stored values, so saving a stub that
+// only names the subreddit never erases metadata fetched earlier.
 func (s *PostgresStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditData) error {
+	if err := s.checkWritable("save_subreddit"); err != nil {
+		return err
+	}
+
 	rawJSON, err := json.Marshal(sub)
 	if err != nil {
 		return &storage.StorageError{Op: "marshal_subreddit", Err: err}
@@ -111,15 +307,19 @@ func (s *PostgresStorage) SaveSubreddit(ctx context.Context, sub *types.Subreddi
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
 		ON CONFLICT (name) DO UPDATE SET
 			display_name = EXCLUDED.display_name,
-			title = EXCLUDED.title,
-			description = EXCLUDED.description,
-			subscribers = EXCLUDED.subscribers,
+			title = COALESCE(NULLIF(EXCLUDED.title, ''), subreddits.title, EXCLUDED.title),
+			description = COALESCE(NULLIF(EXCLUDED.description, ''), subreddits.description, EXCLUDED.description),
+			subscribers = CASE WHEN EXCLUDED.subscribers > 0 OR subreddits.subscribers IS NULL THEN EXCLUDED.subscribers ELSE subreddits.subscribers END,
 			last_synced = NOW(),
-			raw_json = EXCLUDED.raw_json
+			raw_json = CASE
+				WHEN EXCLUDED.title != '' OR EXCLUDED.description != '' OR EXCLUDED.subscribers > 0 OR subreddits.raw_json IS NULL THEN EXCLUDED.raw_json
+				ELSE subreddits.raw_json
+			END
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
-		sub.DisplayName, sub.DisplayName, sub.Title, sub.Description,
+	name := storage.NormalizeSubreddit(sub.DisplayName)
+	err = s.execWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntitySubreddit, name, query,
+		name, sub.DisplayName, sub.Title, sub.Description,
 		sub.Subscribers, nil, rawJSON, // created_utc not available in API
 	)
 
@@ -130,6 +330,23 @@ func (s *PostgresStorage) SaveSubreddit(ctx context.Context, sub *types.Subreddi
 	return nil
 }
 
+// ensureSubreddit creates a placeholder row for a subreddit referenced by a
+// post. An existing row is left alone so its canonical display name and
+// metadata aren't overwritten by however the post spelled the name.
+func (s *PostgresStorage) ensureSubreddit(ctx context.Context, name string) error {
+	query := `
+		INSERT INTO subreddits (name, display_name, last_synced)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, storage.NormalizeSubreddit(name), name); err != nil {
+		return &storage.StorageError{Op: "ensure_subreddit", Err: err}
+	}
+
+	return nil
+}
+
 // GetSubreddit retrieves a subreddit by name
 func (s *PostgresStorage) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
 	query := `
@@ -142,13 +359,13 @@ func (s *PostgresStorage) GetSubreddit(ctx context.Context, name string) (*types
 	var rawJSON []byte
 	var createdUTC sql.NullTime
 
-	err := s.db.QueryRowContext(ctx, query, name).Scan(
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(name)).Scan(
 		&sub.DisplayName, &sub.DisplayName, &sub.Title, &sub.Description,
 		&sub.Subscribers, &createdUTC, &rawJSON,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, &storage.StorageError{Op: "get_subreddit", Err: fmt.Errorf("subreddit not found: %s", name)}
+		return nil, &storage.StorageError{Op: "get_subreddit", Err: fmt.Errorf("subreddit %w: %s", storage.ErrNotFound, name)}
 	}
 
 	if err != nil {
@@ -158,23 +375,143 @@ func (s *PostgresStorage) GetSubreddit(ctx context.Context, name string) (*types
 	return &sub, nil
 }
 
+// ListSubreddits returns stored subreddits a page at a time, optionally
+// filtered to names starting with opts.Search. SortBy may be "name" (the
+// default, ascending) or "subscribers" (descending by default).
+func (s *PostgresStorage) ListSubreddits(ctx context.Context, opts storage.QueryOptions) ([]*types.SubredditData, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+
+	query, args := listSubredditsQuery(opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+	defer rows.Close()
+
+	var subs []*types.SubredditData
+	for rows.Next() {
+		var sub types.SubredditData
+		if err := rows.Scan(&sub.DisplayName, &sub.Title, &sub.Description, &sub.Subscribers); err != nil {
+			return nil, &storage.StorageError{Op: "scan_subreddit", Err: err}
+		}
+		subs = append(subs, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+
+	return subs, nil
+}
+
+// ListSubredditSummaries returns every stored subreddit with counts of its
+// posts and comments and the span of its posts, ordered by name, in a
+// single aggregate query. Subreddits with a row but no posts are included
+// with zero counts.
+func (s *PostgresStorage) ListSubredditSummaries(ctx context.Context) ([]*storage.SubredditSummary, error) {
+	query := `
+		SELECT s.name, COALESCE(p.post_count, 0), COALESCE(c.comment_count, 0),
+			p.oldest, p.newest, s.last_synced
+		FROM subreddits s
+		LEFT JOIN (
+			SELECT subreddit, COUNT(*) AS post_count,
+				MIN(created_utc) AS oldest, MAX(created_utc) AS newest
+			FROM posts
+			GROUP BY subreddit
+		) p ON p.subreddit = s.name
+		LEFT JOIN (
+			SELECT subreddit, COUNT(*) AS comment_count
+			FROM comments
+			GROUP BY subreddit
+		) c ON c.subreddit = s.name
+		ORDER BY s.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddit_summaries", Err: err}
+	}
+	defer rows.Close()
+
+	var summaries []*storage.SubredditSummary
+	for rows.Next() {
+		var summary storage.SubredditSummary
+		var oldest, newest, lastSynced sql.NullTime
+		if err := rows.Scan(&summary.Name, &summary.PostCount, &summary.CommentCount, &oldest, &newest, &lastSynced); err != nil {
+			return nil, &storage.StorageError{Op: "scan_subreddit_summary", Err: err}
+		}
+		summary.OldestPost = oldest.Time
+		summary.NewestPost = newest.Time
+		summary.LastSynced = lastSynced.Time
+		summaries = append(summaries, &summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddit_summaries", Err: err}
+	}
+
+	return summaries, nil
+}
+
+// listSubredditsQuery builds the ListSubreddits query. Placeholder rows
+// created for posts have no metadata yet, so nullable columns are coalesced.
+func listSubredditsQuery(opts storage.QueryOptions) (string, []interface{}) {
+	query := `
+		SELECT COALESCE(display_name, name), COALESCE(title, ''),
+			COALESCE(description, ''), COALESCE(subscribers, 0)
+		FROM subreddits
+	`
+
+	var args []interface{}
+	if opts.Search != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(storage.NormalizeSubreddit(opts.Search))
+		args = append(args, escaped+"%")
+		query += fmt.Sprintf(` WHERE name LIKE $%d`, len(args))
+	}
+
+	sortBy := "name"
+	sortOrder := "ASC"
+	if opts.SortBy == "subscribers" {
+		sortBy = "subscribers"
+		sortOrder = "DESC"
+	}
+	if o := strings.ToUpper(opts.SortOrder); o == "ASC" || o == "DESC" {
+		sortOrder = o
+	}
+
+	limit, offset := pageBounds(opts)
+
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY %s %s, name ASC LIMIT $%d OFFSET $%d", sortBy, sortOrder, len(args)-1, len(args))
+
+	return query, args
+}
+
 // SearchPosts searches for posts using full-text search
 func (s *PostgresStorage) SearchPosts(ctx context.Context, query string, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "search_posts", Err: err}
+	}
+
+	tsQuery := "plainto_tsquery"
+	if opts.SearchMode == storage.SearchModeWeb {
+		tsQuery = "websearch_to_tsquery"
+	}
+
 	sqlQuery := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE to_tsvector('english', title || ' ' || COALESCE(selftext, '')) @@ plainto_tsquery('english', $1)
-		ORDER BY score DESC
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE to_tsvector('english', p.title || ' ' || COALESCE(p.selftext, '')) @@ ` + tsQuery + `('english', $1)
+		ORDER BY p.score DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 25
-	}
+	limit, offset := pageBounds(opts)
 
-	rows, err := s.db.QueryContext(ctx, sqlQuery, query, limit, opts.Offset)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, query, limit, offset)
 	if err != nil {
 		return nil, &storage.StorageError{Op: "search_posts", Err: err}
 	}
@@ -208,50 +545,113 @@ func (s *PostgresStorage) GetPostStats(ctx context.Context, postID string) (*sto
 	var stats storage.PostStats
 	stats.PostID = postID
 
+	ctx, cancel := s.threadQueryContext(ctx)
+	defer cancel()
+
 	err := s.db.QueryRowContext(ctx, query, postID).Scan(
 		&stats.CommentCount, &stats.MaxCommentDepth, &stats.LastUpdated,
 	)
 
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_post_stats", Err: err}
+		return nil, queryError(ctx, "get_post_stats", err)
 	}
 
 	return &stats, nil
 }
 
-// scanPosts is a helper function to scan post rows
-func (s *PostgresStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
-	var posts []*types.Post
+// GetCommentDepthHistogram counts a post's stored comments at each depth,
+// keyed by depth (0 for top-level). A post with no stored comments yields
+// an empty map.
+func (s *PostgresStorage) GetCommentDepthHistogram(ctx context.Context, postID string) (map[int]int, error) {
+	query := `
+		SELECT depth, COUNT(*)
+		FROM comments
+		WHERE post_id = $1
+		GROUP BY depth
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comment_depth_histogram", Err: err}
+	}
+	defer rows.Close()
 
+	histogram := make(map[int]int)
 	for rows.Next() {
-		var post types.Post
-		var rawJSON []byte
-		var upvoteRatio sql.NullFloat64
-		var isVideo bool
-		var createdAt time.Time
-		var editedUTC sql.NullTime
-
-		err := rows.Scan(
-			&post.ID, &post.Subreddit, &post.Author, &post.Title,
-			&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
-			&post.NumComments, &createdAt, &editedUTC,
-			&post.IsSelf, &isVideo, &rawJSON,
+		var depth, count int
+		if err := rows.Scan(&depth, &count); err != nil {
+			return nil, &storage.StorageError{Op: "scan_depth_histogram", Err: err}
+		}
+		histogram[depth] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_depth_histogram", Err: err}
+	}
+
+	return histogram, nil
+}
+
+// GetSubredditStatsRange returns post/comment counts, total post score and
+// unique authors for a subreddit between start and end (zero times are unbounded)
+func (s *PostgresStorage) GetSubredditStatsRange(ctx context.Context, subreddit string, start, end time.Time) (*storage.SubredditStats, error) {
+	query := `
+		WITH window_posts AS (
+			SELECT author, score
+			FROM posts
+			WHERE subreddit = $1
+			  AND ($2::timestamp IS NULL OR created_utc >= $2)
+			  AND ($3::timestamp IS NULL OR created_utc <= $3)
+		),
+		window_comments AS (
+			SELECT c.author
+			FROM comments c
+			WHERE c.subreddit = $1
+			  AND ($2::timestamp IS NULL OR c.created_utc >= $2)
+			  AND ($3::timestamp IS NULL OR c.created_utc <= $3)
 		)
+		SELECT
+			(SELECT COUNT(*) FROM window_posts),
+			(SELECT COUNT(*) FROM window_comments),
+			(SELECT COALESCE(SUM(score), 0) FROM window_posts),
+			(SELECT COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY score), 0) FROM window_posts),
+			(SELECT COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY score), 0) FROM window_posts),
+			(SELECT COUNT(DISTINCT author) FROM (
+				SELECT author FROM window_posts
+				UNION
+				SELECT author FROM window_comments
+			) authors WHERE author IS NOT NULL AND author != '' AND author != '[deleted]')
+	`
 
-		if err != nil {
-			return nil, &storage.StorageError{Op: "scan_post", Err: err}
-		}
+	stats := storage.SubredditStats{
+		Subreddit: subreddit,
+		Start:     start,
+		End:       end,
+	}
+
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(subreddit), timePtrOrNil(start.UTC(), true), timePtrOrNil(end.UTC(), true)).Scan(
+		&stats.PostCount, &stats.CommentCount, &stats.TotalScore,
+		&stats.MedianScore, &stats.P90Score, &stats.UniqueAuthors,
+	)
+
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_subreddit_stats_range", Err: err}
+	}
 
-		post.CreatedUTC = timeToUnixFloat(createdAt)
+	return &stats, nil
+}
+
+// scanPosts is a helper function to scan post rows
+func (s *PostgresStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
+	var posts []*types.Post
 
-		// Reconstruct Edited field
-		if editedUTC.Valid {
-			post.Edited = types.Edited{IsEdited: true, Timestamp: timeToUnixFloat(editedUTC.Time)}
-		} else {
-			post.Edited = types.Edited{IsEdited: false}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		posts = append(posts, &post)
+		posts = append(posts, post)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -260,3 +660,37 @@ func (s *PostgresStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
 
 	return posts, nil
 }
+
+// scanPost scans a post row selected as postColumns followed by any extra
+// destinations
+func scanPost(rows *sql.Rows, extra ...interface{}) (*types.Post, error) {
+	var post types.Post
+	var rawJSON []byte
+	var upvoteRatio sql.NullFloat64
+	var isVideo bool
+	var createdAt time.Time
+	var editedUTC sql.NullTime
+
+	dest := []interface{}{
+		&post.ID, &post.Subreddit, &post.Author, &post.Title,
+		&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
+		&post.NumComments, &createdAt, &editedUTC,
+		&post.IsSelf, &isVideo, &rawJSON, &post.Stickied,
+		&post.LinkFlairText, &post.AuthorFlairText, &post.Over18, &post.Locked,
+	}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, &storage.StorageError{Op: "scan_post", Err: err}
+	}
+
+	post.CreatedUTC = timeToUnixFloat(createdAt)
+
+	// Reconstruct Edited field
+	if editedUTC.Valid {
+		post.Edited = types.Edited{IsEdited: true, Timestamp: timeToUnixFloat(editedUTC.Time)}
+	} else {
+		post.Edited = types.Edited{IsEdited: false}
+	}
+
+	return &post, nil
+}
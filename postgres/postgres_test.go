@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/lib/pq"
 	"github.com/jamesprial/go-reddit-storage"
 )
 
@@ -296,4 +298,189 @@ func TestPostgresStorage_SaveAndGetComments(t *testing.T) {
 	if len(retrieved) != 2 {
 		t.Errorf("Expected 2 comments, got %d", len(retrieved))
 	}
-}
\ No newline at end of file
+}
+func TestReadOnlyConnString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"postgres://user@localhost/reddit", "postgres://user@localhost/reddit?default_transaction_read_only=on"},
+		{"postgres://user@localhost/reddit?sslmode=disable", "postgres://user@localhost/reddit?sslmode=disable&default_transaction_read_only=on"},
+		{"host=localhost dbname=reddit", "host=localhost dbname=reddit default_transaction_read_only=on"},
+	}
+
+	for _, tt := range tests {
+		if got := readOnlyConnString(tt.in); got != tt.want {
+			t.Errorf("readOnlyConnString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPostgresStorage_TimestamplessEdit(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "edit_post", Name: "t3_edit_post"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Edited comments",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	// Reddit reports "edited": true with no timestamp for some edits
+	comments := []*types.Comment{
+		{
+			ThingData: types.ThingData{ID: "edit_flag", Name: "t1_edit_flag"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_edit_post",
+			ParentID:  "t3_edit_post",
+			Body:      "Edited, no timestamp",
+			Edited:    types.Edited{IsEdited: true},
+		},
+		{
+			ThingData: types.ThingData{ID: "edit_none", Name: "t1_edit_none"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Add(time.Minute).Unix())},
+			LinkID:    "t3_edit_post",
+			ParentID:  "t3_edit_post",
+			Body:      "Never edited",
+		},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	got, err := store.GetCommentsByPost(ctx, "edit_post")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+
+	edited := make(map[string]types.Edited)
+	for _, comment := range got {
+		edited[comment.ID] = comment.Edited
+	}
+
+	if !edited["edit_flag"].IsEdited || edited["edit_flag"].Timestamp != 0 {
+		t.Errorf("Expected timestampless edit to be kept, got %+v", edited["edit_flag"])
+	}
+	if edited["edit_none"].IsEdited {
+		t.Errorf("Expected unedited comment, got %+v", edited["edit_none"])
+	}
+}
+
+// sqlStateError simulates a driver error carrying a SQLSTATE
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestWithTxRetry(t *testing.T) {
+	store := &PostgresStorage{opts: Options{TxRetries: 3, TxRetryBackoff: time.Millisecond}}
+	ctx := context.Background()
+
+	calls := 0
+	err := store.withTxRetry(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return &storage.StorageError{Op: "insert_comment", Err: sqlStateError("40001")}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on third attempt, got err=%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.withTxRetry(ctx, func() error {
+		calls++
+		return fmt.Errorf("commit: %w", &pq.Error{Code: "40P01"})
+	})
+	if err == nil || calls != 4 {
+		t.Errorf("Expected deadlock to be retried until failing after 4 attempts, got err=%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.withTxRetry(ctx, func() error {
+		calls++
+		return sqlStateError("23505") // unique_violation
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected non-retryable error to skip retries, got err=%v after %d calls", err, calls)
+	}
+
+	store.opts.TxRetries = -1
+	calls = 0
+	err = store.withTxRetry(ctx, func() error {
+		calls++
+		return sqlStateError("40001")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected negative TxRetries to disable retrying, got err=%v after %d calls", err, calls)
+	}
+}
+
+func TestPostgresStorage_SavePostWithNullByte(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "nullbyte", Name: "t3_nullbyte"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "testuser",
+		Title:     "Broken\x00title",
+		SelfText:  "bad \xff bytes",
+	}
+
+	// Without sanitizing, Postgres rejects the NUL byte
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post with NUL byte: %v", err)
+	}
+
+	retrieved, err := store.GetPost(ctx, "nullbyte")
+	if err != nil {
+		t.Fatalf("Failed to get post: %v", err)
+	}
+	if retrieved.Title != "Brokentitle" || retrieved.SelfText != "bad � bytes" {
+		t.Errorf("Expected sanitized text, got title %q selftext %q", retrieved.Title, retrieved.SelfText)
+	}
+	if post.Title != "Broken\x00title" {
+		t.Errorf("Expected caller's post to be left unmodified, got %q", post.Title)
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"nul\x00byte", "nulbyte"},
+		{"bad \xff\xfe bytes", "bad � bytes"},
+		{"\x00\xff", "�"},
+		{"emoji 🎉 ok", "emoji 🎉 ok"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeText(tt.in); got != tt.want {
+			t.Errorf("sanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	store := &PostgresStorage{opts: *DefaultOptions()}
+	comment := &types.Comment{Body: "hi\x00"}
+	if clean := store.sanitizeComment(comment); clean.Body != "hi" || comment.Body != "hi\x00" {
+		t.Errorf("Expected sanitized copy, got %q (original %q)", clean.Body, comment.Body)
+	}
+
+	store.opts.SanitizeText = false
+	if got := store.sanitizeComment(comment); got != comment {
+		t.Errorf("Expected comment untouched with SanitizeText off")
+	}
+}
@@ -14,10 +14,15 @@ import (
 
 // SavePost saves or updates a single post
 func (s *PostgresStorage) SavePost(ctx context.Context, post *types.Post) error {
+	if err := s.checkWritable("save_post"); err != nil {
+		return err
+	}
+
+	post = s.sanitizePost(post)
+
 	// Ensure subreddit exists first
 	if post.Subreddit != "" {
-		sub := &types.SubredditData{DisplayName: post.Subreddit}
-		if err := s.SaveSubreddit(ctx, sub); err != nil {
+		if err := s.ensureSubreddit(ctx, post.Subreddit); err != nil {
 			return err
 		}
 	}
@@ -31,16 +36,22 @@ func (s *PostgresStorage) SavePost(ctx context.Context, post *types.Post) error
 		INSERT INTO posts (
 			id, subreddit, author, title, selftext, url,
 			score, upvote_ratio, num_comments, created_utc,
-			edited_utc, is_self, is_video, raw_json, last_updated
+			edited_utc, is_self, is_video, raw_json, stickied, removed_at,
+			link_flair_text, author_flair_text, over_18, locked, last_updated
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW()
 		)
-		ON CONFLICT (id) DO UPDATE SET
+		ON CONFLICT (id) DO UPDATE SET ` + s.firstSeenUpdates() + s.selftextUpdates() + `
 			score = EXCLUDED.score,
 			num_comments = EXCLUDED.num_comments,
 			edited_utc = EXCLUDED.edited_utc,
+			stickied = EXCLUDED.stickied,
+			link_flair_text = EXCLUDED.link_flair_text,
+			author_flair_text = EXCLUDED.author_flair_text,
+			over_18 = EXCLUDED.over_18,
+			locked = EXCLUDED.locked,
 			last_updated = NOW(),
-			raw_json = EXCLUDED.raw_json
+			` + removedUpdates + `
 	`
 
 	createdAt, _ := unixFloatToTime(post.CreatedUTC)
@@ -49,12 +60,25 @@ func (s *PostgresStorage) SavePost(ctx context.Context, post *types.Post) error
 		hasEdited = false
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
-		post.ID, post.Subreddit, post.Author, post.Title,
+	// Moderator fields aren't saved here, but removal is judged the same way
+	stored := &storage.StoredPost{Post: post}
+
+	save := statement{query: query, args: []interface{}{
+		post.ID, storage.NormalizeSubreddit(post.Subreddit), storage.NormalizeAuthor(post.Author), post.Title,
 		post.SelfText, post.URL, post.Score, nil, // upvote_ratio not in API wrapper types.Post yet
 		post.NumComments, createdAt, timePtrOrNil(editedAt, hasEdited),
 		post.IsSelf, false, rawJSON, // is_video not in API wrapper types.Post yet
-	)
+		post.Stickied, removedAt(stored),
+		post.LinkFlairText, post.AuthorFlairText, post.Over18, post.Locked,
+	}}
+
+	stmts := []statement{save}
+	if revision := s.postRevision(stored); revision != nil {
+		stmts = []statement{*revision, save}
+	}
+	stmts = append(stmts, postMediaStatements(post.ID, storage.ParsePostMedia(post))...)
+
+	err = s.execAllWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntityPost, post.ID, stmts...)
 
 	if err != nil {
 		return &storage.StorageError{Op: "save_post", Err: err}
@@ -65,6 +89,34 @@ func (s *PostgresStorage) SavePost(ctx context.Context, post *types.Post) error
 
 // SavePosts saves or updates multiple posts in a transaction
 func (s *PostgresStorage) SavePosts(ctx context.Context, posts []*types.Post) error {
+	if err := s.checkWritable("save_posts"); err != nil {
+		return err
+	}
+
+	stored := make([]*storage.StoredPost, len(posts))
+	for i, post := range posts {
+		stored[i] = &storage.StoredPost{Post: post}
+	}
+
+	return s.withTxRetry(ctx, func() error {
+		return s.saveStoredPosts(ctx, stored)
+	})
+}
+
+// SaveStoredPosts saves or updates multiple posts along with their moderator
+// fields in a transaction. Moderator fields that are nil leave any stored
+// value untouched.
+func (s *PostgresStorage) SaveStoredPosts(ctx context.Context, posts []*storage.StoredPost) error {
+	if err := s.checkWritable("save_stored_posts"); err != nil {
+		return err
+	}
+
+	return s.withTxRetry(ctx, func() error {
+		return s.saveStoredPosts(ctx, posts)
+	})
+}
+
+func (s *PostgresStorage) saveStoredPosts(ctx context.Context, posts []*storage.StoredPost) error {
 	if len(posts) == 0 {
 		return nil
 	}
@@ -80,17 +132,36 @@ func (s *PostgresStorage) SavePosts(ctx context.Context, posts []*types.Post) er
 		INSERT INTO posts (
 			id, subreddit, author, title, selftext, url,
 			score, upvote_ratio, num_comments, created_utc,
-			edited_utc, is_self, is_video, raw_json, last_updated
+			edited_utc, is_self, is_video, raw_json, stickied,
+			num_reports, removed_by_category, account,
+			media_type, media_width, media_height, more_comments_count, removed_at,
+			crosspost_parent_id, link_flair_text, author_flair_text,
+			over_18, locked, spoiler, last_updated
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26,
+			$27, $28, $29, NOW()
 		)
-		ON CONFLICT (id) DO UPDATE SET
+		ON CONFLICT (id) DO UPDATE SET ` + s.firstSeenUpdates() + s.selftextUpdates() + `
 			score = EXCLUDED.score,
 			num_comments = EXCLUDED.num_comments,
 			upvote_ratio = EXCLUDED.upvote_ratio,
 			edited_utc = EXCLUDED.edited_utc,
+			stickied = EXCLUDED.stickied,
+			link_flair_text = EXCLUDED.link_flair_text,
+			author_flair_text = EXCLUDED.author_flair_text,
+			over_18 = EXCLUDED.over_18,
+			locked = EXCLUDED.locked,
+			spoiler = COALESCE(EXCLUDED.spoiler, posts.spoiler),
+			num_reports = COALESCE(EXCLUDED.num_reports, posts.num_reports),
+			removed_by_category = COALESCE(EXCLUDED.removed_by_category, posts.removed_by_category),
+			account = COALESCE(EXCLUDED.account, posts.account),
+			media_type = COALESCE(EXCLUDED.media_type, posts.media_type),
+			media_width = COALESCE(EXCLUDED.media_width, posts.media_width),
+			media_height = COALESCE(EXCLUDED.media_height, posts.media_height),
+			more_comments_count = COALESCE(EXCLUDED.more_comments_count, posts.more_comments_count),
+			crosspost_parent_id = COALESCE(EXCLUDED.crosspost_parent_id, posts.crosspost_parent_id),
 			last_updated = NOW(),
-			raw_json = EXCLUDED.raw_json
+			` + removedUpdates + `
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
@@ -102,18 +173,20 @@ func (s *PostgresStorage) SavePosts(ctx context.Context, posts []*types.Post) er
 	// Ensure subreddits exist
 	subreddits := make(map[string]bool)
 	for _, post := range posts {
-		if post.Subreddit != "" && !subreddits[post.Subreddit] {
-			sub := &types.SubredditData{DisplayName: post.Subreddit}
-			if err := s.SaveSubreddit(ctx, sub); err != nil {
+		key := storage.NormalizeSubreddit(post.Subreddit)
+		if key != "" && !subreddits[key] {
+			if err := s.ensureSubreddit(ctx, post.Subreddit); err != nil {
 				return err
 			}
-			subreddits[post.Subreddit] = true
+			subreddits[key] = true
 		}
 	}
 
 	// Insert posts
 	for _, post := range posts {
-		rawJSON, err := json.Marshal(post)
+		post := s.sanitizeStoredPost(post)
+
+		rawJSON, err := json.Marshal(post.Post)
 		if err != nil {
 			return &storage.StorageError{Op: "marshal_post", Err: err}
 		}
@@ -124,16 +197,39 @@ func (s *PostgresStorage) SavePosts(ctx context.Context, posts []*types.Post) er
 			hasEdited = false
 		}
 
+		mediaType, mediaWidth, mediaHeight := mediaValues(post.Media)
+
+		if revision := s.postRevision(post); revision != nil {
+			if _, err := tx.ExecContext(ctx, revision.query, revision.args...); err != nil {
+				return &storage.StorageError{Op: "insert_post_revision", Err: err}
+			}
+		}
+
 		_, err = stmt.ExecContext(ctx,
-			post.ID, post.Subreddit, post.Author, post.Title,
+			post.ID, storage.NormalizeSubreddit(post.Subreddit), storage.NormalizeAuthor(post.Author), post.Title,
 			post.SelfText, post.URL, post.Score, nil, // upvote_ratio not in API wrapper types.Post yet
 			post.NumComments, createdAt, timePtrOrNil(editedAt, hasEdited),
 			post.IsSelf, false, rawJSON, // is_video not in API wrapper types.Post yet
+			post.Stickied,
+			post.NumReports, post.RemovedByCategory, nullIfEmpty(post.Account),
+			mediaType, mediaWidth, mediaHeight, post.MoreCommentsCount, removedAt(post),
+			nullIfEmpty(post.CrosspostParentID), post.LinkFlairText, post.AuthorFlairText,
+			post.Over18, post.Locked, post.Spoiler,
 		)
 
 		if err != nil {
 			return &storage.StorageError{Op: "insert_post", Err: err}
 		}
+
+		for _, media := range postMediaStatements(post.ID, storage.ResolvePostMedia(post)) {
+			if _, err := tx.ExecContext(ctx, media.query, media.args...); err != nil {
+				return &storage.StorageError{Op: "insert_post_media", Err: err}
+			}
+		}
+
+		if err := s.appendOutbox(ctx, tx, storage.OutboxOpSave, storage.OutboxEntityPost, post.ID); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -146,10 +242,9 @@ func (s *PostgresStorage) SavePosts(ctx context.Context, posts []*types.Post) er
 // GetPost retrieves a single post by ID
 func (s *PostgresStorage) GetPost(ctx context.Context, id string) (*types.Post, error) {
 	query := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE id = $1
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.id = $1
 	`
 
 	var post types.Post
@@ -164,7 +259,8 @@ func (s *PostgresStorage) GetPost(ctx context.Context, id string) (*types.Post,
 		&post.ID, &post.Subreddit, &post.Author, &post.Title,
 		&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
 		&post.NumComments, &createdAt, &editedUTC,
-		&post.IsSelf, &isVideo, &rawJSON,
+		&post.IsSelf, &isVideo, &rawJSON, &post.Stickied,
+		&post.LinkFlairText, &post.AuthorFlairText, &post.Over18, &post.Locked,
 	)
 
 	post.CreatedUTC = timeToUnixFloat(createdAt)
@@ -177,7 +273,7 @@ func (s *PostgresStorage) GetPost(ctx context.Context, id string) (*types.Post,
 	}
 
 	if err == sql.ErrNoRows {
-		return nil, &storage.StorageError{Op: "get_post", Err: fmt.Errorf("post not found: %s", id)}
+		return nil, &storage.StorageError{Op: "get_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
 	}
 
 	if err != nil {
@@ -189,35 +285,364 @@ func (s *PostgresStorage) GetPost(ctx context.Context, id string) (*types.Post,
 
 // GetPostsBySubreddit retrieves posts from a subreddit with filtering options
 func (s *PostgresStorage) GetPostsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+	}
+
+	query, args := postsQuery(postColumns, storage.PostFilter{Subreddit: subreddit}, opts)
+
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetPostCounts returns the ID, score and comment count of a subreddit's
+// posts, filtered, sorted and paginated by opts as for GetPostsBySubreddit,
+// without reading their raw JSON
+func (s *PostgresStorage) GetPostCounts(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*storage.PostCounts, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_post_counts", Err: err}
+	}
+
+	query, args := postsQuery("p.id, p.score, p.num_comments", storage.PostFilter{Subreddit: subreddit}, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_post_counts", Err: err}
+	}
+	defer rows.Close()
+
+	var counts []*storage.PostCounts
+	for rows.Next() {
+		var c storage.PostCounts
+		if err := rows.Scan(&c.ID, &c.Score, &c.NumComments); err != nil {
+			return nil, &storage.StorageError{Op: "scan_post_counts", Err: err}
+		}
+		counts = append(counts, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_post_counts", Err: err}
+	}
+
+	return counts, nil
+}
+
+// FindPosts retrieves posts matching every set field of filter, combining a
+// text search with the structural filters in a single query. Dates, sorting
+// and pagination come from opts as for GetPostsBySubreddit, and
+// opts.SearchMode selects how filter.TextQuery is parsed.
+func (s *PostgresStorage) FindPosts(ctx context.Context, filter storage.PostFilter, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "find_posts", Err: err}
+	}
+
+	query, args := postsQuery(postColumns, filter, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "find_posts", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetStoredPost retrieves a single post by ID along with the columns
+// storage keeps beyond types.Post, including whether it has been removed
+func (s *PostgresStorage) GetStoredPost(ctx context.Context, id string) (*storage.StoredPost, error) {
+	query := `
+		SELECT ` + storedPostColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.id = $1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_post", Err: err}
+	}
+	defer rows.Close()
+
+	posts, err := scanStoredPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, &storage.StorageError{Op: "get_stored_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
+	}
+
+	return posts[0], nil
+}
+
+// GetStoredPostsBySubreddit retrieves posts from a subreddit along with their
+// moderator fields
+func (s *PostgresStorage) GetStoredPostsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*storage.StoredPost, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_posts_by_subreddit", Err: err}
+	}
+
+	query, args := postsQuery(storedPostColumns, storage.PostFilter{Subreddit: subreddit}, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_posts_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	return scanStoredPosts(rows)
+}
+
+// scanStoredPosts scans rows selecting storedPostColumns
+func scanStoredPosts(rows *sql.Rows) ([]*storage.StoredPost, error) {
+	var posts []*storage.StoredPost
+	for rows.Next() {
+		var numReports sql.NullInt64
+		var removedByCategory sql.NullString
+		var account sql.NullString
+		var mediaType sql.NullString
+		var mediaWidth, mediaHeight sql.NullInt64
+		var moreComments sql.NullInt64
+		var removedAt sql.NullTime
+		var crosspostParent sql.NullString
+		var spoiler sql.NullBool
+
+		post, err := scanPost(rows, &numReports, &removedByCategory, &account, &mediaType, &mediaWidth, &mediaHeight, &moreComments, &removedAt, &crosspostParent, &spoiler)
+		if err != nil {
+			return nil, err
+		}
+
+		stored := &storage.StoredPost{Post: post, Account: account.String, CrosspostParentID: crosspostParent.String}
+		if numReports.Valid {
+			n := int(numReports.Int64)
+			stored.NumReports = &n
+		}
+		if removedByCategory.Valid {
+			stored.RemovedByCategory = &removedByCategory.String
+		}
+		if mediaType.Valid {
+			stored.Media = &storage.MediaInfo{
+				Type:   mediaType.String,
+				Width:  int(mediaWidth.Int64),
+				Height: int(mediaHeight.Int64),
+			}
+		}
+		if moreComments.Valid {
+			n := int(moreComments.Int64)
+			stored.MoreCommentsCount = &n
+		}
+		if removedAt.Valid {
+			stored.RemovedAt = &removedAt.Time
+		}
+		if spoiler.Valid {
+			stored.Spoiler = &spoiler.Bool
+		}
+
+		posts = append(posts, stored)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_posts", Err: err}
+	}
+
+	return posts, nil
+}
+
+// GetPostsUpdatedSince retrieves posts saved at or after since, oldest save
+// first, so an external system can poll for changes by passing the time of
+// its previous poll. Every save counts, whether or not any value changed.
+// opts.Subreddit restricts the posts and opts.Limit/Offset paginate; other
+// options are ignored.
+func (s *PostgresStorage) GetPostsUpdatedSince(ctx context.Context, since time.Time, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_updated_since", Err: err}
+	}
+
+	// last_updated is a timestamp without time zone set from NOW(), so it
+	// holds session-local time; casting through timestamptz converts since
+	// the same way
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.last_updated >= $1::timestamptz::timestamp
+	`
+	args := []interface{}{since}
+	argPos := 2
+
+	if opts.Subreddit != "" {
+		query += fmt.Sprintf(" AND p.subreddit = $%d", argPos)
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+		argPos++
+	}
+
+	limit, offset := pageBounds(opts)
+	query += fmt.Sprintf(" ORDER BY p.last_updated, p.id LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_updated_since", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetCrossposts retrieves the stored crossposts of a post, newest first. A
+// post with no stored crossposts returns none.
+func (s *PostgresStorage) GetCrossposts(ctx context.Context, postID string) ([]*types.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.crosspost_parent_id = $1
+		ORDER BY p.created_utc DESC, p.id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_crossposts", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// postColumns lists the posts columns read by scanPost, in scan order. It
+// selects from postsFrom so the subreddit comes back under its canonical name.
+const postColumns = `p.id, COALESCE(NULLIF(sr.display_name, ''), p.subreddit), p.author, p.title,
+		       p.selftext, p.url, p.score, p.upvote_ratio, p.num_comments, p.created_utc,
+		       p.edited_utc, p.is_self, p.is_video, p.raw_json, p.stickied,
+		       p.link_flair_text, p.author_flair_text, p.over_18, p.locked`
+
+// storedPostColumns extends postColumns with the columns scanStoredPosts
+// reads into a storage.StoredPost
+const storedPostColumns = postColumns + `, p.num_reports, p.removed_by_category, p.account,
+		       p.media_type, p.media_width, p.media_height, p.more_comments_count, p.removed_at,
+		       p.crosspost_parent_id, p.spoiler`
+
+// postsFrom joins posts (aliased p) to the subreddit row holding the
+// canonical display name
+const postsFrom = `posts p LEFT JOIN subreddits sr ON sr.name = p.subreddit`
+
+// archivedCommentsCount counts the stored comments of post p for the
+// "archived_comments" sort. It is evaluated per candidate post, so sorting a
+// large subreddit this way costs one indexed comments lookup per post.
+const archivedCommentsCount = `(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id)`
+
+// postsQuery builds the filtered, sorted and paginated query behind
+// GetPostsBySubreddit and FindPosts, selecting the given columns
+func postsQuery(columns string, filter storage.PostFilter, opts storage.QueryOptions) (string, []interface{}) {
 	// Build query with options
 	query := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE subreddit = $1
+		SELECT ` + columns + `
+		FROM ` + postsFrom + `
+		WHERE 1=1
 	`
 
 	var args []interface{}
-	args = append(args, subreddit)
-	argPos := 2
+	argPos := 1
+
+	if filter.Subreddit != "" {
+		query += fmt.Sprintf(" AND p.subreddit = $%d", argPos)
+		args = append(args, storage.NormalizeSubreddit(filter.Subreddit))
+		argPos++
+	}
+
+	if filter.Author != "" {
+		query += fmt.Sprintf(" AND p.author = $%d", argPos)
+		args = append(args, filter.Author)
+		argPos++
+	}
+
+	if filter.MinScore != nil {
+		query += fmt.Sprintf(" AND p.score >= $%d", argPos)
+		args = append(args, *filter.MinScore)
+		argPos++
+	}
+
+	if filter.TextQuery != "" {
+		tsQuery := "plainto_tsquery"
+		if opts.SearchMode == storage.SearchModeWeb {
+			tsQuery = "websearch_to_tsquery"
+		}
+		query += fmt.Sprintf(" AND to_tsvector('english', p.title || ' ' || COALESCE(p.selftext, '')) @@ %s('english', $%d)", tsQuery, argPos)
+		args = append(args, filter.TextQuery)
+		argPos++
+	}
+
+	if filter.SavedSource != "" {
+		query += fmt.Sprintf(" AND p.id IN (SELECT item_id FROM saved_items WHERE source = $%d AND NOT is_comment)", argPos)
+		args = append(args, filter.SavedSource)
+		argPos++
+	}
 
 	// Add date filters if provided
 	if !opts.StartDate.IsZero() {
-		query += fmt.Sprintf(" AND created_utc >= $%d", argPos)
+		query += fmt.Sprintf(" AND p.created_utc >= $%d", argPos)
 		args = append(args, opts.StartDate)
 		argPos++
 	}
 
 	if !opts.EndDate.IsZero() {
-		query += fmt.Sprintf(" AND created_utc <= $%d", argPos)
+		query += fmt.Sprintf(" AND p.created_utc <= $%d", argPos)
 		args = append(args, opts.EndDate)
 		argPos++
 	}
 
-	// Add sorting
-	sortBy := opts.SortBy
-	if sortBy == "" {
-		sortBy = "created_utc"
+	if opts.RemovedOnly {
+		query += " AND p.removed_by_category IS NOT NULL"
+	}
+
+	if opts.ExcludeRemoved {
+		query += " AND p.removed_at IS NULL"
+	}
+
+	if opts.Account != "" {
+		query += fmt.Sprintf(" AND p.account = $%d", argPos)
+		args = append(args, opts.Account)
+		argPos++
+	}
+
+	if opts.Flair != "" {
+		query += fmt.Sprintf(" AND LOWER(p.link_flair_text) = LOWER($%d)", argPos)
+		args = append(args, opts.Flair)
+		argPos++
+	}
+
+	if opts.MinComments != nil {
+		query += fmt.Sprintf(" AND p.num_comments >= $%d", argPos)
+		args = append(args, *opts.MinComments)
+		argPos++
+	}
+
+	if opts.ExcludeStickied {
+		query += " AND NOT p.stickied"
+	}
+
+	if opts.OnlyStickied {
+		query += " AND p.stickied"
+	}
+
+	if opts.ExcludeNSFW {
+		query += " AND NOT p.over_18"
+	}
+
+	if opts.NonEmptySelfText {
+		query += " AND p.is_self AND p.selftext <> ''"
+	}
+
+	if opts.HasMedia {
+		conds := []string{"p.is_video"}
+		for _, pattern := range storage.MediaURLPatterns {
+			conds = append(conds, fmt.Sprintf("p.url ILIKE $%d", argPos))
+			args = append(args, pattern)
+			argPos++
+		}
+		query += " AND (" + strings.Join(conds, " OR ") + ")"
 	}
 
 	sortOrder := strings.ToUpper(opts.SortOrder)
@@ -225,13 +650,33 @@ func (s *PostgresStorage) GetPostsBySubreddit(ctx context.Context, subreddit str
 		sortOrder = "DESC"
 	}
 
+	// Keyset pagination continues strictly after the cursor in created
+	// order, using the ID to break ties between posts created together
+	if opts.After != nil {
+		cmp := "<"
+		if sortOrder == "ASC" {
+			cmp = ">"
+		}
+		afterTime, _ := unixFloatToTime(opts.After.CreatedUTC)
+		query += fmt.Sprintf(" AND (p.created_utc, p.id) %s ($%d, $%d)", cmp, argPos, argPos+1)
+		args = append(args, afterTime, opts.After.ID)
+		argPos += 2
+	}
+
+	// Add sorting
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = "created_utc"
+	}
+
 	// Validate sort column to prevent SQL injection
 	validSortColumns := map[string]bool{
-		"created_utc":  true,
-		"created":      true,
-		"score":        true,
-		"num_comments": true,
-		"comments":     true,
+		"created_utc":       true,
+		"created":           true,
+		"score":             true,
+		"num_comments":      true,
+		"comments":          true,
+		"archived_comments": true,
 	}
 
 	if sortBy == "comments" {
@@ -244,23 +689,347 @@ func (s *PostgresStorage) GetPostsBySubreddit(ctx context.Context, subreddit str
 		sortBy = "created_utc"
 	}
 
-	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
-
 	// Add pagination
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 25
+	limit, offset := pageBounds(opts)
+
+	if opts.After != nil {
+		query += fmt.Sprintf(" ORDER BY p.created_utc %s, p.id %s", sortOrder, sortOrder)
+		query += fmt.Sprintf(" LIMIT $%d", argPos)
+		args = append(args, limit)
+		return query, args
+	}
+
+	sortExpr := "p." + sortBy
+	if sortBy == "archived_comments" {
+		sortExpr = archivedCommentsCount
 	}
 
+	// The ID tie-breaker keeps ordering stable, so a first page fetched
+	// without a cursor lines up with the cursor pages that follow it
+	query += fmt.Sprintf(" ORDER BY %s %s, p.id %s", sortExpr, sortOrder, sortOrder)
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPos, argPos+1)
-	args = append(args, limit, opts.Offset)
+	args = append(args, limit, offset)
+
+	return query, args
+}
+
+// GetLatestPost retrieves the most recently created post stored for a subreddit
+func (s *PostgresStorage) GetLatestPost(ctx context.Context, subreddit string) (*types.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.subreddit = $1
+		ORDER BY p.created_utc DESC
+		LIMIT 1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, storage.NormalizeSubreddit(subreddit))
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_latest_post", Err: err}
+	}
+	defer rows.Close()
+
+	posts, err := s.scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, &storage.StorageError{Op: "get_latest_post", Err: fmt.Errorf("post %w for subreddit: %s", storage.ErrNotFound, subreddit)}
+	}
+
+	return posts[0], nil
+}
+
+// GetStoredNumComments returns the num_comments last stored for each post in
+// ids, so the archiver can tell which posts are stored at all. IDs that
+// aren't stored are absent from the result.
+func (s *PostgresStorage) GetStoredNumComments(ctx context.Context, ids []string) (map[string]int, error) {
+	return s.countsByID(ctx, "get_stored_num_comments", "num_comments", ids)
+}
+
+// GetCommentsFetched returns the num_comments each of ids had when its
+// comments were last fetched and saved, as recorded by MarkCommentsFetched.
+// Posts whose comments were never fetched are absent from the result.
+func (s *PostgresStorage) GetCommentsFetched(ctx context.Context, ids []string) (map[string]int, error) {
+	return s.countsByID(ctx, "get_comments_fetched", "comments_fetched_num", ids)
+}
+
+// MarkCommentsFetched records that postID's comments were fetched and saved
+// while it had numComments comments
+func (s *PostgresStorage) MarkCommentsFetched(ctx context.Context, postID string, numComments int) error {
+	if err := s.checkWritable("mark_comments_fetched"); err != nil {
+		return err
+	}
+
+	err := s.withTxRetry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx, "UPDATE posts SET comments_fetched_num = $1 WHERE id = $2", numComments, postID)
+		return err
+	})
+	if err != nil {
+		return &storage.StorageError{Op: "mark_comments_fetched", Err: err}
+	}
+	return nil
+}
+
+// countsByID reads an integer column of the posts in ids, keyed by ID.
+// Posts that aren't stored or hold NULL are absent from the result.
+func (s *PostgresStorage) countsByID(ctx context.Context, op, column string, ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+
+	for start := 0; start < len(ids); start += idChunkSize {
+		chunk := ids[start:min(start+idChunkSize, len(ids))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+
+		query := "SELECT id, " + column + " FROM posts WHERE id IN (" + strings.Join(placeholders, ", ") + ") AND " + column + " IS NOT NULL"
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, &storage.StorageError{Op: op, Err: err}
+		}
+
+		for rows.Next() {
+			var id string
+			var count int
+			if err := rows.Scan(&id, &count); err != nil {
+				rows.Close()
+				return nil, &storage.StorageError{Op: op, Err: err}
+			}
+			counts[id] = count
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, &storage.StorageError{Op: op, Err: err}
+		}
+	}
+
+	return counts, nil
+}
+
+// idChunkSize bounds how many IDs DeletePosts, GetArchivedCommentCounts and
+// countsByID bind per statement
+const idChunkSize = 500
+
+// DeletePosts deletes posts by ID along with their comments in a single
+// transaction, returning how many posts were deleted. IDs that aren't stored
+// are ignored.
+func (s *PostgresStorage) DeletePosts(ctx context.Context, ids []string) (int, error) {
+	if err := s.checkWritable("delete_posts"); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var deleted int
+	err := s.withTxRetry(ctx, func() error {
+		var err error
+		deleted, _, err = s.deletePosts(ctx, ids)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (s *PostgresStorage) deletePosts(ctx context.Context, ids []string) (posts, comments int, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(ids); start += idChunkSize {
+		chunk := ids[start:min(start+idChunkSize, len(ids))]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args[i] = id
+		}
+		inList := strings.Join(placeholders, ", ")
+
+		// Count before deleting; replies removed by the parent_id cascade
+		// aren't reported as affected rows
+		var chunkComments int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments WHERE post_id IN ("+inList+")", args...).Scan(&chunkComments); err != nil {
+			return 0, 0, &storage.StorageError{Op: "count_comments", Err: err}
+		}
+		comments += chunkComments
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE post_id IN ("+inList+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_comments", Err: err}
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM posts WHERE id IN ("+inList+")", args...)
+		if err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_posts", Err: err}
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_posts", Err: err}
+		}
+		posts += int(affected)
+	}
+
+	// Comments go with their posts, so the post events cover them
+	if err := s.appendOutbox(ctx, tx, storage.OutboxOpDelete, storage.OutboxEntityPost, ids...); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, &storage.StorageError{Op: "commit_transaction", Err: err}
+	}
+
+	return posts, comments, nil
+}
+
+// pruneBatchSize is how many posts PrunePosts deletes per transaction, so
+// a large prune never holds the write lock for long
+const pruneBatchSize = idChunkSize
+
+// PrunePosts deletes the posts opts selects along with their comments,
+// pruneBatchSize posts per transaction, and counts what it deleted. If a
+// batch fails or ctx is cancelled, the counts so far are returned with the
+// error; the batches already deleted stay deleted.
+func (s *PostgresStorage) PrunePosts(ctx context.Context, opts storage.PruneOptions) (*storage.PruneResult, error) {
+	if err := s.checkWritable("prune_posts"); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+	}
+
+	result := &storage.PruneResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		ids, err := s.prunableIDs(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+
+		var posts, comments int
+		err = s.withTxRetry(ctx, func() error {
+			var err error
+			posts, comments, err = s.deletePosts(ctx, ids)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		result.PostsDeleted += posts
+		result.CommentsDeleted += comments
+
+		// Nothing left that another batch would find
+		if posts == 0 || len(ids) < pruneBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// prunableIDs returns up to pruneBatchSize IDs of posts PrunePosts deletes
+func (s *PostgresStorage) prunableIDs(ctx context.Context, opts storage.PruneOptions) ([]string, error) {
+	query := "SELECT id FROM posts WHERE created_utc < $1"
+	args := []interface{}{opts.Before.UTC()}
+	if opts.Subreddit != "" {
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+		query += fmt.Sprintf(" AND subreddit = $%d", len(args))
+	}
+	if opts.KeepMinScore > 0 {
+		args = append(args, opts.KeepMinScore)
+		query += fmt.Sprintf(" AND score < $%d", len(args))
+	}
+	args = append(args, pruneBatchSize)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
 
-	// Execute query
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
 	}
 	defer rows.Close()
 
-	return s.scanPosts(rows)
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+	}
+
+	return ids, nil
+}
+
+// DeletePost deletes a post along with its comments in a single
+// transaction. A post that isn't stored returns an error wrapping
+// storage.ErrNotFound.
+func (s *PostgresStorage) DeletePost(ctx context.Context, id string) error {
+	deleted, err := s.DeletePosts(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return &storage.StorageError{Op: "delete_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
+	}
+	return nil
+}
+
+// nullIfEmpty stores an empty string as NULL so COALESCE keeps the old value
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// mediaValues returns the media_type, media_width and media_height values
+// to store for a post, all NULL when it has no media metadata
+func mediaValues(media *storage.MediaInfo) (interface{}, interface{}, interface{}) {
+	if media == nil {
+		return nil, nil, nil
+	}
+	return media.Type, media.Width, media.Height
+}
+
+// firstSeenUpdates returns the upsert assignments that refresh a re-saved
+// post's author and title, or nothing when Options.PreserveFirstSeen keeps
+// the values from the first save
+func (s *PostgresStorage) firstSeenUpdates() string {
+	if s.opts.PreserveFirstSeen {
+		return ""
+	}
+	return "author = EXCLUDED.author, title = EXCLUDED.title,"
+}
+
+// removedUpdates are the upsert assignments that record when a re-saved post
+// was first seen removed, clearing it if the post is live again, and keep
+// the archived raw JSON while it is removed
+const removedUpdates = `removed_at = CASE WHEN EXCLUDED.removed_at IS NULL THEN NULL ELSE COALESCE(posts.removed_at, EXCLUDED.removed_at) END,
+			raw_json = CASE WHEN EXCLUDED.removed_at IS NULL THEN EXCLUDED.raw_json ELSE posts.raw_json END`
+
+// removedAt returns the removed_at value to save with post: the current time
+// if Reddit shows it as removed or deleted, NULL otherwise
+func removedAt(post *storage.StoredPost) interface{} {
+	if !post.IsRemoved() {
+		return nil
+	}
+	return time.Now().UTC()
 }
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Default transaction retry settings, used when the corresponding Options
+// are zero
+const (
+	defaultTxRetries      = 3
+	defaultTxRetryBackoff = 50 * time.Millisecond
+)
+
+// withTxRetry runs a transactional write, retrying it with jittered
+// exponential backoff while it fails with a serialization failure or a
+// deadlock. Postgres aborts the whole transaction in both cases, so fn must
+// begin, run and commit its own transaction and be safe to re-run from the
+// start.
+func (s *PostgresStorage) withTxRetry(ctx context.Context, fn func() error) error {
+	retries := s.opts.TxRetries
+	if retries == 0 {
+		retries = defaultTxRetries
+	}
+
+	backoff := s.opts.TxRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isRetryableTxError(err) {
+			return err
+		}
+
+		// Sleep between half and all of the doubled delay so the
+		// conflicting transactions don't collide again in lockstep
+		delay := backoff << attempt
+		delay = delay/2 + rand.N(delay/2+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableTxError reports whether err carries SQLSTATE 40001
+// (serialization_failure) or 40P01 (deadlock_detected). *pq.Error satisfies
+// the SQLState interface.
+func isRetryableTxError(err error) bool {
+	var coded interface{ SQLState() string }
+	if !errors.As(err, &coded) {
+		return false
+	}
+
+	switch coded.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// sanitizeText strips NUL bytes and replaces invalid UTF-8 with U+FFFD.
+// Postgres rejects both in text columns, and NUL (escaped as \u0000) in jsonb.
+func sanitizeText(s string) string {
+	if utf8.ValidString(s) && !strings.Contains(s, "\x00") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+}
+
+// sanitizePost returns post with its title and selftext sanitized when
+// Options.SanitizeText is on. The caller's post is copied, never modified.
+func (s *PostgresStorage) sanitizePost(post *types.Post) *types.Post {
+	if !s.opts.SanitizeText {
+		return post
+	}
+
+	title, selfText := sanitizeText(post.Title), sanitizeText(post.SelfText)
+	if title == post.Title && selfText == post.SelfText {
+		return post
+	}
+
+	clean := *post
+	clean.Title = title
+	clean.SelfText = selfText
+	return &clean
+}
+
+// sanitizeStoredPost is sanitizePost for a StoredPost
+func (s *PostgresStorage) sanitizeStoredPost(post *storage.StoredPost) *storage.StoredPost {
+	clean := s.sanitizePost(post.Post)
+	if clean == post.Post {
+		return post
+	}
+
+	stored := *post
+	stored.Post = clean
+	return &stored
+}
+
+// sanitizeComment returns comment with its body sanitized when
+// Options.SanitizeText is on. The caller's comment is copied, never modified.
+func (s *PostgresStorage) sanitizeComment(comment *types.Comment) *types.Comment {
+	if !s.opts.SanitizeText {
+		return comment
+	}
+
+	body := sanitizeText(comment.Body)
+	if body == comment.Body {
+		return comment
+	}
+
+	clean := *comment
+	clean.Body = body
+	return &clean
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// SaveScoreSnapshots records post score snapshots in a transaction
+func (s *PostgresStorage) SaveScoreSnapshots(ctx context.Context, snapshots []*storage.ScoreSnapshot) error {
+	if err := s.checkWritable("save_score_snapshots"); err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	err := s.withTxRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO score_history (post_id, score, num_comments, recorded_at)
+			VALUES ($1, $2, $3, $4)
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, snapshot := range snapshots {
+			if _, err := stmt.ExecContext(ctx,
+				snapshot.PostID, snapshot.Score, snapshot.NumComments, snapshot.RecordedAt.UTC(),
+			); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+
+	if err != nil {
+		return &storage.StorageError{Op: "save_score_snapshots", Err: err}
+	}
+
+	return nil
+}
+
+// GetScoreHistory returns a post's score snapshots, oldest first. A post
+// without recorded snapshots returns none.
+func (s *PostgresStorage) GetScoreHistory(ctx context.Context, postID string) ([]*storage.ScoreSnapshot, error) {
+	query := `
+		SELECT post_id, score, num_comments, recorded_at
+		FROM score_history
+		WHERE post_id = $1
+		ORDER BY recorded_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_score_history", Err: err}
+	}
+	defer rows.Close()
+
+	var history []*storage.ScoreSnapshot
+	for rows.Next() {
+		var snapshot storage.ScoreSnapshot
+		if err := rows.Scan(&snapshot.PostID, &snapshot.Score, &snapshot.NumComments, &snapshot.RecordedAt); err != nil {
+			return nil, &storage.StorageError{Op: "scan_score_snapshot", Err: err}
+		}
+		history = append(history, &snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_score_history", Err: err}
+	}
+
+	return history, nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// SaveSubredditMetadata adds a snapshot of a subreddit's rules, sidebar and
+// wiki index. Saving a second snapshot with the same FetchedAt replaces it.
+func (s *PostgresStorage) SaveSubredditMetadata(ctx context.Context, metadata *storage.SubredditMetadata) error {
+	if err := s.checkWritable("save_subreddit_metadata"); err != nil {
+		return err
+	}
+
+	rules, err := json.Marshal(metadata.Rules)
+	if err != nil {
+		return &storage.StorageError{Op: "marshal_subreddit_rules", Err: err}
+	}
+	wikiPages, err := json.Marshal(metadata.WikiPages)
+	if err != nil {
+		return &storage.StorageError{Op: "marshal_wiki_pages", Err: err}
+	}
+
+	query := `
+		INSERT INTO subreddit_metadata (subreddit, fetched_at, sidebar, rules, wiki_pages)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (subreddit, fetched_at) DO UPDATE SET
+			sidebar = EXCLUDED.sidebar,
+			rules = EXCLUDED.rules,
+			wiki_pages = EXCLUDED.wiki_pages
+	`
+
+	err = s.withTxRetry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx, query,
+			storage.NormalizeSubreddit(metadata.Subreddit), metadata.FetchedAt.UTC(),
+			metadata.Sidebar, string(rules), string(wikiPages),
+		)
+		return err
+	})
+
+	if err != nil {
+		return &storage.StorageError{Op: "save_subreddit_metadata", Err: err}
+	}
+
+	return nil
+}
+
+// GetSubredditMetadata returns the most recently fetched snapshot of a
+// subreddit's rules, sidebar and wiki index
+func (s *PostgresStorage) GetSubredditMetadata(ctx context.Context, subreddit string) (*storage.SubredditMetadata, error) {
+	query := `
+		SELECT subreddit, fetched_at, sidebar, rules, wiki_pages
+		FROM subreddit_metadata
+		WHERE subreddit = $1
+		ORDER BY fetched_at DESC
+		LIMIT 1
+	`
+
+	var metadata storage.SubredditMetadata
+	var rules, wikiPages []byte
+
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(subreddit)).Scan(
+		&metadata.Subreddit, &metadata.FetchedAt, &metadata.Sidebar, &rules, &wikiPages,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, &storage.StorageError{Op: "get_subreddit_metadata", Err: fmt.Errorf("subreddit metadata %w: %s", storage.ErrNotFound, subreddit)}
+	}
+
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_subreddit_metadata", Err: err}
+	}
+
+	if err := json.Unmarshal(rules, &metadata.Rules); err != nil {
+		return nil, &storage.StorageError{Op: "unmarshal_subreddit_rules", Err: err}
+	}
+	if err := json.Unmarshal(wikiPages, &metadata.WikiPages); err != nil {
+		return nil, &storage.StorageError{Op: "unmarshal_wiki_pages", Err: err}
+	}
+
+	return &metadata, nil
+}
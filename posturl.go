@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPostURL is wrapped by errors returned for URLs that don't link
+// to a Reddit post
+var ErrInvalidPostURL = errors.New("not a reddit post URL")
+
+var (
+	postIDPattern    = regexp.MustCompile(`^[a-z0-9]+$`)
+	subredditPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// ParsePostURL extracts the subreddit and post ID from a Reddit post URL.
+// It accepts permalinks on reddit.com and its subdomains (www, old, new, np,
+// m), with or without the title slug, a trailing comment ID or query string,
+// and redd.it short links. The scheme may be omitted. Short links and
+// /comments/<id> links without a subreddit return an empty subreddit.
+func ParsePostURL(rawURL string) (subreddit, postID string, err error) {
+	invalid := func(reason string) (string, string, error) {
+		return "", "", fmt.Errorf("%w: %s: %s", ErrInvalidPostURL, reason, rawURL)
+	}
+
+	trimmed := strings.TrimSpace(rawURL)
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "https://" + trimmed
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return invalid("malformed URL")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch {
+	case host == "redd.it":
+		if len(segments) != 1 {
+			return invalid("short link has no post ID")
+		}
+		postID = segments[0]
+
+	case host == "reddit.com" || strings.HasSuffix(host, ".reddit.com"):
+		// /r/<subreddit>/comments/<id>[/<slug>[/<comment>]] or /comments/<id>
+		if len(segments) >= 2 && strings.EqualFold(segments[0], "r") {
+			subreddit = segments[1]
+			if !subredditPattern.MatchString(subreddit) {
+				return invalid("invalid subreddit")
+			}
+			segments = segments[2:]
+		}
+		if len(segments) < 2 || segments[0] != "comments" {
+			return invalid("not a post permalink")
+		}
+		postID = segments[1]
+
+	default:
+		return invalid("not a reddit host")
+	}
+
+	postID = strings.TrimPrefix(strings.ToLower(postID), "t3_")
+	if !postIDPattern.MatchString(postID) {
+		return invalid("invalid post ID")
+	}
+	return subreddit, postID, nil
+}
+
+// ArchivePostURL archives the post a Reddit URL links to, as ArchivePost
+// does. URLs ParsePostURL rejects return an error wrapping
+// ErrInvalidPostURL without calling the API. For links that don't name the
+// subreddit, the stored post's subreddit is used, falling back to r/all,
+// which Reddit redirects to the post's own subreddit.
+func (a *Archiver) ArchivePostURL(ctx context.Context, rawURL string, includeComments bool) (*ArchiveResult, error) {
+	subreddit, postID, err := ParsePostURL(rawURL)
+	if err != nil {
+		return &ArchiveResult{}, &StorageError{Op: "archive_post_url", Err: err}
+	}
+
+	if subreddit == "" {
+		subreddit = "all"
+		if post, err := a.storage.GetPost(ctx, postID); err == nil {
+			subreddit = post.Subreddit
+		} else if !errors.Is(err, ErrNotFound) {
+			return &ArchiveResult{}, err
+		}
+	}
+
+	return a.ArchivePost(ctx, subreddit, postID, includeComments)
+}
@@ -0,0 +1,86 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+func TestParsePostURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		wantSubreddit string
+		wantID        string
+		wantErr       bool
+	}{
+		{"www permalink with slug", "https://www.reddit.com/r/golang/comments/abc123/some_title/", "golang", "abc123", false},
+		{"old reddit without slug", "https://old.reddit.com/r/golang/comments/abc123", "golang", "abc123", false},
+		{"comment permalink", "https://www.reddit.com/r/golang/comments/abc123/some_title/def456/", "golang", "abc123", false},
+		{"query string", "https://reddit.com/r/GoLang/comments/abc123/t/?utm_source=share", "GoLang", "abc123", false},
+		{"no scheme", "www.reddit.com/r/golang/comments/abc123", "golang", "abc123", false},
+		{"mobile subdomain", "https://m.reddit.com/r/golang/comments/ABC123/", "golang", "abc123", false},
+		{"short link", "https://redd.it/abc123", "", "abc123", false},
+		{"comments without subreddit", "https://www.reddit.com/comments/abc123", "", "abc123", false},
+		{"subreddit page", "https://www.reddit.com/r/golang/", "", "", true},
+		{"user page", "https://www.reddit.com/user/spez", "", "", true},
+		{"other host", "https://example.com/r/golang/comments/abc123", "", "", true},
+		{"lookalike host", "https://notreddit.com/r/golang/comments/abc123", "", "", true},
+		{"bad post ID", "https://www.reddit.com/r/golang/comments/abc-123", "", "", true},
+		{"empty short link", "https://redd.it/", "", "", true},
+		{"empty", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subreddit, postID, err := storage.ParsePostURL(tt.url)
+			if tt.wantErr {
+				if !errors.Is(err, storage.ErrInvalidPostURL) {
+					t.Errorf("Expected ErrInvalidPostURL, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePostURL failed: %v", err)
+			}
+			if subreddit != tt.wantSubreddit || postID != tt.wantID {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tt.wantSubreddit, tt.wantID, subreddit, postID)
+			}
+		})
+	}
+}
+
+func TestArchivePostURL(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	post := testutil.NewTestPost("abc123", "golang", "Linked post")
+	mockClient.commentsMap["abc123"] = &types.CommentsResponse{Post: post}
+
+	if _, err := archiver.ArchivePostURL(ctx, "https://old.reddit.com/r/golang/comments/abc123/linked_post/", false); err != nil {
+		t.Fatalf("ArchivePostURL failed: %v", err)
+	}
+	if _, err := store.GetPost(ctx, "abc123"); err != nil {
+		t.Errorf("Expected the post to be stored, got %v", err)
+	}
+
+	// Short links work too
+	if _, err := archiver.ArchivePostURL(ctx, "https://redd.it/abc123", false); err != nil {
+		t.Errorf("ArchivePostURL with a short link failed: %v", err)
+	}
+
+	// Invalid URLs are rejected before any API call
+	mockClient.calls = 0
+	_, err := archiver.ArchivePostURL(ctx, "https://www.reddit.com/r/golang/", false)
+	if !errors.Is(err, storage.ErrInvalidPostURL) {
+		t.Errorf("Expected ErrInvalidPostURL, got %v", err)
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for an invalid URL, got %d", mockClient.calls)
+	}
+}
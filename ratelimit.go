@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// throttledClient spaces out calls to the wrapped client so that successive
+// calls start at least interval apart (see ArchiverOptions.MinRequestInterval)
+type throttledClient struct {
+	client   RedditClient
+	interval time.Duration
+
+	mu       sync.Mutex
+	nextCall time.Time // Earliest start of the next call
+}
+
+// wait blocks until the next call may start, reserving its slot, or until
+// ctx is done
+func (c *throttledClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	start := c.nextCall
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	c.nextCall = start.Add(c.interval)
+	c.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *throttledClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetSubreddit(ctx, name)
+}
+
+func (c *throttledClient) GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetHot(ctx, req)
+}
+
+func (c *throttledClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetNew(ctx, req)
+}
+
+func (c *throttledClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.client.GetComments(ctx, req)
+}
+
+func (c *throttledClient) GetUserPosts(ctx context.Context, req *UserRequest) (*types.PostsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return uc.GetUserPosts(ctx, req)
+}
+
+func (c *throttledClient) GetUserComments(ctx context.Context, req *UserRequest) (*UserCommentsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return uc.GetUserComments(ctx, req)
+}
+
+func (c *throttledClient) GetSaved(ctx context.Context, req *types.Pagination) (*SavedResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return sc.GetSaved(ctx, req)
+}
+
+func (c *throttledClient) GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return sc.GetUpvoted(ctx, req)
+}
+
+func (c *throttledClient) GetTop(ctx context.Context, req *types.PostsRequest, timeRange TimeRange) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return lc.GetTop(ctx, req, timeRange)
+}
+
+func (c *throttledClient) GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return lc.GetRising(ctx, req)
+}
+
+func (c *throttledClient) GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error) {
+	mc, err := moreCommentsClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return mc.GetMoreComments(ctx, req)
+}
+
+func (c *throttledClient) Search(ctx context.Context, req *SearchRequest) (*types.PostsResponse, error) {
+	sc, err := searchClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return sc.Search(ctx, req)
+}
+
+func (c *throttledClient) GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error) {
+	ic, err := infoClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return ic.GetInfo(ctx, fullnames)
+}
+
+func (c *throttledClient) GetSubredditRules(ctx context.Context, subreddit string) ([]SubredditRule, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return mc.GetSubredditRules(ctx, subreddit)
+}
+
+func (c *throttledClient) GetWikiPages(ctx context.Context, subreddit string) ([]string, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return mc.GetWikiPages(ctx, subreddit)
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// RetryPolicy controls how the Archiver retries Reddit API calls that fail
+// with a transient error: HTTP 429 or 5xx, timeouts and dropped connections.
+// Other errors, such as a 403 or 404, are returned on the first attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a call is tried, counting the first
+	// attempt. 0 or 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry. It doubles for each
+	// retry after that, up to MaxDelay.
+	// Default: 500ms
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between attempts.
+	// Default: 30s
+	MaxDelay time.Duration
+
+	// Jitter is the fraction, from 0 to 1, of each wait that is randomised
+	// so that concurrent callers don't retry in lockstep. 0 waits exactly
+	// the backoff delay.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the policy NewArchiver uses: up to 4 attempts
+// starting 500ms apart with 20% jitter
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// delay returns how long to wait before retry number n (1 for the first)
+func (p RetryPolicy) delay(n int) time.Duration {
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	d := base
+	for i := 1; i < n && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		d -= time.Duration(jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// statusPattern finds the HTTP status in the API wrapper's error messages,
+// whose status-carrying error type is internal to the wrapper
+var statusPattern = regexp.MustCompile(`status (\d{3})`)
+
+// isTransientError reports whether err is worth retrying
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	status := 0
+	var coded interface{ StatusCode() int }
+	if errors.As(err, &coded) {
+		status = coded.StatusCode()
+	} else if m := statusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+	return status == 429 || status >= 500 && status <= 599
+}
+
+// retry calls fn until it succeeds, fails with a permanent error, or c's
+// policy's attempts run out, logging and counting each retry. Waits between
+// attempts end early if ctx is cancelled.
+func retry[T any](ctx context.Context, c *retryingClient, op string, fn func() (T, error)) (T, error) {
+	policy := c.policy
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !isTransientError(err) {
+			return result, err
+		}
+
+		wait := policy.delay(attempt)
+		c.metrics.retries.Add(1)
+		c.logger.Warn("reddit API call failed, retrying",
+			"op", op,
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"wait", wait.Round(time.Millisecond),
+			"error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		}
+	}
+}
+
+// retryingClient retries the wrapped client's calls according to policy
+type retryingClient struct {
+	client  RedditClient
+	policy  RetryPolicy
+	logger  *slog.Logger
+	metrics *archiverMetrics
+}
+
+func (c *retryingClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	return retry(ctx, c, "GetSubreddit", func() (*types.SubredditData, error) {
+		return c.client.GetSubreddit(ctx, name)
+	})
+}
+
+func (c *retryingClient) GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	return retry(ctx, c, "GetHot", func() (*types.PostsResponse, error) {
+		return c.client.GetHot(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	return retry(ctx, c, "GetNew", func() (*types.PostsResponse, error) {
+		return c.client.GetNew(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	return retry(ctx, c, "GetComments", func() (*types.CommentsResponse, error) {
+		return c.client.GetComments(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetUserPosts(ctx context.Context, req *UserRequest) (*types.PostsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetUserPosts", func() (*types.PostsResponse, error) {
+		return uc.GetUserPosts(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetUserComments(ctx context.Context, req *UserRequest) (*UserCommentsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetUserComments", func() (*UserCommentsResponse, error) {
+		return uc.GetUserComments(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetSaved(ctx context.Context, req *types.Pagination) (*SavedResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetSaved", func() (*SavedResponse, error) {
+		return sc.GetSaved(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetUpvoted", func() (*types.PostsResponse, error) {
+		return sc.GetUpvoted(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetTop(ctx context.Context, req *types.PostsRequest, timeRange TimeRange) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetTop", func() (*types.PostsResponse, error) {
+		return lc.GetTop(ctx, req, timeRange)
+	})
+}
+
+func (c *retryingClient) GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetRising", func() (*types.PostsResponse, error) {
+		return lc.GetRising(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error) {
+	mc, err := moreCommentsClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetMoreComments", func() ([]*types.Comment, error) {
+		return mc.GetMoreComments(ctx, req)
+	})
+}
+
+func (c *retryingClient) Search(ctx context.Context, req *SearchRequest) (*types.PostsResponse, error) {
+	sc, err := searchClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "Search", func() (*types.PostsResponse, error) {
+		return sc.Search(ctx, req)
+	})
+}
+
+func (c *retryingClient) GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error) {
+	ic, err := infoClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetInfo", func() ([]*types.Post, error) {
+		return ic.GetInfo(ctx, fullnames)
+	})
+}
+
+func (c *retryingClient) GetSubredditRules(ctx context.Context, subreddit string) ([]SubredditRule, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetSubredditRules", func() ([]SubredditRule, error) {
+		return mc.GetSubredditRules(ctx, subreddit)
+	})
+}
+
+func (c *retryingClient) GetWikiPages(ctx context.Context, subreddit string) ([]string, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return retry(ctx, c, "GetWikiPages", func() ([]string, error) {
+		return mc.GetWikiPages(ctx, subreddit)
+	})
+}
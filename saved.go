@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// SavedClient is implemented by Reddit clients that can list the
+// authenticated account's saved and upvoted items. ArchiveSaved and
+// ArchiveUpvoted need one; *graw.Client doesn't list them yet, so wrap it or
+// substitute a client that does.
+type SavedClient interface {
+	GetSaved(ctx context.Context, req *types.Pagination) (*SavedResponse, error)
+	GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error)
+}
+
+// SavedResponse is a page of the account's saved listing, which mixes posts
+// and comments
+type SavedResponse struct {
+	Posts         []*types.Post
+	Comments      []*types.Comment
+	AfterFullname string // Reddit fullname of the last item, for the next page
+}
+
+// ErrSavedUnsupported is returned by ArchiveSaved and ArchiveUpvoted when the
+// archiver's client doesn't implement SavedClient
+var ErrSavedUnsupported = errors.New("reddit client does not support saved and upvoted listings")
+
+// savedClient returns c as a SavedClient, if it is one
+func savedClient(c RedditClient) (SavedClient, error) {
+	sc, ok := c.(SavedClient)
+	if !ok {
+		return nil, ErrSavedUnsupported
+	}
+	return sc, nil
+}
+
+// SavedArchiveOptions configures ArchiveSaved and ArchiveUpvoted
+type SavedArchiveOptions struct {
+	MaxItems int // Stop after this many items (default 1000, as many as Reddit lists)
+}
+
+// SavedArchiveResult summarizes an ArchiveSaved or ArchiveUpvoted run
+type SavedArchiveResult struct {
+	PostsSaved    int
+	CommentsSaved int
+	FailedItems   []string // IDs of comments that could not be saved
+}
+
+// ArchiveSaved archives the authenticated account's saved posts and
+// comments, paging through the listing 100 items at a time until
+// opts.MaxItems items are fetched or the listing ends. Each item is
+// recorded as a SavedItem with source SavedSourceSaved, so the saved archive
+// can be read back with GetSavedItems or FindPosts with
+// PostFilter.SavedSource. A saved comment's thread is archived first when its
+// post isn't stored, as ArchiveUser does; a comment that still can't be saved
+// is logged and listed in the result's FailedItems. If ctx is cancelled,
+// buffered writes are flushed before returning.
+func (a *Archiver) ArchiveSaved(ctx context.Context, opts SavedArchiveOptions) (*SavedArchiveResult, error) {
+	return a.archiveAccountListing(ctx, "archive_saved", SavedSourceSaved, opts, func(client SavedClient, req *types.Pagination) (*SavedResponse, error) {
+		return client.GetSaved(ctx, req)
+	})
+}
+
+// ArchiveUpvoted archives the authenticated account's upvoted posts as
+// ArchiveSaved archives saved ones, recording them with source
+// SavedSourceUpvoted
+func (a *Archiver) ArchiveUpvoted(ctx context.Context, opts SavedArchiveOptions) (*SavedArchiveResult, error) {
+	return a.archiveAccountListing(ctx, "archive_upvoted", SavedSourceUpvoted, opts, func(client SavedClient, req *types.Pagination) (*SavedResponse, error) {
+		resp, err := client.GetUpvoted(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SavedResponse{Posts: resp.Posts, AfterFullname: resp.AfterFullname}, nil
+	})
+}
+
+func (a *Archiver) archiveAccountListing(ctx context.Context, op, source string, opts SavedArchiveOptions, fetch func(SavedClient, *types.Pagination) (*SavedResponse, error)) (*SavedArchiveResult, error) {
+	result := &SavedArchiveResult{}
+	if _, err := savedClient(unwrapClient(a.client)); err != nil {
+		return result, &StorageError{Op: op, Err: err}
+	}
+	client, err := savedClient(a.client)
+	if err != nil {
+		return result, &StorageError{Op: op, Err: err}
+	}
+
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = 1000
+	}
+
+	err = a.archiveSavedPages(ctx, client, source, opts.MaxItems, fetch, result)
+	if ctx.Err() != nil {
+		return result, a.flushOnCancel(ctx)
+	}
+	return result, err
+}
+
+func (a *Archiver) archiveSavedPages(ctx context.Context, client SavedClient, source string, maxItems int, fetch func(SavedClient, *types.Pagination) (*SavedResponse, error), result *SavedArchiveResult) error {
+	var after string
+	threads := make(map[string]bool) // Posts already handled by ensureThread
+	for fetched := 0; fetched < maxItems; {
+		resp, err := fetch(client, &types.Pagination{Limit: min(100, maxItems-fetched), After: after})
+		if err != nil {
+			return &StorageError{Op: "fetch_" + source, Err: err}
+		}
+		if len(resp.Posts)+len(resp.Comments) == 0 {
+			return nil
+		}
+		fetched += len(resp.Posts) + len(resp.Comments)
+
+		recordedAt := time.Now()
+		var items []*SavedItem
+
+		if len(resp.Posts) > 0 {
+			if err := a.storage.SavePosts(ctx, resp.Posts); err != nil {
+				return err
+			}
+			if err := a.postsSaved(ctx, resp.Posts...); err != nil {
+				return err
+			}
+			for _, post := range resp.Posts {
+				items = append(items, &SavedItem{Source: source, ID: post.ID, PostID: post.ID, RecordedAt: recordedAt})
+			}
+			result.PostsSaved += len(resp.Posts)
+		}
+
+		for _, comment := range resp.Comments {
+			if err := a.ensureThread(ctx, comment, threads); err != nil {
+				a.logger.Warn("archiving thread failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("archive_thread", err)
+			}
+
+			if err := a.storage.SaveComment(ctx, comment); err != nil {
+				a.logger.Warn("saving comment failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("save_comment", err)
+				result.FailedItems = append(result.FailedItems, comment.ID)
+				continue
+			}
+			a.metrics.saved(0, 1)
+			if err := a.commentsArchived(comment.LinkID, 1); err != nil {
+				a.logger.Warn("comment hook failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("save_comment", err)
+			}
+			items = append(items, &SavedItem{
+				Source:     source,
+				ID:         comment.ID,
+				IsComment:  true,
+				PostID:     strings.TrimPrefix(comment.LinkID, "t3_"),
+				RecordedAt: recordedAt,
+			})
+			result.CommentsSaved++
+		}
+
+		if err := a.storage.SaveSavedItems(ctx, items); err != nil {
+			return err
+		}
+		a.logger.Info("archived account listing", "source", source, "posts_saved", result.PostsSaved, "comments_saved", result.CommentsSaved)
+
+		if after = resp.AfterFullname; after == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
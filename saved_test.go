@@ -0,0 +1,122 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+// mockSavedClient adds the account's saved and upvoted listings to
+// mockRedditClient. The saved listing serves its posts as the first page and
+// its comments as the second.
+type mockSavedClient struct {
+	*mockRedditClient
+	savedPosts    []*types.Post
+	savedComments []*types.Comment
+	upvoted       []*types.Post
+}
+
+func (m *mockSavedClient) GetSaved(ctx context.Context, req *types.Pagination) (*storage.SavedResponse, error) {
+	switch req.After {
+	case "":
+		return &storage.SavedResponse{Posts: m.savedPosts, AfterFullname: "t3_saved"}, nil
+	case "t3_saved":
+		return &storage.SavedResponse{Comments: m.savedComments}, nil
+	default:
+		return &storage.SavedResponse{}, nil
+	}
+}
+
+func (m *mockSavedClient) GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error) {
+	posts, next := page(m.upvoted, &storage.UserRequest{Pagination: *req}, func(p *types.Post) string { return "t3_" + p.ID })
+	return &types.PostsResponse{Posts: posts, AfterFullname: next}, nil
+}
+
+func TestArchiveSaved(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	client := &mockSavedClient{mockRedditClient: mockClient}
+	client.savedPosts = []*types.Post{
+		testutil.NewTestPost("s1", "golang", "Saved one"),
+		testutil.NewTestPost("s2", "rust", "Saved two"),
+	}
+	client.upvoted = []*types.Post{testutil.NewTestPost("up1", "golang", "Upvoted")}
+
+	// A saved reply in a thread that isn't stored yet
+	thread := testutil.NewTestPost("th1", "golang", "Thread")
+	parent := testutil.NewTestComment("sp1", "th1", "bob", "Question")
+	parent.ParentID = "t3_th1"
+	reply := testutil.NewTestComment("sc1", "th1", "alice", "Answer worth saving")
+	reply.ParentID = "t1_sp1"
+	mockClient.commentsMap["th1"] = &types.CommentsResponse{Post: thread, Comments: []*types.Comment{parent}}
+	client.savedComments = []*types.Comment{reply}
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.ArchiveSaved(ctx, storage.SavedArchiveOptions{})
+	if err != nil {
+		t.Fatalf("ArchiveSaved failed: %v", err)
+	}
+	if result.PostsSaved != 2 || result.CommentsSaved != 1 || len(result.FailedItems) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if _, err := archiver.ArchiveUpvoted(ctx, storage.SavedArchiveOptions{}); err != nil {
+		t.Fatalf("ArchiveUpvoted failed: %v", err)
+	}
+
+	items, err := store.GetSavedItems(ctx, storage.SavedSourceSaved, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("GetSavedItems failed: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 saved items, got %d", len(items))
+	}
+	for _, item := range items {
+		if item.ID == "sc1" && (!item.IsComment || item.PostID != "th1") {
+			t.Errorf("Expected sc1 to be recorded as a comment on th1, got %+v", item)
+		}
+	}
+
+	// Only directly saved posts match the filter, not the saved comment's
+	// thread or upvoted posts
+	posts, err := store.FindPosts(ctx, storage.PostFilter{SavedSource: storage.SavedSourceSaved}, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("FindPosts failed: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Errorf("Expected 2 saved posts, got %d", len(posts))
+	}
+
+	upvoted, err := store.FindPosts(ctx, storage.PostFilter{SavedSource: storage.SavedSourceUpvoted}, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("FindPosts failed: %v", err)
+	}
+	if len(upvoted) != 1 || upvoted[0].ID != "up1" {
+		t.Errorf("Expected up1 as the only upvoted post, got %d posts", len(upvoted))
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "th1")
+	if err != nil {
+		t.Fatalf("GetCommentsByPost failed: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Errorf("Expected the saved reply and its parent to be stored, got %d comments", len(comments))
+	}
+}
+
+func TestArchiveSavedUnsupported(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	_, err := archiver.ArchiveSaved(context.Background(), storage.SavedArchiveOptions{})
+	if !errors.Is(err, storage.ErrSavedUnsupported) {
+		t.Errorf("Expected ErrSavedUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SubredditConfig is how Archiver.Run archives one subreddit
+type SubredditConfig struct {
+	Name string
+
+	// Interval is the time between the starts of successive passes over
+	// the subreddit. A pass that overruns it is followed immediately by the
+	// next.
+	Interval time.Duration
+
+	// Options configures each pass, as for ArchiveSubreddit. An empty Sort
+	// archives the "new" listing.
+	Options ArchiveOptions
+}
+
+// ErrNotRunning is returned by UpdateConfigs when no Run is in progress
+var ErrNotRunning = errors.New("archiver is not running")
+
+// schedule holds the configs of an archiver's running Run
+type schedule struct {
+	mu      sync.Mutex
+	running bool
+	configs []SubredditConfig
+	reload  chan struct{} // Signalled when configs change
+}
+
+// checkConfigs reports the first of configs Run can't archive: an empty or
+// repeated name, a non-positive interval or options ArchiveSubreddit would
+// reject
+func (a *Archiver) checkConfigs(configs []SubredditConfig) error {
+	if len(configs) == 0 {
+		return errors.New("no subreddits given")
+	}
+
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		name := NormalizeSubreddit(config.Name)
+		if name == "" {
+			return fmt.Errorf("%w: SubredditConfig.Name is empty", ErrInvalidOptions)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w: subreddit %s is configured twice", ErrInvalidOptions, config.Name)
+		}
+		seen[name] = true
+
+		if config.Interval <= 0 {
+			return invalidOption("SubredditConfig.Interval", config.Interval, "must be positive")
+		}
+		if err := a.checkOptions(config.runOptions()); err != nil {
+			return fmt.Errorf("subreddit %s: %w", config.Name, err)
+		}
+	}
+	return nil
+}
+
+// runOptions returns the options for a pass over c's subreddit
+func (c SubredditConfig) runOptions() ArchiveOptions {
+	opts := c.Options
+	if opts.Sort == "" {
+		opts.Sort = SortNew
+	}
+	return opts
+}
+
+// Run archives each configured subreddit every config.Interval with its own
+// options until ctx is cancelled. Passes run one at a time, so they share
+// the archiver's rate limiting, and the subreddits' first passes are spread
+// across their intervals so they don't all fire at once. A pass that fails
+// is logged and the others carry on. UpdateConfigs changes the subreddits
+// while Run is in progress. Only one Run may be in progress per archiver.
+// Cancellation ends a wait immediately; buffered writes are flushed before
+// ctx's error is returned.
+func (a *Archiver) Run(ctx context.Context, configs []SubredditConfig) error {
+	if err := a.checkConfigs(configs); err != nil {
+		return &StorageError{Op: "run", Err: err}
+	}
+
+	a.schedule.mu.Lock()
+	if a.schedule.running {
+		a.schedule.mu.Unlock()
+		return &StorageError{Op: "run", Err: errors.New("archiver is already running")}
+	}
+	a.schedule.running = true
+	a.schedule.configs = slices.Clone(configs)
+	a.schedule.reload = make(chan struct{}, 1)
+	reload := a.schedule.reload
+	a.schedule.mu.Unlock()
+
+	defer func() {
+		a.schedule.mu.Lock()
+		a.schedule.running = false
+		a.schedule.configs = nil
+		a.schedule.mu.Unlock()
+	}()
+
+	return a.runPasses(ctx, reload, func() []scheduledPass {
+		configs := a.scheduledConfigs()
+		passes := make([]scheduledPass, len(configs))
+		for i, config := range configs {
+			passes[i] = scheduledPass{subreddit: config.Name, interval: config.Interval, opts: config.runOptions()}
+		}
+		return passes
+	}, nil)
+}
+
+// scheduledConfigs returns the configs of the running Run
+func (a *Archiver) scheduledConfigs() []SubredditConfig {
+	a.schedule.mu.Lock()
+	defer a.schedule.mu.Unlock()
+	return a.schedule.configs
+}
+
+// UpdateConfigs replaces the subreddits a running Run archives, taking
+// effect before its next pass. Subreddits added are archived straight away,
+// removed ones are dropped, and a changed interval counts from the
+// subreddit's last pass. Invalid configs are rejected as a whole, as Run
+// would reject them, and ErrNotRunning is returned when no Run is in
+// progress.
+func (a *Archiver) UpdateConfigs(configs []SubredditConfig) error {
+	if err := a.checkConfigs(configs); err != nil {
+		return &StorageError{Op: "update_configs", Err: err}
+	}
+
+	a.schedule.mu.Lock()
+	defer a.schedule.mu.Unlock()
+
+	if !a.schedule.running {
+		return &StorageError{Op: "update_configs", Err: ErrNotRunning}
+	}
+	a.schedule.configs = slices.Clone(configs)
+	select {
+	case a.schedule.reload <- struct{}{}:
+	default: // A reload is already pending
+	}
+	a.logger.Info("updated subreddit configs", "subreddits", len(configs))
+	return nil
+}
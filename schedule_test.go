@@ -0,0 +1,102 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// waitForRuns polls until subreddit has at least n recorded archive runs
+func waitForRuns(t *testing.T, store storage.Storage, subreddit string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, err := store.GetArchiveRuns(context.Background(), subreddit, n)
+		if err == nil && len(runs) >= n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d runs of r/%s", n, subreddit)
+}
+
+func TestArchiverRunUpdateConfigs(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- archiver.Run(ctx, []storage.SubredditConfig{
+			{Name: "golang", Interval: 5 * time.Millisecond, Options: storage.ArchiveOptions{Limit: 10}},
+		})
+	}()
+	waitForRuns(t, store, "golang", 2)
+
+	// A subreddit added while running is archived with its own options
+	err := archiver.UpdateConfigs([]storage.SubredditConfig{
+		{Name: "golang", Interval: time.Hour},
+		{Name: "rust", Interval: 5 * time.Millisecond, Options: storage.ArchiveOptions{Sort: storage.SortHot, IncludeComments: true}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateConfigs failed: %v", err)
+	}
+	waitForRuns(t, store, "rust", 2)
+
+	// Bad configs are rejected without disturbing the running schedule
+	if err := archiver.UpdateConfigs([]storage.SubredditConfig{{Name: "rust", Interval: 0}}); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected ErrInvalidOptions for a zero interval, got %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to stop when its context is cancelled")
+	}
+
+	if err := archiver.UpdateConfigs([]storage.SubredditConfig{{Name: "golang", Interval: time.Minute}}); !errors.Is(err, storage.ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning once Run returned, got %v", err)
+	}
+}
+
+func TestArchiverRunRejectsInvalidConfigs(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		configs []storage.SubredditConfig
+	}{
+		{"empty name", []storage.SubredditConfig{{Interval: time.Minute}}},
+		{"repeated name", []storage.SubredditConfig{{Name: "golang", Interval: time.Minute}, {Name: "GoLang", Interval: time.Hour}}},
+		{"zero interval", []storage.SubredditConfig{{Name: "golang"}}},
+		{"bad options", []storage.SubredditConfig{{Name: "golang", Interval: time.Minute, Options: storage.ArchiveOptions{Limit: -1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := archiver.Run(ctx, tt.configs); !errors.Is(err, storage.ErrInvalidOptions) {
+				t.Errorf("Expected ErrInvalidOptions, got %v", err)
+			}
+		})
+	}
+
+	if err := archiver.Run(ctx, nil); err == nil {
+		t.Error("Expected an error for no subreddits")
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for invalid configs, got %d", mockClient.calls)
+	}
+}
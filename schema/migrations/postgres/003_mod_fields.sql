@@ -0,0 +1,5 @@
+-- Moderator-only post fields, NULL unless the post was fetched with mod credentials
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS num_reports INTEGER;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS removed_by_category TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_posts_removed ON posts(removed_by_category) WHERE removed_by_category IS NOT NULL;
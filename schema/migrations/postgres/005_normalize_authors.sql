@@ -0,0 +1,3 @@
+-- Deleted accounts are stored as '[deleted]' rather than an empty or NULL author
+UPDATE posts SET author = '[deleted]' WHERE author IS NULL OR TRIM(author) = '';
+UPDATE comments SET author = '[deleted]' WHERE author IS NULL OR TRIM(author) = '';
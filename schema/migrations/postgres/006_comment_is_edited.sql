@@ -0,0 +1,4 @@
+-- Reddit can report an edit without a timestamp, so track the edit flag separately
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS is_edited BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE comments SET is_edited = TRUE WHERE edited_utc IS NOT NULL;
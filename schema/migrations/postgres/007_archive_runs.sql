@@ -0,0 +1,13 @@
+-- Per-run archiver timings, for spotting slow subreddits
+CREATE TABLE IF NOT EXISTS archive_runs (
+    id BIGSERIAL PRIMARY KEY,
+    subreddit TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    fetch_duration_ms BIGINT NOT NULL DEFAULT 0,
+    save_duration_ms BIGINT NOT NULL DEFAULT 0,
+    posts_processed INTEGER NOT NULL DEFAULT 0,
+    comments_saved INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_archive_runs_subreddit ON archive_runs(subreddit, started_at DESC);
@@ -0,0 +1,4 @@
+-- Media metadata promoted from the post's media fields (ArchiveOptions.ResolveMedia)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS media_type TEXT;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS media_width INTEGER;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS media_height INTEGER;
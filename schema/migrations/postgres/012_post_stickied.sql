@@ -0,0 +1,5 @@
+-- Whether a post is stickied (pinned) by the subreddit's moderators
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS stickied BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Backfill from the stored API response
+UPDATE posts SET stickied = TRUE WHERE raw_json->>'stickied' = 'true';
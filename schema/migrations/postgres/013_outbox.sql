@@ -0,0 +1,8 @@
+-- Change log of saves and deletes for downstream sync (Options.Outbox)
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    op TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
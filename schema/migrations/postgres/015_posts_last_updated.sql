@@ -0,0 +1,2 @@
+-- Supports polling for recently saved posts (GetPostsUpdatedSince)
+CREATE INDEX IF NOT EXISTS idx_posts_last_updated ON posts(last_updated);
@@ -0,0 +1,7 @@
+-- Where each subreddit's last backfill stopped, so an interrupted run can resume
+CREATE TABLE IF NOT EXISTS backfill_checkpoints (
+    subreddit TEXT PRIMARY KEY,
+    after TEXT NOT NULL,
+    posts_fetched INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL
+);
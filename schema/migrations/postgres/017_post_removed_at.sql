@@ -0,0 +1,6 @@
+-- When a post was first seen removed or deleted on Reddit; NULL while it is live
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP;
+
+-- Backfill posts that were already removed when they were archived
+UPDATE posts SET removed_at = last_updated
+WHERE removed_at IS NULL AND selftext IN ('[removed]', '[deleted]');
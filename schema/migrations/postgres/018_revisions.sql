@@ -0,0 +1,23 @@
+-- Earlier post selftexts and comment bodies, recorded when a save replaces
+-- them and Options.KeepRevisions is on
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    selftext TEXT,
+    score INTEGER,
+    edited_utc TIMESTAMP,
+    replaced_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_revisions_post ON post_revisions(post_id, id);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    body TEXT,
+    score INTEGER,
+    edited_utc TIMESTAMP,
+    replaced_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment ON comment_revisions(comment_id, id);
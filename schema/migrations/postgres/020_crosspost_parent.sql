@@ -0,0 +1,4 @@
+-- The post a crosspost was made from, as a bare ID; NULL for other posts
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS crosspost_parent_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_posts_crosspost_parent ON posts(crosspost_parent_id) WHERE crosspost_parent_id IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Whether a post is NSFW, locked or marked as a spoiler. Spoiler isn't in
+-- the API wrapper's post type, so it stays NULL unless imported.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS over_18 BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS spoiler BOOLEAN;
+
+-- Backfill from the stored API response
+UPDATE posts SET over_18 = TRUE WHERE raw_json->>'over_18' = 'true';
+UPDATE posts SET locked = TRUE WHERE raw_json->>'locked' = 'true';
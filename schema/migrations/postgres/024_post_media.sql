@@ -0,0 +1,12 @@
+-- Images, videos and embeds attached to posts, in order. Rows are replaced
+-- whenever a save finds media for the post; posts saved before this
+-- migration have none until they are saved again.
+CREATE TABLE IF NOT EXISTS post_media (
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    media_type TEXT NOT NULL,
+    url TEXT NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, position)
+);
@@ -0,0 +1,14 @@
+-- Posts and comments archived from the authenticated account's saved and
+-- upvoted listings. post_id is the item itself for a post and the comment's
+-- post for a comment.
+CREATE TABLE IF NOT EXISTS saved_items (
+    source TEXT NOT NULL,
+    item_id TEXT NOT NULL,
+    is_comment BOOLEAN NOT NULL DEFAULT FALSE,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    recorded_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (source, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_items_post ON saved_items(post_id);
+CREATE INDEX IF NOT EXISTS idx_saved_items_recorded ON saved_items(source, recorded_at);
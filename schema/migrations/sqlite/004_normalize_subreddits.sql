@@ -0,0 +1,27 @@
+-- Subreddits are keyed by their lowercased name; display_name keeps the canonical spelling.
+-- Merge case-variant rows into the lowercase key and repoint their posts.
+INSERT OR IGNORE INTO subreddits (name, display_name, title, description, subscribers, created_utc, last_synced, raw_json)
+SELECT LOWER(name), display_name, title, description, subscribers, created_utc, last_synced, raw_json
+FROM subreddits
+WHERE name <> LOWER(name)
+ORDER BY (COALESCE(title, '') <> '') DESC, last_synced DESC;
+
+-- Prefer the spelling and metadata from a variant that was synced from the API
+UPDATE subreddits
+SET (display_name, title, description, subscribers, created_utc, last_synced, raw_json) = (
+    SELECT v.display_name, v.title, v.description, v.subscribers, v.created_utc, v.last_synced, v.raw_json
+    FROM subreddits v
+    WHERE LOWER(v.name) = subreddits.name AND v.name <> subreddits.name
+    ORDER BY (COALESCE(v.title, '') <> '') DESC, v.last_synced DESC
+    LIMIT 1
+)
+WHERE name = LOWER(name)
+  AND COALESCE(title, '') = ''
+  AND EXISTS (
+    SELECT 1 FROM subreddits v
+    WHERE LOWER(v.name) = subreddits.name AND v.name <> subreddits.name
+  );
+
+UPDATE posts SET subreddit = LOWER(subreddit) WHERE subreddit <> LOWER(subreddit);
+
+DELETE FROM subreddits WHERE name <> LOWER(name);
@@ -0,0 +1,4 @@
+-- Reddit can report an edit without a timestamp, so track the edit flag separately
+ALTER TABLE comments ADD COLUMN is_edited INTEGER NOT NULL DEFAULT 0;
+
+UPDATE comments SET is_edited = 1 WHERE edited_utc IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Which Reddit account (app credential) last archived each row
+ALTER TABLE posts ADD COLUMN account TEXT;
+ALTER TABLE comments ADD COLUMN account TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_posts_account ON posts(account);
+CREATE INDEX IF NOT EXISTS idx_comments_account ON comments(account);
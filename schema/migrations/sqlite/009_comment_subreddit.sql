@@ -0,0 +1,7 @@
+-- Copy each comment's post subreddit onto the comment so subreddit-wide
+-- comment queries don't need to join posts
+ALTER TABLE comments ADD COLUMN subreddit TEXT;
+
+UPDATE comments SET subreddit = (SELECT p.subreddit FROM posts p WHERE p.id = comments.post_id);
+
+CREATE INDEX IF NOT EXISTS idx_comments_subreddit ON comments(subreddit, created_utc DESC);
@@ -0,0 +1,2 @@
+-- Unexpanded "more" comment stubs reported with the post's last fetched thread
+ALTER TABLE posts ADD COLUMN more_comments_count INTEGER;
@@ -0,0 +1,5 @@
+-- Whether a post is stickied (pinned) by the subreddit's moderators
+ALTER TABLE posts ADD COLUMN stickied INTEGER NOT NULL DEFAULT 0;
+
+-- Backfill from the stored API response
+UPDATE posts SET stickied = 1 WHERE json_valid(raw_json) AND json_extract(raw_json, '$.stickied') = 1;
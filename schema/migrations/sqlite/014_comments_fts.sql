@@ -0,0 +1,23 @@
+-- Full-text index over comment bodies for SearchComments, kept in sync with
+-- the comments table by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS comments_fts USING fts5(
+    body,
+    content = 'comments',
+    content_rowid = 'rowid',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO comments_fts (comments_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_insert AFTER INSERT ON comments BEGIN
+    INSERT INTO comments_fts (rowid, body) VALUES (new.rowid, new.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_delete AFTER DELETE ON comments BEGIN
+    INSERT INTO comments_fts (comments_fts, rowid, body) VALUES ('delete', old.rowid, old.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_update AFTER UPDATE OF body ON comments BEGIN
+    INSERT INTO comments_fts (comments_fts, rowid, body) VALUES ('delete', old.rowid, old.body);
+    INSERT INTO comments_fts (rowid, body) VALUES (new.rowid, new.body);
+END;
@@ -0,0 +1,6 @@
+-- When a post was first seen removed or deleted on Reddit; NULL while it is live
+ALTER TABLE posts ADD COLUMN removed_at REAL;
+
+-- Backfill posts that were already removed when they were archived
+UPDATE posts SET removed_at = CAST(strftime('%s', last_updated) AS REAL)
+WHERE selftext IN ('[removed]', '[deleted]');
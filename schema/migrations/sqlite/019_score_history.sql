@@ -0,0 +1,10 @@
+-- Snapshots of post scores over time, recorded by the archiver when
+-- ArchiverOptions.RecordScoreHistory is set
+CREATE TABLE IF NOT EXISTS score_history (
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL,
+    num_comments INTEGER NOT NULL,
+    recorded_at REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_score_history_post ON score_history(post_id, recorded_at);
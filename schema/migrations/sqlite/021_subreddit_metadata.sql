@@ -0,0 +1,10 @@
+-- Snapshots of subreddit rules, sidebar and wiki index, one per fetch, saved
+-- by Archiver.ArchiveSubredditMetadata
+CREATE TABLE IF NOT EXISTS subreddit_metadata (
+    subreddit TEXT NOT NULL,
+    fetched_at REAL NOT NULL,
+    sidebar TEXT NOT NULL DEFAULT '',
+    rules TEXT NOT NULL DEFAULT '[]',
+    wiki_pages TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (subreddit, fetched_at)
+);
@@ -0,0 +1,12 @@
+-- Post and author flair text, promoted from the stored API response so posts
+-- can be filtered by flair (QueryOptions.Flair)
+ALTER TABLE posts ADD COLUMN link_flair_text TEXT;
+ALTER TABLE posts ADD COLUMN author_flair_text TEXT;
+
+-- Backfill from the stored API response
+UPDATE posts SET
+    link_flair_text = json_extract(raw_json, '$.link_flair_text'),
+    author_flair_text = json_extract(raw_json, '$.author_flair_text')
+WHERE json_valid(raw_json);
+
+CREATE INDEX IF NOT EXISTS idx_posts_flair ON posts(subreddit, link_flair_text COLLATE NOCASE) WHERE link_flair_text IS NOT NULL;
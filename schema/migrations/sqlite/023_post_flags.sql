@@ -0,0 +1,9 @@
+-- Whether a post is NSFW, locked or marked as a spoiler. Spoiler isn't in
+-- the API wrapper's post type, so it stays NULL unless imported.
+ALTER TABLE posts ADD COLUMN over_18 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN spoiler INTEGER;
+
+-- Backfill from the stored API response
+UPDATE posts SET over_18 = 1 WHERE json_valid(raw_json) AND json_extract(raw_json, '$.over_18') = 1;
+UPDATE posts SET locked = 1 WHERE json_valid(raw_json) AND json_extract(raw_json, '$.locked') = 1;
@@ -0,0 +1,9 @@
+-- num_comments as of the last time a post's comments were fetched and saved
+-- successfully; NULL until they have been. The archiver skips a thread only
+-- when this matches the current count, so a failed fetch is retried.
+ALTER TABLE posts ADD COLUMN comments_fetched_num INTEGER;
+
+-- Posts with archived comments count as fetched at their stored count, so
+-- upgrading doesn't refetch every thread; the rest are fetched once more
+UPDATE posts SET comments_fetched_num = num_comments
+WHERE EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id);
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchMode selects how SearchPosts interprets its query
+type SearchMode int
+
+const (
+	// SearchModePlain matches all words in the query; quotes and operators
+	// are treated as ordinary text
+	SearchModePlain SearchMode = iota
+
+	// SearchModeWeb accepts web search syntax: "quoted phrases", -excluded
+	// terms and OR between alternatives
+	SearchModeWeb
+)
+
+// SearchTerm is a single word or phrase in a web search query
+type SearchTerm struct {
+	Text    string
+	Negated bool
+}
+
+// ParseWebSearch splits a query using web search syntax (as accepted by
+// PostgreSQL's websearch_to_tsquery) into clauses that must all match, each
+// holding alternatives of which at least one must match. Backends without
+// native support use it to build equivalent filters.
+func ParseWebSearch(query string) [][]SearchTerm {
+	var clauses [][]SearchTerm
+	joinNext := false
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negated := false
+		if runes[i] == '-' {
+			negated = true
+			i++
+		}
+
+		var text string
+		phrase := false
+		if i < len(runes) && runes[i] == '"' {
+			// Phrase runs to the closing quote, or the end of the query
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			continue
+		}
+
+		if !phrase && !negated && strings.EqualFold(text, "or") {
+			// OR between two terms; a leading or trailing OR is ignored
+			joinNext = len(clauses) > 0
+			continue
+		}
+
+		term := SearchTerm{Text: text, Negated: negated}
+		if joinNext {
+			last := len(clauses) - 1
+			clauses[last] = append(clauses[last], term)
+			joinNext = false
+			continue
+		}
+
+		clauses = append(clauses, []SearchTerm{term})
+	}
+
+	return clauses
+}
@@ -0,0 +1,39 @@
+package storage_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+func TestParseWebSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  [][]storage.SearchTerm
+	}{
+		{"", nil},
+		{"go generics", [][]storage.SearchTerm{
+			{{Text: "go"}},
+			{{Text: "generics"}},
+		}},
+		{`"exact  phrase" -excluded term`, [][]storage.SearchTerm{
+			{{Text: "exact phrase"}},
+			{{Text: "excluded", Negated: true}},
+			{{Text: "term"}},
+		}},
+		{"rust or go -java", [][]storage.SearchTerm{
+			{{Text: "rust"}, {Text: "go"}},
+			{{Text: "java", Negated: true}},
+		}},
+		{`or "unterminated phrase`, [][]storage.SearchTerm{
+			{{Text: "unterminated phrase"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := storage.ParseWebSearch(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseWebSearch(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
 	"github.com/jamesprial/go-reddit-storage"
@@ -12,7 +14,11 @@ import (
 
 // SaveComment saves or updates a single comment
 func (s *SQLiteStorage) SaveComment(ctx context.Context, comment *types.Comment) error {
-	rawJSON, err := json.Marshal(comment)
+	if err := s.checkWritable("save_comment"); err != nil {
+		return err
+	}
+
+	rawJSON, err := s.commentRawJSON(comment)
 	if err != nil {
 		return &storage.StorageError{Op: "marshal_comment", Err: err}
 	}
@@ -20,15 +26,18 @@ func (s *SQLiteStorage) SaveComment(ctx context.Context, comment *types.Comment)
 	query := `
 		INSERT INTO comments (
 			id, post_id, parent_id, author, body, score,
-			depth, created_utc, edited_utc, raw_json, last_updated
+			depth, created_utc, edited_utc, is_edited, raw_json, subreddit, last_updated
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			(SELECT subreddit FROM posts WHERE id = ?), CURRENT_TIMESTAMP
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			score = excluded.score,
 			body = excluded.body,
 			edited_utc = excluded.edited_utc,
+			is_edited = excluded.is_edited,
 			last_updated = CURRENT_TIMESTAMP,
+			subreddit = excluded.subreddit,
 			raw_json = excluded.raw_json
 	`
 
@@ -71,11 +80,23 @@ func (s *SQLiteStorage) SaveComment(ctx context.Context, comment *types.Comment)
 		editedUTC = comment.Edited.Timestamp
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
-		comment.ID, postID, parentID, comment.Author,
+	isEdited := 0
+	if comment.Edited.IsEdited {
+		isEdited = 1
+	}
+
+	save := statement{query: query, args: []interface{}{
+		comment.ID, postID, parentID, storage.NormalizeAuthor(comment.Author),
 		comment.Body, comment.Score, depth, comment.CreatedUTC,
-		editedUTC, string(rawJSON),
-	)
+		editedUTC, isEdited, rawJSON, postID,
+	}}
+
+	stmts := []statement{save}
+	if revision := s.commentRevision(comment); revision != nil {
+		stmts = []statement{*revision, save}
+	}
+
+	err = s.execAllWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntityComment, comment.ID, stmts...)
 
 	if err != nil {
 		return &storage.StorageError{Op: "save_comment", Err: err}
@@ -84,17 +105,34 @@ func (s *SQLiteStorage) SaveComment(ctx context.Context, comment *types.Comment)
 	return nil
 }
 
-// SaveComments saves or updates multiple comments in a transaction
+// SaveComments saves or updates multiple comments. Batches larger than
+// Options.MaxBatchSize are written in several transactions; each chunk is
+// atomic but the batch as a whole is not.
 func (s *SQLiteStorage) SaveComments(ctx context.Context, comments []*types.Comment) error {
-	if len(comments) == 0 {
-		return nil
+	if err := s.checkWritable("save_comments"); err != nil {
+		return err
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return &storage.StorageError{Op: "begin_transaction", Err: err}
+	_, err := s.saveComments(ctx, comments, storage.SaveCommentsOptions{})
+	return err
+}
+
+// SaveCommentsWithOptions saves or updates multiple comments like
+// SaveComments, dropping any that exceed opts.MaxDepth and writing
+// opts.BatchSize comments per transaction when set, and returns how many
+// were stored. On error the count covers the chunks already committed.
+func (s *SQLiteStorage) SaveCommentsWithOptions(ctx context.Context, comments []*types.Comment, opts storage.SaveCommentsOptions) (int, error) {
+	if err := s.checkWritable("save_comments"); err != nil {
+		return 0, err
+	}
+
+	return s.saveComments(ctx, comments, opts)
+}
+
+func (s *SQLiteStorage) saveComments(ctx context.Context, comments []*types.Comment, opts storage.SaveCommentsOptions) (int, error) {
+	if len(comments) == 0 {
+		return 0, nil
 	}
-	defer tx.Rollback()
 
 	// Build a map of comment ID to parent ID for depth calculation
 	commentMap := make(map[string]string) // commentID -> parentID (stripped)
@@ -111,8 +149,41 @@ func (s *SQLiteStorage) SaveComments(ctx context.Context, comments []*types.Comm
 		commentMap[comment.ID] = parentID
 	}
 
-	// Function to calculate depth by recursively following parent chain
+	// The depth cache outlives each chunk so replies in a later chunk can
+	// resolve parents saved by an earlier one without another query
 	depthCache := make(map[string]int)
+
+	saved := 0
+	batchSize := s.maxBatchSize(opts)
+	for start := 0; start < len(comments); start += batchSize {
+		end := min(start+batchSize, len(comments))
+
+		var chunkSaved int
+		err := s.withBusyRetry(ctx, func() error {
+			var err error
+			chunkSaved, err = s.saveCommentChunk(ctx, comments[start:end], commentMap, depthCache, opts)
+			return err
+		})
+		if err != nil {
+			return saved, err
+		}
+		saved += chunkSaved
+	}
+
+	return saved, nil
+}
+
+// saveCommentChunk writes one chunk of a SaveComments batch in a transaction,
+// skipping comments at or below opts.MaxDepth (0 for no limit), and returns how
+// many were written
+func (s *SQLiteStorage) saveCommentChunk(ctx context.Context, comments []*types.Comment, commentMap map[string]string, depthCache map[string]int, opts storage.SaveCommentsOptions) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	// Function to calculate depth by recursively following parent chain
 	var calculateDepth func(commentID string) int
 	calculateDepth = func(commentID string) int {
 		// Check cache first
@@ -147,29 +218,40 @@ func (s *SQLiteStorage) SaveComments(ctx context.Context, comments []*types.Comm
 	query := `
 		INSERT INTO comments (
 			id, post_id, parent_id, author, body, score,
-			depth, created_utc, edited_utc, raw_json, last_updated
+			depth, created_utc, edited_utc, is_edited, raw_json, account, subreddit, last_updated
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			(SELECT subreddit FROM posts WHERE id = ?), CURRENT_TIMESTAMP
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			score = excluded.score,
 			body = excluded.body,
 			edited_utc = excluded.edited_utc,
+			is_edited = excluded.is_edited,
 			depth = excluded.depth,
+			account = COALESCE(excluded.account, comments.account),
 			last_updated = CURRENT_TIMESTAMP,
+			subreddit = excluded.subreddit,
 			raw_json = excluded.raw_json
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return &storage.StorageError{Op: "prepare_statement", Err: err}
+		return 0, &storage.StorageError{Op: "prepare_statement", Err: err}
 	}
 	defer stmt.Close()
 
+	saved := 0
 	for _, comment := range comments {
-		rawJSON, err := json.Marshal(comment)
+		// Calculate proper depth
+		depth := calculateDepth(comment.ID)
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			continue
+		}
+
+		rawJSON, err := s.commentRawJSON(comment)
 		if err != nil {
-			return &storage.StorageError{Op: "marshal_comment", Err: err}
+			return 0, &storage.StorageError{Op: "marshal_comment", Err: err}
 		}
 
 		// Handle NULL parent_id for top-level comments
@@ -192,41 +274,152 @@ func (s *SQLiteStorage) SaveComments(ctx context.Context, comments []*types.Comm
 			postID = postID[3:]
 		}
 
-		// Calculate proper depth
-		depth := calculateDepth(comment.ID)
-
 		// Handle edited timestamp
 		var editedUTC interface{}
 		if comment.Edited.IsEdited && comment.Edited.Timestamp > 0 {
 			editedUTC = comment.Edited.Timestamp
 		}
 
+		isEdited := 0
+		if comment.Edited.IsEdited {
+			isEdited = 1
+		}
+
+		if revision := s.commentRevision(comment); revision != nil {
+			if _, err := tx.ExecContext(ctx, revision.query, revision.args...); err != nil {
+				return 0, &storage.StorageError{Op: "insert_comment_revision", Err: err}
+			}
+		}
+
 		_, err = stmt.ExecContext(ctx,
-			comment.ID, postID, parentID, comment.Author,
+			comment.ID, postID, parentID, storage.NormalizeAuthor(comment.Author),
 			comment.Body, comment.Score, depth, comment.CreatedUTC,
-			editedUTC, string(rawJSON),
+			editedUTC, isEdited, rawJSON, nullIfEmpty(opts.Account), postID,
 		)
 
 		if err != nil {
-			return &storage.StorageError{Op: "insert_comment", Err: err}
+			return 0, &storage.StorageError{Op: "insert_comment", Err: err}
+		}
+
+		if err := s.appendOutbox(ctx, tx, storage.OutboxOpSave, storage.OutboxEntityComment, comment.ID); err != nil {
+			return 0, err
 		}
+		saved++
 	}
 
 	if err := tx.Commit(); err != nil {
-		return &storage.StorageError{Op: "commit_transaction", Err: err}
+		return 0, &storage.StorageError{Op: "commit_transaction", Err: err}
 	}
 
-	return nil
+	return saved, nil
+}
+
+// DeleteComments deletes all of a post's comments in a single transaction,
+// keeping the post, and returns how many were deleted. A post that isn't
+// stored returns an error wrapping storage.ErrNotFound.
+func (s *SQLiteStorage) DeleteComments(ctx context.Context, postID string) (int, error) {
+	if err := s.checkWritable("delete_comments"); err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	err := s.withBusyRetry(ctx, func() error {
+		var err error
+		deleted, err = s.deleteComments(ctx, postID)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (s *SQLiteStorage) deleteComments(ctx context.Context, postID string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM posts WHERE id = ?", postID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, postID)}
+	}
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	// Collect the IDs up front: replies removed by the parent_id cascade
+	// aren't counted as affected rows, and outbox events need the IDs
+	var ids []string
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM comments WHERE post_id = ?", postID)
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	// Delete revisions explicitly; foreign key cascades depend on a
+	// per-connection pragma that may not be set on this connection
+	if _, err := tx.ExecContext(ctx, "DELETE FROM comment_revisions WHERE comment_id IN (SELECT id FROM comments WHERE post_id = ?)", postID); err != nil {
+		return 0, &storage.StorageError{Op: "delete_revisions", Err: err}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM saved_items WHERE post_id = ? AND is_comment = 1", postID); err != nil {
+		return 0, &storage.StorageError{Op: "delete_saved_items", Err: err}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE post_id = ?", postID); err != nil {
+		return 0, &storage.StorageError{Op: "delete_comments", Err: err}
+	}
+
+	if err := s.appendOutbox(ctx, tx, storage.OutboxOpDelete, storage.OutboxEntityComment, ids...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, &storage.StorageError{Op: "commit_transaction", Err: err}
+	}
+
+	return len(ids), nil
 }
 
 // GetCommentsByPost retrieves all comments for a post, preserving thread structure
 func (s *SQLiteStorage) GetCommentsByPost(ctx context.Context, postID string) ([]*types.Comment, error) {
+	stored, err := s.getStoredCommentsByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*types.Comment, len(stored))
+	for i, comment := range stored {
+		comments[i] = comment.Comment
+	}
+
+	return comments, nil
+}
+
+// getStoredCommentsByPost retrieves all comments for a post in thread order
+// along with their stored depth. Each path segment is the comment's creation
+// time followed by its ID, so siblings created in the same second still sort
+// deterministically; '/' separates levels and sorts before any ID character,
+// keeping replies ahead of their parent's next sibling.
+func (s *SQLiteStorage) getStoredCommentsByPost(ctx context.Context, postID string) ([]*storage.StoredComment, error) {
 	query := `
 		WITH RECURSIVE comment_tree AS (
 			-- Top-level comments
 			SELECT id, post_id, parent_id, author, body, score, depth,
-			       created_utc, edited_utc, raw_json, 0 as level,
-			       created_utc as path
+			       created_utc, edited_utc, is_edited, raw_json, 0 as level,
+			       created_utc || ' ' || id as path
 			FROM comments
 			WHERE post_id = ? AND parent_id IS NULL
 
@@ -234,67 +427,352 @@ func (s *SQLiteStorage) GetCommentsByPost(ctx context.Context, postID string) ([
 
 			-- Nested comments
 			SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score,
-			       c.depth, c.created_utc, c.edited_utc, c.raw_json,
+			       c.depth, c.created_utc, c.edited_utc, c.is_edited, c.raw_json,
 			       ct.level + 1,
-			       ct.path || c.created_utc
+			       ct.path || '/' || c.created_utc || ' ' || c.id
 			FROM comments c
 			JOIN comment_tree ct ON c.parent_id = ct.id
 		)
 		SELECT id, post_id, parent_id, author, body, score, depth,
-		       created_utc, edited_utc, raw_json
+		       created_utc, edited_utc, is_edited, raw_json
 		FROM comment_tree
 		ORDER BY path
 	`
 
+	ctx, cancel := s.threadQueryContext(ctx)
+	defer cancel()
+
 	rows, err := s.db.QueryContext(ctx, query, postID)
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_comments_by_post", Err: err}
+		return nil, queryError(ctx, "get_comments_by_post", err)
 	}
 	defer rows.Close()
 
-	var comments []*types.Comment
+	var comments []*storage.StoredComment
 
 	for rows.Next() {
-		var comment types.Comment
-		var rawJSON string
-		var parentID sql.NullString
-		var postIDRaw string
-		var depth int
-		var editedUTC sql.NullString
-
-		err := rows.Scan(
-			&comment.ID, &postIDRaw, &parentID, &comment.Author,
-			&comment.Body, &comment.Score, &depth, &comment.CreatedUTC,
-			&editedUTC, &rawJSON,
-		)
+		comment, depth, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, &storage.StoredComment{Comment: comment, Depth: depth})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, "scan_comments", err)
+	}
+
+	return comments, nil
+}
+
+// GetCommentTreeNested retrieves all comments for a post nested under their
+// parents
+func (s *SQLiteStorage) GetCommentTreeNested(ctx context.Context, postID string) ([]*storage.CommentNode, error) {
+	comments, err := s.GetCommentsByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
 
+	return storage.BuildCommentTree(comments), nil
+}
+
+// GetTopComments retrieves the n highest-scoring top-level comments of a
+// post, ignoring replies. Ties go to the earlier comment.
+func (s *SQLiteStorage) GetTopComments(ctx context.Context, postID string, n int) ([]*storage.StoredComment, error) {
+	if n <= 0 {
+		n = 25
+	}
+
+	query := `
+		SELECT id, post_id, parent_id, author, body, score, depth,
+		       created_utc, edited_utc, is_edited, raw_json
+		FROM comments
+		WHERE post_id = ? AND parent_id IS NULL
+		ORDER BY score DESC, created_utc ASC, id ASC
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, n)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_top_comments", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*storage.StoredComment
+
+	for rows.Next() {
+		comment, depth, err := scanComment(rows)
 		if err != nil {
-			return nil, &storage.StorageError{Op: "scan_comment", Err: err}
+			return nil, err
 		}
 
-		// Reconstruct fullnames with prefixes
-		comment.LinkID = "t3_" + postIDRaw
+		comments = append(comments, &storage.StoredComment{Comment: comment, Depth: depth})
+	}
 
-		if parentID.Valid {
-			comment.ParentID = "t1_" + parentID.String
-		} else {
-			comment.ParentID = comment.LinkID
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+// GetTopLevelComments retrieves a page of a post's top-level comments with
+// their reply counts
+func (s *SQLiteStorage) GetTopLevelComments(ctx context.Context, postID string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	return s.getThreadComments(ctx, "get_top_level_comments", "c.post_id = ? AND c.parent_id IS NULL", postID, opts)
+}
+
+// GetReplies retrieves a page of a comment's direct replies with their own
+// reply counts. commentID is the comment's ID without the t1_ prefix.
+func (s *SQLiteStorage) GetReplies(ctx context.Context, commentID string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	return s.getThreadComments(ctx, "get_replies", "c.parent_id = ?", commentID, opts)
+}
+
+// getThreadComments pages through the comments matching where, which takes
+// a single argument, counting each one's direct replies
+func (s *SQLiteStorage) getThreadComments(ctx context.Context, op, where, arg string, opts storage.QueryOptions) ([]*storage.ThreadComment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: op, Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json,
+		       (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id)
+		FROM comments c
+		WHERE ` + where
+
+	args := []interface{}{arg}
+
+	if opts.MinScore != nil {
+		query += " AND c.score >= ?"
+		args = append(args, *opts.MinScore)
+	}
+
+	sortBy := "c.score"
+	if opts.SortBy == "created" || opts.SortBy == "created_utc" {
+		sortBy = "c.created_utc"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	// The ID tie-breaker keeps pages stable across equal sort keys
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id %s", sortBy, sortOrder, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: op, Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*storage.ThreadComment
+
+	for rows.Next() {
+		var replyCount int
+		comment, _, err := scanComment(rows, &replyCount)
+		if err != nil {
+			return nil, err
 		}
 
-		// Reconstruct Edited field
-		if editedUTC.Valid {
-			// Try to parse as float64
-			var timestamp float64
-			if _, err := fmt.Sscanf(editedUTC.String, "%f", &timestamp); err == nil {
-				comment.Edited = types.Edited{IsEdited: true, Timestamp: timestamp}
-			} else {
-				comment.Edited = types.Edited{IsEdited: false}
-			}
-		} else {
-			comment.Edited = types.Edited{IsEdited: false}
+		comments = append(comments, &storage.ThreadComment{Comment: comment, ReplyCount: replyCount})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+// ExportPostMarkdown writes a post and its comment thread to w as Markdown
+func (s *SQLiteStorage) ExportPostMarkdown(ctx context.Context, postID string, w io.Writer) error {
+	post, err := s.GetPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	comments, err := s.getStoredCommentsByPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.WritePostMarkdown(w, post, comments); err != nil {
+		return &storage.StorageError{Op: "export_post_markdown", Err: err}
+	}
+
+	return nil
+}
+
+// GetCommentsByAuthorWithContext retrieves an author's comments along with the
+// title and subreddit of the post each one belongs to
+func (s *SQLiteStorage) GetCommentsByAuthorWithContext(ctx context.Context, author string, opts storage.QueryOptions) ([]*storage.CommentWithPost, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_author_with_context", Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json, p.title,
+		       COALESCE(NULLIF(sr.display_name, ''), p.subreddit)
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		LEFT JOIN subreddits sr ON sr.name = p.subreddit
+		WHERE c.author = ?
+	`
+
+	var args []interface{}
+	args = append(args, author)
+
+	// Add date filters if provided
+	if !opts.StartDate.IsZero() {
+		query += " AND c.created_utc >= ?"
+		args = append(args, timeToUnixFloat(opts.StartDate))
+	}
+
+	if !opts.EndDate.IsZero() {
+		query += " AND c.created_utc <= ?"
+		args = append(args, timeToUnixFloat(opts.EndDate))
+	}
+
+	if opts.MinScore != nil {
+		query += " AND c.score >= ?"
+		args = append(args, *opts.MinScore)
+	}
+
+	if opts.Account != "" {
+		query += " AND c.account = ?"
+		args = append(args, opts.Account)
+	}
+
+	// Comments can only be sorted by creation time or score
+	sortBy := "c.created_utc"
+	if opts.SortBy == "score" {
+		sortBy = "c.score"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_author", Err: err}
+	}
+	defer rows.Close()
+
+	var results []*storage.CommentWithPost
+
+	for rows.Next() {
+		var result storage.CommentWithPost
+
+		comment, _, err := scanComment(rows, &result.PostTitle, &result.PostSubreddit)
+		if err != nil {
+			return nil, err
 		}
 
-		comments = append(comments, &comment)
+		result.Comment = comment
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return results, nil
+}
+
+// GetCommentsBySubreddit retrieves comments on any post in a subreddit using
+// the subreddit copied onto each comment when it is saved. Comments can be
+// sorted by creation time (default) or score.
+func (s *SQLiteStorage) GetCommentsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*types.Comment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_subreddit", Err: err}
+	}
+
+	query := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json
+		FROM comments c
+		WHERE c.subreddit = ?
+	`
+
+	var args []interface{}
+	args = append(args, storage.NormalizeSubreddit(subreddit))
+
+	// Add date filters if provided
+	if !opts.StartDate.IsZero() {
+		query += " AND c.created_utc >= ?"
+		args = append(args, timeToUnixFloat(opts.StartDate))
+	}
+
+	if !opts.EndDate.IsZero() {
+		query += " AND c.created_utc <= ?"
+		args = append(args, timeToUnixFloat(opts.EndDate))
+	}
+
+	if opts.MinScore != nil {
+		query += " AND c.score >= ?"
+		args = append(args, *opts.MinScore)
+	}
+
+	if opts.Account != "" {
+		query += " AND c.account = ?"
+		args = append(args, opts.Account)
+	}
+
+	sortBy := "c.created_utc"
+	if opts.SortBy == "score" {
+		sortBy = "c.score"
+	}
+
+	sortOrder := strings.ToUpper(opts.SortOrder)
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		sortOrder = "DESC"
+	}
+
+	// The ID tie-breaker keeps pages stable across equal sort keys
+	query += fmt.Sprintf(" ORDER BY %s %s, c.id %s", sortBy, sortOrder, sortOrder)
+
+	// Add pagination
+	limit, offset := pageBounds(opts)
+
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comments_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*types.Comment
+
+	for rows.Next() {
+		comment, _, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -302,4 +780,224 @@ func (s *SQLiteStorage) GetCommentsByPost(ctx context.Context, postID string) ([
 	}
 
 	return comments, nil
-}
\ No newline at end of file
+}
+
+// SearchComments finds comments whose body matches query using the
+// comments_fts full-text index, best-scoring first. opts.Subreddit scopes
+// the search and opts.MinScore drops low-scoring comments.
+func (s *SQLiteStorage) SearchComments(ctx context.Context, query string, opts storage.QueryOptions) ([]*types.Comment, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "search_comments", Err: err}
+	}
+
+	match := ftsQuery(query, opts.SearchMode)
+	if match == "" {
+		// Like plainto_tsquery, a query with no terms matches nothing
+		return nil, nil
+	}
+
+	sqlQuery := `
+		SELECT c.id, c.post_id, c.parent_id, c.author, c.body, c.score, c.depth,
+		       c.created_utc, c.edited_utc, c.is_edited, c.raw_json
+		FROM comments_fts
+		JOIN comments c ON c.rowid = comments_fts.rowid
+		WHERE comments_fts MATCH ?
+	`
+	args := []interface{}{match}
+
+	if opts.Subreddit != "" {
+		sqlQuery += " AND c.subreddit = ?"
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+	}
+
+	if opts.MinScore != nil {
+		sqlQuery += " AND c.score >= ?"
+		args = append(args, *opts.MinScore)
+	}
+
+	sqlQuery += " ORDER BY c.score DESC, c.id LIMIT ? OFFSET ?"
+
+	limit, offset := pageBounds(opts)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "search_comments", Err: err}
+	}
+	defer rows.Close()
+
+	var comments []*types.Comment
+
+	for rows.Next() {
+		comment, _, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_comments", Err: err}
+	}
+
+	return comments, nil
+}
+
+// ftsQuery converts a search query into an FTS5 MATCH expression, quoting
+// every term so FTS5 operators in user input are matched as text. Plain
+// queries require every word. Web queries follow ParseWebSearch, except that
+// FTS5 can only exclude terms from a match: a negated alternative inside an
+// OR group is ignored, and a query with nothing but exclusions yields "".
+func ftsQuery(query string, mode storage.SearchMode) string {
+	quote := func(term string) string {
+		return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	if mode != storage.SearchModeWeb {
+		words := strings.Fields(query)
+		for i, word := range words {
+			words[i] = quote(word)
+		}
+		return strings.Join(words, " AND ")
+	}
+
+	var required, excluded []string
+	for _, clause := range storage.ParseWebSearch(query) {
+		if len(clause) == 1 && clause[0].Negated {
+			excluded = append(excluded, quote(clause[0].Text))
+			continue
+		}
+
+		var alternatives []string
+		for _, term := range clause {
+			if !term.Negated {
+				alternatives = append(alternatives, quote(term.Text))
+			}
+		}
+		if len(alternatives) > 0 {
+			required = append(required, "("+strings.Join(alternatives, " OR ")+")")
+		}
+	}
+
+	if len(required) == 0 {
+		return ""
+	}
+
+	match := "(" + strings.Join(required, " AND ") + ")"
+	for _, term := range excluded {
+		match += " NOT " + term
+	}
+	return match
+}
+
+// GetArchivedCommentCounts counts the stored comments of each post in
+// postIDs, so a repeated comment pass can compare them with num_comments and
+// skip threads that already look complete. Every requested ID is present in
+// the result; posts without stored comments count 0.
+func (s *SQLiteStorage) GetArchivedCommentCounts(ctx context.Context, postIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(postIDs))
+	for _, id := range postIDs {
+		counts[id] = 0
+	}
+
+	for start := 0; start < len(postIDs); start += idChunkSize {
+		chunk := postIDs[start:min(start+idChunkSize, len(postIDs))]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		query := "SELECT post_id, COUNT(*) FROM comments WHERE post_id IN (" + placeholders + ") GROUP BY post_id"
+		if err := scanCommentCounts(ctx, s.db, query, args, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// scanCommentCounts runs a post_id, COUNT(*) query and records each count
+func scanCommentCounts(ctx context.Context, db *sql.DB, query string, args []interface{}, counts map[string]int) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &storage.StorageError{Op: "get_archived_comment_counts", Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID string
+		var count int
+		if err := rows.Scan(&postID, &count); err != nil {
+			return &storage.StorageError{Op: "scan_comment_counts", Err: err}
+		}
+		counts[postID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return &storage.StorageError{Op: "scan_comment_counts", Err: err}
+	}
+
+	return nil
+}
+
+// commentRawJSON returns the raw_json value to store for a comment, or nil
+// when Options.StoreCommentRawJSON is off
+func (s *SQLiteStorage) commentRawJSON(comment *types.Comment) (interface{}, error) {
+	if !s.opts.StoreCommentRawJSON {
+		return nil, nil
+	}
+
+	rawJSON, err := json.Marshal(comment)
+	if err != nil {
+		return nil, err
+	}
+	return string(rawJSON), nil
+}
+
+// scanComment scans a comment row selected as id, post_id, parent_id, author,
+// body, score, depth, created_utc, edited_utc, is_edited, raw_json followed by any extra
+// destinations, returning the comment and its stored depth
+func scanComment(rows *sql.Rows, extra ...interface{}) (*types.Comment, int, error) {
+	var comment types.Comment
+	var rawJSON sql.NullString // NULL when saved with StoreCommentRawJSON off
+	var parentID sql.NullString
+	var postIDRaw string
+	var depth int
+	var editedUTC sql.NullString
+	var isEdited int
+
+	dest := []interface{}{
+		&comment.ID, &postIDRaw, &parentID, &comment.Author,
+		&comment.Body, &comment.Score, &depth, &comment.CreatedUTC,
+		&editedUTC, &isEdited, &rawJSON,
+	}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, 0, &storage.StorageError{Op: "scan_comment", Err: err}
+	}
+
+	// Reconstruct fullnames with prefixes
+	comment.LinkID = "t3_" + postIDRaw
+
+	if parentID.Valid {
+		comment.ParentID = "t1_" + parentID.String
+	} else {
+		comment.ParentID = comment.LinkID
+	}
+
+	// Reconstruct Edited field; Reddit sometimes reports an edit without a
+	// timestamp, which is only recorded in is_edited
+	comment.Edited = types.Edited{IsEdited: isEdited != 0}
+	if editedUTC.Valid {
+		// Try to parse as float64
+		var timestamp float64
+		if _, err := fmt.Sscanf(editedUTC.String, "%f", &timestamp); err == nil {
+			comment.Edited = types.Edited{IsEdited: true, Timestamp: timestamp}
+		}
+	}
+
+	return &comment, depth, nil
+}
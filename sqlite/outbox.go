@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// statement is a write query together with its arguments
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// execWithOutbox runs a single-statement write, in one transaction with its
+// outbox event when Options.Outbox is on
+func (s *SQLiteStorage) execWithOutbox(ctx context.Context, op, entityType, entityID, query string, args ...interface{}) error {
+	return s.execAllWithOutbox(ctx, op, entityType, entityID, statement{query: query, args: args})
+}
+
+// execAllWithOutbox runs writes in order, in one transaction with their
+// outbox event when there are several or Options.Outbox is on
+func (s *SQLiteStorage) execAllWithOutbox(ctx context.Context, op, entityType, entityID string, stmts ...statement) error {
+	return s.withBusyRetry(ctx, func() error {
+		if !s.opts.Outbox && len(stmts) == 1 {
+			_, err := s.db.ExecContext(ctx, stmts[0].query, stmts[0].args...)
+			return err
+		}
+
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+				return err
+			}
+		}
+		if err := s.appendOutbox(ctx, tx, op, entityType, entityID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// appendOutbox records an event per entity ID in tx when Options.Outbox is
+// on, so the events commit or roll back with the change itself
+func (s *SQLiteStorage) appendOutbox(ctx context.Context, tx *sql.Tx, op, entityType string, ids ...string) error {
+	if !s.opts.Outbox || len(ids) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO outbox (op, entity_type, entity_id, created_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return &storage.StorageError{Op: "append_outbox", Err: err}
+	}
+	defer stmt.Close()
+
+	now := timeToUnixFloat(time.Now())
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, op, entityType, id, now); err != nil {
+			return &storage.StorageError{Op: "append_outbox", Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ReadOutbox returns up to limit outbox events with IDs greater than afterID,
+// oldest first
+func (s *SQLiteStorage) ReadOutbox(ctx context.Context, afterID int64, limit int) ([]*storage.OutboxEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, op, entity_type, entity_id, created_at
+		FROM outbox
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "read_outbox", Err: err}
+	}
+	defer rows.Close()
+
+	var events []*storage.OutboxEvent
+	for rows.Next() {
+		var event storage.OutboxEvent
+		var createdAt float64
+
+		if err := rows.Scan(&event.ID, &event.Op, &event.EntityType, &event.EntityID, &createdAt); err != nil {
+			return nil, &storage.StorageError{Op: "scan_outbox_event", Err: err}
+		}
+
+		event.CreatedAt = unixFloatToTime(createdAt)
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "read_outbox", Err: err}
+	}
+
+	return events, nil
+}
+
+// AckOutbox deletes outbox events with IDs up to and including upToID once a
+// consumer has processed them
+func (s *SQLiteStorage) AckOutbox(ctx context.Context, upToID int64) error {
+	if err := s.checkWritable("ack_outbox"); err != nil {
+		return err
+	}
+
+	err := s.withBusyRetry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx, "DELETE FROM outbox WHERE id <= ?", upToID)
+		return err
+	})
+
+	if err != nil {
+		return &storage.StorageError{Op: "ack_outbox", Err: err}
+	}
+
+	return nil
+}
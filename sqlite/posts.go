@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
 	"github.com/jamesprial/go-reddit-storage"
@@ -13,10 +14,13 @@ import (
 
 // SavePost saves or updates a single post
 func (s *SQLiteStorage) SavePost(ctx context.Context, post *types.Post) error {
+	if err := s.checkWritable("save_post"); err != nil {
+		return err
+	}
+
 	// Ensure subreddit exists first
 	if post.Subreddit != "" {
-		sub := &types.SubredditData{DisplayName: post.Subreddit}
-		if err := s.SaveSubreddit(ctx, sub); err != nil {
+		if err := s.ensureSubreddit(ctx, post.Subreddit); err != nil {
 			return err
 		}
 	}
@@ -30,17 +34,23 @@ func (s *SQLiteStorage) SavePost(ctx context.Context, post *types.Post) error {
 		INSERT INTO posts (
 			id, subreddit, author, title, selftext, url,
 			score, upvote_ratio, num_comments, created_utc,
-			edited_utc, is_self, is_video, raw_json, last_updated
+			edited_utc, is_self, is_video, raw_json, stickied, removed_at,
+			link_flair_text, author_flair_text, over_18, locked, last_updated
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
 		)
-		ON CONFLICT (id) DO UPDATE SET
+		ON CONFLICT (id) DO UPDATE SET ` + s.firstSeenUpdates() + s.selftextUpdates() + `
 			score = excluded.score,
 			num_comments = excluded.num_comments,
 			upvote_ratio = excluded.upvote_ratio,
 			edited_utc = excluded.edited_utc,
+			stickied = excluded.stickied,
+			link_flair_text = excluded.link_flair_text,
+			author_flair_text = excluded.author_flair_text,
+			over_18 = excluded.over_18,
+			locked = excluded.locked,
 			last_updated = CURRENT_TIMESTAMP,
-			raw_json = excluded.raw_json
+			` + removedUpdates + `
 	`
 
 	isSelf := 0
@@ -48,18 +58,36 @@ func (s *SQLiteStorage) SavePost(ctx context.Context, post *types.Post) error {
 		isSelf = 1
 	}
 
+	stickied := 0
+	if post.Stickied {
+		stickied = 1
+	}
+
 	// Handle edited timestamp
 	var editedUTC interface{}
 	if post.Edited.IsEdited && post.Edited.Timestamp > 0 {
 		editedUTC = post.Edited.Timestamp
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
-		post.ID, post.Subreddit, post.Author, post.Title,
+	// Moderator fields aren't saved here, but removal is judged the same way
+	stored := &storage.StoredPost{Post: post}
+
+	save := statement{query: query, args: []interface{}{
+		post.ID, storage.NormalizeSubreddit(post.Subreddit), storage.NormalizeAuthor(post.Author), post.Title,
 		post.SelfText, post.URL, post.Score, nil, // upvote_ratio not in API wrapper types.Post yet
 		post.NumComments, post.CreatedUTC, editedUTC,
 		isSelf, 0, string(rawJSON), // is_video not in API wrapper types.Post yet
-	)
+		stickied, removedAt(stored),
+		post.LinkFlairText, post.AuthorFlairText, boolInt(post.Over18), boolInt(post.Locked),
+	}}
+
+	stmts := []statement{save}
+	if revision := s.postRevision(stored); revision != nil {
+		stmts = []statement{*revision, save}
+	}
+	stmts = append(stmts, postMediaStatements(post.ID, storage.ParsePostMedia(post))...)
+
+	err = s.execAllWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntityPost, post.ID, stmts...)
 
 	if err != nil {
 		return &storage.StorageError{Op: "save_post", Err: err}
@@ -70,6 +98,34 @@ func (s *SQLiteStorage) SavePost(ctx context.Context, post *types.Post) error {
 
 // SavePosts saves or updates multiple posts in a transaction
 func (s *SQLiteStorage) SavePosts(ctx context.Context, posts []*types.Post) error {
+	if err := s.checkWritable("save_posts"); err != nil {
+		return err
+	}
+
+	stored := make([]*storage.StoredPost, len(posts))
+	for i, post := range posts {
+		stored[i] = &storage.StoredPost{Post: post}
+	}
+
+	return s.withBusyRetry(ctx, func() error {
+		return s.saveStoredPosts(ctx, stored)
+	})
+}
+
+// SaveStoredPosts saves or updates multiple posts along with their moderator
+// fields in a transaction. Moderator fields that are nil leave any stored
+// value untouched.
+func (s *SQLiteStorage) SaveStoredPosts(ctx context.Context, posts []*storage.StoredPost) error {
+	if err := s.checkWritable("save_stored_posts"); err != nil {
+		return err
+	}
+
+	return s.withBusyRetry(ctx, func() error {
+		return s.saveStoredPosts(ctx, posts)
+	})
+}
+
+func (s *SQLiteStorage) saveStoredPosts(ctx context.Context, posts []*storage.StoredPost) error {
 	if len(posts) == 0 {
 		return nil
 	}
@@ -84,17 +140,35 @@ func (s *SQLiteStorage) SavePosts(ctx context.Context, posts []*types.Post) erro
 		INSERT INTO posts (
 			id, subreddit, author, title, selftext, url,
 			score, upvote_ratio, num_comments, created_utc,
-			edited_utc, is_self, is_video, raw_json, last_updated
+			edited_utc, is_self, is_video, raw_json, stickied,
+			num_reports, removed_by_category, account,
+			media_type, media_width, media_height, more_comments_count, removed_at,
+			crosspost_parent_id, link_flair_text, author_flair_text,
+			over_18, locked, spoiler, last_updated
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
 		)
-		ON CONFLICT (id) DO UPDATE SET
+		ON CONFLICT (id) DO UPDATE SET ` + s.firstSeenUpdates() + s.selftextUpdates() + `
 			score = excluded.score,
 			num_comments = excluded.num_comments,
 			upvote_ratio = excluded.upvote_ratio,
 			edited_utc = excluded.edited_utc,
+			stickied = excluded.stickied,
+			link_flair_text = excluded.link_flair_text,
+			author_flair_text = excluded.author_flair_text,
+			over_18 = excluded.over_18,
+			locked = excluded.locked,
+			spoiler = COALESCE(excluded.spoiler, posts.spoiler),
+			num_reports = COALESCE(excluded.num_reports, posts.num_reports),
+			removed_by_category = COALESCE(excluded.removed_by_category, posts.removed_by_category),
+			account = COALESCE(excluded.account, posts.account),
+			media_type = COALESCE(excluded.media_type, posts.media_type),
+			media_width = COALESCE(excluded.media_width, posts.media_width),
+			media_height = COALESCE(excluded.media_height, posts.media_height),
+			more_comments_count = COALESCE(excluded.more_comments_count, posts.more_comments_count),
+			crosspost_parent_id = COALESCE(excluded.crosspost_parent_id, posts.crosspost_parent_id),
 			last_updated = CURRENT_TIMESTAMP,
-			raw_json = excluded.raw_json
+			` + removedUpdates + `
 	`
 
 	stmt, err := tx.PrepareContext(ctx, query)
@@ -106,18 +180,18 @@ func (s *SQLiteStorage) SavePosts(ctx context.Context, posts []*types.Post) erro
 	// Ensure subreddits exist
 	subreddits := make(map[string]bool)
 	for _, post := range posts {
-		if post.Subreddit != "" && !subreddits[post.Subreddit] {
-			sub := &types.SubredditData{DisplayName: post.Subreddit}
-			if err := s.SaveSubreddit(ctx, sub); err != nil {
+		key := storage.NormalizeSubreddit(post.Subreddit)
+		if key != "" && !subreddits[key] {
+			if err := s.ensureSubreddit(ctx, post.Subreddit); err != nil {
 				return err
 			}
-			subreddits[post.Subreddit] = true
+			subreddits[key] = true
 		}
 	}
 
 	// Insert posts
 	for _, post := range posts {
-		rawJSON, err := json.Marshal(post)
+		rawJSON, err := json.Marshal(post.Post)
 		if err != nil {
 			return &storage.StorageError{Op: "marshal_post", Err: err}
 		}
@@ -127,22 +201,50 @@ func (s *SQLiteStorage) SavePosts(ctx context.Context, posts []*types.Post) erro
 			isSelf = 1
 		}
 
+		stickied := 0
+		if post.Stickied {
+			stickied = 1
+		}
+
 		// Handle edited timestamp
 		var editedUTC interface{}
 		if post.Edited.IsEdited && post.Edited.Timestamp > 0 {
 			editedUTC = post.Edited.Timestamp
 		}
 
+		mediaType, mediaWidth, mediaHeight := mediaValues(post.Media)
+
+		if revision := s.postRevision(post); revision != nil {
+			if _, err := tx.ExecContext(ctx, revision.query, revision.args...); err != nil {
+				return &storage.StorageError{Op: "insert_post_revision", Err: err}
+			}
+		}
+
 		_, err = stmt.ExecContext(ctx,
-			post.ID, post.Subreddit, post.Author, post.Title,
+			post.ID, storage.NormalizeSubreddit(post.Subreddit), storage.NormalizeAuthor(post.Author), post.Title,
 			post.SelfText, post.URL, post.Score, nil, // upvote_ratio not in API wrapper types.Post yet
 			post.NumComments, post.CreatedUTC, editedUTC,
 			isSelf, 0, string(rawJSON), // is_video not in API wrapper types.Post yet
+			stickied,
+			post.NumReports, post.RemovedByCategory, nullIfEmpty(post.Account),
+			mediaType, mediaWidth, mediaHeight, post.MoreCommentsCount, removedAt(post),
+			nullIfEmpty(post.CrosspostParentID), post.LinkFlairText, post.AuthorFlairText,
+			boolInt(post.Over18), boolInt(post.Locked), nullableBool(post.Spoiler),
 		)
 
 		if err != nil {
 			return &storage.StorageError{Op: "insert_post", Err: err}
 		}
+
+		for _, media := range postMediaStatements(post.ID, storage.ResolvePostMedia(post)) {
+			if _, err := tx.ExecContext(ctx, media.query, media.args...); err != nil {
+				return &storage.StorageError{Op: "insert_post_media", Err: err}
+			}
+		}
+
+		if err := s.appendOutbox(ctx, tx, storage.OutboxOpSave, storage.OutboxEntityPost, post.ID); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -155,15 +257,14 @@ func (s *SQLiteStorage) SavePosts(ctx context.Context, posts []*types.Post) erro
 // GetPost retrieves a single post by ID
 func (s *SQLiteStorage) GetPost(ctx context.Context, id string) (*types.Post, error) {
 	query := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE id = ?
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.id = ?
 	`
 
 	var post types.Post
 	var rawJSON string
-	var isSelf, isVideo int
+	var isSelf, isVideo, stickied int
 	var upvoteRatio sql.NullFloat64
 	var editedUTC sql.NullString
 
@@ -171,11 +272,12 @@ func (s *SQLiteStorage) GetPost(ctx context.Context, id string) (*types.Post, er
 		&post.ID, &post.Subreddit, &post.Author, &post.Title,
 		&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
 		&post.NumComments, &post.CreatedUTC, &editedUTC,
-		&isSelf, &isVideo, &rawJSON,
+		&isSelf, &isVideo, &rawJSON, &stickied,
+		&post.LinkFlairText, &post.AuthorFlairText, &post.Over18, &post.Locked,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, &storage.StorageError{Op: "get_post", Err: fmt.Errorf("post not found: %s", id)}
+		return nil, &storage.StorageError{Op: "get_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
 	}
 
 	if err != nil {
@@ -183,6 +285,7 @@ func (s *SQLiteStorage) GetPost(ctx context.Context, id string) (*types.Post, er
 	}
 
 	post.IsSelf = isSelf != 0
+	post.Stickied = stickied != 0
 
 	// Reconstruct Edited field
 	if editedUTC.Valid {
@@ -201,32 +304,355 @@ func (s *SQLiteStorage) GetPost(ctx context.Context, id string) (*types.Post, er
 
 // GetPostsBySubreddit retrieves posts from a subreddit with filtering options
 func (s *SQLiteStorage) GetPostsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+	}
+
+	query, args := postsQuery(postColumns, storage.PostFilter{Subreddit: subreddit}, opts)
+
+	// Execute query
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetPostCounts returns the ID, score and comment count of a subreddit's
+// posts, filtered, sorted and paginated by opts as for GetPostsBySubreddit,
+// without reading their raw JSON
+func (s *SQLiteStorage) GetPostCounts(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*storage.PostCounts, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_post_counts", Err: err}
+	}
+
+	query, args := postsQuery("p.id, p.score, p.num_comments", storage.PostFilter{Subreddit: subreddit}, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_post_counts", Err: err}
+	}
+	defer rows.Close()
+
+	var counts []*storage.PostCounts
+	for rows.Next() {
+		var c storage.PostCounts
+		if err := rows.Scan(&c.ID, &c.Score, &c.NumComments); err != nil {
+			return nil, &storage.StorageError{Op: "scan_post_counts", Err: err}
+		}
+		counts = append(counts, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_post_counts", Err: err}
+	}
+
+	return counts, nil
+}
+
+// FindPosts retrieves posts matching every set field of filter, combining a
+// text search with the structural filters in a single query. Dates, sorting
+// and pagination come from opts as for GetPostsBySubreddit, and
+// opts.SearchMode selects how filter.TextQuery is parsed.
+func (s *SQLiteStorage) FindPosts(ctx context.Context, filter storage.PostFilter, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "find_posts", Err: err}
+	}
+
+	query, args := postsQuery(postColumns, filter, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "find_posts", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetStoredPost retrieves a single post by ID along with the columns
+// storage keeps beyond types.Post, including whether it has been removed
+func (s *SQLiteStorage) GetStoredPost(ctx context.Context, id string) (*storage.StoredPost, error) {
+	query := `
+		SELECT ` + storedPostColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.id = ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_post", Err: err}
+	}
+	defer rows.Close()
+
+	posts, err := scanStoredPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, &storage.StorageError{Op: "get_stored_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
+	}
+
+	return posts[0], nil
+}
+
+// GetStoredPostsBySubreddit retrieves posts from a subreddit along with their
+// moderator fields
+func (s *SQLiteStorage) GetStoredPostsBySubreddit(ctx context.Context, subreddit string, opts storage.QueryOptions) ([]*storage.StoredPost, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_posts_by_subreddit", Err: err}
+	}
+
+	query, args := postsQuery(storedPostColumns, storage.PostFilter{Subreddit: subreddit}, opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_stored_posts_by_subreddit", Err: err}
+	}
+	defer rows.Close()
+
+	return scanStoredPosts(rows)
+}
+
+// scanStoredPosts scans rows selecting storedPostColumns
+func scanStoredPosts(rows *sql.Rows) ([]*storage.StoredPost, error) {
+	var posts []*storage.StoredPost
+	for rows.Next() {
+		var numReports sql.NullInt64
+		var removedByCategory sql.NullString
+		var account sql.NullString
+		var mediaType sql.NullString
+		var mediaWidth, mediaHeight sql.NullInt64
+		var moreComments sql.NullInt64
+		var removedAt sql.NullFloat64
+		var crosspostParent sql.NullString
+		var spoiler sql.NullBool
+
+		post, err := scanPost(rows, &numReports, &removedByCategory, &account, &mediaType, &mediaWidth, &mediaHeight, &moreComments, &removedAt, &crosspostParent, &spoiler)
+		if err != nil {
+			return nil, err
+		}
+
+		stored := &storage.StoredPost{Post: post, Account: account.String, CrosspostParentID: crosspostParent.String}
+		if numReports.Valid {
+			n := int(numReports.Int64)
+			stored.NumReports = &n
+		}
+		if removedByCategory.Valid {
+			stored.RemovedByCategory = &removedByCategory.String
+		}
+		if mediaType.Valid {
+			stored.Media = &storage.MediaInfo{
+				Type:   mediaType.String,
+				Width:  int(mediaWidth.Int64),
+				Height: int(mediaHeight.Int64),
+			}
+		}
+		if moreComments.Valid {
+			n := int(moreComments.Int64)
+			stored.MoreCommentsCount = &n
+		}
+		if removedAt.Valid {
+			t := unixFloatToTime(removedAt.Float64)
+			stored.RemovedAt = &t
+		}
+		if spoiler.Valid {
+			stored.Spoiler = &spoiler.Bool
+		}
+
+		posts = append(posts, stored)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_posts", Err: err}
+	}
+
+	return posts, nil
+}
+
+// GetPostsUpdatedSince retrieves posts saved at or after since, oldest save
+// first, so an external system can poll for changes by passing the time of
+// its previous poll. Every save counts, whether or not any value changed.
+// opts.Subreddit restricts the posts and opts.Limit/Offset paginate; other
+// options are ignored.
+func (s *SQLiteStorage) GetPostsUpdatedSince(ctx context.Context, since time.Time, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_updated_since", Err: err}
+	}
+
+	// last_updated holds CURRENT_TIMESTAMP text, which is UTC with second
+	// precision, so a save in the same second as since is included
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.last_updated >= ?
+	`
+	args := []interface{}{since.UTC().Format("2006-01-02 15:04:05")}
+
+	if opts.Subreddit != "" {
+		query += " AND p.subreddit = ?"
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+	}
+
+	limit, offset := pageBounds(opts)
+	query += " ORDER BY p.last_updated, p.id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_posts_updated_since", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// GetCrossposts retrieves the stored crossposts of a post, newest first. A
+// post with no stored crossposts returns none.
+func (s *SQLiteStorage) GetCrossposts(ctx context.Context, postID string) ([]*types.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.crosspost_parent_id = ?
+		ORDER BY p.created_utc DESC, p.id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_crossposts", Err: err}
+	}
+	defer rows.Close()
+
+	return s.scanPosts(rows)
+}
+
+// postColumns lists the posts columns read by scanPost, in scan order. It
+// selects from postsFrom so the subreddit comes back under its canonical name.
+const postColumns = `p.id, COALESCE(NULLIF(sr.display_name, ''), p.subreddit), p.author, p.title,
+		       p.selftext, p.url, p.score, p.upvote_ratio, p.num_comments, p.created_utc,
+		       p.edited_utc, p.is_self, p.is_video, p.raw_json, p.stickied,
+		       p.link_flair_text, p.author_flair_text, p.over_18, p.locked`
+
+// storedPostColumns extends postColumns with the columns scanStoredPosts
+// reads into a storage.StoredPost
+const storedPostColumns = postColumns + `, p.num_reports, p.removed_by_category, p.account,
+		       p.media_type, p.media_width, p.media_height, p.more_comments_count, p.removed_at,
+		       p.crosspost_parent_id, p.spoiler`
+
+// postsFrom joins posts (aliased p) to the subreddit row holding the
+// canonical display name
+const postsFrom = `posts p LEFT JOIN subreddits sr ON sr.name = p.subreddit`
+
+// archivedCommentsCount counts the stored comments of post p for the
+// "archived_comments" sort. It is evaluated per candidate post, so sorting a
+// large subreddit this way costs one indexed comments lookup per post.
+const archivedCommentsCount = `(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id)`
+
+// postsQuery builds the filtered, sorted and paginated query behind
+// GetPostsBySubreddit and FindPosts, selecting the given columns
+func postsQuery(columns string, filter storage.PostFilter, opts storage.QueryOptions) (string, []interface{}) {
 	// Build query with options
 	query := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE subreddit = ?
+		SELECT ` + columns + `
+		FROM ` + postsFrom + `
+		WHERE 1=1
 	`
 
 	var args []interface{}
-	args = append(args, subreddit)
+
+	if filter.Subreddit != "" {
+		query += " AND p.subreddit = ?"
+		args = append(args, storage.NormalizeSubreddit(filter.Subreddit))
+	}
+
+	if filter.Author != "" {
+		query += " AND p.author = ?"
+		args = append(args, filter.Author)
+	}
+
+	if filter.MinScore != nil {
+		query += " AND p.score >= ?"
+		args = append(args, *filter.MinScore)
+	}
+
+	if filter.TextQuery != "" {
+		where := "(p.title LIKE ? OR p.selftext LIKE ?)"
+		searchPattern := "%" + filter.TextQuery + "%"
+		textArgs := []interface{}{searchPattern, searchPattern}
+
+		if opts.SearchMode == storage.SearchModeWeb {
+			where, textArgs = webSearchFilter(storage.ParseWebSearch(filter.TextQuery))
+		}
+
+		query += " AND (" + where + ")"
+		args = append(args, textArgs...)
+	}
+
+	if filter.SavedSource != "" {
+		query += " AND p.id IN (SELECT item_id FROM saved_items WHERE source = ? AND is_comment = 0)"
+		args = append(args, filter.SavedSource)
+	}
 
 	// Add date filters if provided
 	if !opts.StartDate.IsZero() {
-		query += " AND created_utc >= ?"
+		query += " AND p.created_utc >= ?"
 		args = append(args, timeToUnixFloat(opts.StartDate))
 	}
 
 	if !opts.EndDate.IsZero() {
-		query += " AND created_utc <= ?"
+		query += " AND p.created_utc <= ?"
 		args = append(args, timeToUnixFloat(opts.EndDate))
 	}
 
-	// Add sorting
-	sortBy := opts.SortBy
-	if sortBy == "" {
-		sortBy = "created_utc"
+	if opts.RemovedOnly {
+		query += " AND p.removed_by_category IS NOT NULL"
+	}
+
+	if opts.ExcludeRemoved {
+		query += " AND p.removed_at IS NULL"
+	}
+
+	if opts.Account != "" {
+		query += " AND p.account = ?"
+		args = append(args, opts.Account)
+	}
+
+	if opts.Flair != "" {
+		query += " AND p.link_flair_text = ? COLLATE NOCASE"
+		args = append(args, opts.Flair)
+	}
+
+	if opts.MinComments != nil {
+		query += " AND p.num_comments >= ?"
+		args = append(args, *opts.MinComments)
+	}
+
+	if opts.ExcludeStickied {
+		query += " AND p.stickied = 0"
+	}
+
+	if opts.OnlyStickied {
+		query += " AND p.stickied = 1"
+	}
+
+	if opts.ExcludeNSFW {
+		query += " AND p.over_18 = 0"
+	}
+
+	if opts.NonEmptySelfText {
+		query += " AND p.is_self = 1 AND p.selftext != ''"
+	}
+
+	if opts.HasMedia {
+		// LIKE is case-insensitive for ASCII, which covers hosts and extensions
+		conds := []string{"p.is_video = 1"}
+		for _, pattern := range storage.MediaURLPatterns {
+			conds = append(conds, "p.url LIKE ?")
+			args = append(args, pattern)
+		}
+		query += " AND (" + strings.Join(conds, " OR ") + ")"
 	}
 
 	sortOrder := strings.ToUpper(opts.SortOrder)
@@ -234,13 +660,31 @@ func (s *SQLiteStorage) GetPostsBySubreddit(ctx context.Context, subreddit strin
 		sortOrder = "DESC"
 	}
 
+	// Keyset pagination continues strictly after the cursor in created
+	// order, using the ID to break ties between posts created together
+	if opts.After != nil {
+		cmp := "<"
+		if sortOrder == "ASC" {
+			cmp = ">"
+		}
+		query += fmt.Sprintf(" AND (p.created_utc, p.id) %s (?, ?)", cmp)
+		args = append(args, opts.After.CreatedUTC, opts.After.ID)
+	}
+
+	// Add sorting
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = "created_utc"
+	}
+
 	// Validate sort column to prevent SQL injection
 	validSortColumns := map[string]bool{
-		"created_utc":  true,
-		"created":      true,
-		"score":        true,
-		"num_comments": true,
-		"comments":     true,
+		"created_utc":       true,
+		"created":           true,
+		"score":             true,
+		"num_comments":      true,
+		"comments":          true,
+		"archived_comments": true,
 	}
 
 	if sortBy == "comments" {
@@ -253,23 +697,378 @@ func (s *SQLiteStorage) GetPostsBySubreddit(ctx context.Context, subreddit strin
 		sortBy = "created_utc"
 	}
 
-	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
-
 	// Add pagination
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 25
+	limit, offset := pageBounds(opts)
+
+	if opts.After != nil {
+		query += fmt.Sprintf(" ORDER BY p.created_utc %s, p.id %s", sortOrder, sortOrder)
+		query += " LIMIT ?"
+		args = append(args, limit)
+		return query, args
 	}
 
+	sortExpr := "p." + sortBy
+	if sortBy == "archived_comments" {
+		sortExpr = archivedCommentsCount
+	}
+
+	// The ID tie-breaker keeps ordering stable, so a first page fetched
+	// without a cursor lines up with the cursor pages that follow it
+	query += fmt.Sprintf(" ORDER BY %s %s, p.id %s", sortExpr, sortOrder, sortOrder)
 	query += " LIMIT ? OFFSET ?"
-	args = append(args, limit, opts.Offset)
+	args = append(args, limit, offset)
+
+	return query, args
+}
+
+// GetLatestPost retrieves the most recently created post stored for a subreddit
+func (s *SQLiteStorage) GetLatestPost(ctx context.Context, subreddit string) (*types.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE p.subreddit = ?
+		ORDER BY p.created_utc DESC
+		LIMIT 1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, storage.NormalizeSubreddit(subreddit))
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_latest_post", Err: err}
+	}
+	defer rows.Close()
+
+	posts, err := s.scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, &storage.StorageError{Op: "get_latest_post", Err: fmt.Errorf("post %w for subreddit: %s", storage.ErrNotFound, subreddit)}
+	}
+
+	return posts[0], nil
+}
+
+// GetStoredNumComments returns the num_comments last stored for each post in
+// ids, so the archiver can tell which posts are stored at all. IDs that
+// aren't stored are absent from the result.
+func (s *SQLiteStorage) GetStoredNumComments(ctx context.Context, ids []string) (map[string]int, error) {
+	return s.countsByID(ctx, "get_stored_num_comments", "num_comments", ids)
+}
+
+// GetCommentsFetched returns the num_comments each of ids had when its
+// comments were last fetched and saved, as recorded by MarkCommentsFetched.
+// Posts whose comments were never fetched are absent from the result.
+func (s *SQLiteStorage) GetCommentsFetched(ctx context.Context, ids []string) (map[string]int, error) {
+	return s.countsByID(ctx, "get_comments_fetched", "comments_fetched_num", ids)
+}
+
+// MarkCommentsFetched records that postID's comments were fetched and saved
+// while it had numComments comments
+func (s *SQLiteStorage) MarkCommentsFetched(ctx context.Context, postID string, numComments int) error {
+	if err := s.checkWritable("mark_comments_fetched"); err != nil {
+		return err
+	}
+
+	err := s.withBusyRetry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx, "UPDATE posts SET comments_fetched_num = ? WHERE id = ?", numComments, postID)
+		return err
+	})
+	if err != nil {
+		return &storage.StorageError{Op: "mark_comments_fetched", Err: err}
+	}
+	return nil
+}
+
+// countsByID reads an integer column of the posts in ids, keyed by ID.
+// Posts that aren't stored or hold NULL are absent from the result.
+func (s *SQLiteStorage) countsByID(ctx context.Context, op, column string, ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+
+	for start := 0; start < len(ids); start += idChunkSize {
+		chunk := ids[start:min(start+idChunkSize, len(ids))]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		query := "SELECT id, " + column + " FROM posts WHERE id IN (" + placeholders + ") AND " + column + " IS NOT NULL"
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, &storage.StorageError{Op: op, Err: err}
+		}
+
+		for rows.Next() {
+			var id string
+			var count int
+			if err := rows.Scan(&id, &count); err != nil {
+				rows.Close()
+				return nil, &storage.StorageError{Op: op, Err: err}
+			}
+			counts[id] = count
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, &storage.StorageError{Op: op, Err: err}
+		}
+	}
+
+	return counts, nil
+}
+
+// idChunkSize keeps DeletePosts, GetArchivedCommentCounts and countsByID
+// well under SQLite's bound parameter limit
+const idChunkSize = 500
+
+// DeletePosts deletes posts by ID along with their comments in a single
+// transaction, returning how many posts were deleted. IDs that aren't stored
+// are ignored.
+func (s *SQLiteStorage) DeletePosts(ctx context.Context, ids []string) (int, error) {
+	if err := s.checkWritable("delete_posts"); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var deleted int
+	err := s.withBusyRetry(ctx, func() error {
+		var err error
+		deleted, _, err = s.deletePosts(ctx, ids)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (s *SQLiteStorage) deletePosts(ctx context.Context, ids []string) (posts, comments int, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, &storage.StorageError{Op: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(ids); start += idChunkSize {
+		chunk := ids[start:min(start+idChunkSize, len(ids))]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		// Count before deleting; replies removed by the parent_id cascade
+		// aren't reported as affected rows
+		var chunkComments int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments WHERE post_id IN ("+placeholders+")", args...).Scan(&chunkComments); err != nil {
+			return 0, 0, &storage.StorageError{Op: "count_comments", Err: err}
+		}
+		comments += chunkComments
+
+		// Delete comments, revisions, score history, media and saved items
+		// explicitly; foreign key cascades depend on a per-connection pragma
+		// that may not be set on this connection
+		if _, err := tx.ExecContext(ctx, "DELETE FROM comment_revisions WHERE comment_id IN (SELECT id FROM comments WHERE post_id IN ("+placeholders+"))", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_revisions", Err: err}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM post_revisions WHERE post_id IN ("+placeholders+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_revisions", Err: err}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM score_history WHERE post_id IN ("+placeholders+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_score_history", Err: err}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM post_media WHERE post_id IN ("+placeholders+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_post_media", Err: err}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM saved_items WHERE post_id IN ("+placeholders+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_saved_items", Err: err}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE post_id IN ("+placeholders+")", args...); err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_comments", Err: err}
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM posts WHERE id IN ("+placeholders+")", args...)
+		if err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_posts", Err: err}
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, &storage.StorageError{Op: "delete_posts", Err: err}
+		}
+		posts += int(affected)
+	}
+
+	// Comments go with their posts, so the post events cover them
+	if err := s.appendOutbox(ctx, tx, storage.OutboxOpDelete, storage.OutboxEntityPost, ids...); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, &storage.StorageError{Op: "commit_transaction", Err: err}
+	}
+
+	return posts, comments, nil
+}
+
+// pruneBatchSize is how many posts PrunePosts deletes per transaction, so
+// a large prune never holds the write lock for long
+const pruneBatchSize = idChunkSize
+
+// PrunePosts deletes the posts opts selects along with their comments,
+// pruneBatchSize posts per transaction, and counts what it deleted. If a
+// batch fails or ctx is cancelled, the counts so far are returned with the
+// error; the batches already deleted stay deleted.
+func (s *SQLiteStorage) PrunePosts(ctx context.Context, opts storage.PruneOptions) (*storage.PruneResult, error) {
+	if err := s.checkWritable("prune_posts"); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+	}
+
+	result := &storage.PruneResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		ids, err := s.prunableIDs(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+
+		var posts, comments int
+		err = s.withBusyRetry(ctx, func() error {
+			var err error
+			posts, comments, err = s.deletePosts(ctx, ids)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		result.PostsDeleted += posts
+		result.CommentsDeleted += comments
+
+		// Nothing left that another batch would find
+		if posts == 0 || len(ids) < pruneBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// prunableIDs returns up to pruneBatchSize IDs of posts PrunePosts deletes
+func (s *SQLiteStorage) prunableIDs(ctx context.Context, opts storage.PruneOptions) ([]string, error) {
+	query := "SELECT id FROM posts WHERE created_utc < ?"
+	args := []interface{}{timeToUnixFloat(opts.Before)}
+	if opts.Subreddit != "" {
+		query += " AND subreddit = ?"
+		args = append(args, storage.NormalizeSubreddit(opts.Subreddit))
+	}
+	if opts.KeepMinScore > 0 {
+		query += " AND score < ?"
+		args = append(args, opts.KeepMinScore)
+	}
+	query += " LIMIT ?"
+	args = append(args, pruneBatchSize)
 
-	// Execute query
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_posts_by_subreddit", Err: err}
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
 	}
 	defer rows.Close()
 
-	return s.scanPosts(rows)
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "prune_posts", Err: err}
+	}
+
+	return ids, nil
+}
+
+// DeletePost deletes a post along with its comments in a single
+// transaction. A post that isn't stored returns an error wrapping
+// storage.ErrNotFound.
+func (s *SQLiteStorage) DeletePost(ctx context.Context, id string) error {
+	deleted, err := s.DeletePosts(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return &storage.StorageError{Op: "delete_post", Err: fmt.Errorf("post %w: %s", storage.ErrNotFound, id)}
+	}
+	return nil
+}
+
+// nullIfEmpty stores an empty string as NULL so COALESCE keeps the old value
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// boolInt stores a bool as SQLite's 0 or 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// nullableBool stores a nil bool as NULL so COALESCE keeps the old value
+func nullableBool(b *bool) interface{} {
+	if b == nil {
+		return nil
+	}
+	return boolInt(*b)
+}
+
+// mediaValues returns the media_type, media_width and media_height values
+// to store for a post, all NULL when it has no media metadata
+func mediaValues(media *storage.MediaInfo) (interface{}, interface{}, interface{}) {
+	if media == nil {
+		return nil, nil, nil
+	}
+	return media.Type, media.Width, media.Height
+}
+
+// firstSeenUpdates returns the upsert assignments that refresh a re-saved
+// post's author and title, or nothing when Options.PreserveFirstSeen keeps
+// the values from the first save
+func (s *SQLiteStorage) firstSeenUpdates() string {
+	if s.opts.PreserveFirstSeen {
+		return ""
+	}
+	return "author = excluded.author, title = excluded.title,"
+}
+
+// removedUpdates are the upsert assignments that record when a re-saved post
+// was first seen removed, clearing it if the post is live again, and keep
+// the archived raw JSON while it is removed
+const removedUpdates = `removed_at = CASE WHEN excluded.removed_at IS NULL THEN NULL ELSE COALESCE(posts.removed_at, excluded.removed_at) END,
+			raw_json = CASE WHEN excluded.removed_at IS NULL THEN excluded.raw_json ELSE posts.raw_json END`
+
+// removedAt returns the removed_at value to save with post: the current time
+// if Reddit shows it as removed or deleted, NULL otherwise
+func removedAt(post *storage.StoredPost) interface{} {
+	if !post.IsRemoved() {
+		return nil
+	}
+	return timeToUnixFloat(time.Now())
 }
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Default busy retry settings, used when the corresponding Options are zero
+const (
+	defaultBusyRetries      = 5
+	defaultBusyRetryBackoff = 25 * time.Millisecond
+)
+
+// withBusyRetry runs a write, retrying it with jittered exponential backoff
+// while it fails with SQLITE_BUSY or SQLITE_LOCKED. fn must be safe to re-run
+// from the start, so callers wrap a whole transaction rather than a single
+// statement inside one.
+func (s *SQLiteStorage) withBusyRetry(ctx context.Context, fn func() error) error {
+	retries := s.opts.BusyRetries
+	if retries == 0 {
+		retries = defaultBusyRetries
+	}
+
+	backoff := s.opts.BusyRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultBusyRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isBusyError(err) {
+			return err
+		}
+
+		// Sleep between half and all of the doubled delay so concurrent
+		// writers don't retry in lockstep
+		delay := backoff << attempt
+		delay = delay/2 + rand.N(delay/2+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isBusyError reports whether err is SQLite reporting that the database or a
+// table is locked by another connection
+func isBusyError(err error) bool {
+	var coded interface{ Code() int }
+	if !errors.As(err, &coded) {
+		return false
+	}
+
+	// Extended result codes keep the primary code in the low byte
+	switch coded.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// postRevisionQuery copies a post's stored selftext into post_revisions when
+// the incoming selftext differs. Arguments: replaced_at, post ID, incoming
+// selftext.
+const postRevisionQuery = `
+	INSERT INTO post_revisions (post_id, selftext, score, edited_utc, replaced_at)
+	SELECT id, selftext, score, edited_utc, ?
+	FROM posts
+	WHERE id = ? AND selftext IS NOT ?
+`
+
+// commentRevisionQuery copies a comment's stored body into comment_revisions
+// when the incoming body differs. Arguments: replaced_at, comment ID,
+// incoming body.
+const commentRevisionQuery = `
+	INSERT INTO comment_revisions (comment_id, body, score, edited_utc, replaced_at)
+	SELECT id, body, score, edited_utc, ?
+	FROM comments
+	WHERE id = ? AND body IS NOT ?
+`
+
+// postRevision returns the statement recording post's stored revision before
+// it is saved again, or nil when Options.KeepRevisions is off or Reddit shows
+// the post as removed, in which case the stored selftext is kept
+func (s *SQLiteStorage) postRevision(post *storage.StoredPost) *statement {
+	if !s.opts.KeepRevisions || post.IsRemoved() {
+		return nil
+	}
+	return &statement{
+		query: postRevisionQuery,
+		args:  []interface{}{timeToUnixFloat(time.Now()), post.ID, post.SelfText},
+	}
+}
+
+// commentRevision returns the statement recording comment's stored revision
+// before it is saved again, or nil when Options.KeepRevisions is off
+func (s *SQLiteStorage) commentRevision(comment *types.Comment) *statement {
+	if !s.opts.KeepRevisions {
+		return nil
+	}
+	return &statement{
+		query: commentRevisionQuery,
+		args:  []interface{}{timeToUnixFloat(time.Now()), comment.ID, comment.Body},
+	}
+}
+
+// selftextUpdates returns the upsert assignment that refreshes a re-saved
+// post's selftext when Options.KeepRevisions keeps the earlier one, unless
+// the post is removed
+func (s *SQLiteStorage) selftextUpdates() string {
+	if !s.opts.KeepRevisions {
+		return ""
+	}
+	return "selftext = CASE WHEN excluded.removed_at IS NULL THEN excluded.selftext ELSE posts.selftext END,"
+}
+
+// GetPostRevisions retrieves the earlier versions of a post's selftext
+// recorded with Options.KeepRevisions, oldest first. A post without recorded
+// revisions returns none.
+func (s *SQLiteStorage) GetPostRevisions(ctx context.Context, id string) ([]*storage.Revision, error) {
+	query := `
+		SELECT selftext, score, edited_utc, replaced_at
+		FROM post_revisions
+		WHERE post_id = ?
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_post_revisions", Err: err}
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// GetCommentRevisions retrieves the earlier versions of a comment's body
+// recorded with Options.KeepRevisions, oldest first. A comment without
+// recorded revisions returns none.
+func (s *SQLiteStorage) GetCommentRevisions(ctx context.Context, id string) ([]*storage.Revision, error) {
+	query := `
+		SELECT body, score, edited_utc, replaced_at
+		FROM comment_revisions
+		WHERE comment_id = ?
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comment_revisions", Err: err}
+	}
+	defer rows.Close()
+
+	return scanRevisions(rows)
+}
+
+// scanRevisions scans rows of body, score, edited_utc and replaced_at
+func scanRevisions(rows *sql.Rows) ([]*storage.Revision, error) {
+	var revisions []*storage.Revision
+	for rows.Next() {
+		var body sql.NullString
+		var score sql.NullInt64
+		var editedUTC sql.NullFloat64
+		var replacedAt float64
+
+		if err := rows.Scan(&body, &score, &editedUTC, &replacedAt); err != nil {
+			return nil, &storage.StorageError{Op: "scan_revision", Err: err}
+		}
+
+		revisions = append(revisions, &storage.Revision{
+			Body:       body.String,
+			Score:      int(score.Int64),
+			EditedUTC:  editedUTC.Float64,
+			ReplacedAt: unixFloatToTime(replacedAt),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_revisions", Err: err}
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// SaveSavedItems records saved and upvoted items in a transaction. An item
+// already recorded from the same listing keeps its first RecordedAt.
+func (s *SQLiteStorage) SaveSavedItems(ctx context.Context, items []*storage.SavedItem) error {
+	if err := s.checkWritable("save_saved_items"); err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	err := s.withBusyRetry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO saved_items (source, item_id, is_comment, post_id, recorded_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (source, item_id) DO NOTHING
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, item := range items {
+			isComment := 0
+			if item.IsComment {
+				isComment = 1
+			}
+			if _, err := stmt.ExecContext(ctx,
+				item.Source, item.ID, isComment, item.PostID, timeToUnixFloat(item.RecordedAt),
+			); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+
+	if err != nil {
+		return &storage.StorageError{Op: "save_saved_items", Err: err}
+	}
+
+	return nil
+}
+
+// GetSavedItems returns the items recorded from a saved or upvoted listing,
+// most recently recorded first, paginated by opts.Limit and opts.Offset
+func (s *SQLiteStorage) GetSavedItems(ctx context.Context, source string, opts storage.QueryOptions) ([]*storage.SavedItem, error) {
+	limit, offset := pageBounds(opts)
+	query := `
+		SELECT source, item_id, is_comment, post_id, recorded_at
+		FROM saved_items
+		WHERE source = ?
+		ORDER BY recorded_at DESC, item_id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, source, limit, offset)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_saved_items", Err: err}
+	}
+	defer rows.Close()
+
+	var items []*storage.SavedItem
+	for rows.Next() {
+		var item storage.SavedItem
+		var recordedAt float64
+		if err := rows.Scan(&item.Source, &item.ID, &item.IsComment, &item.PostID, &recordedAt); err != nil {
+			return nil, &storage.StorageError{Op: "scan_saved_item", Err: err}
+		}
+		item.RecordedAt = unixFloatToTime(recordedAt)
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_saved_items", Err: err}
+	}
+
+	return items, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -16,12 +17,131 @@ import (
 
 // SQLiteStorage implements the Storage interface for SQLite
 type SQLiteStorage struct {
-	db *sql.DB
+	db   *sql.DB
+	opts Options
 }
 
-// New creates a new SQLite storage instance
+// defaultMaxBatchSize is used when Options.MaxBatchSize is unset
+const defaultMaxBatchSize = 1000
+
+// defaultQueryLimit is the page size used when QueryOptions.Limit is unset
+const defaultQueryLimit = 25
+
+// defaultThreadQueryTimeout is used when Options.ThreadQueryTimeout is unset
+const defaultThreadQueryTimeout = 30 * time.Second
+
+// Options configures SQLite storage behavior
+type Options struct {
+	// MaxBatchSize caps how many comments SaveComments writes per transaction.
+	// Larger batches are split into several transactions so a very wide thread
+	// doesn't hold the database write lock for the whole save.
+	// Default: 1000
+	MaxBatchSize int
+
+	// ReadOnly opens the database with mode=ro and rejects every write
+	// (Save/Delete methods and RunMigrations) with storage.ErrReadOnly.
+	// Default: false
+	ReadOnly bool
+
+	// BusyRetries is how many times a write failing with SQLITE_BUSY or
+	// SQLITE_LOCKED is retried before the error is returned. Negative
+	// disables retrying.
+	// Default: 5
+	BusyRetries int
+
+	// BusyRetryBackoff is the base delay before the first busy retry. It
+	// doubles on each attempt and is jittered.
+	// Default: 25ms
+	BusyRetryBackoff time.Duration
+
+	// StoreCommentRawJSON keeps each comment's full API response in
+	// raw_json. Turning it off stores NULL instead, which roughly halves
+	// comment storage; nothing on the read path needs it. Re-saving a
+	// comment with it off clears previously stored JSON. Being a bool,
+	// it is only on by default when starting from DefaultOptions.
+	// Default: true
+	StoreCommentRawJSON bool
+
+	// Outbox appends an event to the outbox table for every saved post,
+	// comment and subreddit and every deleted post, in the same
+	// transaction as the change. Consumers page through the events with
+	// ReadOutbox and remove them with AckOutbox.
+	// Default: false
+	Outbox bool
+
+	// ThreadQueryTimeout bounds each recursive comment-tree query
+	// (GetCommentsByPost, GetCommentTreeNested, ExportPostMarkdown and
+	// GetPostStats), so one giant thread can't hold a connection for long.
+	// The query fails with an error wrapping context.DeadlineExceeded when
+	// it runs out. Negative leaves only the caller's context in charge.
+	// Default: 30s
+	ThreadQueryTimeout time.Duration
+
+	// PreserveFirstSeen keeps the author and title a post was first saved
+	// with when it is saved again. Turning it off refreshes them on every
+	// save, e.g. to pick up an author later shown as [deleted]. created_utc
+	// is never overwritten either way. Like StoreCommentRawJSON it is only
+	// on by default when starting from DefaultOptions.
+	// Default: true
+	PreserveFirstSeen bool
+
+	// KeepRevisions records the stored selftext or body, score and edit
+	// time of a post or comment in post_revisions or comment_revisions
+	// whenever a save brings different content, so GetPostRevisions and
+	// GetCommentRevisions can reconstruct its edit history. With it on,
+	// re-saving a post also refreshes its selftext, which is otherwise kept
+	// from the first save; a removed post's placeholder is never stored
+	// over it.
+	// Default: false
+	KeepRevisions bool
+}
+
+// DefaultOptions returns the default SQLite storage options
+func DefaultOptions() *Options {
+	return &Options{
+		MaxBatchSize:        defaultMaxBatchSize,
+		BusyRetries:         defaultBusyRetries,
+		BusyRetryBackoff:    defaultBusyRetryBackoff,
+		ThreadQueryTimeout:  defaultThreadQueryTimeout,
+		StoreCommentRawJSON: true,
+		PreserveFirstSeen:   true,
+	}
+}
+
+func init() {
+	storage.Register("sqlite", openConfig)
+}
+
+// openConfig opens SQLite storage for storage.Open
+func openConfig(cfg storage.Config) (storage.Storage, error) {
+	var opts *Options
+	if cfg.Options != nil {
+		var ok bool
+		if opts, ok = cfg.Options.(*Options); !ok {
+			return nil, &storage.StorageError{Op: "open", Err: fmt.Errorf("options of type %T are not *sqlite.Options", cfg.Options)}
+		}
+	}
+
+	return NewWithOptions(cfg.DSN, opts)
+}
+
+// New creates a new SQLite storage instance with default options
 func New(dbPath string) (*SQLiteStorage, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	return NewWithOptions(dbPath, DefaultOptions())
+}
+
+// NewWithOptions creates a new SQLite storage instance with custom options
+func NewWithOptions(dbPath string, opts *Options) (*SQLiteStorage, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	dsn := dbPath
+	if opts.ReadOnly {
+		dsn = readOnlyDSN(dbPath)
+	}
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, &storage.StorageError{Op: "open", Err: err}
 	}
@@ -31,15 +151,89 @@ func New(dbPath string) (*SQLiteStorage, error) {
 		return nil, &storage.StorageError{Op: "enable_foreign_keys", Err: err}
 	}
 
-	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
-		return nil, &storage.StorageError{Op: "enable_wal", Err: err}
+	// Switching journal mode is a write, so a read-only handle keeps
+	// whatever mode the database file already uses
+	if !opts.ReadOnly {
+		if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+			return nil, &storage.StorageError{Op: "enable_wal", Err: err}
+		}
+	}
+
+	return &SQLiteStorage{db: db, opts: *opts}, nil
+}
+
+// readOnlyDSN converts a database path into a URI that opens it read-only
+func readOnlyDSN(dbPath string) string {
+	if strings.HasPrefix(dbPath, "file:") {
+		if strings.Contains(dbPath, "?") {
+			return dbPath + "&mode=ro"
+		}
+		return dbPath + "?mode=ro"
+	}
+	return "file:" + dbPath + "?mode=ro"
+}
+
+// checkWritable rejects write operations when the storage is read-only
+func (s *SQLiteStorage) checkWritable(op string) error {
+	if s.opts.ReadOnly {
+		return &storage.StorageError{Op: op, Err: storage.ErrReadOnly}
+	}
+	return nil
+}
+
+// maxBatchSize returns the comment batch size for a save: opts.BatchSize if
+// set, otherwise the configured size or the default
+func (s *SQLiteStorage) maxBatchSize(opts storage.SaveCommentsOptions) int {
+	if opts.BatchSize > 0 {
+		return opts.BatchSize
+	}
+	if s.opts.MaxBatchSize <= 0 {
+		return defaultMaxBatchSize
 	}
+	return s.opts.MaxBatchSize
+}
 
-	return &SQLiteStorage{db: db}, nil
+// threadQueryContext derives the context for a recursive comment-tree query,
+// bounded by Options.ThreadQueryTimeout
+func (s *SQLiteStorage) threadQueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.opts.ThreadQueryTimeout
+	if timeout == 0 {
+		timeout = defaultThreadQueryTimeout
+	}
+	if timeout < 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// queryError wraps a failed query's error for op. When ctx was cancelled or
+// timed out the driver reports that in its own words, so the context's
+// error is returned instead for callers to match with errors.Is.
+func queryError(ctx context.Context, op string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	return &storage.StorageError{Op: op, Err: err}
+}
+
+// pageBounds returns the LIMIT and OFFSET for a paginated query. A zero or
+// negative opts.Limit falls back to the default page size and a negative
+// opts.Offset starts from the first row, so bad caller input can't reach
+// the SQL.
+func pageBounds(opts storage.QueryOptions) (limit, offset int) {
+	limit = opts.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	return limit, max(opts.Offset, 0)
 }
 
 // RunMigrations runs all pending database migrations
 func (s *SQLiteStorage) RunMigrations(ctx context.Context) error {
+	if err := s.checkWritable("run_migrations"); err != nil {
+		return err
+	}
+
 	runner, err := schema.NewMigrationRunner(s.db, "sqlite")
 	if err != nil {
 		return &storage.StorageError{Op: "create_migration_runner", Err: err}
@@ -60,8 +254,14 @@ func (s *SQLiteStorage) Close() error {
 	return nil
 }
 
-// SaveSubreddit saves or updates a subreddit
+// SaveSubreddit saves or updates a subreddit. Empty titles and descriptions
+// and zero subscriber counts keep the stored values, so saving a stub that
+// only names the subreddit never erases metadata fetched earlier.
 func (s *SQLiteStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditData) error {
+	if err := s.checkWritable("save_subreddit"); err != nil {
+		return err
+	}
+
 	rawJSON, err := json.Marshal(sub)
 	if err != nil {
 		return &storage.StorageError{Op: "marshal_subreddit", Err: err}
@@ -74,15 +274,19 @@ func (s *SQLiteStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditD
 		) VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT (name) DO UPDATE SET
 			display_name = excluded.display_name,
-			title = excluded.title,
-			description = excluded.description,
-			subscribers = excluded.subscribers,
+			title = COALESCE(NULLIF(excluded.title, ''), subreddits.title, excluded.title),
+			description = COALESCE(NULLIF(excluded.description, ''), subreddits.description, excluded.description),
+			subscribers = CASE WHEN excluded.subscribers > 0 OR subreddits.subscribers IS NULL THEN excluded.subscribers ELSE subreddits.subscribers END,
 			last_synced = CURRENT_TIMESTAMP,
-			raw_json = excluded.raw_json
+			raw_json = CASE
+				WHEN excluded.title != '' OR excluded.description != '' OR excluded.subscribers > 0 OR subreddits.raw_json IS NULL THEN excluded.raw_json
+				ELSE subreddits.raw_json
+			END
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
-		sub.DisplayName, sub.DisplayName, sub.Title, sub.Description,
+	name := storage.NormalizeSubreddit(sub.DisplayName)
+	err = s.execWithOutbox(ctx, storage.OutboxOpSave, storage.OutboxEntitySubreddit, name, query,
+		name, sub.DisplayName, sub.Title, sub.Description,
 		sub.Subscribers, nil, string(rawJSON), // created_utc not available
 	)
 
@@ -93,6 +297,28 @@ func (s *SQLiteStorage) SaveSubreddit(ctx context.Context, sub *types.SubredditD
 	return nil
 }
 
+// ensureSubreddit creates a placeholder row for a subreddit referenced by a
+// post. An existing row is left alone so its canonical display name and
+// metadata aren't overwritten by however the post spelled the name.
+func (s *SQLiteStorage) ensureSubreddit(ctx context.Context, name string) error {
+	query := `
+		INSERT INTO subreddits (name, display_name, last_synced)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO NOTHING
+	`
+
+	err := s.withBusyRetry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx, query, storage.NormalizeSubreddit(name), name)
+		return err
+	})
+
+	if err != nil {
+		return &storage.StorageError{Op: "ensure_subreddit", Err: err}
+	}
+
+	return nil
+}
+
 // GetSubreddit retrieves a subreddit by name
 func (s *SQLiteStorage) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
 	query := `
@@ -105,13 +331,13 @@ func (s *SQLiteStorage) GetSubreddit(ctx context.Context, name string) (*types.S
 	var rawJSON string
 	var createdUTC sql.NullString
 
-	err := s.db.QueryRowContext(ctx, query, name).Scan(
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(name)).Scan(
 		&sub.DisplayName, &sub.DisplayName, &sub.Title, &sub.Description,
 		&sub.Subscribers, &createdUTC, &rawJSON,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, &storage.StorageError{Op: "get_subreddit", Err: fmt.Errorf("subreddit not found: %s", name)}
+		return nil, &storage.StorageError{Op: "get_subreddit", Err: fmt.Errorf("subreddit %w: %s", storage.ErrNotFound, name)}
 	}
 
 	if err != nil {
@@ -121,25 +347,152 @@ func (s *SQLiteStorage) GetSubreddit(ctx context.Context, name string) (*types.S
 	return &sub, nil
 }
 
+// ListSubreddits returns stored subreddits a page at a time, optionally
+// filtered to names starting with opts.Search. SortBy may be "name" (the
+// default, ascending) or "subscribers" (descending by default).
+func (s *SQLiteStorage) ListSubreddits(ctx context.Context, opts storage.QueryOptions) ([]*types.SubredditData, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+
+	query, args := listSubredditsQuery(opts)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+	defer rows.Close()
+
+	var subs []*types.SubredditData
+	for rows.Next() {
+		var sub types.SubredditData
+		if err := rows.Scan(&sub.DisplayName, &sub.Title, &sub.Description, &sub.Subscribers); err != nil {
+			return nil, &storage.StorageError{Op: "scan_subreddit", Err: err}
+		}
+		subs = append(subs, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddits", Err: err}
+	}
+
+	return subs, nil
+}
+
+// ListSubredditSummaries returns every stored subreddit with counts of its
+// posts and comments and the span of its posts, ordered by name, in a
+// single aggregate query. Subreddits with a row but no posts are included
+// with zero counts.
+func (s *SQLiteStorage) ListSubredditSummaries(ctx context.Context) ([]*storage.SubredditSummary, error) {
+	query := `
+		SELECT s.name, COALESCE(p.post_count, 0), COALESCE(c.comment_count, 0),
+			p.oldest, p.newest, s.last_synced
+		FROM subreddits s
+		LEFT JOIN (
+			SELECT subreddit, COUNT(*) AS post_count,
+				MIN(CAST(created_utc AS REAL)) AS oldest, MAX(CAST(created_utc AS REAL)) AS newest
+			FROM posts
+			GROUP BY subreddit
+		) p ON p.subreddit = s.name
+		LEFT JOIN (
+			SELECT subreddit, COUNT(*) AS comment_count
+			FROM comments
+			GROUP BY subreddit
+		) c ON c.subreddit = s.name
+		ORDER BY s.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddit_summaries", Err: err}
+	}
+	defer rows.Close()
+
+	var summaries []*storage.SubredditSummary
+	for rows.Next() {
+		var summary storage.SubredditSummary
+		var oldest, newest sql.NullFloat64
+		var lastSynced sql.NullString
+		if err := rows.Scan(&summary.Name, &summary.PostCount, &summary.CommentCount, &oldest, &newest, &lastSynced); err != nil {
+			return nil, &storage.StorageError{Op: "scan_subreddit_summary", Err: err}
+		}
+		summary.OldestPost = unixFloatToTime(oldest.Float64)
+		summary.NewestPost = unixFloatToTime(newest.Float64)
+		if lastSynced.Valid {
+			if parsed, parseErr := time.Parse("2006-01-02 15:04:05", lastSynced.String); parseErr == nil {
+				summary.LastSynced = parsed
+			}
+		}
+		summaries = append(summaries, &summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "list_subreddit_summaries", Err: err}
+	}
+
+	return summaries, nil
+}
+
+// listSubredditsQuery builds the ListSubreddits query. Placeholder rows
+// created for posts have no metadata yet, so nullable columns are coalesced.
+func listSubredditsQuery(opts storage.QueryOptions) (string, []interface{}) {
+	query := `
+		SELECT COALESCE(display_name, name), COALESCE(title, ''),
+			COALESCE(description, ''), COALESCE(subscribers, 0)
+		FROM subreddits
+	`
+
+	var args []interface{}
+	if opts.Search != "" {
+		query += ` WHERE name LIKE ? ESCAPE '\'`
+		args = append(args, escapeLike(storage.NormalizeSubreddit(opts.Search))+"%")
+	}
+
+	sortBy := "name"
+	sortOrder := "ASC"
+	if opts.SortBy == "subscribers" {
+		sortBy = "subscribers"
+		sortOrder = "DESC"
+	}
+	if o := strings.ToUpper(opts.SortOrder); o == "ASC" || o == "DESC" {
+		sortOrder = o
+	}
+
+	limit, offset := pageBounds(opts)
+
+	query += fmt.Sprintf(" ORDER BY %s %s, name ASC LIMIT ? OFFSET ?", sortBy, sortOrder)
+	args = append(args, limit, offset)
+
+	return query, args
+}
+
 // SearchPosts searches for posts (basic implementation for SQLite)
 func (s *SQLiteStorage) SearchPosts(ctx context.Context, query string, opts storage.QueryOptions) ([]*types.Post, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, &storage.StorageError{Op: "search_posts", Err: err}
+	}
+
 	// SQLite doesn't have full-text search by default, so we use LIKE
+	where := "p.title LIKE ? OR p.selftext LIKE ?"
+	searchPattern := "%" + query + "%"
+	args := []interface{}{searchPattern, searchPattern}
+
+	if opts.SearchMode == storage.SearchModeWeb {
+		where, args = webSearchFilter(storage.ParseWebSearch(query))
+	}
+
 	sqlQuery := `
-		SELECT id, subreddit, author, title, selftext, url, score, upvote_ratio,
-		       num_comments, created_utc, edited_utc, is_self, is_video, raw_json
-		FROM posts
-		WHERE title LIKE ? OR selftext LIKE ?
-		ORDER BY score DESC
+		SELECT ` + postColumns + `
+		FROM ` + postsFrom + `
+		WHERE ` + where + `
+		ORDER BY p.score DESC
 		LIMIT ? OFFSET ?
 	`
 
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 25
-	}
+	limit, offset := pageBounds(opts)
 
-	searchPattern := "%" + query + "%"
-	rows, err := s.db.QueryContext(ctx, sqlQuery, searchPattern, searchPattern, limit, opts.Offset)
+	args = append(args, limit, offset)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, &storage.StorageError{Op: "search_posts", Err: err}
 	}
@@ -148,6 +501,40 @@ func (s *SQLiteStorage) SearchPosts(ctx context.Context, query string, opts stor
 	return s.scanPosts(rows)
 }
 
+// webSearchFilter translates parsed web search clauses into LIKE conditions
+// on title and selftext. Words match as substrings, so this approximates
+// PostgreSQL's websearch_to_tsquery rather than reproducing its stemming.
+func webSearchFilter(clauses [][]storage.SearchTerm) (string, []interface{}) {
+	if len(clauses) == 0 {
+		// Like websearch_to_tsquery, a query with no terms matches nothing
+		return "0", nil
+	}
+
+	var args []interface{}
+	ands := make([]string, len(clauses))
+	for i, clause := range clauses {
+		ors := make([]string, len(clause))
+		for j, term := range clause {
+			match := `(p.title LIKE ? ESCAPE '\' OR COALESCE(p.selftext, '') LIKE ? ESCAPE '\')`
+			if term.Negated {
+				match = "NOT " + match
+			}
+			ors[j] = match
+
+			pattern := "%" + escapeLike(term.Text) + "%"
+			args = append(args, pattern, pattern)
+		}
+		ands[i] = "(" + strings.Join(ors, " OR ") + ")"
+	}
+
+	return strings.Join(ands, " AND "), args
+}
+
+// escapeLike escapes LIKE wildcards so a term matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // GetPostStats returns statistics about a post
 func (s *SQLiteStorage) GetPostStats(ctx context.Context, postID string) (*storage.PostStats, error) {
 	query := `
@@ -175,12 +562,15 @@ func (s *SQLiteStorage) GetPostStats(ctx context.Context, postID string) (*stora
 
 	var lastUpdated sql.NullString
 
+	ctx, cancel := s.threadQueryContext(ctx)
+	defer cancel()
+
 	err := s.db.QueryRowContext(ctx, query, postID, postID).Scan(
 		&stats.CommentCount, &stats.MaxCommentDepth, &lastUpdated,
 	)
 
 	if err != nil {
-		return nil, &storage.StorageError{Op: "get_post_stats", Err: err}
+		return nil, queryError(ctx, "get_post_stats", err)
 	}
 
 	if lastUpdated.Valid {
@@ -192,43 +582,126 @@ func (s *SQLiteStorage) GetPostStats(ctx context.Context, postID string) (*stora
 	return &stats, nil
 }
 
-// scanPosts is a helper function to scan post rows
-func (s *SQLiteStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
-	var posts []*types.Post
+// GetCommentDepthHistogram counts a post's stored comments at each depth,
+// keyed by depth (0 for top-level). A post with no stored comments yields
+// an empty map.
+func (s *SQLiteStorage) GetCommentDepthHistogram(ctx context.Context, postID string) (map[int]int, error) {
+	query := `
+		SELECT depth, COUNT(*)
+		FROM comments
+		WHERE post_id = ?
+		GROUP BY depth
+	`
 
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_comment_depth_histogram", Err: err}
+	}
+	defer rows.Close()
+
+	histogram := make(map[int]int)
 	for rows.Next() {
-		var post types.Post
-		var rawJSON string
-		var isSelf, isVideo int
-		var upvoteRatio sql.NullFloat64
-		var editedUTC sql.NullString
-
-		err := rows.Scan(
-			&post.ID, &post.Subreddit, &post.Author, &post.Title,
-			&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
-			&post.NumComments, &post.CreatedUTC, &editedUTC,
-			&isSelf, &isVideo, &rawJSON,
+		var depth, count int
+		if err := rows.Scan(&depth, &count); err != nil {
+			return nil, &storage.StorageError{Op: "scan_depth_histogram", Err: err}
+		}
+		histogram[depth] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &storage.StorageError{Op: "scan_depth_histogram", Err: err}
+	}
+
+	return histogram, nil
+}
+
+// GetSubredditStatsRange returns post/comment counts, total post score and
+// unique authors for a subreddit between start and end (zero times are unbounded)
+func (s *SQLiteStorage) GetSubredditStatsRange(ctx context.Context, subreddit string, start, end time.Time) (*storage.SubredditStats, error) {
+	query := `
+		WITH window_posts AS (
+			SELECT author, score
+			FROM posts
+			WHERE subreddit = ?1
+			  AND (?2 IS NULL OR created_utc >= ?2)
+			  AND (?3 IS NULL OR created_utc <= ?3)
+		),
+		window_comments AS (
+			SELECT c.author
+			FROM comments c
+			WHERE c.subreddit = ?1
+			  AND (?2 IS NULL OR c.created_utc >= ?2)
+			  AND (?3 IS NULL OR c.created_utc <= ?3)
+		),
+		ranked_scores AS (
+			SELECT score,
+			       ROW_NUMBER() OVER (ORDER BY score) - 1 AS idx,
+			       COUNT(*) OVER () AS n
+			FROM window_posts
 		)
+		SELECT
+			(SELECT COUNT(*) FROM window_posts),
+			(SELECT COUNT(*) FROM window_comments),
+			(SELECT COALESCE(SUM(score), 0) FROM window_posts),
+			` + scorePercentile("0.5") + `,
+			` + scorePercentile("0.9") + `,
+			(SELECT COUNT(DISTINCT author) FROM (
+				SELECT author FROM window_posts
+				UNION
+				SELECT author FROM window_comments
+			) WHERE author IS NOT NULL AND author != '' AND author != '[deleted]')
+	`
 
-		if err != nil {
-			return nil, &storage.StorageError{Op: "scan_post", Err: err}
-		}
+	var startArg, endArg interface{}
+	if !start.IsZero() {
+		startArg = timeToUnixFloat(start)
+	}
+	if !end.IsZero() {
+		endArg = timeToUnixFloat(end)
+	}
 
-		post.IsSelf = isSelf != 0
+	stats := storage.SubredditStats{
+		Subreddit: subreddit,
+		Start:     start,
+		End:       end,
+	}
 
-		// Reconstruct Edited field
-		if editedUTC.Valid {
-			var timestamp float64
-			if _, err := fmt.Sscanf(editedUTC.String, "%f", &timestamp); err == nil {
-				post.Edited = types.Edited{IsEdited: true, Timestamp: timestamp}
-			} else {
-				post.Edited = types.Edited{IsEdited: false}
-			}
-		} else {
-			post.Edited = types.Edited{IsEdited: false}
+	err := s.db.QueryRowContext(ctx, query, storage.NormalizeSubreddit(subreddit), startArg, endArg).Scan(
+		&stats.PostCount, &stats.CommentCount, &stats.TotalScore,
+		&stats.MedianScore, &stats.P90Score, &stats.UniqueAuthors,
+	)
+
+	if err != nil {
+		return nil, &storage.StorageError{Op: "get_subreddit_stats_range", Err: err}
+	}
+
+	return &stats, nil
+}
+
+// scorePercentile returns a scalar subquery computing the p-th percentile of
+// the ranked_scores CTE. SQLite has no PERCENTILE_CONT, so it ranks the
+// scores and interpolates linearly between the two at fractional position
+// p*(n-1), which gives the same result. With no scores it yields 0.
+func scorePercentile(p string) string {
+	return `COALESCE((
+				SELECT lo.score + (` + p + ` * (lo.n - 1) - lo.idx) * (hi.score - lo.score)
+				FROM ranked_scores lo
+				JOIN ranked_scores hi ON hi.idx = MIN(lo.idx + 1, lo.n - 1)
+				WHERE lo.idx = CAST(` + p + ` * (lo.n - 1) AS INTEGER)
+			), 0)`
+}
+
+// scanPosts is a helper function to scan post rows
+func (s *SQLiteStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
+	var posts []*types.Post
+
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		posts = append(posts, &post)
+		posts = append(posts, post)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -237,3 +710,42 @@ func (s *SQLiteStorage) scanPosts(rows *sql.Rows) ([]*types.Post, error) {
 
 	return posts, nil
 }
+
+// scanPost scans a post row selected as postColumns followed by any extra
+// destinations
+func scanPost(rows *sql.Rows, extra ...interface{}) (*types.Post, error) {
+	var post types.Post
+	var rawJSON string
+	var isSelf, isVideo, stickied int
+	var upvoteRatio sql.NullFloat64
+	var editedUTC sql.NullString
+
+	dest := []interface{}{
+		&post.ID, &post.Subreddit, &post.Author, &post.Title,
+		&post.SelfText, &post.URL, &post.Score, &upvoteRatio,
+		&post.NumComments, &post.CreatedUTC, &editedUTC,
+		&isSelf, &isVideo, &rawJSON, &stickied,
+		&post.LinkFlairText, &post.AuthorFlairText, &post.Over18, &post.Locked,
+	}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, &storage.StorageError{Op: "scan_post", Err: err}
+	}
+
+	post.IsSelf = isSelf != 0
+	post.Stickied = stickied != 0
+
+	// Reconstruct Edited field
+	if editedUTC.Valid {
+		var timestamp float64
+		if _, err := fmt.Sscanf(editedUTC.String, "%f", &timestamp); err == nil {
+			post.Edited = types.Edited{IsEdited: true, Timestamp: timestamp}
+		} else {
+			post.Edited = types.Edited{IsEdited: false}
+		}
+	} else {
+		post.Edited = types.Edited{IsEdited: false}
+	}
+
+	return &post, nil
+}
@@ -2,7 +2,12 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -310,6 +315,46 @@ func TestSQLiteStorage_GetPostsBySubreddit_DateFilters(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_GetLatestPost(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if _, err := store.GetLatestPost(ctx, "latest"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for empty subreddit, got %v", err)
+	}
+
+	now := time.Now()
+	posts := []*types.Post{
+		{
+			ThingData: types.ThingData{ID: "earlier", Name: "t3_earlier"},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-1 * time.Hour).Unix())},
+			Subreddit: "latest",
+			Title:     "Earlier Post",
+		},
+		{
+			ThingData: types.ThingData{ID: "latest1", Name: "t3_latest1"},
+			Created:   types.Created{CreatedUTC: float64(now.Unix())},
+			Subreddit: "latest",
+			Title:     "Latest Post",
+		},
+	}
+
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	latest, err := store.GetLatestPost(ctx, "latest")
+	if err != nil {
+		t.Fatalf("Failed to get latest post: %v", err)
+	}
+
+	if latest.ID != "latest1" {
+		t.Errorf("Expected latest post latest1, got %s", latest.ID)
+	}
+}
+
 func TestSQLiteStorage_GetPostStats_NoComments(t *testing.T) {
 	store := getTestDB(t)
 	defer store.Close()
@@ -346,6 +391,125 @@ func TestSQLiteStorage_GetPostStats_NoComments(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_GetSubredditStatsRange(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	now := time.Now()
+	lastWeek := now.Add(-7 * 24 * time.Hour)
+
+	posts := []*types.Post{
+		{
+			ThingData: types.ThingData{ID: "thisweek", Name: "t3_thisweek"},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-1 * time.Hour).Unix())},
+			Subreddit: "statsrange",
+			Author:    "alice",
+			Title:     "This Week",
+			Score:     10,
+		},
+		{
+			ThingData: types.ThingData{ID: "lastweek", Name: "t3_lastweek"},
+			Created:   types.Created{CreatedUTC: float64(lastWeek.Unix())},
+			Subreddit: "statsrange",
+			Author:    "bob",
+			Title:     "Last Week",
+			Score:     100,
+		},
+	}
+
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{
+			ThingData: types.ThingData{ID: "src1", Name: "t1_src1"},
+			Created:   types.Created{CreatedUTC: float64(now.Unix())},
+			LinkID:    "t3_lastweek",
+			Author:    "carol",
+			Body:      "Late reply to an old post",
+		},
+		{
+			ThingData: types.ThingData{ID: "src2", Name: "t1_src2"},
+			Created:   types.Created{CreatedUTC: float64(lastWeek.Unix())},
+			LinkID:    "t3_lastweek",
+			Author:    "dave",
+			Body:      "Old reply",
+		},
+	}
+
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	stats, err := store.GetSubredditStatsRange(ctx, "statsrange", now.Add(-24*time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+
+	if stats.PostCount != 1 || stats.CommentCount != 1 || stats.TotalScore != 10 || stats.UniqueAuthors != 2 {
+		t.Errorf("Unexpected windowed stats: %+v", stats)
+	}
+
+	all, err := store.GetSubredditStatsRange(ctx, "statsrange", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get unbounded stats: %v", err)
+	}
+
+	if all.PostCount != 2 || all.CommentCount != 2 || all.TotalScore != 110 || all.UniqueAuthors != 4 {
+		t.Errorf("Unexpected unbounded stats: %+v", all)
+	}
+}
+
+func TestSQLiteStorage_SubredditStatsPercentiles(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Add(-time.Hour).Unix())
+
+	empty, err := store.GetSubredditStatsRange(ctx, "percentiles", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if empty.MedianScore != 0 || empty.P90Score != 0 {
+		t.Errorf("Expected zero percentiles without posts, got %v/%v", empty.MedianScore, empty.P90Score)
+	}
+
+	// Scores 1..10 saved out of order
+	var posts []*types.Post
+	for _, score := range []int{7, 3, 10, 1, 5, 9, 2, 8, 4, 6} {
+		id := fmt.Sprintf("pc%d", score)
+		posts = append(posts, &types.Post{ThingData: types.ThingData{ID: id, Name: "t3_" + id}, Created: types.Created{CreatedUTC: created}, Subreddit: "percentiles", Title: id, Score: score})
+	}
+	if err := store.SavePosts(ctx, posts[:1]); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	single, err := store.GetSubredditStatsRange(ctx, "percentiles", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if single.MedianScore != 7 || single.P90Score != 7 {
+		t.Errorf("Expected both percentiles to be the only score, got %v/%v", single.MedianScore, single.P90Score)
+	}
+
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	// Matches PERCENTILE_CONT: interpolated at position p*(n-1)
+	stats, err := store.GetSubredditStatsRange(ctx, "percentiles", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if math.Abs(stats.MedianScore-5.5) > 1e-9 || math.Abs(stats.P90Score-9.1) > 1e-9 {
+		t.Errorf("Expected median 5.5 and p90 9.1, got %v/%v", stats.MedianScore, stats.P90Score)
+	}
+}
+
 func TestSQLiteStorage_SaveAndGetComments(t *testing.T) {
 	store := getTestDB(t)
 	defer store.Close()
@@ -405,6 +569,187 @@ func TestSQLiteStorage_SaveAndGetComments(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_SaveComments_ChunkedDepth(t *testing.T) {
+	store, err := NewWithOptions(t.TempDir()+"/chunked.db", &Options{MaxBatchSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to create SQLite storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "widepost", Name: "t3_widepost"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Wide Post",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	// A five-deep reply chain spans three chunks of two
+	ids := []string{"d0", "d1", "d2", "d3", "d4"}
+	var comments []*types.Comment
+	for i, id := range ids {
+		comment := &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_widepost",
+			ParentID:  "t3_widepost",
+			Author:    "user",
+			Body:      "depth " + id,
+		}
+		if i > 0 {
+			comment.ParentID = "t1_" + ids[i-1]
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	for i, id := range ids {
+		var depth int
+		if err := store.db.QueryRowContext(ctx, "SELECT depth FROM comments WHERE id = ?", id).Scan(&depth); err != nil {
+			t.Fatalf("Failed to read depth for %s: %v", id, err)
+		}
+		if depth != i {
+			t.Errorf("Expected depth %d for %s, got %d", i, id, depth)
+		}
+	}
+}
+
+func TestSQLiteStorage_SaveCommentsWithOptions_BatchSize(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "batchpost", Name: "t3_batchpost"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Batched Post",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	// A reply chain saved one comment per transaction, so every parent is
+	// resolved from an earlier batch
+	ids := []string{"b0", "b1", "b2", "b3"}
+	var comments []*types.Comment
+	for i, id := range ids {
+		comment := &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_batchpost",
+			ParentID:  "t3_batchpost",
+			Author:    "user",
+			Body:      "depth " + id,
+		}
+		if i > 0 {
+			comment.ParentID = "t1_" + ids[i-1]
+		}
+		comments = append(comments, comment)
+	}
+
+	saved, err := store.SaveCommentsWithOptions(ctx, comments, storage.SaveCommentsOptions{BatchSize: 1, MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("SaveCommentsWithOptions failed: %v", err)
+	}
+	if saved != 3 {
+		t.Errorf("Expected 3 comments within MaxDepth, got %d", saved)
+	}
+
+	for i, id := range ids[:3] {
+		var depth int
+		if err := store.db.QueryRowContext(ctx, "SELECT depth FROM comments WHERE id = ?", id).Scan(&depth); err != nil {
+			t.Fatalf("Failed to read depth for %s: %v", id, err)
+		}
+		if depth != i {
+			t.Errorf("Expected depth %d for %s, got %d", i, id, depth)
+		}
+	}
+}
+
+func TestSQLiteStorage_GetCommentsByAuthorWithContext(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	posts := []*types.Post{
+		{
+			ThingData: types.ThingData{ID: "ctxpost1", Name: "t3_ctxpost1"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			Subreddit: "golang",
+			Title:     "Go Post",
+		},
+		{
+			ThingData: types.ThingData{ID: "ctxpost2", Name: "t3_ctxpost2"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			Subreddit: "rust",
+			Title:     "Rust Post",
+		},
+	}
+
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{
+			ThingData: types.ThingData{ID: "ctxc1", Name: "t1_ctxc1"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Add(-1 * time.Minute).Unix())},
+			LinkID:    "t3_ctxpost1",
+			Author:    "profileuser",
+			Body:      "On the Go post",
+		},
+		{
+			ThingData: types.ThingData{ID: "ctxc2", Name: "t1_ctxc2"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_ctxpost2",
+			Author:    "profileuser",
+			Body:      "On the Rust post",
+		},
+		{
+			ThingData: types.ThingData{ID: "ctxc3", Name: "t1_ctxc3"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_ctxpost1",
+			Author:    "someoneelse",
+			Body:      "Not in the profile",
+		},
+	}
+
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	results, err := store.GetCommentsByAuthorWithContext(ctx, "profileuser", storage.QueryOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get comments by author: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 comments, got %d", len(results))
+	}
+
+	// Newest first by default
+	if results[0].Comment.ID != "ctxc2" || results[0].PostTitle != "Rust Post" || results[0].PostSubreddit != "rust" {
+		t.Errorf("Unexpected first result: %s on %q in %s", results[0].Comment.ID, results[0].PostTitle, results[0].PostSubreddit)
+	}
+
+	if results[1].Comment.ID != "ctxc1" || results[1].PostTitle != "Go Post" || results[1].PostSubreddit != "golang" {
+		t.Errorf("Unexpected second result: %s on %q in %s", results[1].Comment.ID, results[1].PostTitle, results[1].PostSubreddit)
+	}
+}
+
 func TestSQLiteStorage_Migrations(t *testing.T) {
 	tmpFile := t.TempDir() + "/migrations_test.db"
 
@@ -432,3 +777,3076 @@ func TestMain(m *testing.M) {
 	code := m.Run()
 	os.Exit(code)
 }
+
+func TestSQLiteStorage_ReadOnly(t *testing.T) {
+	tmpFile := t.TempDir() + "/readonly.db"
+	ctx := context.Background()
+
+	writer, err := New(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create SQLite storage: %v", err)
+	}
+	if err := writer.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "ro1", Name: "t3_ro1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "alice",
+		Title:     "Read only",
+	}
+	if err := writer.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	writer.Close()
+
+	opts := DefaultOptions()
+	opts.ReadOnly = true
+	store, err := NewWithOptions(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("Failed to open read-only storage: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.GetPost(ctx, "ro1"); err != nil {
+		t.Fatalf("Failed to read post from read-only storage: %v", err)
+	}
+
+	post.Score = 99
+	if err := store.SavePost(ctx, post); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from SavePost, got %v", err)
+	}
+
+	if err := store.RunMigrations(ctx); !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from RunMigrations, got %v", err)
+	}
+
+	// The connection itself must refuse writes too
+	if _, err := store.db.ExecContext(ctx, "UPDATE posts SET score = 1 WHERE id = 'ro1'"); err == nil {
+		t.Error("Expected raw write on read-only connection to fail")
+	}
+}
+
+func TestSQLiteStorage_StoredPostModFields(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	now := time.Now()
+	reports := 3
+	removedBy := "moderator"
+
+	posts := []*storage.StoredPost{
+		{
+			Post: &types.Post{
+				ThingData: types.ThingData{ID: "mod1", Name: "t3_mod1"},
+				Created:   types.Created{CreatedUTC: float64(now.Unix())},
+				Subreddit: "modlog",
+				Author:    "alice",
+				Title:     "Removed post",
+			},
+			NumReports:        &reports,
+			RemovedByCategory: &removedBy,
+		},
+		{
+			Post: &types.Post{
+				ThingData: types.ThingData{ID: "mod2", Name: "t3_mod2"},
+				Created:   types.Created{CreatedUTC: float64(now.Add(-time.Hour).Unix())},
+				Subreddit: "modlog",
+				Author:    "bob",
+				Title:     "Normal post",
+			},
+		},
+	}
+
+	if err := store.SaveStoredPosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save stored posts: %v", err)
+	}
+
+	// A later non-mod fetch must not wipe the moderator fields
+	refetched := *posts[0].Post
+	refetched.Score = 42
+	if err := store.SavePosts(ctx, []*types.Post{&refetched}); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+
+	removed, err := store.GetStoredPostsBySubreddit(ctx, "modlog", storage.QueryOptions{RemovedOnly: true})
+	if err != nil {
+		t.Fatalf("Failed to get removed posts: %v", err)
+	}
+
+	if len(removed) != 1 {
+		t.Fatalf("Expected 1 removed post, got %d", len(removed))
+	}
+
+	got := removed[0]
+	if got.ID != "mod1" || got.Score != 42 {
+		t.Errorf("Unexpected removed post: id=%s score=%d", got.ID, got.Score)
+	}
+	if got.NumReports == nil || *got.NumReports != 3 {
+		t.Errorf("Expected num_reports 3, got %v", got.NumReports)
+	}
+	if got.RemovedByCategory == nil || *got.RemovedByCategory != "moderator" {
+		t.Errorf("Expected removed_by_category moderator, got %v", got.RemovedByCategory)
+	}
+
+	all, err := store.GetStoredPostsBySubreddit(ctx, "modlog", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(all))
+	}
+	if all[1].NumReports != nil || all[1].RemovedByCategory != nil {
+		t.Errorf("Expected nil mod fields for non-mod post, got %v / %v", all[1].NumReports, all[1].RemovedByCategory)
+	}
+}
+
+func TestSQLiteStorage_GetCommentTreeNested(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "nest1", Name: "t3_nest1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Nested",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	base := float64(time.Now().Unix())
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "n1"}, Created: types.Created{CreatedUTC: base}, LinkID: "t3_nest1", ParentID: "t3_nest1", Body: "root 1"},
+		{ThingData: types.ThingData{ID: "n2"}, Created: types.Created{CreatedUTC: base + 1}, LinkID: "t3_nest1", ParentID: "t1_n1", Body: "reply 1"},
+		{ThingData: types.ThingData{ID: "n3"}, Created: types.Created{CreatedUTC: base + 2}, LinkID: "t3_nest1", ParentID: "t1_n2", Body: "reply 2"},
+		{ThingData: types.ThingData{ID: "n4"}, Created: types.Created{CreatedUTC: base + 3}, LinkID: "t3_nest1", ParentID: "t1_n1", Body: "reply 3"},
+		{ThingData: types.ThingData{ID: "n5"}, Created: types.Created{CreatedUTC: base + 4}, LinkID: "t3_nest1", ParentID: "t3_nest1", Body: "root 2"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	roots, err := store.GetCommentTreeNested(ctx, "nest1")
+	if err != nil {
+		t.Fatalf("Failed to get nested tree: %v", err)
+	}
+
+	if len(roots) != 2 || roots[0].Comment.ID != "n1" || roots[1].Comment.ID != "n5" {
+		t.Fatalf("Unexpected roots: %+v", roots)
+	}
+
+	children := roots[0].Children
+	if len(children) != 2 || children[0].Comment.ID != "n2" || children[1].Comment.ID != "n4" {
+		t.Fatalf("Unexpected children of n1: %+v", children)
+	}
+
+	grandchildren := children[0].Children
+	if len(grandchildren) != 1 || grandchildren[0].Comment.ID != "n3" || grandchildren[0].Depth != 2 {
+		t.Errorf("Expected n3 at depth 2 under n2, got %+v", grandchildren)
+	}
+}
+
+// codedError mimics the driver's error type for busy retry tests
+type codedError int
+
+func (e codedError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e codedError) Code() int     { return int(e) }
+
+func TestSQLiteStorage_WithBusyRetry(t *testing.T) {
+	store := &SQLiteStorage{opts: Options{BusyRetries: 3, BusyRetryBackoff: time.Millisecond}}
+	ctx := context.Background()
+
+	calls := 0
+	err := store.withBusyRetry(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return &storage.StorageError{Op: "insert_post", Err: codedError(5)} // SQLITE_BUSY
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on third attempt, got err=%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.withBusyRetry(ctx, func() error {
+		calls++
+		return codedError(517) // SQLITE_BUSY_SNAPSHOT
+	})
+	if err == nil || calls != 4 {
+		t.Errorf("Expected failure after 4 attempts, got err=%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.withBusyRetry(ctx, func() error {
+		calls++
+		return codedError(19) // SQLITE_CONSTRAINT
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected non-busy error to skip retries, got err=%v after %d calls", err, calls)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	calls = 0
+	err = store.withBusyRetry(cancelled, func() error {
+		calls++
+		return codedError(6) // SQLITE_LOCKED
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected cancelled context to stop retrying, got err=%v after %d calls", err, calls)
+	}
+}
+
+func TestSQLiteStorage_BusyRetryUnderContention(t *testing.T) {
+	tmpFile := t.TempDir() + "/busy.db"
+	ctx := context.Background()
+
+	opts := DefaultOptions()
+	opts.BusyRetries = 10
+	opts.BusyRetryBackoff = 10 * time.Millisecond
+
+	store, err := NewWithOptions(tmpFile, opts)
+	if err != nil {
+		t.Fatalf("Failed to create SQLite storage: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Hold the write lock from a second handle for a short while
+	locker, err := sql.Open("sqlite", tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to open locking connection: %v", err)
+	}
+	defer locker.Close()
+
+	conn, err := locker.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get locking connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("Failed to take write lock: %v", err)
+	}
+
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		conn.ExecContext(ctx, "COMMIT")
+		close(released)
+	}()
+
+	sub := &types.SubredditData{DisplayName: "busy"}
+	if err := store.SaveSubreddit(ctx, sub); err != nil {
+		t.Errorf("Expected SaveSubreddit to succeed after the lock was released, got %v", err)
+	}
+
+	<-released
+}
+
+func TestSQLiteStorage_CanonicalSubredditName(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	now := time.Now()
+	posts := []*types.Post{
+		{
+			ThingData: types.ThingData{ID: "case1", Name: "t3_case1"},
+			Created:   types.Created{CreatedUTC: float64(now.Unix())},
+			Subreddit: "Golang",
+			Title:     "Upper",
+		},
+		{
+			ThingData: types.ThingData{ID: "case2", Name: "t3_case2"},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-time.Minute).Unix())},
+			Subreddit: "golang",
+			Title:     "Lower",
+		},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	// The API's spelling becomes the canonical name
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "GoLang", Title: "The Go Programming Language"}); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	for _, name := range []string{"golang", "Golang", "GOLANG"} {
+		got, err := store.GetPostsBySubreddit(ctx, name, storage.QueryOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get posts for %q: %v", name, err)
+		}
+
+		if len(got) != 2 {
+			t.Fatalf("Expected 2 posts for %q, got %d", name, len(got))
+		}
+
+		for _, post := range got {
+			if post.Subreddit != "GoLang" {
+				t.Errorf("Expected canonical subreddit GoLang for post %s, got %q", post.ID, post.Subreddit)
+			}
+		}
+	}
+
+	post, err := store.GetPost(ctx, "case2")
+	if err != nil {
+		t.Fatalf("Failed to get post: %v", err)
+	}
+	if post.Subreddit != "GoLang" {
+		t.Errorf("Expected canonical subreddit GoLang from GetPost, got %q", post.Subreddit)
+	}
+
+	// Saving another post must not clobber the synced subreddit row
+	post.Title = "Lower, updated"
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+
+	sub, err := store.GetSubreddit(ctx, "golang")
+	if err != nil {
+		t.Fatalf("Failed to get subreddit: %v", err)
+	}
+	if sub.DisplayName != "GoLang" || sub.Title != "The Go Programming Language" {
+		t.Errorf("Unexpected subreddit after post saves: %+v", sub)
+	}
+}
+
+func TestSQLiteStorage_ExportPostMarkdown(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "md1", Name: "t3_md1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "alice",
+		Title:     "Markdown export",
+		SelfText:  "Post body",
+		IsSelf:    true,
+		Score:     10,
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	base := float64(time.Now().Unix())
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "mdc1"}, Created: types.Created{CreatedUTC: base}, LinkID: "t3_md1", ParentID: "t3_md1", Author: "bob", Score: 1, Body: "First line\n\nSecond line"},
+		{ThingData: types.ThingData{ID: "mdc2"}, Created: types.Created{CreatedUTC: base + 1}, LinkID: "t3_md1", ParentID: "t1_mdc1", Author: "carol", Score: 3, Body: "A reply"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := store.ExportPostMarkdown(ctx, "md1", &buf); err != nil {
+		t.Fatalf("Failed to export markdown: %v", err)
+	}
+
+	want := `# Markdown export
+
+*r/golang · posted by u/alice · 10 points*
+
+Post body
+
+---
+
+## Comments (2)
+
+- **u/bob** (1 point)
+
+  > First line
+  >
+  > Second line
+
+  - **u/carol** (3 points)
+
+    > A reply
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected markdown:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if err := store.ExportPostMarkdown(ctx, "missing", &buf); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing post, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_NormalizesDeletedAuthor(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "del1", Name: "t3_del1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "",
+		Title:     "Deleted author",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "delc1"}, LinkID: "t3_del1", ParentID: "t3_del1", Author: ""},
+		{ThingData: types.ThingData{ID: "delc2"}, LinkID: "t3_del1", ParentID: "t3_del1", Author: "[deleted]"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	got, err := store.GetPost(ctx, "del1")
+	if err != nil {
+		t.Fatalf("Failed to get post: %v", err)
+	}
+	if got.Author != storage.DeletedAuthor {
+		t.Errorf("Expected post author %q, got %q", storage.DeletedAuthor, got.Author)
+	}
+
+	rows, err := store.db.QueryContext(ctx, "SELECT author, COUNT(*) FROM comments GROUP BY author")
+	if err != nil {
+		t.Fatalf("Failed to group comments by author: %v", err)
+	}
+	defer rows.Close()
+
+	groups := make(map[string]int)
+	for rows.Next() {
+		var author string
+		var count int
+		if err := rows.Scan(&author, &count); err != nil {
+			t.Fatalf("Failed to scan author group: %v", err)
+		}
+		groups[author] = count
+	}
+
+	if len(groups) != 1 || groups[storage.DeletedAuthor] != 2 {
+		t.Errorf("Expected a single [deleted] group of 2, got %v", groups)
+	}
+}
+
+func TestSQLiteStorage_CursorPaginationWithConcurrentInserts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Unix()
+
+	// Pairs of posts share a timestamp so pages split ties on ID
+	var original []*types.Post
+	for i := 0; i < 20; i++ {
+		original = append(original, &types.Post{
+			ThingData: types.ThingData{ID: fmt.Sprintf("cur%02d", i), Name: fmt.Sprintf("t3_cur%02d", i)},
+			Created:   types.Created{CreatedUTC: float64(base + int64(i/2))},
+			Subreddit: "cursor",
+			Title:     fmt.Sprintf("Post %d", i),
+		})
+	}
+	if err := store.SavePosts(ctx, original); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	for _, order := range []string{"desc", "asc"} {
+		t.Run(order, func(t *testing.T) {
+			seen := make(map[string]int)
+			opts := storage.QueryOptions{Limit: 3, SortOrder: order}
+			inserted := 0
+
+			for page := 0; page < 50; page++ {
+				posts, err := store.GetPostsBySubreddit(ctx, "cursor", opts)
+				if err != nil {
+					t.Fatalf("Failed to get page %d: %v", page, err)
+				}
+				if len(posts) == 0 {
+					break
+				}
+
+				for _, post := range posts {
+					seen[post.ID]++
+				}
+
+				// Another writer adds a newer post before the next page
+				inserted++
+				newer := &types.Post{
+					ThingData: types.ThingData{ID: fmt.Sprintf("new-%s-%02d", order, inserted)},
+					Created:   types.Created{CreatedUTC: float64(time.Now().Unix() + int64(inserted))},
+					Subreddit: "cursor",
+					Title:     "Inserted mid-iteration",
+				}
+				if err := store.SavePost(ctx, newer); err != nil {
+					t.Fatalf("Failed to insert post mid-iteration: %v", err)
+				}
+
+				opts.After = storage.NextCursor(posts)
+			}
+
+			for _, post := range original {
+				if seen[post.ID] != 1 {
+					t.Errorf("Expected %s exactly once, saw it %d times", post.ID, seen[post.ID])
+				}
+			}
+
+			for id, count := range seen {
+				if count != 1 {
+					t.Errorf("Post %s returned %d times", id, count)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_DeletePosts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// More IDs than one delete chunk
+	var posts []*types.Post
+	var ids []string
+	for i := 0; i < 600; i++ {
+		id := fmt.Sprintf("bulk%03d", i)
+		posts = append(posts, &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			Subreddit: "golang",
+			Title:     "Bulk",
+		})
+		ids = append(ids, id)
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "bulkc1"}, LinkID: "t3_bulk000", ParentID: "t3_bulk000", Body: "root"},
+		{ThingData: types.ThingData{ID: "bulkc2"}, LinkID: "t3_bulk000", ParentID: "t1_bulkc1", Body: "reply"},
+		{ThingData: types.ThingData{ID: "bulkc3"}, LinkID: "t3_bulk599", ParentID: "t3_bulk599", Body: "kept"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	toDelete := append(ids[:550:550], "missing")
+	deleted, err := store.DeletePosts(ctx, toDelete)
+	if err != nil {
+		t.Fatalf("Failed to delete posts: %v", err)
+	}
+	if deleted != 550 {
+		t.Errorf("Expected 550 posts deleted, got %d", deleted)
+	}
+
+	if _, err := store.GetPost(ctx, "bulk000"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected bulk000 to be deleted, got %v", err)
+	}
+
+	var commentCount int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments").Scan(&commentCount); err != nil {
+		t.Fatalf("Failed to count comments: %v", err)
+	}
+	if commentCount != 1 {
+		t.Errorf("Expected only the comment on a kept post to remain, got %d comments", commentCount)
+	}
+
+	remaining, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get remaining posts: %v", err)
+	}
+	if len(remaining) != 50 {
+		t.Errorf("Expected 50 remaining posts, got %d", len(remaining))
+	}
+}
+
+func TestSQLiteStorage_DeletePost(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for _, id := range []string{"del1", "del2"} {
+		post := &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			Subreddit: "golang",
+			Title:     "Delete me",
+		}
+		if err := store.SavePost(ctx, post); err != nil {
+			t.Fatalf("Failed to save post: %v", err)
+		}
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "delc1"}, LinkID: "t3_del1", ParentID: "t3_del1", Body: "root"},
+		{ThingData: types.ThingData{ID: "delc2"}, LinkID: "t3_del1", ParentID: "t1_delc1", Body: "reply"},
+		{ThingData: types.ThingData{ID: "delc3"}, LinkID: "t3_del2", ParentID: "t3_del2", Body: "other"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	deleted, err := store.DeleteComments(ctx, "del1")
+	if err != nil {
+		t.Fatalf("DeleteComments failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 comments deleted, got %d", deleted)
+	}
+	if _, err := store.GetPost(ctx, "del1"); err != nil {
+		t.Errorf("Expected DeleteComments to keep the post, got %v", err)
+	}
+
+	if err := store.DeletePost(ctx, "del2"); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+	if _, err := store.GetPost(ctx, "del2"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected del2 to be deleted, got %v", err)
+	}
+
+	var commentCount int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments").Scan(&commentCount); err != nil {
+		t.Fatalf("Failed to count comments: %v", err)
+	}
+	if commentCount != 0 {
+		t.Errorf("Expected no comments left, got %d", commentCount)
+	}
+
+	if err := store.DeletePost(ctx, "del2"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing post, got %v", err)
+	}
+	if _, err := store.DeleteComments(ctx, "del2"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting a missing post's comments, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_PrunePosts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	old := time.Now().AddDate(0, 0, -200)
+	newPost := func(id, subreddit string, created time.Time, score int) *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(created.Unix())},
+			Subreddit: subreddit,
+			Title:     "Prune",
+			Score:     score,
+		}
+	}
+
+	// More old posts than one prune batch
+	var posts []*types.Post
+	for i := 0; i < 600; i++ {
+		posts = append(posts, newPost(fmt.Sprintf("old%03d", i), "golang", old, 1))
+	}
+	posts = append(posts,
+		newPost("oldgood", "golang", old, 500),
+		newPost("recent", "golang", time.Now(), 1),
+		newPost("oldrust", "rust", old, 1),
+	)
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "prc1"}, LinkID: "t3_old000", ParentID: "t3_old000", Body: "root"},
+		{ThingData: types.ThingData{ID: "prc2"}, LinkID: "t3_old000", ParentID: "t1_prc1", Body: "reply"},
+		{ThingData: types.ThingData{ID: "prc3"}, LinkID: "t3_oldgood", ParentID: "t3_oldgood", Body: "kept"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	if _, err := store.PrunePosts(ctx, storage.PruneOptions{Subreddit: "golang"}); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected ErrInvalidOptions without Before, got %v", err)
+	}
+
+	result, err := store.PrunePosts(ctx, storage.PruneOptions{
+		Subreddit:    "golang",
+		Before:       time.Now().AddDate(0, 0, -180),
+		KeepMinScore: 100,
+	})
+	if err != nil {
+		t.Fatalf("PrunePosts failed: %v", err)
+	}
+	if result.PostsDeleted != 600 || result.CommentsDeleted != 2 {
+		t.Errorf("Expected 600 posts and 2 comments deleted, got %+v", result)
+	}
+
+	for _, id := range []string{"oldgood", "recent", "oldrust"} {
+		if _, err := store.GetPost(ctx, id); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", id, err)
+		}
+	}
+	if _, err := store.GetPost(ctx, "old599"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected old599 to be pruned, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_TimestamplessEdit(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "edit_post", Name: "t3_edit_post"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Edited comments",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	// Reddit reports "edited": true with no timestamp for some edits
+	comments := []*types.Comment{
+		{
+			ThingData: types.ThingData{ID: "edit_flag", Name: "t1_edit_flag"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			LinkID:    "t3_edit_post",
+			ParentID:  "t3_edit_post",
+			Body:      "Edited, no timestamp",
+			Edited:    types.Edited{IsEdited: true},
+		},
+		{
+			ThingData: types.ThingData{ID: "edit_none", Name: "t1_edit_none"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Add(time.Minute).Unix())},
+			LinkID:    "t3_edit_post",
+			ParentID:  "t3_edit_post",
+			Body:      "Never edited",
+		},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	got, err := store.GetCommentsByPost(ctx, "edit_post")
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+
+	edited := make(map[string]types.Edited)
+	for _, comment := range got {
+		edited[comment.ID] = comment.Edited
+	}
+
+	if !edited["edit_flag"].IsEdited || edited["edit_flag"].Timestamp != 0 {
+		t.Errorf("Expected timestampless edit to be kept, got %+v", edited["edit_flag"])
+	}
+	if edited["edit_none"].IsEdited {
+		t.Errorf("Expected unedited comment, got %+v", edited["edit_none"])
+	}
+}
+
+func TestSQLiteStorage_SearchPostsWebMode(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	now := float64(time.Now().Unix())
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "web1"}, Created: types.Created{CreatedUTC: now}, Subreddit: "golang", Title: "Error handling in Go", SelfText: "wrapping errors", Score: 3},
+		{ThingData: types.ThingData{ID: "web2"}, Created: types.Created{CreatedUTC: now}, Subreddit: "golang", Title: "Handling errors in Rust", SelfText: "the ? operator", Score: 2},
+		{ThingData: types.ThingData{ID: "web3"}, Created: types.Created{CreatedUTC: now}, Subreddit: "golang", Title: "100% coverage", SelfText: "error handling tests", Score: 1},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`"error handling" -rust`, []string{"web1", "web3"}},
+		{`"handling errors" or coverage`, []string{"web2", "web3"}},
+		{`100%`, []string{"web3"}},
+		{`-error`, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := store.SearchPosts(ctx, tt.query, storage.QueryOptions{SearchMode: storage.SearchModeWeb})
+		if err != nil {
+			t.Fatalf("SearchPosts(%q) failed: %v", tt.query, err)
+		}
+
+		var ids []string
+		for _, post := range got {
+			ids = append(ids, post.ID)
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("SearchPosts(%q) = %v, want %v", tt.query, ids, tt.want)
+		}
+	}
+
+	// Plain mode keeps treating the query as literal text
+	got, err := store.SearchPosts(ctx, `"error handling" -rust`, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Plain SearchPosts failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected plain mode to match nothing, got %d posts", len(got))
+	}
+}
+
+func TestSQLiteStorage_ListSubreddits(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	subs := []*types.SubredditData{
+		{DisplayName: "golang", Subscribers: 250000},
+		{DisplayName: "GoDot", Subscribers: 180000},
+		{DisplayName: "go_bots", Subscribers: 10},
+		{DisplayName: "rust", Subscribers: 300000},
+	}
+	for _, sub := range subs {
+		if err := store.SaveSubreddit(ctx, sub); err != nil {
+			t.Fatalf("Failed to save subreddit: %v", err)
+		}
+	}
+	// Placeholder row with no metadata, as created when saving a post
+	if err := store.ensureSubreddit(ctx, "gopher"); err != nil {
+		t.Fatalf("Failed to ensure subreddit: %v", err)
+	}
+
+	names := func(subs []*types.SubredditData) []string {
+		var out []string
+		for _, sub := range subs {
+			out = append(out, sub.DisplayName)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		opts storage.QueryOptions
+		want []string
+	}{
+		{"default name order", storage.QueryOptions{}, []string{"go_bots", "GoDot", "golang", "gopher", "rust"}},
+		{"prefix search", storage.QueryOptions{Search: "GO"}, []string{"go_bots", "GoDot", "golang", "gopher"}},
+		{"underscore is literal", storage.QueryOptions{Search: "go_"}, []string{"go_bots"}},
+		{"by subscribers", storage.QueryOptions{SortBy: "subscribers", Limit: 2}, []string{"rust", "golang"}},
+		{"second page", storage.QueryOptions{Search: "go", Limit: 2, Offset: 2}, []string{"golang", "gopher"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListSubreddits(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ListSubreddits failed: %v", err)
+			}
+			if fmt.Sprint(names(got)) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, names(got))
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_ListSubredditSummaries(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "empty", Title: "No posts"}); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "sum1", Name: "t3_sum1"}, Created: types.Created{CreatedUTC: float64(older.Unix())}, Subreddit: "golang", Title: "Old"},
+		{ThingData: types.ThingData{ID: "sum2", Name: "t3_sum2"}, Created: types.Created{CreatedUTC: float64(newer.Unix())}, Subreddit: "golang", Title: "New"},
+		{ThingData: types.ThingData{ID: "sum3", Name: "t3_sum3"}, Created: types.Created{CreatedUTC: float64(newer.Unix())}, Subreddit: "rust", Title: "Rust"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "sumc1"}, LinkID: "t3_sum1", ParentID: "t3_sum1", Body: "a"},
+		{ThingData: types.ThingData{ID: "sumc2"}, LinkID: "t3_sum2", ParentID: "t3_sum2", Body: "b"},
+		{ThingData: types.ThingData{ID: "sumc3"}, LinkID: "t3_sum2", ParentID: "t1_sumc2", Body: "c"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	summaries, err := store.ListSubredditSummaries(ctx)
+	if err != nil {
+		t.Fatalf("ListSubredditSummaries failed: %v", err)
+	}
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 subreddits, got %d", len(summaries))
+	}
+
+	empty, golang, rust := summaries[0], summaries[1], summaries[2]
+	if empty.Name != "empty" || empty.PostCount != 0 || !empty.OldestPost.IsZero() {
+		t.Errorf("Unexpected summary for a subreddit without posts: %+v", empty)
+	}
+	if golang.Name != "golang" || golang.PostCount != 2 || golang.CommentCount != 3 {
+		t.Errorf("Expected golang with 2 posts and 3 comments, got %+v", golang)
+	}
+	if !golang.OldestPost.Equal(older) || !golang.NewestPost.Equal(newer) {
+		t.Errorf("Expected golang posts from %v to %v, got %v to %v", older, newer, golang.OldestPost, golang.NewestPost)
+	}
+	if golang.LastSynced.IsZero() {
+		t.Error("Expected golang to have a last synced time")
+	}
+	if rust.Name != "rust" || rust.PostCount != 1 || rust.CommentCount != 0 {
+		t.Errorf("Expected rust with 1 post and no comments, got %+v", rust)
+	}
+}
+
+func TestSQLiteStorage_ArchiveRuns(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	run := storage.ArchiveRun{
+		Subreddit:      "GoLang",
+		StartedAt:      start,
+		FetchDuration:  1500 * time.Millisecond,
+		SaveDuration:   250 * time.Millisecond,
+		PostsProcessed: 25,
+		CommentsSaved:  300,
+	}
+	if err := store.RecordArchiveRun(ctx, run); err != nil {
+		t.Fatalf("RecordArchiveRun failed: %v", err)
+	}
+
+	run.StartedAt = start.Add(time.Hour)
+	run.Error = "fetch_posts: rate limited"
+	if err := store.RecordArchiveRun(ctx, run); err != nil {
+		t.Fatalf("RecordArchiveRun failed: %v", err)
+	}
+
+	runs, err := store.GetArchiveRuns(ctx, "golang", 1)
+	if err != nil {
+		t.Fatalf("GetArchiveRuns failed: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("Expected 1 run with limit 1, got %d", len(runs))
+	}
+
+	got := runs[0]
+	if !got.StartedAt.Equal(start.Add(time.Hour)) || got.Error != run.Error {
+		t.Errorf("Expected newest run first, got %+v", got)
+	}
+	if got.FetchDuration != run.FetchDuration || got.SaveDuration != run.SaveDuration {
+		t.Errorf("Expected durations %v/%v, got %v/%v", run.FetchDuration, run.SaveDuration, got.FetchDuration, got.SaveDuration)
+	}
+	if got.PostsProcessed != 25 || got.CommentsSaved != 300 {
+		t.Errorf("Expected counts 25/300, got %d/%d", got.PostsProcessed, got.CommentsSaved)
+	}
+}
+
+func TestSQLiteStorage_BackfillCheckpoint(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if _, err := store.GetBackfillCheckpoint(ctx, "golang"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before any checkpoint, got %v", err)
+	}
+
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	checkpoint := storage.BackfillCheckpoint{Subreddit: "GoLang", After: "t3_abc", PostsFetched: 100, UpdatedAt: updated}
+	if err := store.SaveBackfillCheckpoint(ctx, checkpoint); err != nil {
+		t.Fatalf("SaveBackfillCheckpoint failed: %v", err)
+	}
+
+	// Saving again replaces the checkpoint
+	checkpoint.After = "t3_def"
+	checkpoint.PostsFetched = 200
+	if err := store.SaveBackfillCheckpoint(ctx, checkpoint); err != nil {
+		t.Fatalf("SaveBackfillCheckpoint failed: %v", err)
+	}
+
+	got, err := store.GetBackfillCheckpoint(ctx, "golang")
+	if err != nil {
+		t.Fatalf("GetBackfillCheckpoint failed: %v", err)
+	}
+	if got.Subreddit != "golang" || got.After != "t3_def" || got.PostsFetched != 200 || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("Unexpected checkpoint: %+v", got)
+	}
+
+	if err := store.DeleteBackfillCheckpoint(ctx, "golang"); err != nil {
+		t.Fatalf("DeleteBackfillCheckpoint failed: %v", err)
+	}
+	if _, err := store.GetBackfillCheckpoint(ctx, "golang"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_WithoutCommentRawJSON(t *testing.T) {
+	opts := DefaultOptions()
+	opts.StoreCommentRawJSON = false
+	store, err := NewWithOptions(t.TempDir()+"/noraw.db", opts)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "noraw", Name: "t3_noraw"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Post without comment JSON",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{
+			ThingData: types.ThingData{ID: "nr1", Name: "t1_nr1"},
+			LinkID:    "t3_noraw",
+			Author:    "user1",
+			Body:      "Top level",
+		},
+		{
+			ThingData: types.ThingData{ID: "nr2", Name: "t1_nr2"},
+			LinkID:    "t3_noraw",
+			ParentID:  "t1_nr1",
+			Author:    "user2",
+			Body:      "Reply",
+		},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+	if err := store.SaveComment(ctx, comments[0]); err != nil {
+		t.Fatalf("Failed to save comment: %v", err)
+	}
+
+	var withJSON int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM comments WHERE raw_json IS NOT NULL").Scan(&withJSON); err != nil {
+		t.Fatalf("Failed to count raw_json: %v", err)
+	}
+	if withJSON != 0 {
+		t.Errorf("Expected no stored raw_json, got %d rows", withJSON)
+	}
+
+	retrieved, err := store.GetCommentsByPost(ctx, "noraw")
+	if err != nil {
+		t.Fatalf("Failed to read comments with NULL raw_json: %v", err)
+	}
+	if len(retrieved) != 2 || retrieved[0].Body != "Top level" {
+		t.Errorf("Expected both comments back, got %d", len(retrieved))
+	}
+
+	if _, err := store.GetCommentTreeNested(ctx, "noraw"); err != nil {
+		t.Errorf("Failed to build tree with NULL raw_json: %v", err)
+	}
+}
+
+func TestSQLiteStorage_GetTopComments(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "top", Name: "t3_top"},
+		Created:   types.Created{CreatedUTC: 1700000000},
+		Subreddit: "golang",
+		Title:     "Top comments",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comment := func(id, parent string, score int, created float64) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: created},
+			LinkID:    "t3_top",
+			ParentID:  parent,
+			Author:    "user",
+			Body:      id,
+			Score:     score,
+		}
+	}
+
+	comments := []*types.Comment{
+		comment("low", "t3_top", 1, 1700000001),
+		comment("high", "t3_top", 50, 1700000002),
+		comment("tie_late", "t3_top", 10, 1700000004),
+		comment("tie_early", "t3_top", 10, 1700000003),
+		comment("reply", "t1_low", 500, 1700000005),
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	top, err := store.GetTopComments(ctx, "top", 3)
+	if err != nil {
+		t.Fatalf("GetTopComments failed: %v", err)
+	}
+
+	want := []string{"high", "tie_early", "tie_late"}
+	if len(top) != len(want) {
+		t.Fatalf("Expected %d comments, got %d", len(want), len(top))
+	}
+	for i, id := range want {
+		if top[i].ID != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, top[i].ID)
+		}
+		if top[i].Depth != 0 {
+			t.Errorf("Expected top-level comment, got depth %d", top[i].Depth)
+		}
+	}
+}
+
+func TestSQLiteStorage_FindPosts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	post := func(id, subreddit, author, title string, score int, age time.Duration) *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-age).Unix())},
+			Subreddit: subreddit,
+			Author:    author,
+			Title:     title,
+			Score:     score,
+		}
+	}
+
+	posts := []*types.Post{
+		post("match", "golang", "alice", "Generics in practice", 50, time.Hour),
+		post("lowscore", "golang", "alice", "Generics question", 3, time.Hour),
+		post("old", "golang", "alice", "Generics proposal", 90, 30*24*time.Hour),
+		post("othersub", "rust", "alice", "Generics in Rust", 80, time.Hour),
+		post("notext", "golang", "bob", "Error handling", 70, time.Hour),
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	minScore := 10
+	filter := storage.PostFilter{Subreddit: "golang", MinScore: &minScore, TextQuery: "generics"}
+	opts := storage.QueryOptions{StartDate: now.Add(-7 * 24 * time.Hour)}
+
+	found, err := store.FindPosts(ctx, filter, opts)
+	if err != nil {
+		t.Fatalf("FindPosts failed: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "match" {
+		var ids []string
+		for _, p := range found {
+			ids = append(ids, p.ID)
+		}
+		t.Errorf("Expected only [match], got %v", ids)
+	}
+
+	// Without a text query the structural filters still apply
+	byAuthor, err := store.FindPosts(ctx, storage.PostFilter{Author: "bob"}, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("FindPosts failed: %v", err)
+	}
+	if len(byAuthor) != 1 || byAuthor[0].ID != "notext" {
+		t.Errorf("Expected only bob's post, got %d posts", len(byAuthor))
+	}
+
+	// Web mode syntax works alongside the filters
+	opts.SearchMode = storage.SearchModeWeb
+	filter.TextQuery = `generics -practice`
+	filter.MinScore = nil
+	web, err := store.FindPosts(ctx, filter, opts)
+	if err != nil {
+		t.Fatalf("FindPosts failed: %v", err)
+	}
+	if len(web) != 1 || web[0].ID != "lowscore" {
+		t.Errorf("Expected only [lowscore] in web mode, got %d posts", len(web))
+	}
+}
+
+func TestSQLiteStorage_GetCommentsBySubreddit(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	base := float64(time.Now().Add(-time.Hour).Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "gp1", Name: "t3_gp1"}, Created: types.Created{CreatedUTC: base}, Subreddit: "golang", Title: "One"},
+		{ThingData: types.ThingData{ID: "gp2", Name: "t3_gp2"}, Created: types.Created{CreatedUTC: base}, Subreddit: "GoLang", Title: "Two"},
+		{ThingData: types.ThingData{ID: "rp1", Name: "t3_rp1"}, Created: types.Created{CreatedUTC: base}, Subreddit: "rust", Title: "Other"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comment := func(id, postID string, score int, offset float64) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: base + offset},
+			LinkID:    "t3_" + postID,
+			Author:    "user",
+			Body:      id,
+			Score:     score,
+		}
+	}
+	comments := []*types.Comment{
+		comment("a", "gp1", 5, 10),
+		comment("b", "gp2", 20, 20),
+		comment("c", "gp1", 1, 30),
+		comment("r", "rp1", 100, 40),
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	ids := func(comments []*types.Comment) string {
+		var out []string
+		for _, c := range comments {
+			out = append(out, c.ID)
+		}
+		return fmt.Sprint(out)
+	}
+
+	all, err := store.GetCommentsBySubreddit(ctx, "golang", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("GetCommentsBySubreddit failed: %v", err)
+	}
+	if got := ids(all); got != "[c b a]" {
+		t.Errorf("Expected newest first across posts [c b a], got %s", got)
+	}
+
+	minScore := 5
+	top, err := store.GetCommentsBySubreddit(ctx, "golang", storage.QueryOptions{SortBy: "score", MinScore: &minScore, Limit: 1})
+	if err != nil {
+		t.Fatalf("GetCommentsBySubreddit failed: %v", err)
+	}
+	if got := ids(top); got != "[b]" {
+		t.Errorf("Expected [b], got %s", got)
+	}
+
+	page, err := store.GetCommentsBySubreddit(ctx, "golang", storage.QueryOptions{
+		StartDate: time.Unix(int64(base+15), 0),
+		SortOrder: "asc",
+		Limit:     1,
+		Offset:    1,
+	})
+	if err != nil {
+		t.Fatalf("GetCommentsBySubreddit failed: %v", err)
+	}
+	if got := ids(page); got != "[c]" {
+		t.Errorf("Expected [c], got %s", got)
+	}
+}
+
+func TestSQLiteStorage_SortByArchivedComments(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	// Reddit's reported counts disagree with what was actually archived
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "few", Name: "t3_few"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Few", NumComments: 500},
+		{ThingData: types.ThingData{ID: "many", Name: "t3_many"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Many", NumComments: 2},
+		{ThingData: types.ThingData{ID: "none", Name: "t3_none"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "None", NumComments: 100},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	var comments []*types.Comment
+	for i, postID := range []string{"many", "many", "many", "few"} {
+		id := fmt.Sprintf("ac%d", i)
+		comments = append(comments, &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			LinkID:    "t3_" + postID,
+			Author:    "user",
+			Body:      id,
+		})
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	sorted, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{SortBy: "archived_comments"})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+
+	var ids []string
+	for _, post := range sorted {
+		ids = append(ids, post.ID)
+	}
+	if fmt.Sprint(ids) != "[many few none]" {
+		t.Errorf("Expected [many few none], got %v", ids)
+	}
+}
+
+func TestSQLiteStorage_CommentOrderSameSecondSiblings(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "ties", Name: "t3_ties"},
+		Created:   types.Created{CreatedUTC: created},
+		Subreddit: "golang",
+		Title:     "Same second siblings",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comment := func(id, parent string) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: created},
+			LinkID:    "t3_ties",
+			ParentID:  parent,
+			Author:    "user",
+			Body:      id,
+		}
+	}
+
+	// Saved out of ID order, all in the same second
+	comments := []*types.Comment{
+		comment("sb", "t3_ties"),
+		comment("sa", "t3_ties"),
+		comment("sa2", "t1_sa"),
+		comment("sb1", "t1_sb"),
+		comment("sa1", "t1_sa"),
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		retrieved, err := store.GetCommentsByPost(ctx, "ties")
+		if err != nil {
+			t.Fatalf("Failed to get comments: %v", err)
+		}
+
+		var ids []string
+		for _, c := range retrieved {
+			ids = append(ids, c.ID)
+		}
+		if fmt.Sprint(ids) != "[sa sa1 sa2 sb sb1]" {
+			t.Fatalf("Expected [sa sa1 sa2 sb sb1], got %v", ids)
+		}
+	}
+}
+
+func TestSQLiteStorage_HasMedia(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	urls := map[string]string{
+		"img":     "https://i.redd.it/abc123.jpg",
+		"gallery": "https://www.reddit.com/gallery/xyz",
+		"imgur":   "https://imgur.com/a/Album",
+		"ext":     "https://example.com/photo.PNG",
+		"link":    "https://go.dev/blog/",
+		"self":    "https://www.reddit.com/r/golang/comments/self/",
+	}
+
+	var posts []*types.Post
+	i := 0
+	for id, url := range urls {
+		posts = append(posts, &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-time.Duration(i) * time.Minute).Unix())},
+			Subreddit: "golang",
+			Title:     id,
+			URL:       url,
+		})
+		i++
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	media, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{HasMedia: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, post := range media {
+		got[post.ID] = true
+	}
+	if len(got) != 4 || !got["img"] || !got["gallery"] || !got["imgur"] || !got["ext"] {
+		t.Errorf("Expected img, gallery, imgur and ext, got %v", got)
+	}
+
+	// Extra patterns extend the heuristics
+	saved := storage.MediaURLPatterns
+	storage.MediaURLPatterns = append(append([]string(nil), saved...), "%://go.dev/%")
+	defer func() { storage.MediaURLPatterns = saved }()
+
+	media, err = store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{HasMedia: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(media) != 5 {
+		t.Errorf("Expected 5 posts with custom pattern, got %d", len(media))
+	}
+}
+
+func TestSQLiteStorage_GetTopLevelCommentsAndReplies(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "lazy", Name: "t3_lazy"},
+		Created:   types.Created{CreatedUTC: created},
+		Subreddit: "golang",
+		Title:     "Lazy thread",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comment := func(id, parent string, score int) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: created},
+			LinkID:    "t3_lazy",
+			ParentID:  parent,
+			Author:    "user",
+			Body:      id,
+			Score:     score,
+		}
+	}
+
+	comments := []*types.Comment{
+		comment("t1", "t3_lazy", 5),
+		comment("t2", "t3_lazy", 50),
+		comment("t3", "t3_lazy", 1),
+		comment("r1", "t1_t2", 3),
+		comment("r2", "t1_t2", 7),
+		comment("rr", "t1_r1", 1),
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	top, err := store.GetTopLevelComments(ctx, "lazy", storage.QueryOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("GetTopLevelComments failed: %v", err)
+	}
+	if len(top) != 2 || top[0].ID != "t2" || top[1].ID != "t1" {
+		t.Fatalf("Expected first page [t2 t1], got %v", top)
+	}
+	if top[0].ReplyCount != 2 || top[1].ReplyCount != 0 {
+		t.Errorf("Expected reply counts 2 and 0, got %d and %d", top[0].ReplyCount, top[1].ReplyCount)
+	}
+
+	next, err := store.GetTopLevelComments(ctx, "lazy", storage.QueryOptions{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("GetTopLevelComments failed: %v", err)
+	}
+	if len(next) != 1 || next[0].ID != "t3" {
+		t.Fatalf("Expected second page [t3], got %v", next)
+	}
+
+	replies, err := store.GetReplies(ctx, "t2", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+	if len(replies) != 2 || replies[0].ID != "r2" || replies[1].ID != "r1" {
+		t.Fatalf("Expected replies [r2 r1], got %v", replies)
+	}
+	if replies[1].ReplyCount != 1 || replies[1].ParentID != "t1_t2" {
+		t.Errorf("Expected r1 to have 1 reply and parent t1_t2, got %d and %s", replies[1].ReplyCount, replies[1].ParentID)
+	}
+}
+
+func TestSQLiteStorage_GetCommentDepthHistogram(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "hist", Name: "t3_hist"},
+		Created:   types.Created{CreatedUTC: created},
+		Subreddit: "golang",
+		Title:     "Histogram",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	empty, err := store.GetCommentDepthHistogram(ctx, "hist")
+	if err != nil {
+		t.Fatalf("GetCommentDepthHistogram failed: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("Expected empty histogram, got %v", empty)
+	}
+
+	var comments []*types.Comment
+	for _, c := range [][2]string{
+		{"h1", "t3_hist"}, {"h2", "t3_hist"}, {"h3", "t3_hist"},
+		{"h4", "t1_h1"}, {"h5", "t1_h1"},
+		{"h6", "t1_h4"},
+	} {
+		comments = append(comments, &types.Comment{
+			ThingData: types.ThingData{ID: c[0], Name: "t1_" + c[0]},
+			Created:   types.Created{CreatedUTC: created},
+			LinkID:    "t3_hist",
+			ParentID:  c[1],
+			Author:    "user",
+			Body:      c[0],
+		})
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	histogram, err := store.GetCommentDepthHistogram(ctx, "hist")
+	if err != nil {
+		t.Fatalf("GetCommentDepthHistogram failed: %v", err)
+	}
+
+	want := map[int]int{0: 3, 1: 2, 2: 1}
+	if fmt.Sprint(histogram) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, histogram)
+	}
+}
+
+func TestSQLiteStorage_NonEmptySelfText(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "text", Name: "t3_text"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Text", IsSelf: true, SelfText: "Some body"},
+		{ThingData: types.ThingData{ID: "empty", Name: "t3_empty"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Title only", IsSelf: true},
+		{ThingData: types.ThingData{ID: "link", Name: "t3_link"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Link", URL: "https://go.dev"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	text, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{NonEmptySelfText: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(text) != 1 || text[0].ID != "text" {
+		t.Errorf("Expected only the text post, got %d posts", len(text))
+	}
+}
+
+func TestSQLiteStorage_ExcludeStickied(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "pinned", Name: "t3_pinned"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Announcement", Score: 5000, Stickied: true},
+		{ThingData: types.ThingData{ID: "top", Name: "t3_top"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Top", Score: 300},
+		{ThingData: types.ThingData{ID: "mid", Name: "t3_mid"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Mid", Score: 20},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	pinned, err := store.GetPost(ctx, "pinned")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if !pinned.Stickied {
+		t.Error("Expected stickied to round-trip")
+	}
+
+	organic, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{SortBy: "score", ExcludeStickied: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+
+	var ids []string
+	for _, post := range organic {
+		ids = append(ids, post.ID)
+	}
+	if fmt.Sprint(ids) != "[top mid]" {
+		t.Errorf("Expected [top mid], got %v", ids)
+	}
+
+	// Unpinning is picked up on the next save
+	posts[0].Stickied = false
+	if err := store.SavePosts(ctx, posts[:1]); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	organic, err = store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{SortBy: "score", ExcludeStickied: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(organic) != 3 || organic[0].ID != "pinned" {
+		t.Errorf("Expected unpinned post to lead, got %d posts", len(organic))
+	}
+}
+
+func TestSQLiteStorage_Outbox(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Outbox = true
+	store, err := NewWithOptions(t.TempDir()+"/outbox.db", opts)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "GoLang"}); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "ob1", Name: "t3_ob1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Title:     "Outbox post",
+	}
+	if err := store.SavePosts(ctx, []*types.Post{post}); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	comment := &types.Comment{
+		ThingData: types.ThingData{ID: "obc1", Name: "t1_obc1"},
+		LinkID:    "t3_ob1",
+		ParentID:  "t3_ob1",
+		Author:    "user",
+		Body:      "Outbox comment",
+	}
+	if err := store.SaveComments(ctx, []*types.Comment{comment}); err != nil {
+		t.Fatalf("Failed to save comment: %v", err)
+	}
+
+	if _, err := store.DeletePosts(ctx, []string{"ob1"}); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+
+	events, err := store.ReadOutbox(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("ReadOutbox failed: %v", err)
+	}
+
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Op+" "+event.EntityType+" "+event.EntityID)
+		if event.CreatedAt.IsZero() {
+			t.Errorf("Expected event %d to have a timestamp", event.ID)
+		}
+	}
+	want := "[save subreddit golang save post ob1 save comment obc1 delete post ob1]"
+	if fmt.Sprint(got) != want {
+		t.Fatalf("Expected %s, got %v", want, got)
+	}
+
+	// Paging continues after the last seen ID, and acked events are gone
+	rest, err := store.ReadOutbox(ctx, events[1].ID, 10)
+	if err != nil {
+		t.Fatalf("ReadOutbox failed: %v", err)
+	}
+	if len(rest) != 2 || rest[0].ID != events[2].ID {
+		t.Errorf("Expected the last 2 events after %d, got %d", events[1].ID, len(rest))
+	}
+
+	if err := store.AckOutbox(ctx, events[2].ID); err != nil {
+		t.Fatalf("AckOutbox failed: %v", err)
+	}
+	remaining, err := store.ReadOutbox(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadOutbox failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].Op != storage.OutboxOpDelete {
+		t.Errorf("Expected only the delete event to remain, got %d events", len(remaining))
+	}
+}
+
+func TestSQLiteStorage_OutboxDisabled(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "golang"}); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	events, err := store.ReadOutbox(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadOutbox failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events with the outbox off, got %d", len(events))
+	}
+}
+
+func TestSQLiteStorage_SearchComments(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	base := float64(time.Now().Add(-time.Hour).Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "gp", Name: "t3_gp"}, Created: types.Created{CreatedUTC: base}, Subreddit: "golang", Title: "Go"},
+		{ThingData: types.ThingData{ID: "rp", Name: "t3_rp"}, Created: types.Created{CreatedUTC: base}, Subreddit: "rust", Title: "Rust"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comment := func(id, postID, body string, score int) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: id, Name: "t1_" + id},
+			Created:   types.Created{CreatedUTC: base},
+			LinkID:    "t3_" + postID,
+			Author:    "user",
+			Body:      body,
+			Score:     score,
+		}
+	}
+	comments := []*types.Comment{
+		comment("a", "gp", "Goroutines make concurrency easy", 5),
+		comment("b", "gp", "Channels are great for concurrency", 50),
+		comment("c", "rp", "Concurrency without data races", 20),
+		comment("d", "gp", "Unrelated remark", 100),
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	ids := func(comments []*types.Comment) string {
+		var out []string
+		for _, c := range comments {
+			out = append(out, c.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		opts  storage.QueryOptions
+		want  string
+	}{
+		{"keyword by score", "concurrency", storage.QueryOptions{}, "b,c,a"},
+		{"stemmed", "goroutine", storage.QueryOptions{}, "a"},
+		{"all words required", "concurrency channels", storage.QueryOptions{}, "b"},
+		{"subreddit scoped", "concurrency", storage.QueryOptions{Subreddit: "GoLang"}, "b,a"},
+		{"web negation", "concurrency -channels", storage.QueryOptions{SearchMode: storage.SearchModeWeb}, "c,a"},
+		{"web or", "goroutines OR races", storage.QueryOptions{SearchMode: storage.SearchModeWeb}, "c,a"},
+		{"operators are text", "concurrency AND", storage.QueryOptions{}, ""},
+		{"empty", "  ", storage.QueryOptions{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.SearchComments(ctx, tt.query, tt.opts)
+			if err != nil {
+				t.Fatalf("SearchComments failed: %v", err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, ids(got))
+			}
+		})
+	}
+
+	// Editing a comment must update the index
+	edited := comment("d", "gp", "Concurrency after all", 100)
+	if err := store.SaveComment(ctx, edited); err != nil {
+		t.Fatalf("Failed to save comment: %v", err)
+	}
+
+	got, err := store.SearchComments(ctx, "concurrency", storage.QueryOptions{Subreddit: "golang"})
+	if err != nil {
+		t.Fatalf("SearchComments failed: %v", err)
+	}
+	if ids(got) != "d,b,a" {
+		t.Errorf("Expected the edited comment to match, got %q", ids(got))
+	}
+
+	got, err = store.SearchComments(ctx, "unrelated", storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("SearchComments failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected the old body to be unindexed, got %q", ids(got))
+	}
+}
+
+func TestSQLiteStorage_PreserveFirstSeen(t *testing.T) {
+	ctx := context.Background()
+	created := float64(time.Now().Add(-time.Hour).Unix())
+
+	original := func() *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: "fs1", Name: "t3_fs1"},
+			Created:   types.Created{CreatedUTC: created},
+			Subreddit: "golang",
+			Author:    "gopher",
+			Title:     "Original title",
+			Score:     10,
+		}
+	}
+	refetched := func() *types.Post {
+		post := original()
+		post.CreatedUTC = created + 86400
+		post.Author = ""
+		post.Title = "Mutated title"
+		post.Score = 50
+		return post
+	}
+
+	tests := []struct {
+		name       string
+		preserve   bool
+		wantAuthor string
+		wantTitle  string
+	}{
+		{"preserved", true, "gopher", "Original title"},
+		{"refreshed", false, storage.DeletedAuthor, "Mutated title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.PreserveFirstSeen = tt.preserve
+			store, err := NewWithOptions(t.TempDir()+"/first_seen.db", opts)
+			if err != nil {
+				t.Fatalf("Failed to create SQLite storage: %v", err)
+			}
+			defer store.Close()
+
+			if err := store.RunMigrations(ctx); err != nil {
+				t.Fatalf("Failed to run migrations: %v", err)
+			}
+
+			if err := store.SavePost(ctx, original()); err != nil {
+				t.Fatalf("Failed to save post: %v", err)
+			}
+
+			saves := map[string]func() error{
+				"SavePost": func() error { return store.SavePost(ctx, refetched()) },
+				"SaveStoredPosts": func() error {
+					return store.SaveStoredPosts(ctx, []*storage.StoredPost{{Post: refetched()}})
+				},
+			}
+			for method, save := range saves {
+				if err := save(); err != nil {
+					t.Fatalf("%s failed: %v", method, err)
+				}
+
+				got, err := store.GetPost(ctx, "fs1")
+				if err != nil {
+					t.Fatalf("GetPost failed: %v", err)
+				}
+				if got.CreatedUTC != created {
+					t.Errorf("%s: expected created_utc %v to persist, got %v", method, created, got.CreatedUTC)
+				}
+				if got.Score != 50 {
+					t.Errorf("%s: expected score to refresh to 50, got %d", method, got.Score)
+				}
+				if got.Author != tt.wantAuthor || got.Title != tt.wantTitle {
+					t.Errorf("%s: expected %q/%q, got %q/%q", method, tt.wantAuthor, tt.wantTitle, got.Author, got.Title)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_GetPostsUpdatedSince(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Add(-time.Hour).Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "u1", Name: "t3_u1"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "One"},
+		{ThingData: types.ThingData{ID: "u2", Name: "t3_u2"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Two"},
+		{ThingData: types.ThingData{ID: "u3", Name: "t3_u3"}, Created: types.Created{CreatedUTC: created}, Subreddit: "rust", Title: "Three"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	// Age one post as though it was last saved long ago
+	if _, err := store.db.ExecContext(ctx, "UPDATE posts SET last_updated = '2020-01-01 00:00:00' WHERE id = 'u1'"); err != nil {
+		t.Fatalf("Failed to age post: %v", err)
+	}
+
+	ids := func(posts []*types.Post) string {
+		var out []string
+		for _, p := range posts {
+			out = append(out, p.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		since time.Time
+		opts  storage.QueryOptions
+		want  string
+	}{
+		{"oldest first", time.Time{}, storage.QueryOptions{}, "u1,u2,u3"},
+		{"after cutoff", cutoff, storage.QueryOptions{}, "u2,u3"},
+		{"subreddit", cutoff, storage.QueryOptions{Subreddit: "GoLang"}, "u2"},
+		{"paginated", cutoff, storage.QueryOptions{Limit: 1, Offset: 1}, "u3"},
+		{"future", time.Now().Add(time.Hour), storage.QueryOptions{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.GetPostsUpdatedSince(ctx, tt.since, tt.opts)
+			if err != nil {
+				t.Fatalf("GetPostsUpdatedSince failed: %v", err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, ids(got))
+			}
+		})
+	}
+
+	// Re-saving touches the post again
+	if err := store.SavePost(ctx, posts[0]); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	got, err := store.GetPostsUpdatedSince(ctx, cutoff, storage.QueryOptions{})
+	if err != nil {
+		t.Fatalf("GetPostsUpdatedSince failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Expected the re-saved post to be included, got %q", ids(got))
+	}
+}
+
+func TestSQLiteStorage_NegativePagination(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Add(-time.Hour).Unix())
+
+	post := &types.Post{ThingData: types.ThingData{ID: "np1", Name: "t3_np1"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Author: "gopher", Title: "Negative pagination", Score: 5}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	comment := &types.Comment{ThingData: types.ThingData{ID: "nc1", Name: "t1_nc1"}, Created: types.Created{CreatedUTC: created}, LinkID: "t3_np1", ParentID: "t3_np1", Author: "gopher", Body: "negative pagination"}
+	if err := store.SaveComment(ctx, comment); err != nil {
+		t.Fatalf("Failed to save comment: %v", err)
+	}
+
+	opts := storage.QueryOptions{Limit: -5, Offset: -3}
+
+	queries := map[string]func() (int, error){
+		"GetPostsBySubreddit": func() (int, error) {
+			posts, err := store.GetPostsBySubreddit(ctx, "golang", opts)
+			return len(posts), err
+		},
+		"GetPostsBySubreddit after cursor": func() (int, error) {
+			after := opts
+			after.After = &storage.Cursor{CreatedUTC: created + 1, ID: "zzz"}
+			posts, err := store.GetPostsBySubreddit(ctx, "golang", after)
+			return len(posts), err
+		},
+		"FindPosts": func() (int, error) {
+			posts, err := store.FindPosts(ctx, storage.PostFilter{Author: "gopher"}, opts)
+			return len(posts), err
+		},
+		"GetStoredPostsBySubreddit": func() (int, error) {
+			posts, err := store.GetStoredPostsBySubreddit(ctx, "golang", opts)
+			return len(posts), err
+		},
+		"GetPostsUpdatedSince": func() (int, error) {
+			posts, err := store.GetPostsUpdatedSince(ctx, time.Time{}, opts)
+			return len(posts), err
+		},
+		"SearchPosts": func() (int, error) {
+			posts, err := store.SearchPosts(ctx, "negative", opts)
+			return len(posts), err
+		},
+		"SearchComments": func() (int, error) {
+			comments, err := store.SearchComments(ctx, "negative", opts)
+			return len(comments), err
+		},
+		"ListSubreddits": func() (int, error) {
+			subs, err := store.ListSubreddits(ctx, opts)
+			return len(subs), err
+		},
+		"GetTopLevelComments": func() (int, error) {
+			comments, err := store.GetTopLevelComments(ctx, "np1", opts)
+			return len(comments), err
+		},
+		"GetCommentsByAuthorWithContext": func() (int, error) {
+			comments, err := store.GetCommentsByAuthorWithContext(ctx, "gopher", opts)
+			return len(comments), err
+		},
+		"GetCommentsBySubreddit": func() (int, error) {
+			comments, err := store.GetCommentsBySubreddit(ctx, "golang", opts)
+			return len(comments), err
+		},
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			n, err := query()
+			if !errors.Is(err, storage.ErrInvalidOptions) {
+				t.Fatalf("Expected ErrInvalidOptions, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "QueryOptions.Limit") {
+				t.Errorf("Expected the error to name QueryOptions.Limit, got %v", err)
+			}
+			if n != 0 {
+				t.Errorf("Expected no results, got %d", n)
+			}
+		})
+	}
+
+	if _, err := store.GetReplies(ctx, "nc1", opts); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected GetReplies to return ErrInvalidOptions, got %v", err)
+	}
+
+	// A bad offset alone is named too
+	_, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Offset: -3})
+	if err == nil || !strings.Contains(err.Error(), "QueryOptions.Offset") {
+		t.Errorf("Expected an error naming QueryOptions.Offset, got %v", err)
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		limit, offset         int
+		wantLimit, wantOffset int
+	}{
+		{0, 0, defaultQueryLimit, 0},
+		{10, 20, 10, 20},
+		{-1, -1, defaultQueryLimit, 0},
+		{-100, 5, defaultQueryLimit, 5},
+	}
+
+	for _, tt := range tests {
+		limit, offset := pageBounds(storage.QueryOptions{Limit: tt.limit, Offset: tt.offset})
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Errorf("pageBounds(%d, %d) = %d, %d; want %d, %d", tt.limit, tt.offset, limit, offset, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+func TestSQLiteStorage_SaveSubredditStubKeepsMetadata(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	full := &types.SubredditData{
+		DisplayName: "golang",
+		Title:       "The Go Programming Language",
+		Description: "Ask questions about Go",
+		Subscribers: 250000,
+	}
+	if err := store.SaveSubreddit(ctx, full); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	// A stub naming only the subreddit must not erase the metadata
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "golang"}); err != nil {
+		t.Fatalf("Failed to save stub: %v", err)
+	}
+
+	got, err := store.GetSubreddit(ctx, "golang")
+	if err != nil {
+		t.Fatalf("Failed to get subreddit: %v", err)
+	}
+	if got.Title != full.Title || got.Description != full.Description || got.Subscribers != full.Subscribers {
+		t.Errorf("Expected metadata to survive the stub save, got %q/%q/%d", got.Title, got.Description, got.Subscribers)
+	}
+
+	// Non-empty values still update
+	if err := store.SaveSubreddit(ctx, &types.SubredditData{DisplayName: "golang", Subscribers: 260000}); err != nil {
+		t.Fatalf("Failed to save subreddit: %v", err)
+	}
+
+	got, err = store.GetSubreddit(ctx, "golang")
+	if err != nil {
+		t.Fatalf("Failed to get subreddit: %v", err)
+	}
+	if got.Subscribers != 260000 || got.Title != full.Title {
+		t.Errorf("Expected subscribers to update and title to stay, got %d/%q", got.Subscribers, got.Title)
+	}
+}
+
+func TestSQLiteStorage_MinComments(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "none", Name: "t3_none"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Quiet", NumComments: 0},
+		{ThingData: types.ThingData{ID: "some", Name: "t3_some"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Some", NumComments: 4},
+		{ThingData: types.ThingData{ID: "many", Name: "t3_many"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Busy", NumComments: 5},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name        string
+		minComments *int
+		want        int
+	}{
+		{"unset", nil, 3},
+		{"zero", intPtr(0), 3},
+		{"excludes zero-comment posts", intPtr(1), 2},
+		{"inclusive boundary", intPtr(5), 1},
+		{"above every post", intPtr(6), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{MinComments: tt.minComments})
+			if err != nil {
+				t.Fatalf("GetPostsBySubreddit failed: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Expected %d posts, got %d", tt.want, len(got))
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_ThreadQueryTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	newStore := func(timeout time.Duration) *SQLiteStorage {
+		opts := DefaultOptions()
+		opts.ThreadQueryTimeout = timeout
+		store, err := NewWithOptions(t.TempDir()+"/timeout.db", opts)
+		if err != nil {
+			t.Fatalf("Failed to create SQLite storage: %v", err)
+		}
+		if err := store.RunMigrations(ctx); err != nil {
+			t.Fatalf("Failed to run migrations: %v", err)
+		}
+
+		post := &types.Post{ThingData: types.ThingData{ID: "tq1", Name: "t3_tq1"}, Created: types.Created{CreatedUTC: float64(time.Now().Unix())}, Subreddit: "golang", Title: "Thread"}
+		if err := store.SavePost(ctx, post); err != nil {
+			t.Fatalf("Failed to save post: %v", err)
+		}
+		comment := &types.Comment{ThingData: types.ThingData{ID: "tc1", Name: "t1_tc1"}, LinkID: "t3_tq1", ParentID: "t3_tq1", Author: "gopher", Body: "hi"}
+		if err := store.SaveComment(ctx, comment); err != nil {
+			t.Fatalf("Failed to save comment: %v", err)
+		}
+		return store
+	}
+
+	// A timeout that expires before the query finishes fails it cleanly
+	store := newStore(time.Nanosecond)
+	defer store.Close()
+
+	if _, err := store.GetCommentsByPost(ctx, "tq1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected GetCommentsByPost to fail with DeadlineExceeded, got %v", err)
+	}
+	if _, err := store.GetPostStats(ctx, "tq1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected GetPostStats to fail with DeadlineExceeded, got %v", err)
+	}
+
+	// Disabling the timeout leaves the caller's context in charge
+	unbounded := newStore(-1)
+	defer unbounded.Close()
+
+	comments, err := unbounded.GetCommentsByPost(ctx, "tq1")
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("Expected 1 comment without a timeout, got %d (err=%v)", len(comments), err)
+	}
+
+	expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := unbounded.GetCommentsByPost(expired, "tq1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline to fail the query, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_GetStoredNumComments(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "nc1", Name: "t3_nc1"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Busy", NumComments: 12},
+		{ThingData: types.ThingData{ID: "nc2", Name: "t3_nc2"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Quiet"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	counts, err := store.GetStoredNumComments(ctx, []string{"nc1", "nc2", "unknown"})
+	if err != nil {
+		t.Fatalf("GetStoredNumComments failed: %v", err)
+	}
+
+	want := map[string]int{"nc1": 12, "nc2": 0}
+	if len(counts) != len(want) {
+		t.Errorf("Expected %d entries, got %v", len(want), counts)
+	}
+	for id, n := range want {
+		if got, ok := counts[id]; !ok || got != n {
+			t.Errorf("Expected num_comments %d for %s, got %d (present=%v)", n, id, got, ok)
+		}
+	}
+}
+
+func TestSQLiteStorage_GetPostCounts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "pc1", Name: "t3_pc1"}, Created: types.Created{CreatedUTC: float64(now.Add(-time.Hour).Unix())}, Subreddit: "golang", Title: "Recent", Score: 40, NumComments: 7},
+		{ThingData: types.ThingData{ID: "pc2", Name: "t3_pc2"}, Created: types.Created{CreatedUTC: float64(now.Add(-2 * time.Hour).Unix())}, Subreddit: "golang", Title: "Older", Score: 3},
+		{ThingData: types.ThingData{ID: "pc3", Name: "t3_pc3"}, Created: types.Created{CreatedUTC: float64(now.Add(-72 * time.Hour).Unix())}, Subreddit: "golang", Title: "Stale", Score: 9},
+		{ThingData: types.ThingData{ID: "pc4", Name: "t3_pc4"}, Created: types.Created{CreatedUTC: float64(now.Unix())}, Subreddit: "rust", Title: "Elsewhere"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	counts, err := store.GetPostCounts(ctx, "golang", storage.QueryOptions{SortBy: "created", SortOrder: "desc", StartDate: now.Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("GetPostCounts failed: %v", err)
+	}
+
+	want := []storage.PostCounts{{ID: "pc1", Score: 40, NumComments: 7}, {ID: "pc2", Score: 3}}
+	if len(counts) != len(want) {
+		t.Fatalf("Expected %d posts, got %d", len(want), len(counts))
+	}
+	for i, w := range want {
+		if *counts[i] != w {
+			t.Errorf("Post %d: expected %+v, got %+v", i, w, *counts[i])
+		}
+	}
+
+	if _, err := store.GetPostCounts(ctx, "golang", storage.QueryOptions{Limit: -1}); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected ErrInvalidOptions, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_GetArchivedCommentCounts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	created := float64(time.Now().Unix())
+
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "ac1", Name: "t3_ac1"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "Two comments"},
+		{ThingData: types.ThingData{ID: "ac2", Name: "t3_ac2"}, Created: types.Created{CreatedUTC: created}, Subreddit: "golang", Title: "No comments"},
+	}
+	if err := store.SavePosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	comments := []*types.Comment{
+		{ThingData: types.ThingData{ID: "acc1", Name: "t1_acc1"}, Created: types.Created{CreatedUTC: created}, LinkID: "t3_ac1", ParentID: "t3_ac1", Body: "one"},
+		{ThingData: types.ThingData{ID: "acc2", Name: "t1_acc2"}, Created: types.Created{CreatedUTC: created}, LinkID: "t3_ac1", ParentID: "t1_acc1", Body: "two"},
+	}
+	if err := store.SaveComments(ctx, comments); err != nil {
+		t.Fatalf("Failed to save comments: %v", err)
+	}
+
+	counts, err := store.GetArchivedCommentCounts(ctx, []string{"ac1", "ac2", "unknown"})
+	if err != nil {
+		t.Fatalf("GetArchivedCommentCounts failed: %v", err)
+	}
+
+	want := map[string]int{"ac1": 2, "ac2": 0, "unknown": 0}
+	if len(counts) != len(want) {
+		t.Errorf("Expected %d entries, got %v", len(want), counts)
+	}
+	for id, n := range want {
+		if got, ok := counts[id]; !ok || got != n {
+			t.Errorf("Expected %d comments for %s, got %d (present=%v)", n, id, got, ok)
+		}
+	}
+
+	empty, err := store.GetArchivedCommentCounts(ctx, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty result for no IDs, got %v (err=%v)", empty, err)
+	}
+}
+
+func TestSQLiteStorage_RemovedPost(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	newPost := func(selfText string, score int) *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: "rm1", Name: "t3_rm1"},
+			Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+			Subreddit: "golang",
+			Author:    "gopher",
+			Title:     "Original title",
+			SelfText:  selfText,
+			IsSelf:    true,
+			Score:     score,
+		}
+	}
+
+	post := newPost("Original body", 10)
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	stored, err := store.GetStoredPost(ctx, "rm1")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if stored.RemovedAt != nil {
+		t.Errorf("Expected a live post to have no RemovedAt, got %v", stored.RemovedAt)
+	}
+
+	rawJSON := func() string {
+		var raw string
+		if err := store.db.QueryRowContext(ctx, "SELECT raw_json FROM posts WHERE id = ?", "rm1").Scan(&raw); err != nil {
+			t.Fatalf("Failed to read raw_json: %v", err)
+		}
+		return raw
+	}
+	archived := rawJSON()
+
+	removed := newPost("[removed]", 99)
+
+	var firstRemovedAt time.Time
+	for i := range 2 {
+		if err := store.SaveStoredPosts(ctx, []*storage.StoredPost{{Post: removed}}); err != nil {
+			t.Fatalf("SaveStoredPosts failed: %v", err)
+		}
+
+		stored, err := store.GetStoredPost(ctx, "rm1")
+		if err != nil {
+			t.Fatalf("GetStoredPost failed: %v", err)
+		}
+		if stored.RemovedAt == nil {
+			t.Fatal("Expected the post to be recorded as removed")
+		}
+		if i == 0 {
+			firstRemovedAt = *stored.RemovedAt
+		} else if !stored.RemovedAt.Equal(firstRemovedAt) {
+			t.Errorf("Expected RemovedAt to stay at the first sighting %v, got %v", firstRemovedAt, stored.RemovedAt)
+		}
+		if stored.SelfText != "Original body" || stored.Score != 99 {
+			t.Errorf("Expected the body kept and the score refreshed, got %q/%d", stored.SelfText, stored.Score)
+		}
+		if rawJSON() != archived {
+			t.Error("Expected the archived raw_json to be kept")
+		}
+	}
+
+	posts, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{ExcludeRemoved: true})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected ExcludeRemoved to leave out the removed post, got %d posts", len(posts))
+	}
+
+	// A post restored on Reddit is live again
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	restored, err := store.GetStoredPost(ctx, "rm1")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if restored.RemovedAt != nil {
+		t.Errorf("Expected RemovedAt to be cleared, got %v", restored.RemovedAt)
+	}
+
+	if _, err := store.GetStoredPost(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_DeletedAuthorPost(t *testing.T) {
+	ctx := context.Background()
+
+	opts := DefaultOptions()
+	opts.KeepRevisions = true
+	store, err := NewWithOptions(t.TempDir()+"/deleted_author.db", opts)
+	if err != nil {
+		t.Fatalf("Failed to create SQLite storage: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// The author deleted their account, but the post itself is live
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "da1", Name: "t3_da1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    storage.DeletedAuthor,
+		Title:     "Orphaned post",
+		SelfText:  "Still here",
+		IsSelf:    true,
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	post.SelfText = "Still here, edited"
+	post.Score = 42
+	if err := store.SaveStoredPosts(ctx, []*storage.StoredPost{{Post: post}}); err != nil {
+		t.Fatalf("SaveStoredPosts failed: %v", err)
+	}
+
+	stored, err := store.GetStoredPost(ctx, "da1")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if stored.RemovedAt != nil {
+		t.Errorf("Expected a live post by a deleted author to have no RemovedAt, got %v", stored.RemovedAt)
+	}
+	if stored.SelfText != "Still here, edited" || stored.Score != 42 {
+		t.Errorf("Expected the re-save to update the post, got %q/%d", stored.SelfText, stored.Score)
+	}
+
+	var raw string
+	if err := store.db.QueryRowContext(ctx, "SELECT raw_json FROM posts WHERE id = ?", "da1").Scan(&raw); err != nil {
+		t.Fatalf("Failed to read raw_json: %v", err)
+	}
+	if !strings.Contains(raw, "Still here, edited") {
+		t.Errorf("Expected raw_json to be refreshed, got %s", raw)
+	}
+
+	revisions, err := store.GetPostRevisions(ctx, "da1")
+	if err != nil {
+		t.Fatalf("GetPostRevisions failed: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Body != "Still here" {
+		t.Errorf("Expected the earlier selftext as a revision, got %+v", revisions)
+	}
+}
+
+func TestSQLiteStorage_Revisions(t *testing.T) {
+	ctx := context.Background()
+
+	opts := DefaultOptions()
+	opts.KeepRevisions = true
+	store, err := NewWithOptions(t.TempDir()+"/revisions.db", opts)
+	if err != nil {
+		t.Fatalf("Failed to create SQLite storage: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.RunMigrations(ctx); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	created := float64(time.Now().Add(-time.Hour).Unix())
+	post := func(selfText string, score int, edited float64) *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: "rev1", Name: "t3_rev1"},
+			Created:   types.Created{CreatedUTC: created},
+			Subreddit: "golang",
+			Author:    "gopher",
+			Title:     "Edited post",
+			SelfText:  selfText,
+			IsSelf:    true,
+			Score:     score,
+			Edited:    types.Edited{IsEdited: edited > 0, Timestamp: edited},
+		}
+	}
+	editedAt := float64(time.Now().Unix())
+
+	saves := []func() error{
+		func() error { return store.SavePost(ctx, post("First draft", 1, 0)) },
+		func() error { return store.SavePost(ctx, post("First draft", 5, 0)) }, // Score only: no revision
+		func() error {
+			return store.SaveStoredPosts(ctx, []*storage.StoredPost{{Post: post("Second draft", 8, editedAt)}})
+		},
+		func() error { return store.SavePost(ctx, post("Final", 12, editedAt+60)) },
+		func() error { return store.SavePost(ctx, post("[removed]", 3, editedAt+60)) }, // Removal keeps the body
+	}
+	for i, save := range saves {
+		if err := save(); err != nil {
+			t.Fatalf("Save %d failed: %v", i, err)
+		}
+	}
+
+	revisions, err := store.GetPostRevisions(ctx, "rev1")
+	if err != nil {
+		t.Fatalf("GetPostRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 post revisions, got %d", len(revisions))
+	}
+	if revisions[0].Body != "First draft" || revisions[0].Score != 5 || revisions[0].EditedUTC != 0 {
+		t.Errorf("Unexpected first revision: %+v", revisions[0])
+	}
+	if revisions[1].Body != "Second draft" || revisions[1].Score != 8 || revisions[1].EditedUTC != editedAt {
+		t.Errorf("Unexpected second revision: %+v", revisions[1])
+	}
+	if revisions[0].ReplacedAt.IsZero() || revisions[1].ReplacedAt.Before(revisions[0].ReplacedAt) {
+		t.Errorf("Expected increasing ReplacedAt times, got %v and %v", revisions[0].ReplacedAt, revisions[1].ReplacedAt)
+	}
+
+	current, err := store.GetPost(ctx, "rev1")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if current.SelfText != "Final" {
+		t.Errorf("Expected the latest live selftext to be stored, got %q", current.SelfText)
+	}
+
+	comment := func(body string) *types.Comment {
+		return &types.Comment{
+			ThingData: types.ThingData{ID: "revc1", Name: "t1_revc1"},
+			Created:   types.Created{CreatedUTC: created},
+			LinkID:    "t3_rev1",
+			Author:    "gopher",
+			Body:      body,
+		}
+	}
+	if err := store.SaveComment(ctx, comment("Typo")); err != nil {
+		t.Fatalf("SaveComment failed: %v", err)
+	}
+	if err := store.SaveComment(ctx, comment("Fixed")); err != nil {
+		t.Fatalf("SaveComment failed: %v", err)
+	}
+	if err := store.SaveComments(ctx, []*types.Comment{comment("[deleted]")}); err != nil {
+		t.Fatalf("SaveComments failed: %v", err)
+	}
+
+	revisions, err = store.GetCommentRevisions(ctx, "revc1")
+	if err != nil {
+		t.Fatalf("GetCommentRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Body != "Typo" || revisions[1].Body != "Fixed" {
+		t.Errorf("Expected comment revisions Typo, Fixed; got %+v", revisions)
+	}
+
+	// Revisions go with their post
+	if _, err := store.DeletePosts(ctx, []string{"rev1"}); err != nil {
+		t.Fatalf("DeletePosts failed: %v", err)
+	}
+	for _, table := range []string{"post_revisions", "comment_revisions"} {
+		var n int
+		if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if n != 0 {
+			t.Errorf("Expected %s to be empty after DeletePosts, got %d rows", table, n)
+		}
+	}
+}
+
+func TestSQLiteStorage_RevisionsOff(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "norev", Name: "t3_norev"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "gopher",
+		Title:     "Unversioned",
+		SelfText:  "Original",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	post.SelfText = "Edited"
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+
+	revisions, err := store.GetPostRevisions(ctx, "norev")
+	if err != nil {
+		t.Fatalf("GetPostRevisions failed: %v", err)
+	}
+	if len(revisions) != 0 {
+		t.Errorf("Expected no revisions by default, got %d", len(revisions))
+	}
+
+	// Without revisions the first-saved selftext is kept
+	stored, err := store.GetPost(ctx, "norev")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if stored.SelfText != "Original" {
+		t.Errorf("Expected the original selftext, got %q", stored.SelfText)
+	}
+}
+
+func TestSQLiteStorage_ScoreHistory(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "hist1", Name: "t3_hist1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "gopher",
+		Title:     "Tracked post",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	start := time.Now().Truncate(time.Second)
+	snapshots := []*storage.ScoreSnapshot{
+		{PostID: "hist1", Score: 30, NumComments: 5, RecordedAt: start.Add(2 * time.Hour)},
+		{PostID: "hist1", Score: 1, NumComments: 0, RecordedAt: start},
+		{PostID: "hist1", Score: 12, NumComments: 2, RecordedAt: start.Add(time.Hour)},
+	}
+	if err := store.SaveScoreSnapshots(ctx, snapshots); err != nil {
+		t.Fatalf("SaveScoreSnapshots failed: %v", err)
+	}
+
+	history, err := store.GetScoreHistory(ctx, "hist1")
+	if err != nil {
+		t.Fatalf("GetScoreHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d", len(history))
+	}
+	for i, want := range []int{1, 12, 30} {
+		if history[i].Score != want {
+			t.Errorf("Snapshot %d: expected score %d, got %d", i, want, history[i].Score)
+		}
+	}
+	if !history[0].RecordedAt.Equal(start) {
+		t.Errorf("Expected the first snapshot at %v, got %v", start, history[0].RecordedAt)
+	}
+
+	if history, err := store.GetScoreHistory(ctx, "missing"); err != nil || len(history) != 0 {
+		t.Errorf("Expected no snapshots for an unknown post, got %d (%v)", len(history), err)
+	}
+
+	// Snapshots go with their post
+	if _, err := store.DeletePosts(ctx, []string{"hist1"}); err != nil {
+		t.Fatalf("DeletePosts failed: %v", err)
+	}
+	if history, _ := store.GetScoreHistory(ctx, "hist1"); len(history) != 0 {
+		t.Errorf("Expected DeletePosts to remove the snapshots, got %d", len(history))
+	}
+}
+
+func TestSQLiteStorage_Crossposts(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	newPost := func(id, subreddit string, age time.Duration) *types.Post {
+		return &types.Post{
+			ThingData: types.ThingData{ID: id, Name: "t3_" + id},
+			Created:   types.Created{CreatedUTC: float64(now.Add(-age).Unix())},
+			Subreddit: subreddit,
+			Author:    "gopher",
+			Title:     "Post " + id,
+		}
+	}
+
+	// Decoded the way ImportSubreddit reads a raw post
+	imported, err := storage.StoredPostFromJSON([]byte(`{"id": "xp2", "name": "t3_xp2", "subreddit": "programming", "title": "Imported crosspost", "crosspost_parent": "t3_orig"}`))
+	if err != nil {
+		t.Fatalf("StoredPostFromJSON failed: %v", err)
+	}
+	if imported.CrosspostParentID != "orig" {
+		t.Fatalf("Expected crosspost parent orig, got %q", imported.CrosspostParentID)
+	}
+	imported.CreatedUTC = float64(now.Add(-2 * time.Hour).Unix())
+
+	posts := []*storage.StoredPost{
+		{Post: newPost("orig", "golang", 3*time.Hour)},
+		{Post: newPost("xp1", "gopher", time.Hour), CrosspostParentID: "orig"},
+		imported,
+		{Post: newPost("other", "golang", time.Hour)},
+	}
+	if err := store.SaveStoredPosts(ctx, posts); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	// A save that doesn't know the parent keeps it
+	if err := store.SavePost(ctx, newPost("xp1", "gopher", time.Hour)); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+
+	crossposts, err := store.GetCrossposts(ctx, "orig")
+	if err != nil {
+		t.Fatalf("GetCrossposts failed: %v", err)
+	}
+	if len(crossposts) != 2 || crossposts[0].ID != "xp1" || crossposts[1].ID != "xp2" {
+		t.Fatalf("Expected crossposts xp1, xp2 newest first, got %v", crossposts)
+	}
+
+	stored, err := store.GetStoredPost(ctx, "xp1")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if stored.CrosspostParentID != "orig" {
+		t.Errorf("Expected stored crosspost parent orig, got %q", stored.CrosspostParentID)
+	}
+
+	if crossposts, err := store.GetCrossposts(ctx, "other"); err != nil || len(crossposts) != 0 {
+		t.Errorf("Expected no crossposts of a plain post, got %d (%v)", len(crossposts), err)
+	}
+}
+
+func TestSQLiteStorage_PostMedia(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	post := &types.Post{
+		ThingData: types.ThingData{ID: "media1", Name: "t3_media1"},
+		Created:   types.Created{CreatedUTC: float64(time.Now().Unix())},
+		Subreddit: "golang",
+		Author:    "gopher",
+		Title:     "A picture",
+		URL:       "https://i.redd.it/gopher.png",
+	}
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+
+	media, err := store.GetPostMedia(ctx, "media1")
+	if err != nil {
+		t.Fatalf("GetPostMedia failed: %v", err)
+	}
+	if len(media) != 1 || media[0].Type != storage.PostMediaImage || media[0].URL != post.URL {
+		t.Fatalf("Expected the linked image, got %+v", media)
+	}
+
+	// An imported gallery replaces the earlier media
+	stored, err := storage.StoredPostFromJSON([]byte(`{
+		"id": "media1", "subreddit": "golang", "author": "gopher", "title": "A gallery",
+		"url": "https://www.reddit.com/gallery/media1",
+		"gallery_data": {"items": [{"media_id": "a"}, {"media_id": "b"}]},
+		"media_metadata": {
+			"a": {"status": "valid", "s": {"u": "https://preview.redd.it/a.jpg", "x": 640, "y": 480}},
+			"b": {"status": "valid", "s": {"u": "https://preview.redd.it/b.jpg", "x": 320, "y": 240}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("StoredPostFromJSON failed: %v", err)
+	}
+	if err := store.SaveStoredPosts(ctx, []*storage.StoredPost{stored}); err != nil {
+		t.Fatalf("SaveStoredPosts failed: %v", err)
+	}
+
+	media, err = store.GetPostMedia(ctx, "media1")
+	if err != nil {
+		t.Fatalf("GetPostMedia failed: %v", err)
+	}
+	if len(media) != 2 {
+		t.Fatalf("Expected 2 gallery images, got %d", len(media))
+	}
+	for i, want := range []string{"https://preview.redd.it/a.jpg", "https://preview.redd.it/b.jpg"} {
+		if media[i].Type != storage.PostMediaGallery || media[i].URL != want || media[i].Position != i {
+			t.Errorf("Item %d: expected gallery image %s, got %+v", i, want, media[i])
+		}
+	}
+	if media[0].Width != 640 || media[0].Height != 480 {
+		t.Errorf("Expected 640x480, got %dx%d", media[0].Width, media[0].Height)
+	}
+
+	// Re-saving without the gallery metadata keeps the stored images
+	post.URL = "https://www.reddit.com/gallery/media1"
+	if err := store.SavePost(ctx, post); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	if media, err := store.GetPostMedia(ctx, "media1"); err != nil || len(media) != 2 {
+		t.Errorf("Expected the gallery to survive a re-save, got %d (%v)", len(media), err)
+	}
+
+	// Media goes with its post
+	if _, err := store.DeletePosts(ctx, []string{"media1"}); err != nil {
+		t.Fatalf("DeletePosts failed: %v", err)
+	}
+	if media, err := store.GetPostMedia(ctx, "media1"); err != nil || len(media) != 0 {
+		t.Errorf("Expected no media after deleting the post, got %d (%v)", len(media), err)
+	}
+}
+
+func TestSQLiteStorage_SubredditMetadata(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if _, err := store.GetSubredditMetadata(ctx, "golang"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before any snapshot, got %v", err)
+	}
+
+	start := time.Now().Truncate(time.Second)
+	snapshots := []*storage.SubredditMetadata{
+		{
+			Subreddit: "Golang",
+			Sidebar:   "New sidebar",
+			Rules:     []storage.SubredditRule{{ShortName: "Be kind", Kind: "all"}, {ShortName: "No spam", Kind: "link", Priority: 1}},
+			WikiPages: []string{"index", "faq"},
+			FetchedAt: start.Add(time.Hour),
+		},
+		{
+			Subreddit: "golang",
+			Sidebar:   "Old sidebar",
+			Rules:     []storage.SubredditRule{{ShortName: "Be kind", Kind: "all"}},
+			FetchedAt: start,
+		},
+	}
+	for _, snapshot := range snapshots {
+		if err := store.SaveSubredditMetadata(ctx, snapshot); err != nil {
+			t.Fatalf("SaveSubredditMetadata failed: %v", err)
+		}
+	}
+
+	latest, err := store.GetSubredditMetadata(ctx, "GOLANG")
+	if err != nil {
+		t.Fatalf("GetSubredditMetadata failed: %v", err)
+	}
+	if latest.Sidebar != "New sidebar" || !latest.FetchedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the newest snapshot, got %q fetched at %v", latest.Sidebar, latest.FetchedAt)
+	}
+	if len(latest.Rules) != 2 || latest.Rules[1].ShortName != "No spam" || latest.Rules[1].Priority != 1 {
+		t.Errorf("Unexpected rules: %+v", latest.Rules)
+	}
+	if strings.Join(latest.WikiPages, ",") != "index,faq" {
+		t.Errorf("Expected wiki pages index, faq, got %v", latest.WikiPages)
+	}
+
+	var count int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM subreddit_metadata WHERE subreddit = 'golang'").Scan(&count); err != nil {
+		t.Fatalf("Failed to count snapshots: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected both snapshots to be kept, got %d", count)
+	}
+}
+
+func TestSQLiteStorage_PostFlair(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	flair := func(s string) *string { return &s }
+	posts := []*types.Post{
+		{ThingData: types.ThingData{ID: "fl1", Name: "t3_fl1"}, Created: types.Created{CreatedUTC: float64(now.Unix())}, Subreddit: "golang", Author: "alice", Title: "Question", LinkFlairText: flair("Help"), AuthorFlairText: flair("Gopher")},
+		{ThingData: types.ThingData{ID: "fl2", Name: "t3_fl2"}, Created: types.Created{CreatedUTC: float64(now.Add(-time.Hour).Unix())}, Subreddit: "golang", Author: "bob", Title: "Announcement", LinkFlairText: flair("News")},
+		{ThingData: types.ThingData{ID: "fl3", Name: "t3_fl3"}, Created: types.Created{CreatedUTC: float64(now.Add(-2 * time.Hour).Unix())}, Subreddit: "golang", Author: "carol", Title: "No flair"},
+	}
+	if err := store.SavePost(ctx, posts[0]); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	if err := store.SavePosts(ctx, posts[1:]); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	got, err := store.GetPost(ctx, "fl1")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if got.LinkFlairText == nil || *got.LinkFlairText != "Help" {
+		t.Errorf("Expected link flair Help, got %v", got.LinkFlairText)
+	}
+	if got.AuthorFlairText == nil || *got.AuthorFlairText != "Gopher" {
+		t.Errorf("Expected author flair Gopher, got %v", got.AuthorFlairText)
+	}
+
+	unflaired, err := store.GetPost(ctx, "fl3")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if unflaired.LinkFlairText != nil || unflaired.AuthorFlairText != nil {
+		t.Errorf("Expected no flair, got %v and %v", unflaired.LinkFlairText, unflaired.AuthorFlairText)
+	}
+
+	help, err := store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Flair: "help"})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(help) != 1 || help[0].ID != "fl1" {
+		t.Fatalf("Expected only fl1 flaired Help, got %v", help)
+	}
+	if help[0].AuthorFlairText == nil || *help[0].AuthorFlairText != "Gopher" {
+		t.Errorf("Expected author flair to come back from GetPostsBySubreddit, got %v", help[0].AuthorFlairText)
+	}
+
+	// Re-flairing a post replaces its flair
+	posts[1].LinkFlairText = flair("Help")
+	if err := store.SavePosts(ctx, posts[1:2]); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	help, err = store.GetPostsBySubreddit(ctx, "golang", storage.QueryOptions{Flair: "Help"})
+	if err != nil {
+		t.Fatalf("GetPostsBySubreddit failed: %v", err)
+	}
+	if len(help) != 2 {
+		t.Errorf("Expected 2 posts flaired Help, got %d", len(help))
+	}
+}
+
+func TestSQLiteStorage_PostFlags(t *testing.T) {
+	store := getTestDB(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	newPost := func(id string, age time.Duration) *types.Post {
+		return &types.Post{ThingData: types.ThingData{ID: id, Name: "t3_" + id}, Created: types.Created{CreatedUTC: float64(now.Add(-age).Unix())}, Subreddit: "golang", Author: "gopher", Title: "Post " + id}
+	}
+	nsfw := newPost("nsfw", time.Hour)
+	nsfw.Over18 = true
+	pinned := newPost("pinned", 2*time.Hour)
+	pinned.Stickied = true
+	pinned.Locked = true
+	plain := newPost("plain", 3*time.Hour)
+
+	if err := store.SavePost(ctx, nsfw); err != nil {
+		t.Fatalf("Failed to save post: %v", err)
+	}
+	if err := store.SavePosts(ctx, []*types.Post{pinned, plain}); err != nil {
+		t.Fatalf("Failed to save posts: %v", err)
+	}
+
+	got, err := store.GetPost(ctx, "nsfw")
+	if err != nil {
+		t.Fatalf("GetPost failed: %v", err)
+	}
+	if !got.Over18 || got.Locked {
+		t.Errorf("Expected over_18 and not locked, got over_18=%v locked=%v", got.Over18, got.Locked)
+	}
+
+	ids := func(opts storage.QueryOptions) string {
+		t.Helper()
+		posts, err := store.GetPostsBySubreddit(ctx, "golang", opts)
+		if err != nil {
+			t.Fatalf("GetPostsBySubreddit failed: %v", err)
+		}
+		var ids []string
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if got := ids(storage.QueryOptions{ExcludeNSFW: true}); got != "pinned,plain" {
+		t.Errorf("ExcludeNSFW: expected pinned,plain, got %q", got)
+	}
+	if got := ids(storage.QueryOptions{OnlyStickied: true}); got != "pinned" {
+		t.Errorf("OnlyStickied: expected pinned, got %q", got)
+	}
+
+	// Unpinning and unlocking is picked up on the next save
+	pinned.Stickied = false
+	pinned.Locked = false
+	if err := store.SavePosts(ctx, []*types.Post{pinned}); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	if got := ids(storage.QueryOptions{OnlyStickied: true}); got != "" {
+		t.Errorf("Expected no stickied posts after unpinning, got %q", got)
+	}
+	if got, _ := store.GetPost(ctx, "pinned"); got.Locked {
+		t.Error("Expected the post to be unlocked")
+	}
+
+	// Spoiler only comes from raw JSON and survives saves that don't know it
+	imported, err := storage.StoredPostFromJSON([]byte(`{"id": "plain", "subreddit": "golang", "title": "Post plain", "spoiler": true}`))
+	if err != nil {
+		t.Fatalf("StoredPostFromJSON failed: %v", err)
+	}
+	imported.CreatedUTC = plain.CreatedUTC
+	if err := store.SaveStoredPosts(ctx, []*storage.StoredPost{imported}); err != nil {
+		t.Fatalf("Failed to save stored post: %v", err)
+	}
+	if err := store.SavePost(ctx, plain); err != nil {
+		t.Fatalf("Failed to re-save post: %v", err)
+	}
+	stored, err := store.GetStoredPost(ctx, "plain")
+	if err != nil {
+		t.Fatalf("GetStoredPost failed: %v", err)
+	}
+	if stored.Spoiler == nil || !*stored.Spoiler {
+		t.Errorf("Expected the post to stay marked as a spoiler, got %v", stored.Spoiler)
+	}
+}
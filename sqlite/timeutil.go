@@ -1,6 +1,9 @@
 package sqlite
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 func timeToUnixFloat(t time.Time) float64 {
 	if t.IsZero() {
@@ -8,3 +11,11 @@ func timeToUnixFloat(t time.Time) float64 {
 	}
 	return float64(t.UnixNano()) / 1e9
 }
+
+func unixFloatToTime(ts float64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
@@ -15,19 +19,74 @@ type Storage interface {
 	SavePosts(ctx context.Context, posts []*types.Post) error
 	GetPost(ctx context.Context, id string) (*types.Post, error)
 	GetPostsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*types.Post, error)
+	GetLatestPost(ctx context.Context, subreddit string) (*types.Post, error)
+	FindPosts(ctx context.Context, filter PostFilter, opts QueryOptions) ([]*types.Post, error)
+	SaveStoredPosts(ctx context.Context, posts []*StoredPost) error
+	GetStoredPost(ctx context.Context, id string) (*StoredPost, error)
+	GetStoredPostsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*StoredPost, error)
+	GetPostsUpdatedSince(ctx context.Context, since time.Time, opts QueryOptions) ([]*types.Post, error)
+	DeletePosts(ctx context.Context, ids []string) (int, error)
+	DeletePost(ctx context.Context, id string) error
+	PrunePosts(ctx context.Context, opts PruneOptions) (*PruneResult, error)
+	GetStoredNumComments(ctx context.Context, ids []string) (map[string]int, error)
+	GetCommentsFetched(ctx context.Context, ids []string) (map[string]int, error)
+	MarkCommentsFetched(ctx context.Context, postID string, numComments int) error
+	GetPostCounts(ctx context.Context, subreddit string, opts QueryOptions) ([]*PostCounts, error)
+	GetPostRevisions(ctx context.Context, id string) ([]*Revision, error)
+	GetCrossposts(ctx context.Context, postID string) ([]*types.Post, error)
+	GetPostMedia(ctx context.Context, postID string) ([]*PostMedia, error)
 
 	// Comments
 	SaveComment(ctx context.Context, comment *types.Comment) error
 	SaveComments(ctx context.Context, comments []*types.Comment) error
+	SaveCommentsWithOptions(ctx context.Context, comments []*types.Comment, opts SaveCommentsOptions) (int, error)
 	GetCommentsByPost(ctx context.Context, postID string) ([]*types.Comment, error)
+	GetCommentTreeNested(ctx context.Context, postID string) ([]*CommentNode, error)
+	GetTopComments(ctx context.Context, postID string, n int) ([]*StoredComment, error)
+	GetTopLevelComments(ctx context.Context, postID string, opts QueryOptions) ([]*ThreadComment, error)
+	GetReplies(ctx context.Context, commentID string, opts QueryOptions) ([]*ThreadComment, error)
+	ExportPostMarkdown(ctx context.Context, postID string, w io.Writer) error
+	GetCommentsByAuthorWithContext(ctx context.Context, author string, opts QueryOptions) ([]*CommentWithPost, error)
+	GetCommentsBySubreddit(ctx context.Context, subreddit string, opts QueryOptions) ([]*types.Comment, error)
+	GetCommentRevisions(ctx context.Context, id string) ([]*Revision, error)
+	DeleteComments(ctx context.Context, postID string) (int, error)
 
 	// Subreddits
 	SaveSubreddit(ctx context.Context, sub *types.SubredditData) error
 	GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error)
+	ListSubreddits(ctx context.Context, opts QueryOptions) ([]*types.SubredditData, error)
+	ListSubredditSummaries(ctx context.Context) ([]*SubredditSummary, error)
+	SaveSubredditMetadata(ctx context.Context, metadata *SubredditMetadata) error
+	GetSubredditMetadata(ctx context.Context, subreddit string) (*SubredditMetadata, error)
 
 	// Queries
 	SearchPosts(ctx context.Context, query string, opts QueryOptions) ([]*types.Post, error)
+	SearchComments(ctx context.Context, query string, opts QueryOptions) ([]*types.Comment, error)
 	GetPostStats(ctx context.Context, postID string) (*PostStats, error)
+	GetCommentDepthHistogram(ctx context.Context, postID string) (map[int]int, error)
+	GetArchivedCommentCounts(ctx context.Context, postIDs []string) (map[string]int, error)
+	GetSubredditStatsRange(ctx context.Context, subreddit string, start, end time.Time) (*SubredditStats, error)
+
+	// Archive runs
+	RecordArchiveRun(ctx context.Context, run ArchiveRun) error
+	GetArchiveRuns(ctx context.Context, subreddit string, limit int) ([]*ArchiveRun, error)
+
+	// Score history
+	SaveScoreSnapshots(ctx context.Context, snapshots []*ScoreSnapshot) error
+	GetScoreHistory(ctx context.Context, postID string) ([]*ScoreSnapshot, error)
+
+	// Saved and upvoted items
+	SaveSavedItems(ctx context.Context, items []*SavedItem) error
+	GetSavedItems(ctx context.Context, source string, opts QueryOptions) ([]*SavedItem, error)
+
+	// Backfill checkpoints
+	SaveBackfillCheckpoint(ctx context.Context, checkpoint BackfillCheckpoint) error
+	GetBackfillCheckpoint(ctx context.Context, subreddit string) (*BackfillCheckpoint, error)
+	DeleteBackfillCheckpoint(ctx context.Context, subreddit string) error
+
+	// Outbox
+	ReadOutbox(ctx context.Context, afterID int64, limit int) ([]*OutboxEvent, error)
+	AckOutbox(ctx context.Context, upToID int64) error
 
 	// Management
 	RunMigrations(ctx context.Context) error
@@ -38,10 +97,108 @@ type Storage interface {
 type QueryOptions struct {
 	Limit     int
 	Offset    int
-	SortBy    string    // "created", "score", "comments"
-	SortOrder string    // "asc", "desc"
+	SortBy    string // "created", "score", "comments", "archived_comments"; "name", "subscribers" for ListSubreddits
+	SortOrder string // "asc", "desc"
 	StartDate time.Time
 	EndDate   time.Time
+
+	// After switches GetPostsBySubreddit to keyset pagination: results are
+	// ordered by creation time (SortOrder still applies, SortBy and Offset
+	// are ignored) and start strictly after the cursor. Unlike Offset, rows
+	// inserted between pages can't shift later pages. Use NextCursor to get
+	// the cursor for the following page.
+	After *Cursor
+
+	// SearchMode selects how SearchPosts and SearchComments parse their query
+	// Default: SearchModePlain
+	SearchMode SearchMode
+
+	// RemovedOnly restricts post queries to posts with a recorded
+	// removed_by_category (moderator archives only)
+	RemovedOnly bool
+
+	// ExcludeRemoved leaves out posts storage has recorded as removed or
+	// deleted (see StoredPost.RemovedAt)
+	ExcludeRemoved bool
+
+	// MinScore restricts comment queries to comments scoring at least this
+	// much. Posts are filtered by score with PostFilter.MinScore instead.
+	MinScore *int
+
+	// MinComments restricts post queries to posts with at least this many
+	// comments as reported by Reddit (num_comments, not the archived count)
+	MinComments *int
+
+	// Account restricts post and author-comment queries to rows archived
+	// by this account (see ArchiveOptions.AccountID)
+	Account string
+
+	// Flair restricts post queries to posts whose link flair text matches
+	// this, ignoring case. Posts without flair never match.
+	Flair string
+
+	// HasMedia restricts post queries to video posts and posts linking to
+	// images, videos or galleries (see MediaURLPatterns)
+	HasMedia bool
+
+	// ExcludeStickied drops posts stickied by moderators from post queries,
+	// e.g. to rank organic posts by score without pinned announcements
+	ExcludeStickied bool
+
+	// OnlyStickied restricts post queries to posts stickied by moderators,
+	// such as a subreddit's announcements. It can't be combined with
+	// ExcludeStickied.
+	OnlyStickied bool
+
+	// ExcludeNSFW drops posts marked NSFW (over_18) from post queries
+	ExcludeNSFW bool
+
+	// NonEmptySelfText restricts post queries to self posts with body text,
+	// excluding link posts and title-only self posts
+	NonEmptySelfText bool
+
+	// Subreddit scopes SearchComments and GetPostsUpdatedSince to one
+	// subreddit
+	Subreddit string
+
+	// Search filters ListSubreddits to names starting with this prefix
+	// (case-insensitive)
+	Search string
+}
+
+// PostFilter narrows FindPosts by post attributes. Zero-value fields don't
+// filter; the set ones are ANDed together.
+type PostFilter struct {
+	Subreddit string
+	Author    string
+	MinScore  *int // Only posts scoring at least this much
+
+	// TextQuery restricts results to posts whose title or selftext match,
+	// as SearchPosts would with the same QueryOptions.SearchMode
+	TextQuery string
+
+	// SavedSource restricts results to posts recorded from the account's
+	// saved (SavedSourceSaved) or upvoted (SavedSourceUpvoted) listing. The
+	// posts of saved comments aren't included.
+	SavedSource string
+}
+
+// Cursor marks a post's position in a creation-time listing for keyset
+// pagination
+type Cursor struct {
+	CreatedUTC float64
+	ID         string
+}
+
+// NextCursor returns the cursor that continues after the last post of a page,
+// or nil if the page is empty
+func NextCursor(posts []*types.Post) *Cursor {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	last := posts[len(posts)-1]
+	return &Cursor{CreatedUTC: last.CreatedUTC, ID: last.ID}
 }
 
 // PostStats aggregates statistics about a post
@@ -52,6 +209,334 @@ type PostStats struct {
 	LastUpdated     time.Time
 }
 
+// SubredditStats aggregates archived activity for a subreddit over a time window.
+// Posts are counted by their creation time and comments by theirs, so a comment
+// in the window counts even when its post predates it. TotalScore, MedianScore
+// and P90Score cover post scores only; the percentiles interpolate between the
+// two nearest scores like PERCENTILE_CONT and are 0 without posts.
+// UniqueAuthors counts distinct post and comment authors, excluding "[deleted]".
+type SubredditStats struct {
+	Subreddit     string
+	Start         time.Time // Zero means unbounded
+	End           time.Time // Zero means unbounded
+	PostCount     int
+	CommentCount  int
+	TotalScore    int
+	MedianScore   float64
+	P90Score      float64
+	UniqueAuthors int
+}
+
+// ArchiveRun records the timing of one archiver run against a subreddit.
+// FetchDuration covers Reddit API calls and SaveDuration covers storage
+// writes; durations are stored with millisecond precision. Error is empty
+// for runs that succeeded.
+type ArchiveRun struct {
+	ID             int64
+	Subreddit      string
+	StartedAt      time.Time
+	FetchDuration  time.Duration
+	SaveDuration   time.Duration
+	PostsProcessed int
+	CommentsSaved  int
+	Error          string
+}
+
+// PostCounts is a stored post's score and comment count, read without
+// decoding the rest of the post
+type PostCounts struct {
+	ID          string
+	Score       int
+	NumComments int
+}
+
+// PruneOptions selects the posts PrunePosts deletes
+type PruneOptions struct {
+	Subreddit string    // Only prune this subreddit; empty prunes every subreddit
+	Before    time.Time // Delete posts created before this time (required)
+
+	// KeepMinScore, when positive, keeps posts scoring at least this much
+	// however old they are
+	KeepMinScore int
+}
+
+// PruneResult counts what PrunePosts deleted
+type PruneResult struct {
+	PostsDeleted    int
+	CommentsDeleted int
+}
+
+// ScoreSnapshot records a post's score and comment count at one point in
+// time. The archiver saves one each time it refreshes a post when
+// ArchiverOptions.RecordScoreHistory is set.
+type ScoreSnapshot struct {
+	PostID      string
+	Score       int
+	NumComments int
+	RecordedAt  time.Time
+}
+
+// Listings a SavedItem can come from
+const (
+	SavedSourceSaved   = "saved"
+	SavedSourceUpvoted = "upvoted"
+)
+
+// SavedItem records that a post or comment was archived from the
+// authenticated account's saved or upvoted listing. Recording an item again
+// keeps its first RecordedAt.
+type SavedItem struct {
+	Source     string // SavedSourceSaved or SavedSourceUpvoted
+	ID         string // Post or comment ID
+	IsComment  bool
+	PostID     string // The comment's post, or ID for a post
+	RecordedAt time.Time
+}
+
+// SubredditSummary describes what is archived for one subreddit
+type SubredditSummary struct {
+	Name         string
+	PostCount    int
+	CommentCount int
+	OldestPost   time.Time // Creation time of the oldest stored post; zero without posts
+	NewestPost   time.Time // Creation time of the newest stored post; zero without posts
+	LastSynced   time.Time // When the subreddit's row was last saved; zero if unknown
+}
+
+// SubredditMetadata is a snapshot of a subreddit's rules, sidebar and wiki
+// index as of FetchedAt. Every save adds a snapshot rather than replacing
+// the last, so changes can be traced over time; GetSubredditMetadata
+// returns the latest and an error wrapping ErrNotFound when there is none.
+type SubredditMetadata struct {
+	Subreddit string // Stored under its normalized name (see NormalizeSubreddit)
+	Sidebar   string // The sidebar's markdown (Reddit's description field)
+	Rules     []SubredditRule
+	WikiPages []string // Names of the subreddit's wiki pages
+	FetchedAt time.Time
+}
+
+// SubredditRule is one of a subreddit's rules, as listed on its rules page
+type SubredditRule struct {
+	ShortName   string `json:"short_name"`
+	Description string `json:"description"`
+	Kind        string `json:"kind"`     // What the rule applies to: "link", "comment" or "all"
+	Priority    int    `json:"priority"` // Position in the subreddit's list, starting at 0
+}
+
+// BackfillCheckpoint records where a subreddit's backfill stopped. There is
+// at most one per subreddit; saving replaces it. GetBackfillCheckpoint
+// returns an error wrapping ErrNotFound when there is none.
+type BackfillCheckpoint struct {
+	Subreddit    string
+	After        string // Fullname to continue the "new" listing after
+	PostsFetched int    // Posts saved by the backfill so far
+	UpdatedAt    time.Time
+}
+
+// Outbox event operations
+const (
+	OutboxOpSave   = "save"
+	OutboxOpDelete = "delete"
+)
+
+// Outbox event entity types
+const (
+	OutboxEntityPost      = "post"
+	OutboxEntityComment   = "comment"
+	OutboxEntitySubreddit = "subreddit"
+)
+
+// OutboxEvent records one change made with the backend's Outbox option on.
+// Events are written in the same transaction as the change, so a consumer
+// that reads them in ID order and re-reads the entity sees every committed
+// change. EntityID is the post or comment ID without its prefix, or the
+// normalized subreddit name. Deleting a post also deletes its comments
+// without separate events.
+type OutboxEvent struct {
+	ID         int64
+	Op         string // OutboxOpSave or OutboxOpDelete
+	EntityType string // OutboxEntityPost, OutboxEntityComment or OutboxEntitySubreddit
+	EntityID   string
+	CreatedAt  time.Time
+}
+
+// StoredPost is a post together with the columns storage keeps beyond
+// types.Post. The moderator fields are only present in responses fetched with
+// moderator credentials; they are nil otherwise, and saving a nil value never
+// overwrites one already stored.
+type StoredPost struct {
+	*types.Post
+	NumReports        *int
+	RemovedByCategory *string
+
+	// Account identifies the Reddit account that archived the post. Empty
+	// leaves any stored value untouched.
+	Account string
+
+	// Media is the post's media metadata (see ParseMediaInfo). Nil leaves
+	// any stored value untouched.
+	Media *MediaInfo
+
+	// MoreCommentsCount is how many comment IDs Reddit left unexpanded
+	// behind "more" stubs when the post's thread was last fetched. Nil
+	// leaves any stored value untouched.
+	MoreCommentsCount *int
+
+	// RemovedAt is when storage first saw the post removed or deleted on
+	// Reddit (see IsRemoved), or nil while it is live. Once a post is
+	// removed, re-saving it keeps the archived raw JSON rather than Reddit's
+	// placeholders. It is set by storage and ignored when saving.
+	RemovedAt *time.Time
+
+	// CrosspostParentID is the ID, without its "t3_" prefix, of the post
+	// this one was crossposted from (Reddit's crosspost_parent), or empty
+	// if it isn't a crosspost. Empty leaves any stored value untouched.
+	CrosspostParentID string
+
+	// Spoiler is whether the post is marked as a spoiler. types.Post doesn't
+	// carry Reddit's spoiler field, so it is nil unless the post was decoded
+	// with StoredPostFromJSON or set by the caller. Nil leaves any stored
+	// value untouched.
+	Spoiler *bool
+
+	// MediaItems lists the post's images, videos and embeds for the
+	// post_media table. Nil derives them from Post with ParsePostMedia;
+	// StoredPostFromJSON also finds gallery and preview images, which
+	// types.Post doesn't carry. Saving a post with no media leaves its
+	// stored media untouched.
+	MediaItems []*PostMedia
+}
+
+// StoredPostFromJSON decodes a raw Reddit post object, picking up the
+// moderator fields, crosspost parent, spoiler flag and gallery and preview
+// images that types.Post doesn't carry
+func StoredPostFromJSON(data []byte) (*StoredPost, error) {
+	var post types.Post
+	if err := json.Unmarshal(data, &post); err != nil {
+		return nil, err
+	}
+
+	var modFields struct {
+		NumReports        *int    `json:"num_reports"`
+		RemovedByCategory *string `json:"removed_by_category"`
+		CrosspostParent   string  `json:"crosspost_parent"`
+		Spoiler           *bool   `json:"spoiler"`
+	}
+	if err := json.Unmarshal(data, &modFields); err != nil {
+		return nil, err
+	}
+
+	media, err := parseJSONPostMedia(data, &post)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoredPost{
+		Post:              &post,
+		NumReports:        modFields.NumReports,
+		RemovedByCategory: modFields.RemovedByCategory,
+		CrosspostParentID: strings.TrimPrefix(modFields.CrosspostParent, "t3_"),
+		Spoiler:           modFields.Spoiler,
+		MediaItems:        media,
+	}, nil
+}
+
+// SaveCommentsOptions configures SaveCommentsWithOptions
+type SaveCommentsOptions struct {
+	// MaxDepth drops comments nested MaxDepth or more levels deep (1 keeps
+	// only top-level comments). 0 means no limit. This is lossy: dropped
+	// comments are never written, so unlike filtering at query time they
+	// can't be recovered without fetching the thread again.
+	MaxDepth int
+
+	// Account tags saved comments with the Reddit account that archived
+	// them. Empty leaves any stored value untouched.
+	Account string
+
+	// BatchSize is how many comments are written per transaction, in place
+	// of the backend's MaxBatchSize option. Smaller batches hold the write
+	// lock for less time, and a failure only rolls back its own batch;
+	// depths are still computed across the whole save. 0 uses the
+	// backend's setting.
+	BatchSize int
+}
+
+// StoredComment is a comment together with the columns storage keeps beyond
+// types.Comment
+type StoredComment struct {
+	*types.Comment
+	Depth int // Stored nesting depth; 0 for top-level comments
+}
+
+// Revision is an earlier version of a post's selftext or a comment's body,
+// recorded when a save replaced it with different content. Backends only
+// record revisions when created with their KeepRevisions option; the
+// current version is the stored post or comment itself.
+type Revision struct {
+	Body      string
+	Score     int
+	EditedUTC float64 // Reddit's edit time for this version; 0 if it was never edited
+
+	// ReplacedAt is when a save replaced this version
+	ReplacedAt time.Time
+}
+
+// ThreadComment is a comment together with how many direct replies are
+// stored for it, so a thread view can offer to expand it with GetReplies.
+// GetTopLevelComments and GetReplies page through them by score (SortBy
+// "score", the default) or creation time (SortBy "created").
+type ThreadComment struct {
+	*types.Comment
+	ReplyCount int
+}
+
+// CommentWithPost pairs a comment with display details of the post it was made on
+type CommentWithPost struct {
+	Comment       *types.Comment
+	PostTitle     string
+	PostSubreddit string
+}
+
+// NormalizeSubreddit returns the key a subreddit is stored under. Reddit
+// treats subreddit names case-insensitively, so "Golang" and "golang" map to
+// the same key; the canonical display name is kept separately.
+func NormalizeSubreddit(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// DeletedAuthor is the author Reddit reports for deleted accounts
+const DeletedAuthor = "[deleted]"
+
+// NormalizeAuthor maps the empty author some API responses return for deleted
+// accounts to DeletedAuthor, so deleted content groups under a single author
+func NormalizeAuthor(author string) string {
+	if strings.TrimSpace(author) == "" {
+		return DeletedAuthor
+	}
+	return author
+}
+
+// IsRemovedPost reports whether Reddit shows a post as removed by moderators
+// or deleted by its author, in which case its title, body and author are
+// placeholders rather than the original content. A [deleted] author alone
+// doesn't count: a post outlives its author's deleted account.
+func IsRemovedPost(post *types.Post) bool {
+	return post.SelfText == "[removed]" || post.SelfText == "[deleted]"
+}
+
+// IsRemoved reports whether the post is removed, either by IsRemovedPost or
+// by the removed_by_category moderator responses include
+func (p *StoredPost) IsRemoved() bool {
+	return IsRemovedPost(p.Post) || (p.RemovedByCategory != nil && *p.RemovedByCategory != "")
+}
+
+// ErrNotFound is wrapped by errors returned when a requested record doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// ErrReadOnly is wrapped by errors returned when a write is attempted on
+// storage opened in read-only mode
+var ErrReadOnly = errors.New("storage is read-only")
+
 // StorageError represents a storage operation error
 type StorageError struct {
 	Op  string // Operation being performed
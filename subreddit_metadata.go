@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SubredditMetadataClient is implemented by Reddit clients that can fetch a
+// subreddit's rules and wiki index. ArchiveSubredditMetadata needs one;
+// *graw.Client doesn't fetch either yet, so wrap it or substitute a client
+// that does.
+type SubredditMetadataClient interface {
+	// GetSubredditRules returns the rules listed on a subreddit's rules page
+	GetSubredditRules(ctx context.Context, subreddit string) ([]SubredditRule, error)
+
+	// GetWikiPages returns the names of a subreddit's wiki pages
+	GetWikiPages(ctx context.Context, subreddit string) ([]string, error)
+}
+
+// ErrSubredditMetadataUnsupported is returned by ArchiveSubredditMetadata
+// when the archiver's client doesn't implement SubredditMetadataClient
+var ErrSubredditMetadataUnsupported = errors.New("reddit client does not support subreddit rules and wiki pages")
+
+// subredditMetadataClient returns c as a SubredditMetadataClient, if it is one
+func subredditMetadataClient(c RedditClient) (SubredditMetadataClient, error) {
+	mc, ok := c.(SubredditMetadataClient)
+	if !ok {
+		return nil, ErrSubredditMetadataUnsupported
+	}
+	return mc, nil
+}
+
+// ArchiveSubredditMetadata fetches a subreddit's about page, rules and wiki
+// index and saves them as a new SubredditMetadata snapshot, so repeated runs
+// record how the rules and sidebar change over time. The subreddit itself is
+// saved too, refreshing its subscriber count. Nothing is saved if any fetch
+// fails.
+func (a *Archiver) ArchiveSubredditMetadata(ctx context.Context, subreddit string) (*SubredditMetadata, error) {
+	// Check the client beneath the wrappers before spending an API call
+	if _, err := subredditMetadataClient(unwrapClient(a.client)); err != nil {
+		return nil, &StorageError{Op: "archive_subreddit_metadata", Err: err}
+	}
+	client, err := subredditMetadataClient(a.client)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedAt := time.Now()
+	subInfo, err := a.client.GetSubreddit(ctx, subreddit)
+	if err != nil {
+		return nil, &StorageError{Op: "fetch_subreddit", Err: err}
+	}
+	rules, err := client.GetSubredditRules(ctx, subreddit)
+	if err != nil {
+		return nil, &StorageError{Op: "fetch_subreddit_rules", Err: err}
+	}
+	wikiPages, err := client.GetWikiPages(ctx, subreddit)
+	if err != nil {
+		return nil, &StorageError{Op: "fetch_wiki_pages", Err: err}
+	}
+
+	if err := a.storage.SaveSubreddit(ctx, subInfo); err != nil {
+		return nil, err
+	}
+
+	metadata := &SubredditMetadata{
+		Subreddit: NormalizeSubreddit(subreddit),
+		Sidebar:   subInfo.Description,
+		Rules:     rules,
+		WikiPages: wikiPages,
+		FetchedAt: fetchedAt,
+	}
+	if err := a.storage.SaveSubredditMetadata(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("archived subreddit metadata", "subreddit", subreddit, "rules", len(rules), "wiki_pages", len(wikiPages))
+	return metadata, nil
+}
@@ -0,0 +1,89 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+// mockMetadataClient adds rules and wiki index lookups to mockRedditClient
+type mockMetadataClient struct {
+	*mockRedditClient
+	rules     []storage.SubredditRule
+	wikiPages []string
+	wikiError error
+}
+
+func (m *mockMetadataClient) GetSubredditRules(ctx context.Context, subreddit string) ([]storage.SubredditRule, error) {
+	m.record()
+	return m.rules, nil
+}
+
+func (m *mockMetadataClient) GetWikiPages(ctx context.Context, subreddit string) ([]string, error) {
+	m.record()
+	return m.wikiPages, m.wikiError
+}
+
+func TestArchiveSubredditMetadata(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	client := &mockMetadataClient{
+		mockRedditClient: mockClient,
+		rules:            []storage.SubredditRule{{ShortName: "Stay on topic", Kind: "link"}},
+		wikiPages:        []string{"index"},
+	}
+	archiver := storage.NewArchiver(client, store)
+
+	mockClient.subreddit.Description = "Old sidebar"
+	if _, err := archiver.ArchiveSubredditMetadata(ctx, "golang"); err != nil {
+		t.Fatalf("ArchiveSubredditMetadata failed: %v", err)
+	}
+	mockClient.subreddit.Description = "New sidebar"
+	if _, err := archiver.ArchiveSubredditMetadata(ctx, "golang"); err != nil {
+		t.Fatalf("ArchiveSubredditMetadata failed: %v", err)
+	}
+
+	latest, err := store.GetSubredditMetadata(ctx, "golang")
+	if err != nil {
+		t.Fatalf("GetSubredditMetadata failed: %v", err)
+	}
+	if latest.Sidebar != "New sidebar" {
+		t.Errorf("Expected the latest sidebar, got %q", latest.Sidebar)
+	}
+	if len(latest.Rules) != 1 || latest.Rules[0].ShortName != "Stay on topic" {
+		t.Errorf("Unexpected rules: %+v", latest.Rules)
+	}
+	if len(latest.WikiPages) != 1 || latest.WikiPages[0] != "index" {
+		t.Errorf("Unexpected wiki pages: %v", latest.WikiPages)
+	}
+	if _, err := store.GetSubreddit(ctx, "golang"); err != nil {
+		t.Errorf("Expected the subreddit to be saved too: %v", err)
+	}
+
+	// A failed fetch saves nothing
+	client.wikiError = errors.New("wiki disabled")
+	mockClient.subreddit.Description = "Unsaved sidebar"
+	if _, err := archiver.ArchiveSubredditMetadata(ctx, "golang"); err == nil {
+		t.Fatal("Expected the wiki fetch error to be returned")
+	}
+	if latest, _ := store.GetSubredditMetadata(ctx, "golang"); latest.Sidebar != "New sidebar" {
+		t.Errorf("Expected no snapshot from the failed run, got %q", latest.Sidebar)
+	}
+}
+
+func TestArchiveSubredditMetadataUnsupported(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	_, err := archiver.ArchiveSubredditMetadata(context.Background(), "golang")
+	if !errors.Is(err, storage.ErrSubredditMetadataUnsupported) {
+		t.Fatalf("Expected ErrSubredditMetadataUnsupported, got %v", err)
+	}
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls, got %d", mockClient.calls)
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// timeoutClient bounds each call to the wrapped client by timeout (see
+// ArchiverOptions.RequestTimeout). It sits beneath the throttling and retry
+// wrappers, so waits between calls don't count against it and each attempt
+// gets its own deadline.
+type timeoutClient struct {
+	client  RedditClient
+	timeout time.Duration
+}
+
+// withTimeout calls fn with ctx limited to c.timeout. A call cut short by that
+// limit, rather than by ctx itself, returns an error wrapping
+// context.DeadlineExceeded, which the retry wrapper treats as transient.
+func withTimeout[T any](ctx context.Context, c *timeoutClient, fn func(context.Context) (T, error)) (T, error) {
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result, err := fn(callCtx)
+	if err != nil && ctx.Err() == nil && callCtx.Err() != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("reddit API request timed out after %s: %w", c.timeout, err)
+		} else {
+			err = fmt.Errorf("reddit API request timed out after %s: %w: %w", c.timeout, context.DeadlineExceeded, err)
+		}
+	}
+	return result, err
+}
+
+func (c *timeoutClient) GetSubreddit(ctx context.Context, name string) (*types.SubredditData, error) {
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.SubredditData, error) {
+		return c.client.GetSubreddit(ctx, name)
+	})
+}
+
+func (c *timeoutClient) GetHot(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return c.client.GetHot(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetNew(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return c.client.GetNew(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetComments(ctx context.Context, req *types.CommentsRequest) (*types.CommentsResponse, error) {
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.CommentsResponse, error) {
+		return c.client.GetComments(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetUserPosts(ctx context.Context, req *UserRequest) (*types.PostsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return uc.GetUserPosts(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetUserComments(ctx context.Context, req *UserRequest) (*UserCommentsResponse, error) {
+	uc, err := userClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*UserCommentsResponse, error) {
+		return uc.GetUserComments(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetSaved(ctx context.Context, req *types.Pagination) (*SavedResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*SavedResponse, error) {
+		return sc.GetSaved(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetUpvoted(ctx context.Context, req *types.Pagination) (*types.PostsResponse, error) {
+	sc, err := savedClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return sc.GetUpvoted(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetTop(ctx context.Context, req *types.PostsRequest, timeRange TimeRange) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return lc.GetTop(ctx, req, timeRange)
+	})
+}
+
+func (c *timeoutClient) GetRising(ctx context.Context, req *types.PostsRequest) (*types.PostsResponse, error) {
+	lc, err := listingClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return lc.GetRising(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetMoreComments(ctx context.Context, req *types.MoreCommentsRequest) ([]*types.Comment, error) {
+	mc, err := moreCommentsClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) ([]*types.Comment, error) {
+		return mc.GetMoreComments(ctx, req)
+	})
+}
+
+func (c *timeoutClient) Search(ctx context.Context, req *SearchRequest) (*types.PostsResponse, error) {
+	sc, err := searchClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) (*types.PostsResponse, error) {
+		return sc.Search(ctx, req)
+	})
+}
+
+func (c *timeoutClient) GetInfo(ctx context.Context, fullnames []string) ([]*types.Post, error) {
+	ic, err := infoClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) ([]*types.Post, error) {
+		return ic.GetInfo(ctx, fullnames)
+	})
+}
+
+func (c *timeoutClient) GetSubredditRules(ctx context.Context, subreddit string) ([]SubredditRule, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) ([]SubredditRule, error) {
+		return mc.GetSubredditRules(ctx, subreddit)
+	})
+}
+
+func (c *timeoutClient) GetWikiPages(ctx context.Context, subreddit string) ([]string, error) {
+	mc, err := subredditMetadataClient(c.client)
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(ctx, c, func(ctx context.Context) ([]string, error) {
+		return mc.GetWikiPages(ctx, subreddit)
+	})
+}
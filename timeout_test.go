@@ -0,0 +1,45 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+func TestArchiverRequestTimeout(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	mockClient.commentsDelay = time.Minute // A hung request
+
+	archiver := storage.NewArchiverWithOptions(mockClient, store, &storage.ArchiverOptions{
+		RequestTimeout: 20 * time.Millisecond,
+		Retry:          &storage.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+
+	start := time.Now()
+	_, err := archiver.ArchivePost(ctx, "golang", "post1", true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the hung call to be abandoned, took %v", elapsed)
+	}
+
+	// Each attempt gets its own deadline and timeouts are retried
+	if m := archiver.Metrics(); m.APICalls != 2 || m.Retries != 1 {
+		t.Errorf("Expected 2 timed out attempts, got %+v", m)
+	}
+
+	// Fast calls are unaffected
+	mockClient.commentsDelay = 0
+	if _, err := archiver.ArchivePost(ctx, "golang", "post1", true); err != nil {
+		t.Errorf("ArchivePost failed: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+)
+
+// UserClient is implemented by Reddit clients that can list what a user has
+// posted. ArchiveUser needs one; *graw.Client doesn't list user content yet,
+// so wrap it or substitute a client that does.
+type UserClient interface {
+	GetUserPosts(ctx context.Context, req *UserRequest) (*types.PostsResponse, error)
+	GetUserComments(ctx context.Context, req *UserRequest) (*UserCommentsResponse, error)
+}
+
+// UserRequest describes a request for a page of a user's submissions or
+// comments
+type UserRequest struct {
+	Username string
+	types.Pagination
+}
+
+// UserCommentsResponse is a page of a user's comments
+type UserCommentsResponse struct {
+	Comments      []*types.Comment
+	AfterFullname string // Reddit fullname (e.g. "t1_abc123") of last comment for next page
+}
+
+// ErrUserListingsUnsupported is returned by ArchiveUser when the archiver's
+// client doesn't implement UserClient
+var ErrUserListingsUnsupported = errors.New("reddit client does not support user listings")
+
+// userClient returns c as a UserClient, if it is one
+func userClient(c RedditClient) (UserClient, error) {
+	uc, ok := c.(UserClient)
+	if !ok {
+		return nil, ErrUserListingsUnsupported
+	}
+	return uc, nil
+}
+
+// UserArchiveOptions configures ArchiveUser
+type UserArchiveOptions struct {
+	MaxItems        int  // Stop each listing after this many items (default 1000)
+	IncludePosts    bool // Archive the user's submissions
+	IncludeComments bool // Archive the user's comments
+}
+
+// UserArchiveResult summarizes an ArchiveUser run
+type UserArchiveResult struct {
+	PostsSaved     int
+	CommentsSaved  int
+	FailedComments []string // IDs of comments that could not be saved
+}
+
+// ArchiveUser archives a user's submissions and comments, paging through
+// each listing 100 items at a time until opts.MaxItems items are fetched or
+// the listing ends. Subreddits the user posted in get stub rows. Reddit
+// doesn't return a comment's parents with the user's listing, so the thread
+// of each commented-on post that isn't stored yet is archived first; a
+// comment that still can't be saved is logged and listed in the result's
+// FailedComments. If ctx is cancelled, buffered writes are flushed before
+// returning.
+func (a *Archiver) ArchiveUser(ctx context.Context, username string, opts UserArchiveOptions) (*UserArchiveResult, error) {
+	result := &UserArchiveResult{}
+	err := a.archiveUser(ctx, username, opts, result)
+	if ctx.Err() != nil {
+		return result, a.flushOnCancel(ctx)
+	}
+	return result, err
+}
+
+func (a *Archiver) archiveUser(ctx context.Context, username string, opts UserArchiveOptions, result *UserArchiveResult) error {
+	client, err := userClient(a.client)
+	if err != nil {
+		return &StorageError{Op: "archive_user", Err: err}
+	}
+
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = 1000
+	}
+
+	if opts.IncludePosts {
+		if err := a.archiveUserPosts(ctx, client, username, opts.MaxItems, result); err != nil {
+			return err
+		}
+	}
+
+	if opts.IncludeComments {
+		if err := a.archiveUserComments(ctx, client, username, opts.MaxItems, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Archiver) archiveUserPosts(ctx context.Context, client UserClient, username string, maxItems int, result *UserArchiveResult) error {
+	var after string
+	for fetched := 0; fetched < maxItems; {
+		req := &UserRequest{
+			Username:   username,
+			Pagination: types.Pagination{Limit: min(100, maxItems-fetched), After: after},
+		}
+
+		resp, err := client.GetUserPosts(ctx, req)
+		if err != nil {
+			return &StorageError{Op: "fetch_user_posts", Err: err}
+		}
+		if len(resp.Posts) == 0 {
+			return nil
+		}
+		fetched += len(resp.Posts)
+
+		if err := a.storage.SavePosts(ctx, resp.Posts); err != nil {
+			return err
+		}
+		if err := a.postsSaved(ctx, resp.Posts...); err != nil {
+			return err
+		}
+		result.PostsSaved += len(resp.Posts)
+		a.logger.Info("archived user posts", "username", username, "posts_saved", result.PostsSaved)
+
+		if after = resp.AfterFullname; after == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archiver) archiveUserComments(ctx context.Context, client UserClient, username string, maxItems int, result *UserArchiveResult) error {
+	var after string
+	threads := make(map[string]bool) // Posts already handled by ensureThread
+	for fetched := 0; fetched < maxItems; {
+		req := &UserRequest{
+			Username:   username,
+			Pagination: types.Pagination{Limit: min(100, maxItems-fetched), After: after},
+		}
+
+		resp, err := client.GetUserComments(ctx, req)
+		if err != nil {
+			return &StorageError{Op: "fetch_user_comments", Err: err}
+		}
+		if len(resp.Comments) == 0 {
+			return nil
+		}
+		fetched += len(resp.Comments)
+
+		for _, comment := range resp.Comments {
+			if err := a.ensureThread(ctx, comment, threads); err != nil {
+				a.logger.Warn("archiving thread failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("archive_thread", err)
+			}
+
+			if err := a.storage.SaveComment(ctx, comment); err != nil {
+				a.logger.Warn("saving comment failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("save_comment", err)
+				result.FailedComments = append(result.FailedComments, comment.ID)
+				continue
+			}
+			a.metrics.saved(0, 1)
+			if err := a.commentsArchived(comment.LinkID, 1); err != nil {
+				a.logger.Warn("comment hook failed", "subreddit", comment.Subreddit, "comment_id", comment.ID, "error", err)
+				a.reportError("save_comment", err)
+			}
+			result.CommentsSaved++
+		}
+		a.logger.Info("archived user comments", "username", username, "comments_saved", result.CommentsSaved)
+
+		if after = resp.AfterFullname; after == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureThread archives the thread comment belongs to unless its post is
+// already stored, so the comment's post and parents exist when it is saved.
+// threads records the posts already handled.
+func (a *Archiver) ensureThread(ctx context.Context, comment *types.Comment, threads map[string]bool) error {
+	postID := strings.TrimPrefix(comment.LinkID, "t3_")
+	if postID == "" || threads[postID] {
+		return nil
+	}
+
+	// Only try each post once, even if archiving its thread fails
+	threads[postID] = true
+
+	_, err := a.storage.GetPost(ctx, postID)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := a.waitIfPaused(ctx); err != nil {
+		return err
+	}
+	if _, err := a.archivePost(ctx, comment.Subreddit, postID, true, ArchiveOptions{}, &ArchiveRun{}); err != nil {
+		return fmt.Errorf("post %s: %w", postID, err)
+	}
+	return nil
+}
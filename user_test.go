@@ -0,0 +1,147 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jamesprial/go-reddit-api-wrapper/pkg/types"
+	"github.com/jamesprial/go-reddit-storage"
+	"github.com/jamesprial/go-reddit-storage/internal/testutil"
+)
+
+// mockUserClient adds user listings to mockRedditClient. Each listing is
+// served in pages of at most the requested limit.
+type mockUserClient struct {
+	*mockRedditClient
+	userPosts    []*types.Post
+	userComments []*types.Comment
+	requests     []*storage.UserRequest
+}
+
+// page returns the page of items req asks for, starting after the item whose
+// fullname is req.After, and the fullname to continue from
+func page[T any](items []T, req *storage.UserRequest, fullname func(T) string) ([]T, string) {
+	start := 0
+	if req.After != "" {
+		for i, item := range items {
+			if fullname(item) == req.After {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+req.Limit, len(items))
+	if end <= start {
+		return nil, ""
+	}
+
+	next := ""
+	if end < len(items) {
+		next = fullname(items[end-1])
+	}
+	return items[start:end], next
+}
+
+func (m *mockUserClient) GetUserPosts(ctx context.Context, req *storage.UserRequest) (*types.PostsResponse, error) {
+	m.requests = append(m.requests, req)
+	posts, next := page(m.userPosts, req, func(p *types.Post) string { return "t3_" + p.ID })
+	return &types.PostsResponse{Posts: posts, AfterFullname: next}, nil
+}
+
+func (m *mockUserClient) GetUserComments(ctx context.Context, req *storage.UserRequest) (*storage.UserCommentsResponse, error) {
+	m.requests = append(m.requests, req)
+	comments, next := page(m.userComments, req, func(c *types.Comment) string { return "t1_" + c.ID })
+	return &storage.UserCommentsResponse{Comments: comments, AfterFullname: next}, nil
+}
+
+func TestArchiveUser(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	client := &mockUserClient{mockRedditClient: mockClient}
+	for _, id := range []string{"u1", "u2", "u3"} {
+		post := testutil.NewTestPost(id, "gophers", "By the user")
+		post.Author = "alice"
+		client.userPosts = append(client.userPosts, post)
+	}
+
+	// A reply in a thread that isn't stored yet; its parent arrives with the
+	// archived thread
+	thread := testutil.NewTestPost("t1", "rust", "Someone else's post")
+	parent := testutil.NewTestComment("parent1", "t1", "bob", "Question")
+	parent.ParentID = "t3_t1"
+	reply := testutil.NewTestComment("reply1", "t1", "alice", "Answer")
+	reply.ParentID = "t1_parent1"
+	mockClient.commentsMap["t1"] = &types.CommentsResponse{Post: thread, Comments: []*types.Comment{parent}}
+	client.userComments = []*types.Comment{reply}
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.ArchiveUser(ctx, "alice", storage.UserArchiveOptions{
+		MaxItems:        2,
+		IncludePosts:    true,
+		IncludeComments: true,
+	})
+	if err != nil {
+		t.Fatalf("ArchiveUser failed: %v", err)
+	}
+	if result.PostsSaved != 2 || result.CommentsSaved != 1 || len(result.FailedComments) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// MaxItems limits the posts fetched
+	if _, err := store.GetPost(ctx, "u3"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected u3 to be past MaxItems, got %v", err)
+	}
+
+	// Posting created a stub row for the subreddit
+	subs, err := store.ListSubreddits(ctx, storage.QueryOptions{Search: "gophers"})
+	if err != nil || len(subs) != 1 {
+		t.Errorf("Expected a stub subreddit row, got %d (err=%v)", len(subs), err)
+	}
+
+	comments, err := store.GetCommentsByPost(ctx, "t1")
+	if err != nil {
+		t.Fatalf("GetCommentsByPost failed: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Errorf("Expected the reply and its parent to be stored, got %d comments", len(comments))
+	}
+}
+
+func TestArchiveUserPagination(t *testing.T) {
+	_, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	client := &mockUserClient{mockRedditClient: mockClient}
+	for i := range 150 {
+		post := testutil.NewTestPost(fmt.Sprintf("p%d", i), "golang", "Post")
+		client.userPosts = append(client.userPosts, post)
+	}
+
+	archiver := storage.NewArchiver(client, store)
+	result, err := archiver.ArchiveUser(ctx, "alice", storage.UserArchiveOptions{MaxItems: 1000, IncludePosts: true})
+	if err != nil {
+		t.Fatalf("ArchiveUser failed: %v", err)
+	}
+	if result.PostsSaved != 150 {
+		t.Errorf("Expected 150 posts, got %d", result.PostsSaved)
+	}
+	if len(client.requests) != 2 || client.requests[1].After != "t3_"+client.userPosts[99].ID {
+		t.Errorf("Expected two pages continuing after the 100th post, got %d requests", len(client.requests))
+	}
+}
+
+func TestArchiveUserUnsupportedClient(t *testing.T) {
+	archiver, store, _ := setupTestArchiver(t)
+	defer store.Close()
+
+	_, err := archiver.ArchiveUser(context.Background(), "alice", storage.UserArchiveOptions{IncludePosts: true})
+	if !errors.Is(err, storage.ErrUserListingsUnsupported) {
+		t.Errorf("Expected ErrUserListingsUnsupported, got %v", err)
+	}
+}
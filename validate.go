@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidOptions is wrapped by the errors ArchiveOptions.Validate and
+// QueryOptions.Validate return, and so by every Archiver and backend method
+// given options they reject
+var ErrInvalidOptions = errors.New("invalid options")
+
+// querySortFields are the QueryOptions.SortBy values some query accepts
+var querySortFields = []string{"created", "created_utc", "score", "comments", "num_comments", "archived_comments", "name", "subscribers"}
+
+// invalidOption reports a field holding a value outside accepted
+func invalidOption(field string, value any, accepted string) error {
+	return fmt.Errorf("%w: %s is %v; %s", ErrInvalidOptions, field, value, accepted)
+}
+
+// Validate reports the first field of o that no query accepts: a negative
+// Limit or Offset, an unknown SortBy, SortOrder or SearchMode, both
+// OnlyStickied and ExcludeStickied, or a StartDate after EndDate. Both
+// backends call it before querying, so bad options fail with an error naming
+// the field rather than being ignored.
+func (o QueryOptions) Validate() error {
+	if o.Limit < 0 {
+		return invalidOption("QueryOptions.Limit", o.Limit, "must be 0 (the default page size) or more")
+	}
+	if o.Offset < 0 {
+		return invalidOption("QueryOptions.Offset", o.Offset, "must be 0 or more")
+	}
+	if o.SortBy != "" && !slices.Contains(querySortFields, o.SortBy) {
+		return invalidOption("QueryOptions.SortBy", fmt.Sprintf("%q", o.SortBy), "accepted values are "+strings.Join(querySortFields, ", "))
+	}
+	if o.SortOrder != "" && !strings.EqualFold(o.SortOrder, "asc") && !strings.EqualFold(o.SortOrder, "desc") {
+		return invalidOption("QueryOptions.SortOrder", fmt.Sprintf("%q", o.SortOrder), "accepted values are asc, desc")
+	}
+	if o.SearchMode != SearchModePlain && o.SearchMode != SearchModeWeb {
+		return invalidOption("QueryOptions.SearchMode", int(o.SearchMode), "accepted values are SearchModePlain, SearchModeWeb")
+	}
+	if o.OnlyStickied && o.ExcludeStickied {
+		return invalidOption("QueryOptions.OnlyStickied", true, "can't be combined with ExcludeStickied")
+	}
+	if !o.StartDate.IsZero() && !o.EndDate.IsZero() && o.StartDate.After(o.EndDate) {
+		return invalidOption("QueryOptions.StartDate", o.StartDate.Format(time.RFC3339), "must not be after EndDate "+o.EndDate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Validate reports the first field of o that ArchiveSubreddit would reject:
+// an unknown Sort or TimeRange, a TimeRange with a sort other than SortTop,
+// a negative count, or a FailIfErrorRateAbove outside 0 to 1. It doesn't
+// check whether the archiver's client can fetch the listing asked for.
+func (o ArchiveOptions) Validate() error {
+	if o.Sort != "" && !o.Sort.Valid() {
+		return invalidOption("ArchiveOptions.Sort", fmt.Sprintf("%q", o.Sort), "accepted values are hot, new, top, rising")
+	}
+	if o.TimeRange != "" {
+		if !o.TimeRange.Valid() {
+			return invalidOption("ArchiveOptions.TimeRange", fmt.Sprintf("%q", o.TimeRange), "accepted values are day, week, month, year, all")
+		}
+		if o.Sort != SortTop {
+			return invalidOption("ArchiveOptions.TimeRange", fmt.Sprintf("%q", o.TimeRange), "only applies to Sort top")
+		}
+	}
+
+	counts := []struct {
+		field string
+		value int
+	}{
+		{"Limit", o.Limit},
+		{"MaxCommentDepth", o.MaxCommentDepth},
+		{"Concurrency", o.Concurrency},
+		{"MaxMoreRequests", o.MaxMoreRequests},
+		{"CommentBatchSize", o.CommentBatchSize},
+	}
+	for _, c := range counts {
+		if c.value < 0 {
+			return invalidOption("ArchiveOptions."+c.field, c.value, "must be 0 (the default) or more")
+		}
+	}
+
+	if o.FailIfErrorRateAbove < 0 || o.FailIfErrorRateAbove > 1 {
+		return invalidOption("ArchiveOptions.FailIfErrorRateAbove", o.FailIfErrorRateAbove, "must be between 0 and 1")
+	}
+	return nil
+}
+
+// Validate reports whether o is missing Before, without which PrunePosts
+// wouldn't know what to delete
+func (o PruneOptions) Validate() error {
+	if o.Before.IsZero() {
+		return invalidOption("PruneOptions.Before", "zero", "must be set")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesprial/go-reddit-storage"
+)
+
+func TestQueryOptionsValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		opts  storage.QueryOptions
+		field string // empty when opts are valid
+	}{
+		{"zero value", storage.QueryOptions{}, ""},
+		{"typical", storage.QueryOptions{Limit: 10, Offset: 20, SortBy: "score", SortOrder: "DESC", StartDate: now.Add(-time.Hour), EndDate: now}, ""},
+		{"start date only", storage.QueryOptions{StartDate: now}, ""},
+		{"negative limit", storage.QueryOptions{Limit: -1}, "QueryOptions.Limit"},
+		{"negative offset", storage.QueryOptions{Offset: -1}, "QueryOptions.Offset"},
+		{"unknown sort", storage.QueryOptions{SortBy: "topp"}, "QueryOptions.SortBy"},
+		{"unknown order", storage.QueryOptions{SortOrder: "up"}, "QueryOptions.SortOrder"},
+		{"unknown search mode", storage.QueryOptions{SearchMode: 7}, "QueryOptions.SearchMode"},
+		{"only and exclude stickied", storage.QueryOptions{OnlyStickied: true, ExcludeStickied: true}, "QueryOptions.OnlyStickied"},
+		{"start after end", storage.QueryOptions{StartDate: now, EndDate: now.Add(-time.Hour)}, "QueryOptions.StartDate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Expected valid options, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, storage.ErrInvalidOptions) {
+				t.Fatalf("Expected ErrInvalidOptions, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Expected the error to name %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestArchiveOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  storage.ArchiveOptions
+		field string // empty when opts are valid
+	}{
+		{"zero value", storage.ArchiveOptions{}, ""},
+		{"top of the week", storage.ArchiveOptions{Sort: storage.SortTop, TimeRange: storage.TimeRangeWeek, Limit: 50}, ""},
+		{"unknown sort", storage.ArchiveOptions{Sort: "topp"}, "ArchiveOptions.Sort"},
+		{"unknown time range", storage.ArchiveOptions{Sort: storage.SortTop, TimeRange: "decade"}, "ArchiveOptions.TimeRange"},
+		{"time range without top", storage.ArchiveOptions{Sort: storage.SortNew, TimeRange: storage.TimeRangeDay}, "ArchiveOptions.TimeRange"},
+		{"negative limit", storage.ArchiveOptions{Limit: -5}, "ArchiveOptions.Limit"},
+		{"negative batch size", storage.ArchiveOptions{CommentBatchSize: -1}, "ArchiveOptions.CommentBatchSize"},
+		{"error rate above 1", storage.ArchiveOptions{FailIfErrorRateAbove: 1.5}, "ArchiveOptions.FailIfErrorRateAbove"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Expected valid options, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, storage.ErrInvalidOptions) {
+				t.Fatalf("Expected ErrInvalidOptions, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Expected the error to name %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestArchiverRejectsInvalidOptions(t *testing.T) {
+	archiver, store, mockClient := setupTestArchiver(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	_, err := archiver.ArchiveSubreddit(ctx, "golang", storage.ArchiveOptions{Sort: "topp"})
+	if !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Fatalf("Expected ErrInvalidOptions, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "hot, new, top, rising") {
+		t.Errorf("Expected the error to list the accepted sorts, got %v", err)
+	}
+
+	if _, err := archiver.ArchiveNew(ctx, "golang", storage.ArchiveOptions{Limit: -1}); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected ArchiveNew to reject a negative limit, got %v", err)
+	}
+	if err := archiver.RefreshComments(ctx, "golang", storage.ArchiveOptions{Limit: -1}); !errors.Is(err, storage.ErrInvalidOptions) {
+		t.Errorf("Expected RefreshComments to reject a negative limit, got %v", err)
+	}
+
+	if mockClient.calls != 0 {
+		t.Errorf("Expected no API calls for invalid options, got %d", mockClient.calls)
+	}
+}